@@ -6,6 +6,7 @@ package v1alpha1
 
 import (
 	"context"
+	"errors"
 
 	zip "api.zip"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -74,6 +75,10 @@ func (vs VolumeState) String() string {
 	return string(vs)
 }
 
+// ErrVolumeNotOwned is returned by a volume driver for volumes which are
+// managed by a different driver.
+var ErrVolumeNotOwned = errors.New("volume is not managed by this driver")
+
 // VolumeStatus contains the complete status of the volume.
 type VolumeStatus struct {
 	// State is the current state of the volume.
@@ -82,6 +87,13 @@ type VolumeStatus struct {
 	// DriverConfig is driver-specific attributes which are populated by the
 	// underlying volume implementation.
 	DriverConfig interface{} `json:"driverConfig,omitempty"`
+
+	// Size is the number of bytes the volume occupies on the host.
+	Size int64 `json:"size,omitempty"`
+
+	// AttachedTo is the list of names of the machines the volume is attached
+	// to.
+	AttachedTo []string `json:"attachedTo,omitempty"`
 }
 
 // VolumeService is the interface of available methods which can be performed
@@ -92,6 +104,7 @@ type VolumeService interface {
 	Get(context.Context, *Volume) (*Volume, error)
 	List(context.Context, *VolumeList) (*VolumeList, error)
 	Update(context.Context, *Volume) (*Volume, error)
+	Watch(context.Context, *Volume) (chan *Volume, chan error, error)
 }
 
 // VolumeServiceHandler provides a Zip API Object Framework service for the
@@ -102,6 +115,7 @@ type VolumeServiceHandler struct {
 	get    zip.MethodStrategy[*Volume, *Volume]
 	list   zip.MethodStrategy[*VolumeList, *VolumeList]
 	update zip.MethodStrategy[*Volume, *Volume]
+	watch  zip.StreamStrategy[*Volume, *Volume]
 }
 
 // Create implements VolumeService
@@ -129,6 +143,11 @@ func (client *VolumeServiceHandler) Update(ctx context.Context, req *Volume) (*V
 	return client.update.Do(ctx, req)
 }
 
+// Watch implements VolumeService
+func (client *VolumeServiceHandler) Watch(ctx context.Context, req *Volume) (chan *Volume, chan error, error) {
+	return client.watch.Channel(ctx, req)
+}
+
 // NewVolumeServiceHandler returns a service based on an inline API
 // client which essentially wraps the specific call, enabling pre- and post-
 // call hooks.  This is useful for wrapping the command with decorators, for
@@ -161,11 +180,17 @@ func NewVolumeServiceHandler(ctx context.Context, impl VolumeService, opts ...zi
 		return nil, err
 	}
 
+	watch, err := zip.NewStreamClient(ctx, impl.Watch, opts...)
+	if err != nil {
+		return nil, err
+	}
+
 	return &VolumeServiceHandler{
 		create,
 		delete,
 		get,
 		list,
 		update,
+		watch,
 	}, nil
 }
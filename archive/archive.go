@@ -201,6 +201,13 @@ func TarDir(ctx context.Context, root, prefix, out string, opts ...ArchiveOption
 // TarDirWriter makes a tarball of a given `root` directory into the provided
 // tarball writer `tw`.
 func TarDirWriter(ctx context.Context, root, prefix string, tw *tar.Writer, opts ...ArchiveOption) error {
+	aopts := ArchiveOptions{}
+	for _, opt := range opts {
+		if err := opt(&aopts); err != nil {
+			return err
+		}
+	}
+
 	return filepath.Walk(root, func(path string, _ os.FileInfo, err error) (returnErr error) {
 		if err != nil {
 			return err
@@ -211,8 +218,12 @@ func TarDirWriter(ctx context.Context, root, prefix string, tw *tar.Writer, opts
 			return err
 		}
 
+		if dst == "." && aopts.skipRoot {
+			return nil
+		}
+
 		dst = filepath.ToSlash(filepath.Join(prefix, dst))
 
-		return TarFileWriter(ctx, filepath.Join(root, path), dst, tw, opts...)
+		return TarFileWriter(ctx, path, dst, tw, opts...)
 	})
 }
@@ -7,6 +7,7 @@ package archive
 type ArchiveOptions struct {
 	stripTimes bool
 	gzip       bool
+	skipRoot   bool
 }
 
 type ArchiveOption func(*ArchiveOptions) error
@@ -28,3 +29,12 @@ func WithGzip(gzip bool) ArchiveOption {
 		return nil
 	}
 }
+
+// WithSkipRoot indicates that when archiving a directory, the directory itself
+// should not be added as an entry of the resulting artifact, only its contents.
+func WithSkipRoot(skipRoot bool) ArchiveOption {
+	return func(ao *ArchiveOptions) error {
+		ao.skipRoot = skipRoot
+		return nil
+	}
+}
@@ -21,6 +21,7 @@ type KraftKit struct {
 	NoCheckUpdates bool   `yaml:"no_check_updates" env:"KRAFTKIT_NO_CHECK_UPDATES" long:"no-check-updates" usage:"Do not check for updates" default:"false"`
 	NoColor        bool   `yaml:"no_color" env:"KRAFTKIT_NO_COLOR" long:"no-color" usage:"Disable color output"`
 	NoWarnSudo     bool   `yaml:"no_warn_sudo" env:"KRAFTKIT_NO_WARN_SUDO" long:"no-warn-sudo" usage:"Do not warn on running via sudo" default:"false"`
+	Offline        bool   `yaml:"offline" env:"KRAFTKIT_OFFLINE" long:"offline" usage:"Only use locally cached artifacts and do not access the network" default:"false"`
 	Editor         string `yaml:"editor" env:"KRAFTKIT_EDITOR" long:"editor" usage:"Set the text editor to open when prompt to edit a file"`
 	GitProtocol    string `yaml:"git_protocol" env:"KRAFTKIT_GIT_PROTOCOL" long:"git-protocol" usage:"Preferred Git protocol to use" default:"https"`
 	Pager          string `yaml:"pager,omitempty" env:"KRAFTKIT_PAGER" long:"pager" usage:"System pager to pipe output to" default:"cat"`
@@ -32,12 +33,14 @@ type KraftKit struct {
 	ContainerdAddr string `yaml:"containerd_addr,omitempty" env:"KRAFTKIT_CONTAINERD_ADDR" long:"containerd-addr" usage:"Address of containerd daemon socket" default:""`
 	EventsPidFile  string `yaml:"events_pidfile" env:"KRAFTKIT_EVENTS_PIDFILE" long:"events-pid-file" usage:"Events process ID used when running multiple unikernels"`
 	BuildKitHost   string `yaml:"buildkit_host" env:"KRAFTKIT_BUILDKIT_HOST" long:"buildkit-host" usage:"Path to the buildkit host" default:""`
+	TrustPolicy    string `yaml:"trust_policy" env:"KRAFTKIT_TRUST_POLICY" long:"trust-policy" usage:"Path to the trust policy of OCI image signatures"`
 
 	Paths struct {
 		Plugins   string `yaml:"plugins,omitempty" env:"KRAFTKIT_PATHS_PLUGINS" long:"plugins-dir" usage:"Path to KraftKit plugin directory"`
 		Config    string `yaml:"-" env:"KRAFTKIT_PATHS_CONFIG" long:"config-dir" usage:"Path to KraftKit config directory"`
 		Manifests string `yaml:"manifests,omitempty" env:"KRAFTKIT_PATHS_MANIFESTS" long:"manifests-dir" usage:"Path to Unikraft manifest cache"`
 		Sources   string `yaml:"sources,omitempty" env:"KRAFTKIT_PATHS_SOURCES" long:"sources-dir" usage:"Path to Unikraft component cache"`
+		Cache     string `yaml:"cache,omitempty" env:"KRAFTKIT_PATHS_CACHE" long:"cache-dir" usage:"Path to KraftKit build cache"`
 	} `yaml:"paths,omitempty"`
 
 	Log struct {
@@ -88,6 +91,10 @@ var configDetails = []ConfigDetail{
 		Key:         "pager",
 		Description: "the terminal pager program to send standard output to",
 	},
+	{
+		Key:         "offline",
+		Description: "only use locally cached artifacts and do not access the network",
+	},
 	{
 		Key:         "log.level",
 		Description: "Set the logging verbosity",
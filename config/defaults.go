@@ -37,6 +37,11 @@ func NewDefaultKraftKitConfig() (*KraftKit, error) {
 		c.Paths.Manifests = filepath.Join(DataDir(), "manifests")
 	}
 
+	// ..for the trust policy..
+	if len(c.TrustPolicy) == 0 {
+		c.TrustPolicy = filepath.Join(c.Paths.Config, "policy.yaml")
+	}
+
 	// ..for runtime files..
 	if len(c.RuntimeDir) == 0 {
 		c.RuntimeDir = filepath.Join(DataDir(), "runtime")
@@ -47,11 +52,16 @@ func NewDefaultKraftKitConfig() (*KraftKit, error) {
 		c.EventsPidFile = filepath.Join(c.RuntimeDir, "events.pid")
 	}
 
-	// ..and for cached source files
+	// ..for cached source files..
 	if len(c.Paths.Sources) == 0 {
 		c.Paths.Sources = filepath.Join(DataDir(), "sources")
 	}
 
+	// ..and for cached build artifacts
+	if len(c.Paths.Cache) == 0 {
+		c.Paths.Cache = filepath.Join(DataDir(), "cache")
+	}
+
 	if len(c.Unikraft.Manifests) == 0 {
 		c.Unikraft.Manifests = append(c.Unikraft.Manifests, DefaultManifestIndex)
 	}
@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package hermetic
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCopyDir(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	writeFiles(t, src, map[string]string{
+		"Makefile.uk":    "$(eval $(call addlib,liblwip))",
+		"include/lwip.h": "#define LWIP 1",
+		".git/HEAD":      "ref: refs/heads/stable",
+	})
+
+	if err := os.Symlink("include/lwip.h", filepath.Join(src, "lwip.h")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stale files of a previous copy are removed.
+	writeFiles(t, dst, map[string]string{"stale.c": ""})
+
+	if err := copyDir(src, dst); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dst, ".git")); !os.IsNotExist(err) {
+		t.Errorf("expected .git to be left out, got %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dst, "stale.c")); !os.IsNotExist(err) {
+		t.Errorf("expected stale file to be removed, got %v", err)
+	}
+
+	if link, err := os.Readlink(filepath.Join(dst, "lwip.h")); err != nil || link != "include/lwip.h" {
+		t.Errorf("expected symbolic link to be kept, got %q: %v", link, err)
+	}
+
+	expected, err := hashDir(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := hashDir(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected != actual {
+		t.Errorf("expected copy to have digest %s, got %s", expected, actual)
+	}
+}
+
+func TestHashDir(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"Makefile.uk": "$(eval $(call addlib,libmusl))",
+		"src/main.c":  "int main(void) { return 0; }",
+	})
+
+	digest, err := hashDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := map[string]func() error{
+		"content": func() error {
+			return os.WriteFile(filepath.Join(root, "src", "main.c"), []byte("int main(void) { return 1; }"), 0o644)
+		},
+		"mode": func() error {
+			return os.Chmod(filepath.Join(root, "Makefile.uk"), 0o755)
+		},
+		"name": func() error {
+			return os.Rename(filepath.Join(root, "src", "main.c"), filepath.Join(root, "src", "other.c"))
+		},
+	}
+
+	for _, name := range []string{"content", "mode", "name"} {
+		if err := changes[name](); err != nil {
+			t.Fatal(err)
+		}
+
+		changed, err := hashDir(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if changed == digest {
+			t.Errorf("expected digest to change with the %s of a file", name)
+		}
+
+		digest = changed
+	}
+}
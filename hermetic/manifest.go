@@ -0,0 +1,178 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package hermetic vendors the sources of the components of a project and the
+// upstream archives which they fetch into the project itself, such that the
+// project can be built without retrieving anything.
+package hermetic
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"kraftkit.sh/lockfile"
+	"kraftkit.sh/unikraft"
+)
+
+const (
+	// FileName is the name of the file which records the checksums of the
+	// vendored sources, kept in the vendor directory of the project.
+	FileName = "checksums.yaml"
+
+	// OriginsDir is the directory within the vendor directory which contains
+	// the upstream archives which are fetched by the Unikraft build system.
+	OriginsDir = "origins"
+
+	// SpecVersion is the version of the format of the checksums file.
+	SpecVersion = "v1"
+
+	// KindOrigin is the kind of the upstream archives of libraries.
+	KindOrigin lockfile.Kind = "origin"
+)
+
+// kindOrder is the order in which entries are written to the checksums file.
+var kindOrder = map[lockfile.Kind]int{
+	lockfile.KindCore:     0,
+	lockfile.KindTemplate: 1,
+	lockfile.KindLibrary:  2,
+	KindOrigin:            3,
+}
+
+// Entry is a vendored component or upstream archive.
+type Entry struct {
+	// Kind of the entry.
+	Kind lockfile.Kind `yaml:"kind"`
+
+	// Name of the component, or of the library build directory and the file
+	// name of the upstream archive.
+	Name string `yaml:"name"`
+
+	// Version of the component as requested by the Kraftfile.
+	Version string `yaml:"version,omitempty"`
+
+	// Resolved is the locked version of the component, if any.
+	Resolved string `yaml:"resolved,omitempty"`
+
+	// Path of the entry relative to the working directory of the project.
+	Path string `yaml:"path"`
+
+	// Sha256 is the digest of the file, or of the contents of the directory.
+	Sha256 string `yaml:"sha256"`
+}
+
+// String implements fmt.Stringer.
+func (entry Entry) String() string {
+	if len(entry.Version) > 0 {
+		return fmt.Sprintf("%s %s:%s", entry.Kind, entry.Name, entry.Version)
+	}
+
+	return fmt.Sprintf("%s %s", entry.Kind, entry.Name)
+}
+
+// Manifest records the vendored sources of a project.
+type Manifest struct {
+	// Spec is the version of the format of the checksums file.
+	Spec string `yaml:"spec"`
+
+	// Entries are the vendored components and upstream archives.
+	Entries []Entry `yaml:"entries"`
+}
+
+// PathOf returns the path of the checksums file of the project in the
+// provided working directory.
+func PathOf(workdir string) string {
+	return filepath.Join(workdir, unikraft.HermeticDir, FileName)
+}
+
+// Load returns the checksums of the vendored sources of the project in the
+// provided working directory, or nil if the project has not been vendored.
+func Load(workdir string) (*Manifest, error) {
+	path := PathOf(workdir)
+
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	manifest := Manifest{}
+	if err := yaml.Unmarshal(b, &manifest); err != nil {
+		return nil, fmt.Errorf("could not parse '%s': %w", path, err)
+	}
+
+	if manifest.Spec != SpecVersion {
+		return nil, fmt.Errorf("unsupported spec '%s' in '%s'", manifest.Spec, path)
+	}
+
+	return &manifest, nil
+}
+
+// Save writes the checksums to the provided path.
+func (manifest *Manifest) Save(path string) error {
+	sort.SliceStable(manifest.Entries, func(i, j int) bool {
+		if kindOrder[manifest.Entries[i].Kind] != kindOrder[manifest.Entries[j].Kind] {
+			return kindOrder[manifest.Entries[i].Kind] < kindOrder[manifest.Entries[j].Kind]
+		}
+
+		return manifest.Entries[i].Name < manifest.Entries[j].Name
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString("# This file is generated by kraft.  Do not edit it manually and use\n")
+	buf.WriteString("# 'kraft vendor' to refresh it instead.\n")
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(manifest); err != nil {
+		return fmt.Errorf("could not serialize checksums: %w", err)
+	}
+
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("could not serialize checksums: %w", err)
+	}
+
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// Lookup returns the entry with the provided kind and name, or nil if it is
+// not recorded.
+func (manifest *Manifest) Lookup(kind lockfile.Kind, name string) *Entry {
+	if manifest == nil {
+		return nil
+	}
+
+	for i := range manifest.Entries {
+		if manifest.Entries[i].Kind == kind && manifest.Entries[i].Name == name {
+			return &manifest.Entries[i]
+		}
+	}
+
+	return nil
+}
+
+// IntegrityError is returned when the vendored sources of a project do not
+// match their recorded checksums.
+type IntegrityError struct {
+	// Path is the path of the checksums file.
+	Path string
+
+	// Problems are the human-readable differences to the checksums.
+	Problems []string
+}
+
+// Error implements error.
+func (err *IntegrityError) Error() string {
+	return fmt.Sprintf("vendored sources do not match %s, run 'kraft vendor' to refresh them:\n  - %s",
+		err.Path, strings.Join(err.Problems, "\n  - "),
+	)
+}
@@ -0,0 +1,338 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package hermetic
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"kraftkit.sh/lockfile"
+	"kraftkit.sh/log"
+	"kraftkit.sh/unikraft"
+	"kraftkit.sh/unikraft/app"
+	"kraftkit.sh/unikraft/component"
+	"kraftkit.sh/unikraft/target"
+)
+
+// Vendor copies the sources of the core, the template and the libraries of the
+// project, as well as the upstream archives which have been fetched into its
+// build directory, into the vendor directory of the project and records their
+// checksums.  Components must have been pulled beforehand, unless they are
+// already vendored.
+func Vendor(ctx context.Context, project app.Application, targets ...target.Target) (*Manifest, error) {
+	workdir := project.WorkingDir()
+	vendordir := filepath.Join(workdir, unikraft.HermeticDir)
+
+	components, err := componentsOf(ctx, project, targets...)
+	if err != nil {
+		return nil, err
+	}
+
+	locked, err := lockfile.Load(workdir)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(vendordir, 0o755); err != nil {
+		return nil, fmt.Errorf("could not create vendor directory: %w", err)
+	}
+
+	manifest := &Manifest{
+		Spec: SpecVersion,
+	}
+
+	for _, c := range components {
+		src, err := unikraft.PlaceComponent(workdir, c.Type(), c.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		dst, vendored := unikraft.VendoredComponent(workdir, c.Type(), c.Name())
+
+		if f, err := os.Stat(src); err == nil && f.IsDir() {
+			log.G(ctx).
+				WithField("from", src).
+				WithField("to", dst).
+				Debugf("vendoring %s", unikraft.TypeNameVersion(c))
+
+			if err := copyDir(src, dst); err != nil {
+				return nil, fmt.Errorf("could not vendor %s: %w", unikraft.TypeNameVersion(c), err)
+			}
+		} else if !vendored {
+			return nil, fmt.Errorf("cannot vendor %s which has not been pulled, run 'kraft build' or 'kraft pkg pull' first", unikraft.TypeNameVersion(c))
+		}
+
+		entry := Entry{
+			Kind:     lockfile.KindOf(c),
+			Name:     c.Name(),
+			Version:  c.Version(),
+			Resolved: locked.Version(c, c.Source()),
+		}
+
+		if entry.Resolved == entry.Version {
+			entry.Resolved = ""
+		}
+
+		if err := entry.record(workdir, dst, hashDir); err != nil {
+			return nil, err
+		}
+
+		manifest.Entries = append(manifest.Entries, entry)
+	}
+
+	// Upstream archives are copied from the build directory and previously
+	// vendored archives are kept, e.g. after the build directory is cleaned.
+	outdir := project.OutDir()
+	if !filepath.IsAbs(outdir) {
+		outdir = filepath.Join(workdir, outdir)
+	}
+
+	origins, err := filepath.Glob(filepath.Join(outdir, "*", "origin", "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, origin := range origins {
+		if f, err := os.Stat(origin); err != nil || !f.Mode().IsRegular() {
+			continue
+		}
+
+		name := filepath.Base(filepath.Dir(filepath.Dir(origin))) + "/" + filepath.Base(origin)
+
+		if err := copyFile(origin, filepath.Join(vendordir, OriginsDir, filepath.FromSlash(name))); err != nil {
+			return nil, fmt.Errorf("could not vendor %s: %w", origin, err)
+		}
+	}
+
+	vendoredOrigins, err := filepath.Glob(filepath.Join(vendordir, OriginsDir, "*", "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, origin := range vendoredOrigins {
+		entry := Entry{
+			Kind: KindOrigin,
+			Name: filepath.Base(filepath.Dir(origin)) + "/" + filepath.Base(origin),
+		}
+
+		if err := entry.record(workdir, origin, hashFile); err != nil {
+			return nil, err
+		}
+
+		manifest.Entries = append(manifest.Entries, entry)
+	}
+
+	// Components which are no longer part of the project are removed.
+	for _, dir := range unrecorded(manifest, workdir) {
+		log.G(ctx).
+			WithField("path", dir).
+			Debug("removing stale vendored sources")
+
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := manifest.Save(PathOf(workdir)); err != nil {
+		return nil, fmt.Errorf("could not write checksums: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// Verify checks that every component of the project is vendored at its
+// requested version and that the vendored sources match their recorded
+// checksums.  Any difference is returned as an *IntegrityError.
+func Verify(ctx context.Context, project app.Application, targets ...target.Target) (*Manifest, error) {
+	workdir := project.WorkingDir()
+
+	manifest, err := Load(workdir)
+	if err != nil {
+		return nil, err
+	} else if manifest == nil {
+		return nil, fmt.Errorf("project has no vendored sources, run 'kraft vendor' first")
+	}
+
+	components, err := componentsOf(ctx, project, targets...)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+
+	for _, c := range components {
+		entry := manifest.Lookup(lockfile.KindOf(c), c.Name())
+		if entry == nil {
+			problems = append(problems, fmt.Sprintf("%s %s is not vendored", lockfile.KindOf(c), component.NameAndVersion(c)))
+		} else if entry.Version != c.Version() {
+			problems = append(problems, fmt.Sprintf("%s is vendored but %s is requested", entry.String(), c.Version()))
+		}
+	}
+
+	for _, entry := range manifest.Entries {
+		path := filepath.Join(workdir, filepath.FromSlash(entry.Path))
+
+		f, err := os.Stat(path)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s is missing from %s", entry.String(), entry.Path))
+			continue
+		}
+
+		var digest string
+		if f.IsDir() {
+			digest, err = hashDir(path)
+		} else {
+			digest, err = hashFile(path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not hash %s: %w", entry.Path, err)
+		}
+
+		if digest != entry.Sha256 {
+			problems = append(problems, fmt.Sprintf("%s sha256: recorded '%s' but found '%s'", entry.String(), entry.Sha256, digest))
+		}
+	}
+
+	for _, path := range unrecorded(manifest, workdir) {
+		rel, _ := filepath.Rel(workdir, path)
+		problems = append(problems, fmt.Sprintf("%s is not recorded", filepath.ToSlash(rel)))
+	}
+
+	if len(problems) > 0 {
+		return nil, &IntegrityError{
+			Path:     PathOf(workdir),
+			Problems: problems,
+		}
+	}
+
+	return manifest, nil
+}
+
+// Restore copies the vendored upstream archives of the project into its build
+// directory, such that the Unikraft build system does not download them.
+// Archives which are already present are left untouched.
+func Restore(ctx context.Context, project app.Application) error {
+	workdir := project.WorkingDir()
+
+	outdir := project.OutDir()
+	if !filepath.IsAbs(outdir) {
+		outdir = filepath.Join(workdir, outdir)
+	}
+
+	origins, err := filepath.Glob(filepath.Join(workdir, unikraft.HermeticDir, OriginsDir, "*", "*"))
+	if err != nil {
+		return err
+	}
+
+	for _, origin := range origins {
+		dst := filepath.Join(outdir, filepath.Base(filepath.Dir(origin)), "origin", filepath.Base(origin))
+		if _, err := os.Stat(dst); err == nil {
+			continue
+		}
+
+		log.G(ctx).
+			WithField("archive", dst).
+			Debug("restoring vendored archive")
+
+		if err := copyFile(origin, dst); err != nil {
+			return fmt.Errorf("could not restore vendored archive: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// record sets the path and the digest of the entry.
+func (entry *Entry) record(workdir, path string, hash func(string) (string, error)) error {
+	rel, err := filepath.Rel(workdir, path)
+	if err != nil {
+		return err
+	}
+
+	entry.Path = filepath.ToSlash(rel)
+
+	entry.Sha256, err = hash(path)
+	if err != nil {
+		return fmt.Errorf("could not hash %s: %w", entry.Path, err)
+	}
+
+	return nil
+}
+
+// componentsOf returns the template and the components of the project which
+// can be vendored.  Components whose source is a directory on the host are
+// used as-is and are not vendored.
+func componentsOf(ctx context.Context, project app.Application, targets ...target.Target) ([]component.Component, error) {
+	var ret []component.Component
+	seen := map[string]bool{}
+
+	add := func(c component.Component) {
+		if c.Type() != unikraft.ComponentTypeCore &&
+			c.Type() != unikraft.ComponentTypeLib &&
+			c.Type() != unikraft.ComponentTypeApp {
+			return
+		}
+
+		if len(c.Source()) > 0 && c.Path() == c.Source() {
+			return
+		}
+
+		key := string(c.Type()) + "/" + c.Name()
+		if seen[key] {
+			return
+		}
+
+		seen[key] = true
+		ret = append(ret, c)
+	}
+
+	if template := project.Template(); template != nil {
+		add(template)
+	}
+
+	components, err := project.Components(ctx, targets...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get list of components: %w", err)
+	}
+
+	for _, c := range components {
+		add(c)
+	}
+
+	return ret, nil
+}
+
+// unrecorded returns the vendored components and upstream archives of the
+// project which are not recorded by the manifest.
+func unrecorded(manifest *Manifest, workdir string) []string {
+	recorded := map[string]bool{}
+	for _, entry := range manifest.Entries {
+		recorded[filepath.Join(workdir, filepath.FromSlash(entry.Path))] = true
+	}
+
+	vendordir := filepath.Join(workdir, unikraft.HermeticDir)
+
+	var candidates []string
+	for _, pattern := range []string{
+		filepath.Join(vendordir, "unikraft"),
+		filepath.Join(vendordir, unikraft.ComponentTypeApp.Plural(), "*"),
+		filepath.Join(vendordir, unikraft.ComponentTypeLib.Plural(), "*"),
+		filepath.Join(vendordir, OriginsDir, "*", "*"),
+	} {
+		matches, _ := filepath.Glob(pattern)
+		candidates = append(candidates, matches...)
+	}
+
+	var ret []string
+	for _, candidate := range candidates {
+		if !recorded[candidate] {
+			ret = append(ret, candidate)
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,226 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+
+	"kraftkit.sh/log"
+)
+
+const (
+	// cacheRootfsFileName is the name of the root file system within an entry
+	// of the build cache.
+	cacheRootfsFileName = "rootfs"
+
+	// cacheMetadataFileName is the name of the metadata within an entry of the
+	// build cache.
+	cacheMetadataFileName = "metadata.json"
+)
+
+// cacheEntry is the metadata of a root file system in the build cache which
+// is otherwise only known after building it.
+type cacheEntry struct {
+	Args  []string `json:"args,omitempty"`
+	Env   []string `json:"env,omitempty"`
+	Files []string `json:"files,omitempty"`
+}
+
+// cacheKey returns the key of the build cache entry of the root file system
+// built by the named builder from inputs with the provided digest.  Every
+// option which affects the contents of the result is part of the key.
+func (opts InitrdOptions) cacheKey(builder string, inputs digest.Digest) digest.Digest {
+	compression := CompressionNone
+	if opts.compression.Enabled() {
+		compression = opts.compression
+	}
+
+	format := FormatCpio
+	if opts.format != "" {
+		format = opts.format
+	}
+
+	epoch := ""
+	if opts.reproducible {
+		epoch = strconv.FormatInt(sourceDateEpoch().Unix(), 10)
+	}
+
+	return digest.FromString(strings.Join([]string{
+		builder,
+		inputs.String(),
+		opts.arch,
+		compression.String(),
+		strconv.Itoa(opts.level),
+		format.String(),
+		epoch,
+	}, "\n"))
+}
+
+// loadCache copies the root file system of the build cache entry with the
+// provided key to the output set in the options.  False is returned if the
+// build cache is disabled or the entry does not exist.
+func loadCache(ctx context.Context, opts InitrdOptions, key digest.Digest) (*cacheEntry, bool) {
+	if opts.buildCache == "" {
+		return nil, false
+	}
+
+	dir := filepath.Join(opts.buildCache, key.Encoded())
+
+	raw, err := os.ReadFile(filepath.Join(dir, cacheMetadataFileName))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		log.G(ctx).
+			WithField("key", key.String()).
+			Debugf("ignoring corrupt rootfs cache entry: %s", err)
+		return nil, false
+	}
+
+	if err := copyFile(filepath.Join(dir, cacheRootfsFileName), opts.output, 0o644); err != nil {
+		log.G(ctx).
+			WithField("key", key.String()).
+			Debugf("could not use rootfs cache entry: %s", err)
+		return nil, false
+	}
+
+	log.G(ctx).
+		WithField("key", key.String()).
+		Debug("using cached rootfs")
+
+	return &entry, true
+}
+
+// storeCache adds the root file system at the output set in the options to
+// the build cache under the provided key.  Failing to do so does not fail the
+// build and is only reported.
+func storeCache(ctx context.Context, opts InitrdOptions, key digest.Digest, entry cacheEntry) {
+	if opts.buildCache == "" {
+		return
+	}
+
+	if err := storeCacheEntry(opts, key, entry); err != nil {
+		log.G(ctx).
+			WithField("key", key.String()).
+			Warnf("could not cache rootfs: %s", err)
+	}
+}
+
+func storeCacheEntry(opts InitrdOptions, key digest.Digest, entry cacheEntry) error {
+	if err := os.MkdirAll(opts.buildCache, 0o755); err != nil {
+		return err
+	}
+
+	// Populate the entry in a temporary directory which is atomically moved in
+	// place such that concurrent builds never observe a partial entry.
+	tmp, err := os.MkdirTemp(opts.buildCache, ".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(tmp)
+
+	if err := copyFile(opts.output, filepath.Join(tmp, cacheRootfsFileName), 0o644); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(tmp, cacheMetadataFileName), raw, 0o644); err != nil {
+		return err
+	}
+
+	dir := filepath.Join(opts.buildCache, key.Encoded())
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, dir)
+}
+
+// digestTree returns the digest of the names, types, permissions and contents
+// of all entries of the directory tree at root, excluding the provided paths.
+func digestTree(root string, exclude ...string) (digest.Digest, error) {
+	root = filepath.Clean(root)
+
+	excluded := make(map[string]struct{}, len(exclude))
+	for _, path := range exclude {
+		if path != "" {
+			excluded[filepath.Clean(path)] = struct{}{}
+		}
+	}
+
+	digester := digest.Canonical.Digester()
+	hash := digester.Hash()
+
+	if err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if _, ok := excluded[path]; ok {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(hash, "%s\x00%s\x00", filepath.ToSlash(rel), info.Mode())
+
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(hash, "%s\x00", link)
+
+		case info.Mode().IsRegular():
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(hash, "%d\x00", info.Size())
+			_, err = io.Copy(hash, f)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return "", fmt.Errorf("could not digest %s: %w", root, err)
+	}
+
+	return digester.Digest(), nil
+}
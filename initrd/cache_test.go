@@ -0,0 +1,221 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+)
+
+func TestCacheKey(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+	inputs := digest.FromString("inputs")
+	base := InitrdOptions{
+		compression: CompressionGzip,
+		level:       6,
+		format:      FormatCpio,
+		arch:        "x86_64",
+	}
+	key := base.cacheKey("directory", inputs)
+
+	changed := []struct {
+		name    string
+		builder string
+		inputs  digest.Digest
+		modify  func(*InitrdOptions)
+	}{
+		{"builder", "dockerfile", inputs, func(*InitrdOptions) {}},
+		{"inputs", "directory", digest.FromString("other"), func(*InitrdOptions) {}},
+		{"arch", "directory", inputs, func(opts *InitrdOptions) { opts.arch = "arm64" }},
+		{"compression", "directory", inputs, func(opts *InitrdOptions) { opts.compression = CompressionZstd }},
+		{"no compression", "directory", inputs, func(opts *InitrdOptions) { opts.compression = CompressionNone }},
+		{"level", "directory", inputs, func(opts *InitrdOptions) { opts.level = 9 }},
+		{"format", "directory", inputs, func(opts *InitrdOptions) { opts.format = FormatErofs }},
+		{"reproducible", "directory", inputs, func(opts *InitrdOptions) { opts.reproducible = true }},
+	}
+
+	for _, tc := range changed {
+		opts := base
+		tc.modify(&opts)
+
+		if opts.cacheKey(tc.builder, tc.inputs) == key {
+			t.Errorf("%s: expected the key to change", tc.name)
+		}
+	}
+
+	unchanged := []struct {
+		name   string
+		modify func(*InitrdOptions)
+	}{
+		{"output", func(opts *InitrdOptions) { opts.output = "/tmp/initramfs.cpio" }},
+		{"cache directory", func(opts *InitrdOptions) { opts.cacheDir = "/tmp/cache" }},
+		{"build cache", func(opts *InitrdOptions) { opts.buildCache = "/tmp/rootfs" }},
+		{"workdir", func(opts *InitrdOptions) { opts.workdir = "/tmp/workdir" }},
+		{"default format", func(opts *InitrdOptions) { opts.format = "" }},
+	}
+
+	for _, tc := range unchanged {
+		opts := base
+		tc.modify(&opts)
+
+		if opts.cacheKey("directory", inputs) != key {
+			t.Errorf("%s: expected the key not to change", tc.name)
+		}
+	}
+
+	// An unset compression is the same as no compression.
+	none := base
+	none.compression = CompressionNone
+	unset := base
+	unset.compression = ""
+
+	if none.cacheKey("directory", inputs) != unset.cacheKey("directory", inputs) {
+		t.Errorf("expected unset and no compression to share a key")
+	}
+
+	// Reproducible builds depend on the clamped modification times.
+	reproducible := base
+	reproducible.reproducible = true
+	epochKey := reproducible.cacheKey("directory", inputs)
+
+	t.Setenv("SOURCE_DATE_EPOCH", "1800000000")
+
+	if reproducible.cacheKey("directory", inputs) == epochKey {
+		t.Errorf("expected SOURCE_DATE_EPOCH to change the key of reproducible builds")
+	}
+
+	if base.cacheKey("directory", inputs) != key {
+		t.Errorf("expected SOURCE_DATE_EPOCH not to change the key of other builds")
+	}
+}
+
+func TestCacheLoadStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	key := digest.FromString("key")
+
+	built := filepath.Join(dir, "built.cpio")
+	if err := os.WriteFile(built, []byte("rootfs"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	entry := cacheEntry{
+		Args:  []string{"/bin/app"},
+		Env:   []string{"PATH=/bin"},
+		Files: []string{"/bin/app"},
+	}
+
+	// A disabled cache neither stores nor loads.
+	storeCache(ctx, InitrdOptions{output: built}, key, entry)
+	if _, ok := loadCache(ctx, InitrdOptions{output: filepath.Join(dir, "out.cpio")}, key); ok {
+		t.Fatal("expected disabled cache to miss")
+	}
+
+	cacheDir := filepath.Join(dir, "cache")
+
+	if _, ok := loadCache(ctx, InitrdOptions{buildCache: cacheDir, output: filepath.Join(dir, "out.cpio")}, key); ok {
+		t.Fatal("expected empty cache to miss")
+	}
+
+	storeCache(ctx, InitrdOptions{buildCache: cacheDir, output: built}, key, entry)
+
+	out := filepath.Join(dir, "out.cpio")
+	loaded, ok := loadCache(ctx, InitrdOptions{buildCache: cacheDir, output: out}, key)
+	if !ok {
+		t.Fatal("expected stored entry to hit")
+	}
+
+	if !slices.Equal(loaded.Args, entry.Args) || !slices.Equal(loaded.Env, entry.Env) || !slices.Equal(loaded.Files, entry.Files) {
+		t.Errorf("expected entry %+v, got %+v", entry, *loaded)
+	}
+
+	if content, err := os.ReadFile(out); err != nil || string(content) != "rootfs" {
+		t.Errorf("expected cached rootfs to be copied to the output, got %q: %v", content, err)
+	}
+
+	// No temporary entries are left behind.
+	if tmps, _ := filepath.Glob(filepath.Join(cacheDir, ".tmp-*")); len(tmps) > 0 {
+		t.Errorf("expected no temporary entries, got %v", tmps)
+	}
+
+	if _, ok := loadCache(ctx, InitrdOptions{buildCache: cacheDir, output: out}, digest.FromString("other")); ok {
+		t.Error("expected another key to miss")
+	}
+
+	// Corrupt entries are ignored.
+	metadata := filepath.Join(cacheDir, key.Encoded(), cacheMetadataFileName)
+	if err := os.WriteFile(metadata, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := loadCache(ctx, InitrdOptions{buildCache: cacheDir, output: out}, key); ok {
+		t.Error("expected corrupt entry to miss")
+	}
+}
+
+func TestDirectoryBuildCache(t *testing.T) {
+	ctx := context.Background()
+
+	root := filepath.Join(t.TempDir(), "rootfs")
+	if err := copyTree("testdata/rootfs", root); err != nil {
+		t.Fatal(err)
+	}
+
+	cacheDir := t.TempDir()
+
+	build := func() []byte {
+		t.Helper()
+
+		ird, err := NewFromDirectory(ctx, root,
+			WithBuildCache(cacheDir),
+			WithOutput(filepath.Join(t.TempDir(), "initramfs.cpio")),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		output, err := ird.Build(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		content, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return content
+	}
+
+	_ = build()
+
+	// Mark the single cache entry to tell hits from rebuilds.
+	rootfses, err := filepath.Glob(filepath.Join(cacheDir, "*", cacheRootfsFileName))
+	if err != nil || len(rootfses) != 1 {
+		t.Fatalf("expected a single cache entry, got %v: %v", rootfses, err)
+	}
+
+	if err := os.WriteFile(rootfses[0], []byte("cached"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if content := build(); string(content) != "cached" {
+		t.Errorf("expected unchanged directory to be loaded from the cache")
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "etc", "app.conf"), []byte("changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if content := build(); string(content) == "cached" {
+		t.Errorf("expected changed directory to be rebuilt")
+	}
+}
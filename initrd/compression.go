@@ -0,0 +1,303 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz"
+
+	"kraftkit.sh/kconfig"
+)
+
+// Compression is the algorithm used to compress a CPIO archive.
+type Compression string
+
+const (
+	CompressionNone = Compression("none")
+	CompressionGzip = Compression("gzip")
+	CompressionZstd = Compression("zstd")
+	CompressionXz   = Compression("xz")
+	CompressionLz4  = Compression("lz4")
+)
+
+// String implements fmt.Stringer
+func (compression Compression) String() string {
+	return string(compression)
+}
+
+// Compressions returns the list of supported compression algorithms.
+func Compressions() []Compression {
+	return []Compression{
+		CompressionNone,
+		CompressionGzip,
+		CompressionZstd,
+		CompressionXz,
+		CompressionLz4,
+	}
+}
+
+// Enabled returns whether the archive is compressed at all.
+func (compression Compression) Enabled() bool {
+	return compression != "" && compression != CompressionNone
+}
+
+// KConfig returns the KConfig options the unikernel must be built with to be
+// able to decompress an initramfs compressed with the algorithm.
+func (compression Compression) KConfig() []string {
+	switch compression {
+	case CompressionGzip:
+		return []string{"CONFIG_LIBZLIB"}
+	case CompressionZstd:
+		return []string{"CONFIG_LIBZSTD"}
+	case CompressionXz:
+		return []string{"CONFIG_LIBXZ"}
+	case CompressionLz4:
+		return []string{"CONFIG_LIBLZ4"}
+	}
+
+	return nil
+}
+
+// CheckKConfig returns an error listing the KConfig options which are
+// required to decompress the initramfs but are not enabled in the provided
+// unikernel configuration.  An empty configuration means the unikernel's
+// configuration is not known, in which case no error is returned.
+func (compression Compression) CheckKConfig(kconf kconfig.KeyValueMap) error {
+	if len(kconf) == 0 {
+		return nil
+	}
+
+	var missing []string
+	for _, option := range compression.KConfig() {
+		if !kconf.AnyYes(option) {
+			missing = append(missing, option)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("decompressing a %s initramfs requires the unikernel to be built with %s: enable via `kraft menu` or choose another compression", compression, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// compressionLevels are the ranges of the levels which are supported by each
+// algorithm.  Algorithms which are not listed do not support levels.
+var compressionLevels = map[Compression][2]int{
+	CompressionGzip: {gzip.BestSpeed, gzip.BestCompression},
+	CompressionZstd: {1, 22},
+	CompressionLz4:  {1, 9},
+}
+
+// CheckLevel returns an error if the provided level is not supported by the
+// algorithm.  A level of 0 selects the algorithm's default and is always
+// supported.
+func (compression Compression) CheckLevel(level int) error {
+	if level == 0 || !compression.Enabled() {
+		return nil
+	}
+
+	levels, ok := compressionLevels[compression]
+	if !ok {
+		return fmt.Errorf("%s compression does not support levels", compression)
+	}
+
+	if level < levels[0] || level > levels[1] {
+		return fmt.Errorf("%s compression level must be between %d and %d: %d", compression, levels[0], levels[1], level)
+	}
+
+	return nil
+}
+
+// compressionMagic are the leading bytes of a stream compressed with each
+// algorithm.  LZ4 archives are written in the legacy format, which is what
+// initramfs decompressors expect.
+var compressionMagic = map[Compression][]byte{
+	CompressionGzip: {0x1f, 0x8b},
+	CompressionZstd: {0x28, 0xb5, 0x2f, 0xfd},
+	CompressionXz:   {0xfd, 0x37, 0x7a, 0x58, 0x5a, 0x00},
+	CompressionLz4:  {0x02, 0x21, 0x4c, 0x18},
+}
+
+// DetectCompression returns the compression of the file at the provided path
+// by inspecting its magic number.
+func DetectCompression(path string) (Compression, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close()
+
+	header := make([]byte, 6)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", fmt.Errorf("could not read %s: %w", path, err)
+	}
+
+	for compression, magic := range compressionMagic {
+		if bytes.HasPrefix(header[:n], magic) {
+			return compression, nil
+		}
+	}
+
+	return CompressionNone, nil
+}
+
+// newCompressor returns a writer which compresses everything written to it
+// into w using the algorithm at the provided level.  A level of 0 selects the
+// algorithm's default.
+func newCompressor(w io.Writer, compression Compression, level int) (io.WriteCloser, error) {
+	if err := compression.CheckLevel(level); err != nil {
+		return nil, err
+	}
+
+	switch compression {
+	case CompressionGzip:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+
+		// The gzip header is left empty (no name, no modification time) such
+		// that the compressed archive only depends on its contents.
+		return gzip.NewWriterLevel(w, level)
+
+	case CompressionZstd:
+		opts := []zstd.EOption{}
+		if level != 0 {
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+
+		return zstd.NewWriter(w, opts...)
+
+	case CompressionXz:
+		// Decompressors in kernels typically only support CRC32 checksums.  The
+		// writer has no notion of levels, which are rejected by CheckLevel.
+		return xz.WriterConfig{CheckSum: xz.CRC32}.NewWriter(w)
+
+	case CompressionLz4:
+		writer := lz4.NewWriter(w)
+		opts := []lz4.Option{lz4.LegacyOption(true)}
+		if level != 0 {
+			opts = append(opts, lz4.CompressionLevelOption(lz4.CompressionLevel(1<<(8+level))))
+		}
+
+		if err := writer.Apply(opts...); err != nil {
+			return nil, err
+		}
+
+		return writer, nil
+	}
+
+	return nil, fmt.Errorf("unsupported compression: %s", compression)
+}
+
+// newDecompressor returns a reader which decompresses r with the algorithm.
+// Closing the reader releases the resources of the decompressor but does not
+// close r.
+func newDecompressor(r io.Reader, compression Compression) (io.ReadCloser, error) {
+	switch compression {
+	case CompressionNone, "":
+		return io.NopCloser(r), nil
+	case CompressionGzip:
+		return gzip.NewReader(r)
+	case CompressionZstd:
+		decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+
+		return decoder.IOReadCloser(), nil
+	case CompressionXz:
+		reader, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+
+		return io.NopCloser(reader), nil
+	case CompressionLz4:
+		return io.NopCloser(lz4.NewReader(r)), nil
+	}
+
+	return nil, fmt.Errorf("unsupported compression: %s", compression)
+}
+
+// CompressedSize returns the number of bytes the contents of r occupy when
+// compressed with the algorithm at the provided level.
+func CompressedSize(r io.Reader, compression Compression, level int) (int64, error) {
+	var counter byteCounter
+
+	if !compression.Enabled() {
+		_, err := io.Copy(&counter, r)
+		return int64(counter), err
+	}
+
+	writer, err := newCompressor(&counter, compression, level)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := io.Copy(writer, r); err != nil {
+		return 0, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return 0, err
+	}
+
+	return int64(counter), nil
+}
+
+// Decompress returns a reader of the uncompressed contents of the CPIO archive
+// at the provided path.
+func Decompress(path string) (io.ReadCloser, error) {
+	compression, err := DetectCompression(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := newDecompressor(f, compression)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &decompressReader{ReadCloser: reader, f: f}, nil
+}
+
+// decompressReader is the reader of a compressed file which, when closed,
+// releases the decompressor before closing the file.
+type decompressReader struct {
+	io.ReadCloser
+	f *os.File
+}
+
+// Close implements io.Closer
+func (r *decompressReader) Close() error {
+	return errors.Join(r.ReadCloser.Close(), r.f.Close())
+}
+
+// byteCounter is an io.Writer which discards and counts its input.
+type byteCounter int64
+
+// Write implements io.Writer
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cavaliergopher/cpio"
+	"github.com/klauspost/compress/zstd"
+
+	"kraftkit.sh/initrd"
+)
+
+func TestWithCompressionLevel(t *testing.T) {
+	tests := []struct {
+		compression initrd.Compression
+		level       int
+		valid       bool
+	}{
+		{initrd.CompressionGzip, 0, true},
+		{initrd.CompressionGzip, 9, true},
+		{initrd.CompressionGzip, 10, false},
+		{initrd.CompressionZstd, 22, true},
+		{initrd.CompressionZstd, 23, false},
+		{initrd.CompressionLz4, 1, true},
+		{initrd.CompressionLz4, 9, true},
+		{initrd.CompressionLz4, -1, false},
+		{initrd.CompressionLz4, 10, false},
+		{initrd.CompressionXz, 0, true},
+		{initrd.CompressionXz, 6, false},
+		{initrd.CompressionNone, 6, true},
+	}
+
+	for _, tc := range tests {
+		// The level is checked whichever of both options comes first.
+		for _, opts := range [][]initrd.InitrdOption{
+			{initrd.WithCompression(tc.compression), initrd.WithCompressionLevel(tc.level)},
+			{initrd.WithCompressionLevel(tc.level), initrd.WithCompression(tc.compression)},
+		} {
+			_, err := initrd.NewFromDirectory(context.Background(), "testdata/rootfs", opts...)
+			if tc.valid && err != nil {
+				t.Errorf("%s level %d: unexpected error: %v", tc.compression, tc.level, err)
+			} else if !tc.valid && err == nil {
+				t.Errorf("%s level %d: expected an error", tc.compression, tc.level)
+			}
+		}
+	}
+}
+
+func TestCompressedSize(t *testing.T) {
+	contents := strings.Repeat("kraftkit ", 1024)
+
+	for _, compression := range initrd.Compressions() {
+		size, err := initrd.CompressedSize(strings.NewReader(contents), compression, 0)
+		if err != nil {
+			t.Fatalf("%s: %v", compression, err)
+		}
+
+		if !compression.Enabled() && size != int64(len(contents)) {
+			t.Errorf("%s: expected %d bytes, got %d", compression, len(contents), size)
+		} else if size == 0 {
+			t.Errorf("%s: expected compressed contents", compression)
+		}
+	}
+
+	if _, err := initrd.CompressedSize(strings.NewReader(contents), initrd.CompressionLz4, -1); err == nil {
+		t.Error("expected an error for an invalid lz4 level")
+	}
+}
+
+func TestDecompress(t *testing.T) {
+	ctx := context.Background()
+
+	for _, compression := range initrd.Compressions() {
+		ird, err := initrd.NewFromDirectory(ctx, "testdata/rootfs",
+			initrd.WithCompression(compression),
+			initrd.WithOutput(filepath.Join(t.TempDir(), "initramfs.cpio")),
+		)
+		if err != nil {
+			t.Fatalf("%s: NewFromDirectory: %v", compression, err)
+		}
+
+		irdPath, err := ird.Build(ctx)
+		if err != nil {
+			t.Fatalf("%s: Build: %v", compression, err)
+		}
+
+		reader, err := initrd.Decompress(irdPath)
+		if err != nil {
+			t.Fatalf("%s: Decompress: %v", compression, err)
+		}
+
+		var names []string
+
+		r := cpio.NewReader(reader)
+		for {
+			header, err := r.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				t.Fatalf("%s: %v", compression, err)
+			}
+
+			names = append(names, header.Name)
+		}
+
+		if !slices.Contains(names, "./etc/app.conf") {
+			t.Errorf("%s: expected ./etc/app.conf in decompressed archive, got %v", compression, names)
+		}
+
+		if err := reader.Close(); err != nil {
+			t.Errorf("%s: Close: %v", compression, err)
+		}
+
+		// The decoder, which runs in the background, is released alongside the
+		// file.
+		if compression != initrd.CompressionZstd {
+			continue
+		}
+
+		if _, err := reader.Read(make([]byte, 1)); !errors.Is(err, zstd.ErrDecoderClosed) {
+			t.Errorf("%s: expected the decoder to be closed, got %v", compression, err)
+		}
+	}
+}
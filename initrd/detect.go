@@ -7,11 +7,16 @@ package initrd
 import (
 	"context"
 	"fmt"
+	"strings"
 )
 
 // New attempts to return the builder for a supplied path which
 // will allow the provided ...
 func New(ctx context.Context, path string, opts ...InitrdOption) (Initrd, error) {
+	if strings.HasPrefix(path, ELFPrefix) {
+		return NewFromELF(ctx, path, opts...)
+	}
+
 	if builder, err := NewFromOCIImage(ctx, path, opts...); err == nil {
 		return builder, nil
 	} else if builder, err := NewFromDockerfile(ctx, path, opts...); err == nil {
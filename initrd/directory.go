@@ -62,6 +62,51 @@ func (initrd *directory) Build(ctx context.Context) (string, error) {
 		}
 	}
 
+	if initrd.opts.buildCache == "" {
+		return initrd.build(ctx)
+	}
+
+	inputs, err := digestTree(initrd.path,
+		initrd.opts.output,
+		initrd.opts.cacheDir,
+		initrd.opts.buildCache,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	key := initrd.opts.cacheKey("directory", inputs)
+	if entry, ok := loadCache(ctx, initrd.opts, key); ok {
+		initrd.files = entry.Files
+		return initrd.opts.output, nil
+	}
+
+	output, err := initrd.build(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	storeCache(ctx, initrd.opts, key, cacheEntry{
+		Files: initrd.files,
+	})
+
+	return output, nil
+}
+
+// build serializes the directory to the output set in the options.
+func (initrd *directory) build(ctx context.Context) (string, error) {
+	if initrd.opts.format.BlockDevice() {
+		if err := collectFiles(initrd.path, &initrd.files); err != nil {
+			return "", fmt.Errorf("could not walk output path: %w", err)
+		}
+
+		if err := buildFilesystem(ctx, initrd.opts, initrd.path, initrd.opts.output); err != nil {
+			return "", err
+		}
+
+		return initrd.opts.output, nil
+	}
+
 	f, err := os.OpenFile(initrd.opts.output, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
 	if err != nil {
 		return "", fmt.Errorf("could not open initramfs file: %w", err)
@@ -72,12 +117,12 @@ func (initrd *directory) Build(ctx context.Context) (string, error) {
 	writer := cpio.NewWriter(f)
 	defer writer.Close()
 
-	if err := walkFiles(ctx, initrd.path, writer, &initrd.files); err != nil {
+	if err := walkFiles(ctx, initrd.path, writer, &initrd.files, initrd.opts.reproducible); err != nil {
 		return "", fmt.Errorf("could not walk output path: %w", err)
 	}
 
-	if initrd.opts.compress {
-		if err := compressFiles(initrd.opts.output, writer, f); err != nil {
+	if initrd.opts.compression.Enabled() {
+		if err := compressFiles(initrd.opts, writer, f); err != nil {
 			return "", fmt.Errorf("could not compress files: %w", err)
 		}
 	}
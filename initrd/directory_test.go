@@ -6,9 +6,13 @@ package initrd_test
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/cavaliergopher/cpio"
 
@@ -96,6 +100,115 @@ func TestNewFromDirectory(t *testing.T) {
 	}
 }
 
+func TestNewFromDirectoryReproducible(t *testing.T) {
+	const epoch = 1262304000 // 2010-01-01T00:00:00Z
+
+	t.Setenv("SOURCE_DATE_EPOCH", "1262304000")
+
+	ctx := context.Background()
+
+	// makeTree creates the same tree of files in a new directory, in a different
+	// order, with different modification times and, if possible, owners.
+	makeTree := func(t *testing.T, mtime time.Time, uid int, reverse bool) string {
+		t.Helper()
+
+		dir := t.TempDir()
+
+		steps := []func() error{
+			func() error { return os.MkdirAll(filepath.Join(dir, "etc"), 0o755) },
+			func() error { return os.WriteFile(filepath.Join(dir, "etc", "app.conf"), []byte("key=value\n"), 0o644) },
+			func() error {
+				return os.WriteFile(filepath.Join(dir, "entrypoint.sh"), []byte("#!/bin/sh\nexec /app\n"), 0o755)
+			},
+		}
+		if reverse {
+			steps[1], steps[2] = steps[2], steps[1]
+		}
+
+		for _, step := range steps {
+			if err := step(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := os.Link(filepath.Join(dir, "entrypoint.sh"), filepath.Join(dir, "init")); err != nil {
+			t.Fatal("Failed to create hardlink:", err)
+		}
+
+		if err := os.Symlink("etc/app.conf", filepath.Join(dir, "app.conf")); err != nil {
+			t.Fatal("Failed to create symbolic link:", err)
+		}
+
+		for _, path := range []string{"etc/app.conf", "entrypoint.sh", "etc", "."} {
+			if err := os.Chtimes(filepath.Join(dir, path), mtime, mtime); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if os.Getuid() == 0 {
+			for _, path := range []string{"etc/app.conf", "entrypoint.sh", "app.conf", "etc"} {
+				if err := os.Lchown(filepath.Join(dir, path), uid, uid); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+
+		return dir
+	}
+
+	build := func(t *testing.T, dir string) string {
+		t.Helper()
+
+		output := filepath.Join(t.TempDir(), "initramfs.cpio")
+
+		ird, err := initrd.NewFromDirectory(ctx, dir,
+			initrd.WithOutput(output),
+			initrd.WithReproducible(true),
+		)
+		if err != nil {
+			t.Fatal("NewFromDirectory:", err)
+		}
+
+		if _, err := ird.Build(ctx); err != nil {
+			t.Fatal("Build:", err)
+		}
+
+		data, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		digest := sha256.Sum256(data)
+
+		r := cpio.NewReader(openFile(t, output))
+		for {
+			hdr, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal("Failed to read next cpio header:", err)
+			}
+
+			if hdr.ModTime.Unix() != epoch {
+				t.Errorf("file [%s]: got mtime %d, expected it to be clamped to %d", hdr.Name, hdr.ModTime.Unix(), epoch)
+			}
+			if hdr.Uid != 0 || hdr.Guid != 0 {
+				t.Errorf("file [%s]: got owner %d:%d, expected 0:0", hdr.Name, hdr.Uid, hdr.Guid)
+			}
+		}
+
+		return hex.EncodeToString(digest[:])
+	}
+
+	first := build(t, makeTree(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), 1000, false))
+	second := build(t, makeTree(t, time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC), 2000, true))
+
+	if first != second {
+		t.Errorf("expected identical archives, got digests %s and %s", first, second)
+	}
+}
+
 // openFile opens a file for reading, and closes it when the test completes.
 func openFile(t *testing.T, path string) io.Reader {
 	t.Helper()
@@ -4,5 +4,7 @@
 // You may not use this file except in compliance with the License.
 
 // Package initrd is a package that is used for the dynamic construction of CPIO
-// archives which are used as initramfs for a unikernel instance.
+// archives which are used as initramfs for a unikernel instance, or of
+// read-only EROFS and SquashFS images which are attached to the instance as a
+// block device.
 package initrd
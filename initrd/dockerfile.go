@@ -5,7 +5,7 @@
 package initrd
 
 import (
-	"archive/tar"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -18,15 +18,22 @@ import (
 
 	"golang.org/x/sync/errgroup"
 	"kraftkit.sh/config"
+	"kraftkit.sh/internal/offline"
 	"kraftkit.sh/log"
+	"kraftkit.sh/unikraft"
 
 	sfile "github.com/anchore/stereoscope/pkg/file"
 	soci "github.com/anchore/stereoscope/pkg/image/oci"
 	"github.com/cavaliergopher/cpio"
+	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/types"
 	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
 	"github.com/moby/buildkit/identity"
 	"github.com/moby/buildkit/session/filesync"
 	"github.com/moby/buildkit/util/progress/progressui"
+	"github.com/opencontainers/go-digest"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
 
@@ -153,6 +160,132 @@ func (initrd *dockerfile) Build(ctx context.Context) (string, error) {
 		initrd.opts.output = fi.Name()
 	}
 
+	if initrd.opts.buildCache == "" {
+		return initrd.build(ctx)
+	}
+
+	raw, err := os.ReadFile(initrd.dockerfile)
+	if err != nil {
+		return "", fmt.Errorf("could not read Dockerfile: %w", err)
+	}
+
+	// The build context is sent in full to BuildKit, except for the files which
+	// are generated by building the project itself.
+	buildContext, err := digestTree(initrd.workdir,
+		initrd.opts.output,
+		initrd.opts.cacheDir,
+		initrd.opts.buildCache,
+		filepath.Join(initrd.workdir, unikraft.VendorDir),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	// The base images are referred to by tags which may be moved to different
+	// images, which would otherwise never be picked up once the cache is warm.
+	bases, err := baseImageDigests(ctx, nil, raw)
+	if err != nil {
+		log.G(ctx).
+			WithField("dockerfile", initrd.dockerfile).
+			Debugf("not using rootfs cache: %s", err)
+		return initrd.build(ctx)
+	}
+
+	inputs := digest.FromBytes(raw).String() + buildContext.String()
+	for _, base := range bases {
+		inputs += base.String()
+	}
+
+	key := initrd.opts.cacheKey("dockerfile", digest.FromString(inputs))
+	if entry, ok := loadCache(ctx, initrd.opts, key); ok {
+		initrd.args = entry.Args
+		initrd.env = entry.Env
+		initrd.files = entry.Files
+		return initrd.opts.output, nil
+	}
+
+	output, err := initrd.build(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	storeCache(ctx, initrd.opts, key, cacheEntry{
+		Args:  initrd.args,
+		Env:   initrd.env,
+		Files: initrd.files,
+	})
+
+	return output, nil
+}
+
+// baseImageDigests returns the digests of the images which the stages of the
+// Dockerfile are based on, in order.  Images which are referred to by a tag are
+// resolved against their registry.
+func baseImageDigests(ctx context.Context, sysCtx *types.SystemContext, raw []byte) ([]digest.Digest, error) {
+	result, err := parser.Parse(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse Dockerfile: %w", err)
+	}
+
+	var digests []digest.Digest
+	stages := map[string]struct{}{}
+
+	for _, node := range result.AST.Children {
+		if !strings.EqualFold(node.Value, "from") || node.Next == nil {
+			continue
+		}
+
+		image := node.Next.Value
+
+		// Stages may be based on previous stages of the same Dockerfile.
+		_, stage := stages[strings.ToLower(image)]
+		if as := node.Next.Next; as != nil && strings.EqualFold(as.Value, "as") && as.Next != nil {
+			stages[strings.ToLower(as.Next.Value)] = struct{}{}
+		}
+
+		if stage || image == "scratch" {
+			continue
+		}
+
+		if strings.Contains(image, "$") {
+			return nil, fmt.Errorf("cannot resolve base image with build arguments: %s", image)
+		}
+
+		named, err := reference.ParseNormalizedNamed(image)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse base image %s: %w", image, err)
+		}
+
+		if canonical, ok := named.(reference.Canonical); ok {
+			digests = append(digests, canonical.Digest())
+			continue
+		}
+
+		ref, err := docker.NewReference(reference.TagNameOnly(named))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse base image %s: %w", image, err)
+		}
+
+		// The registry client of containers/image does not use a transport which
+		// offline.Install guards.
+		if offline.Enabled(ctx) {
+			return nil, offline.NotCached(image)
+		}
+
+		dgst, err := docker.GetDigest(ctx, sysCtx, ref)
+		if err != nil {
+			return nil, fmt.Errorf("could not resolve base image %s: %w", image, err)
+		}
+
+		digests = append(digests, dgst)
+	}
+
+	return digests, nil
+}
+
+// build solves the Dockerfile via BuildKit and serializes the resulting image
+// to the output set in the options.
+func (initrd *dockerfile) build(ctx context.Context) (string, error) {
 	outputDir, err := os.MkdirTemp("", "")
 	if err != nil {
 		return "", fmt.Errorf("could not make temporary directory: %w", err)
@@ -344,65 +477,12 @@ func (initrd *dockerfile) Build(ctx context.Context) (string, error) {
 		return "", fmt.Errorf("could not open output tarball: %w", err)
 	}
 
-	tarReader := tar.NewReader(tarArchive)
-
-	hardlinks := make(map[string]string)
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break // End of archive
-		}
-		if err != nil {
-			return "", fmt.Errorf("could not read tar header: %w", err)
-		}
-
-		targetPath := filepath.Join(outputDir, header.Name)
-		switch header.Typeflag {
-		case tar.TypeDir:
-			if err := os.MkdirAll(targetPath, 0o755); err != nil {
-				return "", fmt.Errorf("could not create directory: %w", err)
-			}
-		case tar.TypeReg:
-			file, err := os.OpenFile(targetPath, os.O_CREATE|os.O_RDWR, os.FileMode(header.Mode))
-			if err != nil {
-				return "", fmt.Errorf("could not open file: %w", err)
-			}
-			defer file.Close()
-			if _, err := io.Copy(file, tarReader); err != nil {
-				return "", fmt.Errorf("could not write file: %w", err)
-			}
-		case tar.TypeSymlink:
-			if err := os.Symlink(header.Linkname, targetPath); err != nil {
-				return "", fmt.Errorf("could not create symlink: %w", err)
-			}
-		case tar.TypeLink:
-			hardlinks[header.Name] = header.Linkname
-		case tar.TypeBlock:
-			log.G(ctx).
-				WithField("file", header.Name).
-				Warn("ignoring block devices")
-		case tar.TypeChar:
-			log.G(ctx).
-				WithField("file", header.Name).
-				Warn("ignoring char devices")
-		case tar.TypeFifo:
-			log.G(ctx).
-				WithField("file", header.Name).
-				Warn("ignoring fifo files")
-		default:
-			return "", fmt.Errorf("unsupported file type: %c", header.Typeflag)
-		}
-	}
+	defer tarArchive.Close()
 
-	// Create hardlinks
-	// Note(craciunoiuc): This is done afterwards as the hardlink target might
-	// not have been created yet by the archive unpacker.
-	for src, dst := range hardlinks {
-		targetPath := filepath.Join(outputDir, src)
-		oldPath := filepath.Join(outputDir, dst)
-		if err := os.Link(oldPath, targetPath); err != nil {
-			return "", fmt.Errorf("could not create hardlink: %w", err)
-		}
+	// The exported file system is already flattened, but may still contain
+	// hardlinks and special files.
+	if err := applyLayer(ctx, outputDir, tarArchive); err != nil {
+		return "", fmt.Errorf("could not extract output tarball: %w", err)
 	}
 
 	// parse the output directory with stereoscope
@@ -439,6 +519,18 @@ func (initrd *dockerfile) Build(ctx context.Context) (string, error) {
 		return "", fmt.Errorf("could not cleanup image: %w", err)
 	}
 
+	if initrd.opts.format.BlockDevice() {
+		if err := collectFiles(outputDir, &initrd.files); err != nil {
+			return "", fmt.Errorf("could not walk output path: %w", err)
+		}
+
+		if err := buildFilesystem(ctx, initrd.opts, outputDir, initrd.opts.output); err != nil {
+			return "", err
+		}
+
+		return initrd.opts.output, nil
+	}
+
 	f, err := os.OpenFile(initrd.opts.output, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
 	if err != nil {
 		return "", fmt.Errorf("could not open initramfs file: %w", err)
@@ -449,12 +541,12 @@ func (initrd *dockerfile) Build(ctx context.Context) (string, error) {
 	writer := cpio.NewWriter(f)
 	defer writer.Close()
 
-	if err := walkFiles(ctx, outputDir, writer, &initrd.files); err != nil {
+	if err := walkFiles(ctx, outputDir, writer, &initrd.files, initrd.opts.reproducible); err != nil {
 		return "", fmt.Errorf("could not walk output path: %w", err)
 	}
 
-	if initrd.opts.compress {
-		if err := compressFiles(initrd.opts.output, writer, f); err != nil {
+	if initrd.opts.compression.Enabled() {
+		if err := compressFiles(initrd.opts, writer, f); err != nil {
 			return "", fmt.Errorf("could not compress files: %w", err)
 		}
 	}
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd
+
+import (
+	"context"
+	"errors"
+	"io"
+	golog "log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/containers/image/v5/types"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/opencontainers/go-digest"
+
+	"kraftkit.sh/internal/offline"
+	"kraftkit.sh/internal/offline/offlinetest"
+)
+
+func TestBaseImageDigests(t *testing.T) {
+	server := httptest.NewServer(registry.New(registry.Logger(golog.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	sysCtx := &types.SystemContext{DockerInsecureSkipTLSVerify: types.OptionalBoolTrue}
+	ctx := context.Background()
+
+	// push pushes a random image to the tag of the registry and returns its
+	// digest.
+	push := func(t *testing.T, tag string) digest.Digest {
+		t.Helper()
+
+		img, err := random.Image(64, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ref, err := name.ParseReference(host + "/" + tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := remote.Write(ref, img); err != nil {
+			t.Fatal(err)
+		}
+
+		dgst, err := img.Digest()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return digest.Digest(dgst.String())
+	}
+
+	pinned := digest.FromString("alpine")
+	dockerfile := []byte(strings.Join([]string{
+		"FROM alpine@" + pinned.String() + " AS build",
+		"RUN make",
+		"FROM " + host + "/library/base:latest",
+		"COPY --from=build /app /app",
+		"FROM build AS test",
+		"FROM scratch",
+		"COPY --from=build /app /app",
+	}, "\n"))
+
+	first := push(t, "library/base:latest")
+
+	digests, err := baseImageDigests(ctx, sysCtx, dockerfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(digests) != 2 || digests[0] != pinned || digests[1] != first {
+		t.Errorf("expected base images %s and %s, got %v", pinned, first, digests)
+	}
+
+	// Moving the tag changes the digest of the base image.
+	second := push(t, "library/base:latest")
+
+	digests, err = baseImageDigests(ctx, sysCtx, dockerfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(digests) != 2 || digests[1] != second {
+		t.Errorf("expected moved base image %s, got %v", second, digests)
+	}
+
+	// Base images which depend on build arguments cannot be resolved.
+	if _, err := baseImageDigests(ctx, sysCtx, []byte("ARG BASE=alpine\nFROM ${BASE}\n")); err == nil {
+		t.Errorf("expected base image with build arguments not to be resolved")
+	}
+
+	// Tags are not resolved in offline mode.
+	if _, err := baseImageDigests(offlinetest.Context(t), sysCtx, dockerfile); !errors.Is(err, offline.ErrOffline) {
+		t.Errorf("expected base image not to be resolved offline, got %v", err)
+	}
+}
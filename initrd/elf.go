@@ -0,0 +1,487 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd
+
+import (
+	"bufio"
+	"context"
+	"debug/elf"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"kraftkit.sh/log"
+)
+
+// ELFPrefix is the prefix of a rootfs path which indicates that the rootfs is
+// generated from one or more comma-separated dynamically linked executables of
+// the host, e.g. `elf:/usr/bin/redis-server`.
+const ELFPrefix = "elf:"
+
+// maxSymlinks is the maximum number of symbolic links which are followed when
+// resolving a single path, mirroring the ELOOP limit of Linux.
+const maxSymlinks = 40
+
+type elfbinary struct {
+	opts        InitrdOptions
+	executables []string
+	files       []string
+}
+
+// NewFromELF returns an instantiated Initrd interface which is able to
+// serialize a minimal rootfs which contains the provided host executables,
+// their program interpreter and all of the shared libraries they depend on.
+func NewFromELF(_ context.Context, path string, opts ...InitrdOption) (Initrd, error) {
+	if !strings.HasPrefix(path, ELFPrefix) {
+		return nil, fmt.Errorf("path is not prefixed with %s", ELFPrefix)
+	}
+
+	rootfs := elfbinary{
+		opts: InitrdOptions{},
+	}
+
+	for _, exe := range strings.Split(strings.TrimPrefix(path, ELFPrefix), ",") {
+		if exe == "" {
+			continue
+		}
+
+		exe, err := filepath.Abs(exe)
+		if err != nil {
+			return nil, fmt.Errorf("could not resolve path: %w", err)
+		}
+
+		f, err := elf.Open(exe)
+		if err != nil {
+			return nil, fmt.Errorf("could not open ELF executable %s: %w", exe, err)
+		}
+
+		f.Close()
+
+		rootfs.executables = append(rootfs.executables, exe)
+	}
+
+	if len(rootfs.executables) == 0 {
+		return nil, fmt.Errorf("no ELF executables provided")
+	}
+
+	for _, opt := range opts {
+		if err := opt(&rootfs.opts); err != nil {
+			return nil, err
+		}
+	}
+
+	return &rootfs, nil
+}
+
+// Build implements Initrd.
+func (initrd *elfbinary) Build(ctx context.Context) (string, error) {
+	root, err := os.MkdirTemp(initrd.opts.workdir, "kraftkit-elf-rootfs-*")
+	if err != nil {
+		return "", fmt.Errorf("could not make temporary directory: %w", err)
+	}
+
+	defer os.RemoveAll(root)
+
+	resolver := newELFResolver()
+
+	for _, exe := range initrd.executables {
+		deps, err := resolver.resolve(ctx, exe)
+		if err != nil {
+			return "", fmt.Errorf("could not resolve dependencies of %s: %w", exe, err)
+		}
+
+		for _, dep := range append([]string{exe}, deps...) {
+			if err := copyPath(root, dep); err != nil {
+				return "", fmt.Errorf("could not copy %s: %w", dep, err)
+			}
+		}
+	}
+
+	for _, include := range initrd.opts.includes {
+		host, machine, _ := strings.Cut(include, ":")
+
+		host, err := filepath.Abs(host)
+		if err != nil {
+			return "", fmt.Errorf("could not resolve path: %w", err)
+		}
+
+		if machine == "" {
+			err = copyPath(root, host)
+		} else {
+			err = copyTree(host, filepath.Join(root, filepath.Clean("/"+machine)))
+		}
+		if err != nil {
+			return "", fmt.Errorf("could not include %s: %w", include, err)
+		}
+	}
+
+	dir := directory{
+		opts: initrd.opts,
+		path: root,
+	}
+
+	output, err := dir.Build(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	initrd.opts.output = output
+	initrd.files = dir.Files()
+
+	return output, nil
+}
+
+// Files implements Initrd.
+func (initrd *elfbinary) Files() []string {
+	return initrd.files
+}
+
+// Env implements Initrd.
+func (initrd *elfbinary) Env() []string {
+	return nil
+}
+
+// Args implements Initrd.
+func (initrd *elfbinary) Args() []string {
+	return initrd.executables[:1]
+}
+
+// elfResolver locates the program interpreter and shared libraries of ELF
+// files in the same way as the host's dynamic linker.
+type elfResolver struct {
+	// libraryPath are the directories set via LD_LIBRARY_PATH.
+	libraryPath []string
+
+	// systemPath are the directories configured in /etc/ld.so.conf followed by
+	// the default library directories.
+	systemPath []string
+
+	// seen are the files which have already been resolved.
+	seen map[string]struct{}
+}
+
+// newELFResolver returns a resolver using the library search paths of the
+// host.
+func newELFResolver() *elfResolver {
+	resolver := elfResolver{
+		seen: make(map[string]struct{}),
+	}
+
+	if env := os.Getenv("LD_LIBRARY_PATH"); env != "" {
+		resolver.libraryPath = filepath.SplitList(env)
+	}
+
+	resolver.systemPath = append(resolver.systemPath, parseLdSoConf("/etc/ld.so.conf", 0)...)
+	resolver.systemPath = append(resolver.systemPath,
+		"/lib/x86_64-linux-gnu",
+		"/usr/lib/x86_64-linux-gnu",
+		"/lib/aarch64-linux-gnu",
+		"/usr/lib/aarch64-linux-gnu",
+		"/lib64",
+		"/usr/lib64",
+		"/lib",
+		"/usr/lib",
+		"/usr/local/lib",
+	)
+
+	return &resolver
+}
+
+// resolve returns the host paths of the program interpreter and all shared
+// libraries the ELF file at the provided path transitively depends on.
+func (resolver *elfResolver) resolve(ctx context.Context, path string) ([]string, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	resolver.seen[path] = struct{}{}
+
+	var deps []string
+
+	for _, prog := range f.Progs {
+		if prog.Type != elf.PT_INTERP {
+			continue
+		}
+
+		interp, err := io.ReadAll(prog.Open())
+		if err != nil {
+			return nil, fmt.Errorf("could not read program interpreter: %w", err)
+		}
+
+		// Shared libraries name the same program interpreter as the executable.
+		interpreter := strings.TrimRight(string(interp), "\x00")
+		if _, ok := resolver.seen[interpreter]; ok {
+			continue
+		}
+
+		resolver.seen[interpreter] = struct{}{}
+		deps = append(deps, interpreter)
+	}
+
+	needed, err := f.DynString(elf.DT_NEEDED)
+	if err != nil {
+		// Statically linked executables have no dynamic section.
+		return deps, nil
+	}
+
+	origin := filepath.Dir(path)
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		origin = filepath.Dir(real)
+	}
+
+	rpath, _ := f.DynString(elf.DT_RPATH)
+	runpath, _ := f.DynString(elf.DT_RUNPATH)
+
+	// DT_RPATH is only considered when there is no DT_RUNPATH and is searched
+	// before LD_LIBRARY_PATH, whereas DT_RUNPATH is searched afterwards.
+	var dirs []string
+	if len(runpath) == 0 {
+		dirs = append(dirs, expandOrigin(rpath, origin)...)
+	}
+	dirs = append(dirs, resolver.libraryPath...)
+	dirs = append(dirs, expandOrigin(runpath, origin)...)
+	dirs = append(dirs, resolver.systemPath...)
+
+	for _, name := range needed {
+		lib, err := resolver.find(name, dirs, f.Class, f.Machine)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := resolver.seen[lib]; ok {
+			continue
+		}
+
+		log.G(ctx).
+			WithField("library", lib).
+			Trace("resolved")
+
+		transitive, err := resolver.resolve(ctx, lib)
+		if err != nil {
+			return nil, err
+		}
+
+		deps = append(deps, lib)
+		deps = append(deps, transitive...)
+	}
+
+	return deps, nil
+}
+
+// find returns the first shared library of the provided name in the search
+// directories which matches the class and machine of the dependent file.
+func (resolver *elfResolver) find(name string, dirs []string, class elf.Class, machine elf.Machine) (string, error) {
+	if strings.Contains(name, "/") {
+		return name, nil
+	}
+
+	for _, dir := range dirs {
+		candidate := filepath.Join(dir, name)
+
+		f, err := elf.Open(candidate)
+		if err != nil {
+			continue
+		}
+
+		matches := f.Class == class && f.Machine == machine
+		f.Close()
+
+		if matches {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("could not find shared library %s for %s", name, machine)
+}
+
+// expandOrigin splits the colon-separated search paths of DT_RPATH or
+// DT_RUNPATH entries and substitutes the $ORIGIN token with the directory of
+// the file which contains them.
+func expandOrigin(entries []string, origin string) []string {
+	var dirs []string
+
+	for _, entry := range entries {
+		for _, dir := range strings.Split(entry, ":") {
+			if dir == "" {
+				continue
+			}
+
+			dir = strings.ReplaceAll(dir, "${ORIGIN}", origin)
+			dir = strings.ReplaceAll(dir, "$ORIGIN", origin)
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return dirs
+}
+
+// parseLdSoConf returns the library directories listed in the dynamic linker
+// configuration file at the provided path, following its include directives.
+func parseLdSoConf(path string, depth int) []string {
+	if depth > maxSymlinks {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+
+	defer f.Close()
+
+	var dirs []string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+
+		if line == "" || strings.HasPrefix(line, "hwcap ") {
+			continue
+		}
+
+		if pattern, ok := strings.CutPrefix(line, "include "); ok {
+			pattern = strings.TrimSpace(pattern)
+			if !filepath.IsAbs(pattern) {
+				pattern = filepath.Join(filepath.Dir(path), pattern)
+			}
+
+			matches, _ := filepath.Glob(pattern)
+			for _, match := range matches {
+				dirs = append(dirs, parseLdSoConf(match, depth+1)...)
+			}
+
+			continue
+		}
+
+		dirs = append(dirs, line)
+	}
+
+	return dirs
+}
+
+// copyPath copies the host file at the provided absolute path to the same
+// location within root.  Symbolic links along the path, including those of
+// its parent directories, are re-created in root alongside their targets such
+// that the file is reachable under all of its names.  Directories are copied
+// recursively.
+func copyPath(root, path string) error {
+	return copyPathDepth(root, filepath.Clean(path), 0)
+}
+
+func copyPathDepth(root, path string, links int) error {
+	if links > maxSymlinks {
+		return fmt.Errorf("too many levels of symbolic links: %s", path)
+	}
+
+	components := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	current := "/"
+
+	for i, component := range components {
+		next := filepath.Join(current, component)
+		target := filepath.Join(root, next)
+
+		fi, err := os.Lstat(next)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case fi.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(next)
+			if err != nil {
+				return err
+			}
+
+			if _, err := os.Lstat(target); os.IsNotExist(err) {
+				if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+					return err
+				}
+				if err := os.Symlink(link, target); err != nil {
+					return err
+				}
+			}
+
+			if !filepath.IsAbs(link) {
+				link = filepath.Join(current, link)
+			}
+
+			rest := append([]string{link}, components[i+1:]...)
+
+			return copyPathDepth(root, filepath.Join(rest...), links+1)
+
+		case fi.IsDir() && i < len(components)-1:
+			if err := os.MkdirAll(target, fi.Mode().Perm()|0o700); err != nil {
+				return err
+			}
+
+			current = next
+
+		default:
+			return copyTree(next, target)
+		}
+	}
+
+	return nil
+}
+
+// copyTree copies the file or directory at src to dst, following src itself
+// if it is a symbolic link but preserving symbolic links within directories.
+func copyTree(src, dst string) error {
+	fi, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if !fi.IsDir() {
+		return copyFile(src, dst, fi.Mode().Perm())
+	}
+
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0o700)
+
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+
+			if err := os.RemoveAll(target); err != nil {
+				return err
+			}
+
+			return os.Symlink(link, target)
+
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,184 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd
+
+import (
+	"context"
+	"debug/elf"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestExpandOrigin(t *testing.T) {
+	tests := []struct {
+		entries  []string
+		expected []string
+	}{
+		{nil, nil},
+		{[]string{"/opt/lib"}, []string{"/opt/lib"}},
+		{[]string{"/opt/lib:/usr/local/lib"}, []string{"/opt/lib", "/usr/local/lib"}},
+		{[]string{"$ORIGIN/../lib"}, []string{"/app/bin/../lib"}},
+		{[]string{"${ORIGIN}/lib:$ORIGIN"}, []string{"/app/bin/lib", "/app/bin"}},
+		{[]string{"::/opt/lib:"}, []string{"/opt/lib"}},
+		{[]string{"/opt/a", "$ORIGIN/b"}, []string{"/opt/a", "/app/bin/b"}},
+	}
+
+	for _, tc := range tests {
+		if dirs := expandOrigin(tc.entries, "/app/bin"); !slices.Equal(dirs, tc.expected) {
+			t.Errorf("%v: expected %v, got %v", tc.entries, tc.expected, dirs)
+		}
+	}
+}
+
+func TestParseLdSoConf(t *testing.T) {
+	expected := []string{
+		// Included files are parsed in lexical order in place of the directive.
+		"/usr/local/lib",
+		"/lib/x86_64-linux-gnu",
+		"/usr/lib/x86_64-linux-gnu",
+		"/opt/first/lib",
+	}
+
+	// A file which includes itself is followed up to the maximum depth.
+	for i := 0; i < maxSymlinks; i++ {
+		expected = append(expected, "/opt/loop/lib")
+	}
+
+	if dirs := parseLdSoConf("testdata/ldsoconf/ld.so.conf", 0); !slices.Equal(dirs, expected) {
+		t.Errorf("expected %v, got %v", expected, dirs)
+	}
+
+	if dirs := parseLdSoConf("testdata/ldsoconf/missing.conf", 0); len(dirs) > 0 {
+		t.Errorf("expected no directories for a missing file, got %v", dirs)
+	}
+}
+
+// hostExecutable returns the path of a dynamically linked executable of the
+// host or skips the test if there is none.
+func hostExecutable(t *testing.T) string {
+	t.Helper()
+
+	for _, path := range []string{"/bin/sh", "/usr/bin/env"} {
+		f, err := elf.Open(path)
+		if err != nil {
+			continue
+		}
+
+		needed, _ := f.DynString(elf.DT_NEEDED)
+		f.Close()
+
+		if len(needed) > 0 {
+			return path
+		}
+	}
+
+	t.Skip("no dynamically linked executable found on the host")
+	return ""
+}
+
+func TestELFResolverResolve(t *testing.T) {
+	path := hostExecutable(t)
+
+	deps, err := newELFResolver().resolve(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deps) == 0 {
+		t.Fatalf("expected %s to depend on a program interpreter", path)
+	}
+
+	f, err := elf.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	for _, dep := range deps {
+		if _, err := os.Stat(dep); err != nil {
+			t.Errorf("%s: %v", dep, err)
+		}
+	}
+
+	// Every library is only listed once, even if it is needed by several files.
+	for i, dep := range deps {
+		if slices.Contains(deps[i+1:], dep) {
+			t.Errorf("expected %s to be listed once, got %v", dep, deps)
+		}
+	}
+
+	var libc string
+	for _, dep := range deps {
+		if filepath.Base(dep) == "libc.so.6" || filepath.Base(dep) == "libc.musl-x86_64.so.1" {
+			libc = dep
+		}
+	}
+
+	if libc == "" {
+		t.Logf("no glibc or musl found in %v", deps)
+		return
+	}
+
+	lib, err := elf.Open(libc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer lib.Close()
+
+	if lib.Class != f.Class || lib.Machine != f.Machine {
+		t.Errorf("expected %s to match the class and machine of %s", libc, path)
+	}
+}
+
+func TestELFResolverFind(t *testing.T) {
+	path := hostExecutable(t)
+
+	f, err := elf.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	// Files which are not ELF files of the same name are skipped.
+	invalid := t.TempDir()
+	if err := os.WriteFile(filepath.Join(invalid, "libtest.so"), []byte("not an ELF file"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	valid := t.TempDir()
+	if err := os.Symlink(path, filepath.Join(valid, "libtest.so")); err != nil {
+		t.Fatal(err)
+	}
+
+	resolver := newELFResolver()
+	dirs := []string{t.TempDir(), invalid, valid}
+
+	lib, err := resolver.find("libtest.so", dirs, f.Class, f.Machine)
+	if err != nil {
+		t.Fatal(err)
+	} else if expected := filepath.Join(valid, "libtest.so"); lib != expected {
+		t.Errorf("expected %s, got %s", expected, lib)
+	}
+
+	// A library of another class is not a match.
+	other := elf.ELFCLASS32
+	if f.Class == elf.ELFCLASS32 {
+		other = elf.ELFCLASS64
+	}
+
+	if lib, err := resolver.find("libtest.so", dirs, other, f.Machine); err == nil {
+		t.Errorf("expected no library of class %s, got %s", other, lib)
+	}
+
+	// Names which contain a slash are used as-is.
+	if lib, err := resolver.find("/opt/lib/libtest.so", dirs, f.Class, f.Machine); err != nil || lib != "/opt/lib/libtest.so" {
+		t.Errorf("expected path to be used as-is, got %s: %v", lib, err)
+	}
+}
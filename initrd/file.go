@@ -6,6 +6,7 @@ package initrd
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"os"
 
@@ -13,24 +14,25 @@ import (
 )
 
 type file struct {
-	opts  InitrdOptions
-	path  string
-	files []string
+	opts   InitrdOptions
+	path   string
+	format Format
+	files  []string
 }
 
-// NewFromFile accepts an input file which already represents a CPIO archive and
-// is provided as a mechanism for satisfying the Initrd interface.
+// NewFromFile accepts an input file which already represents a CPIO archive,
+// an EROFS or a SquashFS image and is provided as a mechanism for satisfying
+// the Initrd interface.
 func NewFromFile(_ context.Context, path string, opts ...InitrdOption) (Initrd, error) {
-	fi, err := os.Open(path)
+	format, err := DetectFormat(path)
 	if err != nil {
 		return nil, err
 	}
 
-	defer fi.Close()
-
 	initrd := file{
-		opts: InitrdOptions{},
-		path: path,
+		opts:   InitrdOptions{},
+		path:   path,
+		format: format,
 	}
 
 	for _, opt := range opts {
@@ -39,6 +41,23 @@ func NewFromFile(_ context.Context, path string, opts ...InitrdOption) (Initrd,
 		}
 	}
 
+	// The contents of file system images are not listed, they are mounted
+	// as-is.
+	if format.BlockDevice() {
+		if initrd.opts.format != "" && initrd.opts.format != format {
+			return nil, fmt.Errorf("cannot convert %s image to %s", format, initrd.opts.format)
+		}
+
+		return &initrd, nil
+	}
+
+	fi, err := Decompress(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer fi.Close()
+
 	reader := cpio.NewReader(fi)
 
 	// Iterate through the files in the archive.
@@ -59,8 +78,30 @@ func NewFromFile(_ context.Context, path string, opts ...InitrdOption) (Initrd,
 }
 
 // Build implements Initrd.
-func (initrd *file) Build(_ context.Context) (string, error) {
-	return initrd.path, nil
+func (initrd *file) Build(ctx context.Context) (string, error) {
+	if initrd.format.BlockDevice() || !initrd.opts.format.BlockDevice() {
+		return initrd.path, nil
+	}
+
+	// Re-package the CPIO archive without modifying the original.
+	if initrd.opts.output == "" || initrd.opts.output == initrd.path {
+		return "", fmt.Errorf("cannot convert CPIO archive to %s without an output", initrd.opts.format)
+	}
+
+	raw, err := os.ReadFile(initrd.path)
+	if err != nil {
+		return "", fmt.Errorf("could not read initramfs file: %w", err)
+	}
+
+	if err := os.WriteFile(initrd.opts.output, raw, 0o644); err != nil {
+		return "", fmt.Errorf("could not copy initramfs file: %w", err)
+	}
+
+	if err := convertCpio(ctx, initrd.opts, initrd.opts.output); err != nil {
+		return "", err
+	}
+
+	return initrd.opts.output, nil
 }
 
 // Files implements Initrd.
@@ -0,0 +1,285 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/cavaliergopher/cpio"
+
+	"kraftkit.sh/exec"
+	"kraftkit.sh/log"
+)
+
+// Format is the on-disk representation of the root file system generated by
+// an Initrd builder.
+type Format string
+
+const (
+	// FormatCpio is a CPIO archive which is extracted into memory at boot.
+	FormatCpio = Format("cpio")
+
+	// FormatErofs is an EROFS image which is mounted read-only as a block
+	// device.
+	FormatErofs = Format("erofs")
+
+	// FormatSquashfs is a SquashFS image which is mounted read-only as a block
+	// device.
+	FormatSquashfs = Format("squashfs")
+)
+
+// String implements fmt.Stringer
+func (format Format) String() string {
+	return string(format)
+}
+
+// Formats returns the list of supported root file system formats.
+func Formats() []Format {
+	return []Format{
+		FormatCpio,
+		FormatErofs,
+		FormatSquashfs,
+	}
+}
+
+// BlockDevice returns whether the format is a file system image which is
+// attached to the machine as a block device rather than extracted into memory.
+func (format Format) BlockDevice() bool {
+	return format == FormatErofs || format == FormatSquashfs
+}
+
+const (
+	// squashfsMagic is found at the very start of a SquashFS image.
+	squashfsMagic = "hsqs"
+
+	// erofsMagic is found in the EROFS super block at erofsMagicOffset.
+	erofsMagic       = 0xE0F5E1E2
+	erofsMagicOffset = 1024
+)
+
+// DetectFormat returns the format of an already built root file system by
+// inspecting its magic numbers.  Any file which is not recognised as a file
+// system image is assumed to be a (possibly compressed) CPIO archive.
+func DetectFormat(path string) (Format, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close()
+
+	header := make([]byte, erofsMagicOffset+4)
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", fmt.Errorf("could not read %s: %w", path, err)
+	}
+
+	header = header[:n]
+
+	if bytes.HasPrefix(header, []byte(squashfsMagic)) {
+		return FormatSquashfs, nil
+	}
+
+	if len(header) == erofsMagicOffset+4 && binary.LittleEndian.Uint32(header[erofsMagicOffset:]) == erofsMagic {
+		return FormatErofs, nil
+	}
+
+	return FormatCpio, nil
+}
+
+// erofsCompression maps compression algorithms to the names used by
+// mkfs.erofs.
+var erofsCompression = map[Compression]string{
+	CompressionGzip: "deflate",
+	CompressionZstd: "zstd",
+	CompressionXz:   "lzma",
+	CompressionLz4:  "lz4hc",
+}
+
+// buildFilesystem serializes the contents of the directory into an image of
+// the block device format set in the options at the output path.
+func buildFilesystem(ctx context.Context, opts InitrdOptions, dir, output string) error {
+	format := opts.format
+
+	var bin string
+	var args []string
+
+	switch format {
+	case FormatErofs:
+		bin = "mkfs.erofs"
+		args = []string{"--all-root"}
+		if opts.compression.Enabled() {
+			algorithm, ok := erofsCompression[opts.compression]
+			if !ok {
+				return fmt.Errorf("%s does not support %s compression", format, opts.compression)
+			}
+			if opts.level != 0 {
+				algorithm += "," + strconv.Itoa(opts.level)
+			}
+			args = append(args, "-z"+algorithm)
+		}
+		if opts.reproducible {
+			args = append(args,
+				"-T", strconv.FormatInt(sourceDateEpoch().Unix(), 10),
+				"-U", "00000000-0000-0000-0000-000000000000",
+			)
+		}
+		args = append(args, output, dir)
+
+	case FormatSquashfs:
+		bin = "mksquashfs"
+		args = []string{dir, output, "-noappend", "-all-root", "-quiet"}
+		if opts.compression.Enabled() {
+			args = append(args, "-comp", opts.compression.String())
+			if opts.level != 0 {
+				args = append(args, "-Xcompression-level", strconv.Itoa(opts.level))
+			}
+		} else {
+			args = append(args, "-noI", "-noD", "-noF", "-noX")
+		}
+		if opts.reproducible {
+			epoch := strconv.FormatInt(sourceDateEpoch().Unix(), 10)
+			args = append(args, "-mkfs-time", epoch, "-all-time", epoch)
+		}
+
+	default:
+		return fmt.Errorf("unsupported block device rootfs format: %s", format)
+	}
+
+	// Both tools refuse to (or append to) an existing output.
+	if err := os.Remove(output); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not remove existing rootfs: %w", err)
+	}
+
+	var out bytes.Buffer
+
+	process, err := exec.NewProcess(bin, args,
+		exec.WithStdout(&out),
+	)
+	if err != nil {
+		return fmt.Errorf("could not prepare %s: %w", bin, err)
+	}
+
+	if err := process.StartAndWait(ctx); err != nil {
+		log.G(ctx).Debug(out.String())
+		return fmt.Errorf("could not generate %s rootfs (is %s installed?): %w", format, bin, err)
+	}
+
+	return nil
+}
+
+// convertCpio re-packages the CPIO archive at the provided path in-place into
+// an image of the block device format set in the options.
+func convertCpio(ctx context.Context, opts InitrdOptions, path string) error {
+	tmp, err := os.MkdirTemp("", "kraftkit-rootfs-*")
+	if err != nil {
+		return fmt.Errorf("could not create temporary directory: %w", err)
+	}
+
+	defer os.RemoveAll(tmp)
+
+	if err := extractCpio(path, tmp); err != nil {
+		return fmt.Errorf("could not extract CPIO archive: %w", err)
+	}
+
+	return buildFilesystem(ctx, opts, tmp, path)
+}
+
+// extractCpio unpacks the (possibly compressed) CPIO archive at the provided
+// path into the destination directory.
+func extractCpio(path, dest string) error {
+	f, err := Decompress(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	reader := cpio.NewReader(f)
+
+	// The path of the first entry of each group of hardlinks, keyed by the
+	// inode which the entries of the group share.
+	hardlinks := map[[2]int64]string{}
+
+	for {
+		hdr, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := resolveInRoot(dest, hdr.Name)
+		if err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+
+		switch hdr.Mode & cpio.ModeType {
+		case cpio.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(hdr.Mode.Perm())); err != nil {
+				return err
+			}
+
+			if err := os.Chmod(target, hdr.FileInfo().Mode()&^os.ModeType); err != nil {
+				return err
+			}
+
+		case cpio.TypeSymlink:
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return err
+			}
+
+		case cpio.TypeReg:
+			// Only one entry of a group of hardlinks carries the contents of the
+			// file, which is not necessarily the first one.  Later entries are
+			// linked to the first one and, if they carry the contents, write them
+			// through the link.
+			if hdr.Links > 1 {
+				key := [2]int64{int64(hdr.DeviceID), hdr.Inode}
+				if first, ok := hardlinks[key]; ok {
+					if err := os.Link(first, target); err != nil {
+						return err
+					}
+
+					if hdr.Size == 0 {
+						continue
+					}
+				} else {
+					hardlinks[key] = target
+				}
+			}
+
+			out, err := os.OpenFile(target, os.O_RDWR|os.O_CREATE|os.O_TRUNC, os.FileMode(hdr.Mode.Perm()))
+			if err != nil {
+				return err
+			}
+
+			_, err = io.Copy(out, reader)
+			out.Close()
+			if err != nil {
+				return err
+			}
+
+			if err := os.Chmod(target, hdr.FileInfo().Mode()&^os.ModeType); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
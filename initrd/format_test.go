@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cavaliergopher/cpio"
+)
+
+func TestExtractCpioHardlinks(t *testing.T) {
+	const contents = "#!/bin/sh\nexec /app\n"
+
+	tests := []struct {
+		name string
+		// carrier is the index of the entry of the group of hardlinks which
+		// carries the contents of the file.
+		carrier int
+	}{
+		{"data on the first link", 0},
+		{"data on the last link", 2},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			archive := filepath.Join(t.TempDir(), "initramfs.cpio")
+
+			f, err := os.Create(archive)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			writer := cpio.NewWriter(f)
+
+			for i, name := range []string{"./entrypoint.sh", "./init", "./sbin-init"} {
+				hdr := &cpio.Header{
+					Name:  name,
+					Mode:  cpio.TypeReg | 0o755,
+					Links: 3,
+					Inode: 42,
+				}
+
+				var data []byte
+				if i == tc.carrier {
+					data = []byte(contents)
+					hdr.Size = int64(len(data))
+				}
+
+				if err := writer.WriteHeader(hdr); err != nil {
+					t.Fatal(err)
+				}
+
+				if _, err := writer.Write(data); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := f.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			dest := t.TempDir()
+			if err := extractCpio(archive, dest); err != nil {
+				t.Fatal(err)
+			}
+
+			first, err := os.Stat(filepath.Join(dest, "entrypoint.sh"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, name := range []string{"entrypoint.sh", "init", "sbin-init"} {
+				path := filepath.Join(dest, name)
+
+				data, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if string(data) != contents {
+					t.Errorf("%s: expected contents %q, got %q", name, contents, data)
+				}
+
+				fi, err := os.Stat(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !os.SameFile(first, fi) {
+					t.Errorf("%s: expected a hardlink of entrypoint.sh", name)
+				}
+			}
+		})
+	}
+}
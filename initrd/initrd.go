@@ -4,7 +4,13 @@
 // You may not use this file except in compliance with the License.
 package initrd
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/opencontainers/go-digest"
+)
 
 const (
 	// DefaultInitramfsFileName is the default filename used when creating or
@@ -17,7 +23,8 @@ const (
 )
 
 // Initrd is an interface that is used to allow for different underlying
-// implementations to construct a CPIO archive.
+// implementations to construct a CPIO archive or, see WithFormat, a read-only
+// file system image.
 type Initrd interface {
 	// Build the rootfs and return the location of the result or error.
 	Build(context.Context) (string, error)
@@ -31,3 +38,16 @@ type Initrd interface {
 	// All arguments that are passed to the initramfs.
 	Args() []string
 }
+
+// Digest returns the content-addressable digest of the root file system at the
+// provided path, e.g. as returned by Build.
+func Digest(path string) (digest.Digest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("could not open rootfs: %w", err)
+	}
+
+	defer f.Close()
+
+	return digest.FromReader(f)
+}
@@ -0,0 +1,233 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+
+	"kraftkit.sh/log"
+)
+
+const (
+	// whiteoutPrefix marks an entry of a layer which removes the path of the
+	// same name without the prefix from the lower layers.
+	whiteoutPrefix = ".wh."
+
+	// whiteoutOpaqueDir marks the directory which contains it as opaque, i.e.
+	// none of the contents of the directory from the lower layers are retained.
+	whiteoutOpaqueDir = whiteoutPrefix + whiteoutPrefix + ".opq"
+)
+
+// resolveInRoot returns the path of the entry with the provided name within the
+// root directory.  Symbolic links in the parent directories of the entry are
+// resolved as if root was the root of the file system, such that an archive
+// cannot reach outside of it, whereas the entry itself is not followed.
+func resolveInRoot(root, name string) (string, error) {
+	name = filepath.Clean("/" + name)
+
+	parent, err := securejoin.SecureJoin(root, filepath.Dir(name))
+	if err != nil {
+		return "", fmt.Errorf("could not resolve %s: %w", name, err)
+	}
+
+	return filepath.Join(parent, filepath.Base(name)), nil
+}
+
+// layerMode returns the permissions of the tar entry, including the setuid,
+// setgid and sticky bits.
+func layerMode(header *tar.Header) fs.FileMode {
+	return header.FileInfo().Mode() & (fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky)
+}
+
+// applyLayer extracts the uncompressed OCI image layer read from r on top of
+// the root file system at dir, following the changeset semantics of the OCI
+// image specification: whiteout entries remove paths from the lower layers,
+// opaque directories hide all of their lower contents and hardlinks are
+// re-created as hardlinks.  All paths are resolved within dir.
+func applyLayer(ctx context.Context, dir string, r io.Reader) error {
+	dir = filepath.Clean(dir)
+	reader := tar.NewReader(r)
+
+	// Paths which have been populated by this layer, including their parents,
+	// such that opaque directories only hide the contents of lower layers.
+	added := make(map[string]struct{})
+	markAdded := func(path string) {
+		for path != dir && strings.HasPrefix(path, dir) {
+			added[path] = struct{}{}
+			path = filepath.Dir(path)
+		}
+	}
+
+	var opaque []string
+	var hardlinks []*tar.Header
+
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("could not read tar header: %w", err)
+		}
+
+		name := filepath.Clean("/" + header.Name)
+		if name == "/" {
+			continue
+		}
+
+		target, err := resolveInRoot(dir, name)
+		if err != nil {
+			return err
+		}
+
+		base := filepath.Base(target)
+		parent := filepath.Dir(target)
+
+		if base == whiteoutOpaqueDir {
+			opaque = append(opaque, parent)
+			markAdded(parent)
+			continue
+		}
+
+		if strings.HasPrefix(base, whiteoutPrefix) {
+			whiteout := filepath.Join(parent, strings.TrimPrefix(base, whiteoutPrefix))
+			log.G(ctx).
+				WithField("file", strings.TrimPrefix(whiteout, dir)).
+				Trace("whiteout")
+
+			if err := os.RemoveAll(whiteout); err != nil {
+				return fmt.Errorf("could not remove whiteout file: %w", err)
+			}
+
+			continue
+		}
+
+		if err := os.MkdirAll(parent, 0o755); err != nil {
+			return fmt.Errorf("could not create directory: %w", err)
+		}
+
+		// Entries replace whatever was at their path in the lower layers, except
+		// for directories which are merged.
+		if fi, err := os.Lstat(target); err == nil && !(fi.IsDir() && header.Typeflag == tar.TypeDir) {
+			if err := os.RemoveAll(target); err != nil {
+				return fmt.Errorf("could not replace file: %w", err)
+			}
+		}
+
+		mode := layerMode(header)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, mode.Perm()); err != nil {
+				return fmt.Errorf("could not create directory: %w", err)
+			}
+			if err := os.Chmod(target, mode); err != nil {
+				return fmt.Errorf("could not set directory permissions: %w", err)
+			}
+
+		case tar.TypeReg:
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR|os.O_TRUNC, mode.Perm())
+			if err != nil {
+				return fmt.Errorf("could not open file: %w", err)
+			}
+
+			_, err = io.Copy(file, reader)
+			file.Close()
+			if err != nil {
+				return fmt.Errorf("could not write file: %w", err)
+			}
+
+			// The umask and the kernel may drop the special bits on creation.
+			if err := os.Chmod(target, mode); err != nil {
+				return fmt.Errorf("could not set file permissions: %w", err)
+			}
+
+		case tar.TypeSymlink:
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return fmt.Errorf("could not create symlink: %w", err)
+			}
+
+		case tar.TypeLink:
+			// Note(craciunoiuc): This is done afterwards as the hardlink target might
+			// not have been created yet by the archive unpacker.
+			hardlinks = append(hardlinks, header)
+
+		case tar.TypeFifo:
+			if err := mkfifo(target, uint32(mode.Perm())); err != nil {
+				return fmt.Errorf("could not create fifo: %w", err)
+			}
+
+		case tar.TypeBlock, tar.TypeChar:
+			// Device nodes cannot be created without privileges and are provided by
+			// the unikernel's devfs instead.
+			log.G(ctx).
+				WithField("file", name).
+				Warn("ignoring device node")
+			continue
+
+		default:
+			return fmt.Errorf("unsupported file type: %c", header.Typeflag)
+		}
+
+		markAdded(target)
+	}
+
+	for _, header := range hardlinks {
+		target, err := resolveInRoot(dir, header.Name)
+		if err != nil {
+			return err
+		}
+
+		source, err := resolveInRoot(dir, header.Linkname)
+		if err != nil {
+			return err
+		}
+
+		if err := os.Link(source, target); err != nil {
+			return fmt.Errorf("could not create hardlink: %w", err)
+		}
+
+		markAdded(target)
+	}
+
+	for _, path := range opaque {
+		if err := filepath.WalkDir(path, func(sub string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if sub == path {
+				return nil
+			}
+
+			if _, ok := added[sub]; ok {
+				return nil
+			}
+
+			if err := os.RemoveAll(sub); err != nil {
+				return err
+			}
+
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}); err != nil {
+			return fmt.Errorf("could not apply opaque directory: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -9,20 +9,24 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 	"strings"
 
+	"kraftkit.sh/internal/offline"
 	"kraftkit.sh/log"
+	"kraftkit.sh/oci/trust"
 
-	"github.com/anchore/stereoscope"
-	scfile "github.com/anchore/stereoscope/pkg/file"
-	"github.com/anchore/stereoscope/pkg/filetree/filenode"
 	"github.com/cavaliergopher/cpio"
-	"github.com/containers/image/v5/copy"
-	ociarchive "github.com/containers/image/v5/oci/archive"
-	"github.com/containers/image/v5/signature"
+	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/pkg/blobinfocache/none"
+	"github.com/containers/image/v5/pkg/compression"
 	"github.com/containers/image/v5/transports/alltransports"
 	"github.com/containers/image/v5/types"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/opencontainers/go-digest"
 )
 
 type ociimage struct {
@@ -36,11 +40,12 @@ type ociimage struct {
 
 // NewFromOCIImage creates a new initrd from a remote container image.
 func NewFromOCIImage(ctx context.Context, path string, opts ...InitrdOption) (Initrd, error) {
-	if !strings.Contains("://", path) {
+	// Plain image references default to the Docker transport.
+	ref, err := alltransports.ParseImageName(path)
+	if err != nil {
 		path = fmt.Sprintf("docker://%s", path)
+		ref, err = alltransports.ParseImageName(path)
 	}
-
-	ref, err := alltransports.ParseImageName(path)
 	if err != nil {
 		log.G(ctx).Warnf("could not parse image name: %s", err.Error())
 		return nil, err
@@ -74,21 +79,16 @@ func (initrd *ociimage) Build(ctx context.Context) (string, error) {
 		}
 	}
 
-	policy := &signature.Policy{
-		Default: []signature.PolicyRequirement{
-			signature.NewPRInsecureAcceptAnything(),
-		},
+	// Images are always retrieved from their registry, by a client whose
+	// transport offline.Install does not guard.
+	if initrd.ref.Transport().Name() == "docker" && offline.Enabled(ctx) {
+		return "", offline.NotCached(strings.TrimPrefix(initrd.imageName, "docker://"))
 	}
 
-	policyCtx, err := signature.NewPolicyContext(policy)
-	if err != nil {
-		return "", fmt.Errorf("failed to generate default policy context: %w", err)
+	if err := initrd.verify(ctx); err != nil {
+		return "", err
 	}
 
-	defer func() {
-		_ = policyCtx.Destroy()
-	}()
-
 	img, err := initrd.ref.NewImage(ctx, sysCtx)
 	if err != nil {
 		return "", err
@@ -116,139 +116,189 @@ func (initrd *ociimage) Build(ctx context.Context) (string, error) {
 		initrd.opts.output = fi.Name()
 	}
 
-	// Create a temporary directory to output the image to
-	outputDir, err := os.MkdirTemp("", "")
+	if initrd.opts.buildCache == "" {
+		return initrd.build(ctx, img, sysCtx)
+	}
+
+	manifest, _, err := img.Manifest(ctx)
 	if err != nil {
-		return "", fmt.Errorf("could not make temporary directory: %w", err)
+		return "", fmt.Errorf("could not get image manifest: %w", err)
 	}
 
-	defer func() {
-		_ = os.RemoveAll(outputDir)
-	}()
+	key := initrd.opts.cacheKey("ociimage", digest.FromBytes(manifest))
+	if entry, ok := loadCache(ctx, initrd.opts, key); ok {
+		initrd.files = entry.Files
+		return initrd.opts.output, nil
+	}
+
+	output, err := initrd.build(ctx, img, sysCtx)
+	if err != nil {
+		return "", err
+	}
+
+	storeCache(ctx, initrd.opts, key, cacheEntry{
+		Args:  initrd.args,
+		Env:   initrd.env,
+		Files: initrd.files,
+	})
 
-	ociTarballFile := filepath.Join(outputDir, "oci.tar.gz")
+	return output, nil
+}
+
+// verify checks images of registries against the configured trust policy.
+// Images of other transports, e.g. local archives, are not signed and are
+// always accepted.  The reference is pinned to the digest of the verified
+// image, such that the image which is built is the one which was verified even
+// if its tag is moved in the meantime.
+func (initrd *ociimage) verify(ctx context.Context) error {
+	named := initrd.ref.DockerReference()
+	if initrd.ref.Transport().Name() != "docker" || named == nil {
+		return nil
+	}
 
-	dest, err := ociarchive.NewReference(ociTarballFile, "")
+	ref, err := name.ParseReference(named.String())
 	if err != nil {
-		return "", fmt.Errorf("invalid destination name %s: %v", dest, err)
+		return fmt.Errorf("could not parse image reference: %w", err)
 	}
 
-	opts := copy.Options{
-		ReportWriter:   log.G(ctx).Writer(),
-		DestinationCtx: sysCtx,
-		SourceCtx:      sysCtx,
+	dgst, err := trust.Enforce(ctx, ref,
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+	)
+	if err != nil {
+		return err
+	} else if dgst == (v1.Hash{}) {
+		return nil
 	}
 
-	if _, err = copy.Image(ctx, policyCtx, dest, initrd.ref, &opts); err != nil {
-		return "", fmt.Errorf("failed to copy image: %w", err)
+	pinned, err := reference.WithDigest(reference.TrimNamed(named), digest.Digest(dgst.String()))
+	if err != nil {
+		return fmt.Errorf("could not pin image reference: %w", err)
 	}
 
-	image, err := stereoscope.GetImage(ctx, ociTarballFile)
+	initrd.ref, err = docker.NewReference(pinned)
 	if err != nil {
-		return "", fmt.Errorf("could not load image: %w", err)
+		return fmt.Errorf("could not pin image reference: %w", err)
 	}
 
-	f, err := os.OpenFile(initrd.opts.output, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	return nil
+}
+
+// build applies the layers of the image and serializes the result to the
+// output set in the options.
+func (initrd *ociimage) build(ctx context.Context, img types.ImageCloser, sysCtx *types.SystemContext) (string, error) {
+	// Create a temporary directory to apply the layers of the image to
+	outputDir, err := os.MkdirTemp("", "")
 	if err != nil {
-		return "", fmt.Errorf("could not open initramfs file: %w", err)
+		return "", fmt.Errorf("could not make temporary directory: %w", err)
 	}
 
 	defer func() {
-		_ = f.Close()
+		_ = os.RemoveAll(outputDir)
 	}()
 
-	writer := cpio.NewWriter(f)
+	src, err := initrd.ref.NewImageSource(ctx, sysCtx)
+	if err != nil {
+		return "", fmt.Errorf("could not open image source: %w", err)
+	}
 
 	defer func() {
-		_ = writer.Close()
+		_ = src.Close()
 	}()
 
-	if err := image.SquashedTree().Walk(func(path scfile.Path, f filenode.FileNode) error {
-		if f.Reference == nil {
-			log.G(ctx).
-				WithField("path", path).
-				Warn("skipping: no reference")
-			return nil
+	// Apply the layers in order, from the base layer upwards.
+	for _, layer := range img.LayerInfos() {
+		log.G(ctx).
+			WithField("digest", layer.Digest.String()).
+			Debug("applying layer")
+
+		if err := initrd.applyBlob(ctx, src, layer, outputDir); err != nil {
+			return "", fmt.Errorf("could not apply layer %s: %w", layer.Digest, err)
 		}
+	}
 
-		info, err := image.FileCatalog.Get(*f.Reference)
-		if err != nil {
-			return err
+	if initrd.opts.format.BlockDevice() {
+		if err := collectFiles(outputDir, &initrd.files); err != nil {
+			return "", fmt.Errorf("could not walk output path: %w", err)
 		}
 
-		internal := fmt.Sprintf(".%s", path)
+		if err := buildFilesystem(ctx, initrd.opts, outputDir, initrd.opts.output); err != nil {
+			return "", err
+		}
 
-		if f.FileType == scfile.TypeDirectory {
-			if err := writer.WriteHeader(&cpio.Header{
-				Name: internal,
-				Mode: cpio.FileMode(info.Mode().Perm()) | cpio.TypeDir,
-			}); err != nil {
-				return fmt.Errorf("could not write CPIO header: %w", err)
-			}
+		return initrd.opts.output, nil
+	}
 
-			return nil
-		}
+	f, err := os.OpenFile(initrd.opts.output, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("could not open initramfs file: %w", err)
+	}
 
-		initrd.files = append(initrd.files, internal)
+	defer f.Close()
 
-		log.G(ctx).
-			WithField("file", path).
-			Trace("archiving")
+	writer := cpio.NewWriter(f)
+	defer writer.Close()
 
-		var data []byte
+	if err := walkFiles(ctx, outputDir, writer, &initrd.files, initrd.opts.reproducible); err != nil {
+		return "", fmt.Errorf("could not walk output path: %w", err)
+	}
 
-		header := &cpio.Header{
-			Name:    internal,
-			Mode:    cpio.FileMode(info.Mode().Perm()),
-			ModTime: info.ModTime(),
-			Size:    info.Size(),
+	if initrd.opts.compression.Enabled() {
+		if err := compressFiles(initrd.opts, writer, f); err != nil {
+			return "", fmt.Errorf("could not compress files: %w", err)
 		}
+	}
 
-		// Populate platform specific information
-		populateCPIO(info, header)
-
-		// No other file types are part of the archive.  As a result we only check
-		// whether the path is the same as the reference path which indicates
-		// whether the entry is a symbolic link.
-		if f.FileType == scfile.TypeSymLink {
-			header.Mode |= cpio.TypeSymlink
-			header.Linkname = info.Path
-			header.Size = int64(len(info.Path))
-			data = []byte(info.Path)
-		} else {
-			reader, err := image.OpenPathFromSquash(path)
-			if err != nil {
-				return fmt.Errorf("could not open file: %w", err)
-			}
-
-			header.Mode |= cpio.TypeReg
-			header.Size = info.Size()
-			data, err = io.ReadAll(reader)
-			if err != nil {
-				return fmt.Errorf("could not read file: %w", err)
-			}
-		}
+	return initrd.opts.output, nil
+}
 
-		if err := writer.WriteHeader(header); err != nil {
-			return fmt.Errorf("writing cpio header for %q: %w", internal, err)
-		}
+// applyBlob fetches and decompresses the layer from the image source and
+// applies it to the root file system at dir.  The blob is verified against the
+// digest and size of the layer once it has been read in full: dir is a scratch
+// directory which is discarded if a layer does not match, such that the
+// contents of a tampered layer never reach the output.
+func (initrd *ociimage) applyBlob(ctx context.Context, src types.ImageSource, layer types.BlobInfo, dir string) error {
+	if err := layer.Digest.Validate(); err != nil {
+		return fmt.Errorf("invalid layer digest: %w", err)
+	}
 
-		if _, err := writer.Write(data); err != nil {
-			return fmt.Errorf("could not write CPIO data for %s: %w", internal, err)
-		}
+	blob, _, err := src.GetBlob(ctx, layer, none.NoCache)
+	if err != nil {
+		return fmt.Errorf("could not fetch blob: %w", err)
+	}
 
-		return nil
-	}, nil); err != nil {
-		return "", fmt.Errorf("could not walk image: %w", err)
+	defer blob.Close()
+
+	verifier := layer.Digest.Verifier()
+	var size byteCounter
+	verified := io.TeeReader(blob, io.MultiWriter(verifier, &size))
+
+	reader, _, err := compression.AutoDecompress(verified)
+	if err != nil {
+		return fmt.Errorf("could not decompress blob: %w", err)
 	}
 
-	if initrd.opts.compress {
-		if err := compressFiles(initrd.opts.output, writer, f); err != nil {
-			return "", fmt.Errorf("could not compress files: %w", err)
-		}
+	defer reader.Close()
+
+	if err := applyLayer(ctx, dir, reader); err != nil {
+		return err
 	}
 
-	return initrd.opts.output, nil
+	// The layer may end before the blob does, e.g. with the padding of the
+	// tarball, which is still part of the digest.
+	if _, err := io.Copy(io.Discard, verified); err != nil {
+		return fmt.Errorf("could not read blob: %w", err)
+	}
+
+	if layer.Size >= 0 && int64(size) != layer.Size {
+		return fmt.Errorf("blob size mismatch: expected %d bytes, got %d", layer.Size, size)
+	}
+
+	if !verifier.Verified() {
+		return fmt.Errorf("blob digest mismatch: expected %s", layer.Digest)
+	}
+
+	return nil
 }
 
 // Files implements Initrd.
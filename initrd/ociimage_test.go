@@ -0,0 +1,377 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cavaliergopher/cpio"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"kraftkit.sh/initrd"
+	"kraftkit.sh/internal/offline"
+	"kraftkit.sh/internal/offline/offlinetest"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func TestNewFromOCIImageLayers(t *testing.T) {
+	tests := []struct {
+		name   string
+		image  string
+		golden string
+	}{
+		{
+			// A three layer image which exercises whiteouts of files and
+			// directories, opaque directories, hardlinks across layers, device
+			// nodes, fifos and symbolic links.
+			name:   "multi-layer",
+			image:  "oci:testdata/oci-layers",
+			golden: "testdata/oci-layers.golden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildFromOCIImage(t, tt.image)
+
+			if *update {
+				if err := os.WriteFile(tt.golden, []byte(got), 0o644); err != nil {
+					t.Fatal("Failed to update golden file:", err)
+				}
+			}
+
+			expect, err := os.ReadFile(tt.golden)
+			if err != nil {
+				t.Fatal("Failed to read golden file:", err)
+			}
+
+			if got != string(expect) {
+				t.Errorf("CPIO archive does not match %s (run with -update to regenerate):\ngot:\n%s\nexpected:\n%s", tt.golden, got, expect)
+			}
+		})
+	}
+}
+
+func TestNewFromOCIImageMaliciousLayer(t *testing.T) {
+	// The directory on the host which the layer of the image attempts to create
+	// and the file it attempts to remove, through symbolic links to the root of
+	// the host.
+	host := t.TempDir()
+	escaped := filepath.Join(host, "kraftkit-initrd-escape")
+	victim := filepath.Join(host, "kraftkit-initrd-escape-victim")
+
+	if err := os.WriteFile(victim, []byte("victim\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	image, _ := writeOCIImage(t, []layerEntry{
+		{header: tar.Header{Name: "bin/", Typeflag: tar.TypeDir, Mode: 0o755}},
+		{header: tar.Header{Name: "bin/su", Typeflag: tar.TypeReg, Mode: 0o4755}, data: "su\n"},
+		{header: tar.Header{Name: "data", Typeflag: tar.TypeReg, Mode: 0o644}, data: "data\n"},
+		{header: tar.Header{Name: "escape", Typeflag: tar.TypeSymlink, Linkname: "/", Mode: 0o777}},
+		{header: tar.Header{Name: "up", Typeflag: tar.TypeSymlink, Linkname: strings.Repeat("../", 32), Mode: 0o777}},
+		{header: tar.Header{Name: "escape" + escaped + "/absolute", Typeflag: tar.TypeReg, Mode: 0o644}, data: "pwned\n"},
+		{header: tar.Header{Name: "up" + escaped + "/relative", Typeflag: tar.TypeReg, Mode: 0o644}, data: "pwned\n"},
+		{header: tar.Header{Name: "escape" + host + "/.wh.kraftkit-initrd-escape-victim", Typeflag: tar.TypeReg, Mode: 0o644}},
+		{header: tar.Header{Name: "up" + host + "/.wh.kraftkit-initrd-escape-victim", Typeflag: tar.TypeReg, Mode: 0o644}},
+		{header: tar.Header{Name: "link", Typeflag: tar.TypeLink, Linkname: "escape/data", Mode: 0o644}},
+	})
+
+	got := buildFromOCIImage(t, image)
+
+	if _, err := os.Stat(escaped); err == nil {
+		t.Errorf("layer created %s outside of the root file system", escaped)
+	}
+
+	if _, err := os.Stat(victim); err != nil {
+		t.Errorf("layer removed %s outside of the root file system: %v", victim, err)
+	}
+
+	// The paths are resolved within the root file system instead.
+	inside := "." + filepath.ToSlash(escaped)
+	for _, line := range []string{
+		"./bin/su file 4755 links=1 content=\"su\\n\"",
+		"./data file 0644 links=2 content=\"data\\n\"",
+		"./escape symlink 0777 -> /",
+		"./link file 0644 hardlink=./data links=2 content=\"\"",
+		inside + "/absolute file 0644 links=1 content=\"pwned\\n\"",
+		inside + "/relative file 0644 links=1 content=\"pwned\\n\"",
+	} {
+		if !strings.Contains(got, line+"\n") {
+			t.Errorf("expected CPIO archive to contain %q, got:\n%s", line, got)
+		}
+	}
+}
+
+func TestNewFromOCIImageTamperedLayer(t *testing.T) {
+	image, layer := writeOCIImage(t, []layerEntry{
+		{header: tar.Header{Name: "etc/", Typeflag: tar.TypeDir, Mode: 0o755}},
+		{header: tar.Header{Name: "etc/passwd", Typeflag: tar.TypeReg, Mode: 0o644}, data: "root:x:0:0::/root:/bin/sh\n"},
+	})
+
+	data, err := os.ReadFile(layer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Replace the contents of the file with the same number of bytes, such that
+	// only the digest of the blob differs.
+	tampered := strings.Replace(string(data), "root:x:0:0::/root:/bin/sh", "root::0:0::/root:/bin/sh!", 1)
+	if err := os.WriteFile(layer, []byte(tampered), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	output := filepath.Join(t.TempDir(), "initramfs.cpio")
+
+	ird, err := initrd.NewFromOCIImage(ctx, image,
+		initrd.WithOutput(output),
+	)
+	if err != nil {
+		t.Fatal("NewFromOCIImage:", err)
+	}
+
+	if _, err := ird.Build(ctx); err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Fatalf("expected tampered layer to be rejected, got %v", err)
+	}
+
+	if fi, err := os.Stat(output); err == nil && fi.Size() > 0 {
+		t.Errorf("expected no initramfs to be written, got %d bytes", fi.Size())
+	}
+}
+
+func TestNewFromOCIImageOffline(t *testing.T) {
+	offlinetest.DenyDial(t)
+
+	ctx := offlinetest.Context(t)
+
+	// Images of registries are not retrieved.
+	ird, err := initrd.NewFromOCIImage(ctx, "unikraft.org/nginx:latest",
+		initrd.WithOutput(filepath.Join(t.TempDir(), "initramfs.cpio")),
+	)
+	if err != nil {
+		t.Fatal("NewFromOCIImage:", err)
+	}
+
+	if _, err := ird.Build(ctx); !errors.Is(err, offline.ErrOffline) {
+		t.Errorf("expected image of a registry to be refused, got %v", err)
+	}
+
+	// Local images are still used.
+	image, _ := writeOCIImage(t, []layerEntry{
+		{header: tar.Header{Name: "etc/", Typeflag: tar.TypeDir, Mode: 0o755}},
+	})
+
+	ird, err = initrd.NewFromOCIImage(ctx, image,
+		initrd.WithOutput(filepath.Join(t.TempDir(), "initramfs.cpio")),
+	)
+	if err != nil {
+		t.Fatal("NewFromOCIImage:", err)
+	}
+
+	if _, err := ird.Build(ctx); err != nil {
+		t.Errorf("expected local image to be built, got %v", err)
+	}
+}
+
+// layerEntry is an entry of the tarball of a layer.
+type layerEntry struct {
+	header tar.Header
+	data   string
+}
+
+// writeOCIImage writes an OCI image layout with a single uncompressed layer
+// which consists of the provided entries.  It returns the reference of the
+// image and the path of the blob of its layer.
+func writeOCIImage(t *testing.T, entries []layerEntry) (string, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	writeBlob := func(data []byte) ocispec.Descriptor {
+		t.Helper()
+
+		dgst := digest.FromBytes(data)
+		path := filepath.Join(dir, "blobs", dgst.Algorithm().String(), dgst.Encoded())
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		return ocispec.Descriptor{Digest: dgst, Size: int64(len(data))}
+	}
+
+	writeJSON := func(v any) ocispec.Descriptor {
+		t.Helper()
+
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return writeBlob(data)
+	}
+
+	var layer bytes.Buffer
+	tw := tar.NewWriter(&layer)
+	for _, entry := range entries {
+		hdr := entry.header
+		hdr.Size = int64(len(entry.data))
+		hdr.ModTime = time.Unix(1262304000, 0)
+
+		if err := tw.WriteHeader(&hdr); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tw.Write([]byte(entry.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	layerDesc := writeBlob(layer.Bytes())
+	layerDesc.MediaType = ocispec.MediaTypeImageLayer
+
+	configDesc := writeJSON(ocispec.Image{
+		Platform: ocispec.Platform{Architecture: "amd64", OS: "linux"},
+		RootFS: ocispec.RootFS{
+			Type:    "layers",
+			DiffIDs: []digest.Digest{layerDesc.Digest},
+		},
+	})
+	configDesc.MediaType = ocispec.MediaTypeImageConfig
+
+	manifestDesc := writeJSON(ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    configDesc,
+		Layers:    []ocispec.Descriptor{layerDesc},
+	})
+	manifestDesc.MediaType = ocispec.MediaTypeImageManifest
+
+	index, err := json.Marshal(ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{manifestDesc},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ocispec.ImageIndexFile), index, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ocispec.ImageLayoutFile), []byte(`{"imageLayoutVersion":"1.0.0"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return "oci:" + dir, filepath.Join(dir, "blobs", layerDesc.Digest.Algorithm().String(), layerDesc.Digest.Encoded())
+}
+
+// buildFromOCIImage reproducibly builds an initrd from the provided image and
+// returns the description of its entries.
+func buildFromOCIImage(t *testing.T, image string) string {
+	t.Helper()
+
+	ctx := context.Background()
+	output := filepath.Join(t.TempDir(), "initramfs.cpio")
+
+	ird, err := initrd.NewFromOCIImage(ctx, image,
+		initrd.WithOutput(output),
+		initrd.WithReproducible(true),
+	)
+	if err != nil {
+		t.Fatal("NewFromOCIImage:", err)
+	}
+
+	if _, err := ird.Build(ctx); err != nil {
+		t.Fatal("Build:", err)
+	}
+
+	return describeCPIO(t, output)
+}
+
+// describeCPIO renders the entries of the CPIO archive at the provided path in
+// a stable, human readable form.  Hardlinks are rendered as references to the
+// first entry with the same inode.
+func describeCPIO(t *testing.T, path string) string {
+	t.Helper()
+
+	r := cpio.NewReader(openFile(t, path))
+
+	var out strings.Builder
+	inodes := make(map[int64]string)
+
+	for {
+		hdr, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal("Failed to read next cpio header:", err)
+		}
+
+		var kind string
+		switch hdr.Mode & cpio.ModeType {
+		case cpio.TypeDir:
+			kind = "dir"
+		case cpio.TypeReg:
+			kind = "file"
+		case cpio.TypeSymlink:
+			kind = "symlink"
+		case cpio.TypeFifo:
+			kind = "fifo"
+		default:
+			kind = hdr.Mode.String()
+		}
+
+		fmt.Fprintf(&out, "%s %s %04o", hdr.Name, kind, hdr.Mode&^cpio.ModeType)
+
+		if first, ok := inodes[hdr.Inode]; ok {
+			fmt.Fprintf(&out, " hardlink=%s", first)
+		} else {
+			inodes[hdr.Inode] = hdr.Name
+		}
+
+		switch kind {
+		case "file":
+			data, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal("Failed to read file contents:", err)
+			}
+
+			fmt.Fprintf(&out, " links=%d content=%q", hdr.Links, data)
+		case "symlink":
+			fmt.Fprintf(&out, " -> %s", hdr.Linkname)
+		}
+
+		out.WriteString("\n")
+	}
+
+	return out.String()
+}
@@ -4,24 +4,78 @@
 // You may not use this file except in compliance with the License.
 package initrd
 
+import "fmt"
+
 type InitrdOptions struct {
-	compress bool
-	output   string
-	cacheDir string
-	arch     string
-	workdir  string
+	compression  Compression
+	level        int
+	format       Format
+	output       string
+	cacheDir     string
+	arch         string
+	workdir      string
+	reproducible bool
+	includes     []string
+	buildCache   string
 }
 
 type InitrdOption func(*InitrdOptions) error
 
-// WithCompression sets the compression of the resulting CPIO archive file.
-func WithCompression(compress bool) InitrdOption {
+// WithCompression sets the compression algorithm of the resulting CPIO
+// archive file, or of the contents of the file system image.
+func WithCompression(compression Compression) InitrdOption {
+	return func(opts *InitrdOptions) error {
+		if compression == "" {
+			opts.compression = CompressionNone
+			return nil
+		}
+
+		for _, c := range Compressions() {
+			if c == compression {
+				opts.compression = compression
+				return compression.CheckLevel(opts.level)
+			}
+		}
+
+		return fmt.Errorf("unsupported compression: %s", compression)
+	}
+}
+
+// WithCompressionLevel sets the level of the compression algorithm set via
+// WithCompression.  The meaning of the level depends on the algorithm, with 0
+// selecting its default.  The level is checked against the algorithm
+// regardless of the order in which both options are applied.
+func WithCompressionLevel(level int) InitrdOption {
 	return func(opts *InitrdOptions) error {
-		opts.compress = compress
+		if err := opts.compression.CheckLevel(level); err != nil {
+			return err
+		}
+
+		opts.level = level
 		return nil
 	}
 }
 
+// WithFormat sets the format of the resulting root file system.  By default,
+// a CPIO archive is generated.
+func WithFormat(format Format) InitrdOption {
+	return func(opts *InitrdOptions) error {
+		if format == "" {
+			opts.format = FormatCpio
+			return nil
+		}
+
+		for _, f := range Formats() {
+			if f == format {
+				opts.format = format
+				return nil
+			}
+		}
+
+		return fmt.Errorf("unsupported rootfs format: %s", format)
+	}
+}
+
 // WithOutput sets the location of the output location of the resulting CPIO
 // archive file.
 func WithOutput(output string) InitrdOption {
@@ -41,6 +95,16 @@ func WithCacheDir(dir string) InitrdOption {
 	}
 }
 
+// WithBuildCache enables the content-addressed cache of built root file
+// systems at the provided directory.  Builds whose inputs and options match a
+// previous build copy its result from the cache instead of being rebuilt.
+func WithBuildCache(dir string) InitrdOption {
+	return func(opts *InitrdOptions) error {
+		opts.buildCache = dir
+		return nil
+	}
+}
+
 // WithArchitecture sets the architecture of the file contents of binaries in
 // the initramfs.  Files may not always be architecture specific, this option
 // simply indicates the target architecture if any binaries are compiled by the
@@ -61,3 +125,26 @@ func WithWorkdir(dir string) InitrdOption {
 		return nil
 	}
 }
+
+// WithReproducible normalizes the resulting root file system such that
+// identical inputs always produce an identical output.  Entries are sorted,
+// ownership is reset to root and modification times are clamped to the value
+// of the SOURCE_DATE_EPOCH environmental variable (or the Unix epoch if
+// unset).
+func WithReproducible(reproducible bool) InitrdOption {
+	return func(opts *InitrdOptions) error {
+		opts.reproducible = reproducible
+		return nil
+	}
+}
+
+// WithIncludes adds additional files from the host to a root file system which
+// is generated from ELF executables.  Each include is of the form
+// <host>[:<machine>], where the file is placed at the same path as on the host
+// unless a path in the machine is provided.
+func WithIncludes(includes ...string) InitrdOption {
+	return func(opts *InitrdOptions) error {
+		opts.includes = append(opts.includes, includes...)
+		return nil
+	}
+}
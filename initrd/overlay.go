@@ -0,0 +1,564 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cavaliergopher/cpio"
+
+	"kraftkit.sh/log"
+)
+
+// File is a file of the host which is added to a root file system, possibly
+// overriding an existing file at the same destination.
+type File struct {
+	// Source is the path of the file or directory on the host.
+	Source string
+
+	// Destination is the absolute path of the file within the root file system.
+	Destination string
+
+	// Mode are the permissions of the file.  When unset, the permissions of the
+	// source are retained.
+	Mode fs.FileMode
+
+	// UID is the owning user of the file.
+	UID int
+
+	// GID is the owning group of the file.
+	GID int
+}
+
+// ParseFile parses a file in the form of `SRC:DST[:mode[:uid:gid]]`, where
+// the mode is in octal notation.
+func ParseFile(spec string) (File, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 || len(parts) == 4 || len(parts) > 5 {
+		return File{}, fmt.Errorf("invalid file '%s': expected SRC:DST[:mode[:uid:gid]]", spec)
+	}
+
+	if parts[0] == "" || parts[1] == "" {
+		return File{}, fmt.Errorf("invalid file '%s': source and destination must be set", spec)
+	}
+
+	file := File{
+		Source:      parts[0],
+		Destination: filepath.Clean("/" + parts[1]),
+	}
+
+	if len(parts) > 2 && parts[2] != "" {
+		mode, err := strconv.ParseUint(parts[2], 8, 32)
+		if err != nil || mode > 0o7777 {
+			return File{}, fmt.Errorf("invalid file '%s': mode must be in octal notation", spec)
+		}
+
+		file.Mode = fs.FileMode(mode)
+	}
+
+	if len(parts) == 5 {
+		var err error
+
+		if file.UID, err = strconv.Atoi(parts[3]); err != nil {
+			return File{}, fmt.Errorf("invalid file '%s': uid must be numeric", spec)
+		}
+
+		if file.GID, err = strconv.Atoi(parts[4]); err != nil {
+			return File{}, fmt.Errorf("invalid file '%s': gid must be numeric", spec)
+		}
+	}
+
+	return file, nil
+}
+
+// String implements fmt.Stringer
+func (file File) String() string {
+	return file.Source + ":" + file.Destination
+}
+
+type overlay struct {
+	opts  InitrdOptions
+	base  Initrd
+	files []File
+	added []string
+}
+
+// NewOverlay returns an Initrd which layers the provided files on top of the
+// CPIO archive built by the base Initrd and serializes the result into a new
+// CPIO archive.  The archive of the base Initrd is left untouched unless it is
+// located at the same output.
+func NewOverlay(base Initrd, files []File, opts ...InitrdOption) (Initrd, error) {
+	initrd := overlay{
+		base:  base,
+		files: files,
+	}
+
+	for _, opt := range opts {
+		if err := opt(&initrd.opts); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, file := range files {
+		if _, err := os.Stat(file.Source); err != nil {
+			return nil, fmt.Errorf("could not add %s: %w", file.Source, err)
+		}
+	}
+
+	return &initrd, nil
+}
+
+// overlayEntry is a single file or directory of the host which is added to
+// the archive.
+type overlayEntry struct {
+	name string
+	path string
+	info fs.FileInfo
+	file File
+}
+
+// Build implements Initrd.
+func (initrd *overlay) Build(ctx context.Context) (string, error) {
+	base, err := initrd.base.Build(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if len(initrd.files) == 0 {
+		return base, nil
+	}
+
+	format, err := DetectFormat(base)
+	if err != nil {
+		return "", err
+	}
+
+	if format.BlockDevice() {
+		return "", fmt.Errorf("adding files is only supported for %s rootfs, not %s", FormatCpio, format)
+	}
+
+	// Unless explicitly requested, the archive is re-emitted with the same
+	// compression as the base.
+	compression := initrd.opts.compression
+	if compression == "" {
+		if compression, err = DetectCompression(base); err != nil {
+			return "", err
+		}
+	}
+
+	entries, err := initrd.entries()
+	if err != nil {
+		return "", err
+	}
+
+	if initrd.opts.output == "" {
+		fi, err := os.CreateTemp("", "")
+		if err != nil {
+			return "", fmt.Errorf("could not make temporary file: %w", err)
+		}
+
+		initrd.opts.output = fi.Name()
+		fi.Close()
+	}
+
+	// Write to a temporary file next to the output as the output may be the
+	// archive of the base.
+	tmp, err := os.CreateTemp(filepath.Dir(initrd.opts.output), ".initramfs-*")
+	if err != nil {
+		return "", fmt.Errorf("could not make temporary file: %w", err)
+	}
+
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	var out io.Writer = tmp
+	var compressor io.WriteCloser
+	if compression.Enabled() {
+		compressor, err = newCompressor(tmp, compression, initrd.opts.level)
+		if err != nil {
+			return "", fmt.Errorf("could not initialize %s compressor: %w", compression, err)
+		}
+
+		out = compressor
+	}
+
+	writer := cpio.NewWriter(out)
+
+	if err := initrd.write(ctx, base, writer, entries); err != nil {
+		return "", err
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("could not close CPIO writer: %w", err)
+	}
+
+	if compressor != nil {
+		if err := compressor.Close(); err != nil {
+			return "", fmt.Errorf("could not close %s writer: %w", compression, err)
+		}
+	}
+
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("could not close initramfs file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), initrd.opts.output); err != nil {
+		return "", fmt.Errorf("could not move initramfs file: %w", err)
+	}
+
+	return initrd.opts.output, nil
+}
+
+// entries expands the files to add, recursing into directories, in the order
+// in which they are serialized.
+func (initrd *overlay) entries() ([]overlayEntry, error) {
+	var entries []overlayEntry
+
+	for _, file := range initrd.files {
+		source := filepath.Clean(file.Source)
+
+		// The source itself is always followed, e.g. to add the target of a
+		// symbolically linked certificate.
+		if resolved, err := filepath.EvalSymlinks(source); err == nil {
+			source = resolved
+		}
+
+		if err := filepath.Walk(source, func(path string, info fs.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			rel, err := filepath.Rel(source, path)
+			if err != nil {
+				return err
+			}
+
+			entries = append(entries, overlayEntry{
+				name: "." + filepath.ToSlash(filepath.Join(file.Destination, rel)),
+				path: path,
+				info: info,
+				file: file,
+			})
+
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("could not add %s: %w", file.Source, err)
+		}
+	}
+
+	return entries, nil
+}
+
+// linkGroup is a group of hardlinks of the base archive of which entries are
+// overridden.
+type linkGroup struct {
+	// removed is the number of overridden entries of the group.
+	removed int
+
+	// data is the contents of the file if they were carried by an overridden
+	// entry, until they are taken over by the first remaining entry.
+	data []byte
+}
+
+// linkKey returns the key which identifies the group of hardlinks of the
+// entry.
+func linkKey(hdr *cpio.Header) [2]int64 {
+	return [2]int64{int64(hdr.DeviceID), hdr.Inode}
+}
+
+// overriddenLinks returns the groups of hardlinks of the base archive which
+// lose entries to overridden paths.
+func overriddenLinks(base string, overridden map[string]struct{}) (map[[2]int64]*linkGroup, error) {
+	f, err := Decompress(base)
+	if err != nil {
+		return nil, fmt.Errorf("could not open base initramfs: %w", err)
+	}
+
+	defer f.Close()
+
+	reader := cpio.NewReader(f)
+	groups := map[[2]int64]*linkGroup{}
+
+	for {
+		hdr, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read base initramfs: %w", err)
+		}
+
+		if hdr.Mode&cpio.ModeType != cpio.TypeReg || hdr.Links < 2 {
+			continue
+		}
+
+		if _, ok := overridden["."+filepath.ToSlash(filepath.Clean("/"+hdr.Name))]; !ok {
+			continue
+		}
+
+		group, ok := groups[linkKey(hdr)]
+		if !ok {
+			group = &linkGroup{}
+			groups[linkKey(hdr)] = group
+		}
+
+		group.removed++
+
+		if hdr.Size > 0 {
+			if group.data, err = io.ReadAll(reader); err != nil {
+				return nil, fmt.Errorf("could not read base initramfs: %w", err)
+			}
+		}
+	}
+
+	return groups, nil
+}
+
+// write copies the entries of the base archive which are not overridden
+// followed by the added entries and any of their missing parent directories.
+func (initrd *overlay) write(ctx context.Context, base string, writer *cpio.Writer, entries []overlayEntry) error {
+	overridden := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		overridden[entry.name] = struct{}{}
+	}
+
+	groups, err := overriddenLinks(base, overridden)
+	if err != nil {
+		return err
+	}
+
+	f, err := Decompress(base)
+	if err != nil {
+		return fmt.Errorf("could not open base initramfs: %w", err)
+	}
+
+	defer f.Close()
+
+	reader := cpio.NewReader(f)
+	dirs := map[string]struct{}{".": {}}
+	var inode int64
+
+	for {
+		hdr, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("could not read base initramfs: %w", err)
+		}
+
+		name := "." + filepath.ToSlash(filepath.Clean("/"+hdr.Name))
+		if hdr.Inode > inode {
+			inode = hdr.Inode
+		}
+
+		if _, ok := overridden[name]; ok {
+			log.G(ctx).
+				WithField("file", name).
+				Debug("overriding")
+			continue
+		}
+
+		if hdr.Mode&cpio.ModeType == cpio.TypeDir {
+			dirs[name] = struct{}{}
+		}
+
+		header := &cpio.Header{
+			Name:     hdr.Name,
+			Linkname: hdr.Linkname,
+			Mode:     hdr.Mode,
+			Uid:      hdr.Uid,
+			Guid:     hdr.Guid,
+			ModTime:  hdr.ModTime,
+			Links:    hdr.Links,
+			Inode:    hdr.Inode,
+			Size:     hdr.Size,
+		}
+
+		var data io.Reader = reader
+		if hdr.Mode&cpio.ModeType == cpio.TypeSymlink {
+			header.Size = int64(len(hdr.Linkname))
+			data = strings.NewReader(hdr.Linkname)
+		}
+
+		// The remaining links of a group of which entries are overridden take
+		// over the contents if they were carried by an overridden entry.
+		if group, ok := groups[linkKey(hdr)]; ok && hdr.Mode&cpio.ModeType == cpio.TypeReg && hdr.Links > 1 {
+			header.Links = max(hdr.Links-group.removed, 1)
+
+			if group.data != nil {
+				header.Size = int64(len(group.data))
+				data = bytes.NewReader(group.data)
+				group.data = nil
+			}
+		}
+
+		if err := writer.WriteHeader(header); err != nil {
+			return fmt.Errorf("could not write CPIO header: %w", err)
+		}
+
+		if _, err := io.Copy(writer, data); err != nil {
+			return fmt.Errorf("could not write CPIO data for %s: %w", name, err)
+		}
+	}
+
+	epoch := sourceDateEpoch()
+
+	// Parent directories which do not exist in the base archive are created
+	// before any of their contents.
+	var parents []string
+	for _, entry := range entries {
+		for parent := path.Dir(entry.name[1:]); parent != "/"; parent = path.Dir(parent) {
+			dir := "." + parent
+
+			if _, ok := dirs[dir]; ok {
+				break
+			}
+
+			// Added directories are serialized along with their contents.
+			if _, ok := overridden[dir]; ok {
+				break
+			}
+
+			dirs[dir] = struct{}{}
+			parents = append(parents, dir)
+		}
+	}
+
+	sort.Strings(parents)
+
+	for _, dir := range parents {
+		inode++
+
+		header := &cpio.Header{
+			Name:    dir,
+			Mode:    cpio.FileMode(0o755) | cpio.TypeDir,
+			ModTime: epoch,
+			Inode:   inode,
+		}
+
+		if err := writer.WriteHeader(header); err != nil {
+			return fmt.Errorf("could not write CPIO header: %w", err)
+		}
+	}
+
+	for _, entry := range entries {
+		inode++
+
+		perm := entry.info.Mode().Perm()
+		if entry.file.Mode != 0 {
+			perm = entry.file.Mode
+		}
+
+		header := &cpio.Header{
+			Name:    entry.name,
+			Mode:    cpio.FileMode(perm),
+			ModTime: entry.info.ModTime(),
+			Links:   1,
+			Inode:   inode,
+		}
+
+		if initrd.opts.reproducible {
+			normalizeCPIO(header, epoch, inode)
+		}
+
+		header.Uid = entry.file.UID
+		header.Guid = entry.file.GID
+
+		var data []byte
+		switch {
+		case entry.info.IsDir():
+			if _, ok := dirs[entry.name]; ok {
+				// Directories of the base are merged with the added contents.
+				continue
+			}
+
+			header.Mode |= cpio.TypeDir
+
+		case entry.info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(entry.path)
+			if err != nil {
+				return fmt.Errorf("could not read symbolic link: %w", err)
+			}
+
+			header.Mode |= cpio.TypeSymlink
+			header.Linkname = link
+			data = []byte(link)
+
+		case entry.info.Mode().IsRegular():
+			header.Mode |= cpio.TypeReg
+
+			data, err = os.ReadFile(entry.path)
+			if err != nil {
+				return fmt.Errorf("could not read file: %w", err)
+			}
+
+		default:
+			log.G(ctx).Warnf("unsupported file: %s", entry.path)
+			continue
+		}
+
+		header.Size = int64(len(data))
+
+		log.G(ctx).
+			WithField("file", entry.name).
+			Trace("adding")
+
+		if err := writer.WriteHeader(header); err != nil {
+			return fmt.Errorf("could not write CPIO header for %s: %w", entry.name, err)
+		}
+
+		if _, err := writer.Write(data); err != nil {
+			return fmt.Errorf("could not write CPIO data for %s: %w", entry.name, err)
+		}
+
+		if !entry.info.IsDir() {
+			initrd.added = append(initrd.added, entry.name)
+		}
+	}
+
+	return nil
+}
+
+// Files implements Initrd.
+func (initrd *overlay) Files() []string {
+	files := initrd.base.Files()
+
+	for _, name := range initrd.added {
+		found := false
+		for _, file := range files {
+			if file == name {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			files = append(files, name)
+		}
+	}
+
+	return files
+}
+
+// Env implements Initrd.
+func (initrd *overlay) Env() []string {
+	return initrd.base.Env()
+}
+
+// Args implements Initrd.
+func (initrd *overlay) Args() []string {
+	return initrd.base.Args()
+}
@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package initrd_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cavaliergopher/cpio"
+
+	"kraftkit.sh/initrd"
+)
+
+func TestOverlayHardlinks(t *testing.T) {
+	const contents = "#!/bin/sh\nexec /app\n"
+
+	tests := []struct {
+		name string
+		// carrier is the index of the entry of the group of hardlinks which
+		// carries the contents of the file.
+		carrier int
+		// override is the path which is overridden.
+		override string
+		expected []string
+	}{
+		{
+			name:     "carrier is overridden",
+			carrier:  0,
+			override: "/entrypoint.sh",
+			expected: []string{
+				`./init file 0755 links=2 content="#!/bin/sh\nexec /app\n"`,
+				`./sbin-init file 0755 hardlink=./init links=2 content=""`,
+				`./entrypoint.sh file 0644 links=1 content="replaced\n"`,
+			},
+		},
+		{
+			name:     "last carrier is overridden",
+			carrier:  2,
+			override: "/sbin-init",
+			expected: []string{
+				`./entrypoint.sh file 0755 links=2 content="#!/bin/sh\nexec /app\n"`,
+				`./init file 0755 hardlink=./entrypoint.sh links=2 content=""`,
+				`./sbin-init file 0644 links=1 content="replaced\n"`,
+			},
+		},
+		{
+			name:     "link is overridden",
+			carrier:  0,
+			override: "/init",
+			expected: []string{
+				`./entrypoint.sh file 0755 links=2 content="#!/bin/sh\nexec /app\n"`,
+				`./sbin-init file 0755 hardlink=./entrypoint.sh links=2 content=""`,
+				`./init file 0644 links=1 content="replaced\n"`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			dir := t.TempDir()
+
+			base := filepath.Join(dir, "base.cpio")
+
+			f, err := os.Create(base)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			writer := cpio.NewWriter(f)
+
+			for i, name := range []string{"./entrypoint.sh", "./init", "./sbin-init"} {
+				hdr := &cpio.Header{
+					Name:  name,
+					Mode:  cpio.TypeReg | 0o755,
+					Links: 3,
+					Inode: 42,
+				}
+
+				var data []byte
+				if i == tc.carrier {
+					data = []byte(contents)
+					hdr.Size = int64(len(data))
+				}
+
+				if err := writer.WriteHeader(hdr); err != nil {
+					t.Fatal(err)
+				}
+
+				if _, err := writer.Write(data); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := f.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			replacement := filepath.Join(dir, "replacement")
+			if err := os.WriteFile(replacement, []byte("replaced\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			ird, err := initrd.NewFromFile(ctx, base)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			output := filepath.Join(dir, "initramfs.cpio")
+
+			ird, err = initrd.NewOverlay(ird, []initrd.File{{
+				Source:      replacement,
+				Destination: tc.override,
+			}}, initrd.WithOutput(output))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := ird.Build(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			got := describeCPIO(t, output)
+			if got != strings.Join(tc.expected, "\n")+"\n" {
+				t.Errorf("expected archive:\n%s\ngot:\n%s", strings.Join(tc.expected, "\n"), got)
+			}
+		})
+	}
+}
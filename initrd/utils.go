@@ -5,22 +5,74 @@
 package initrd
 
 import (
-	"compress/gzip"
 	"context"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cavaliergopher/cpio"
 	"kraftkit.sh/log"
 )
 
-func walkFiles(ctx context.Context, outputDir string, writer *cpio.Writer, files *[]string) error {
+// sourceDateEpoch returns the time to which modification times are clamped
+// when building reproducibly, as set by the SOURCE_DATE_EPOCH environmental
+// variable, or the Unix epoch if unset or invalid.
+func sourceDateEpoch() time.Time {
+	if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		return time.Unix(epoch, 0).UTC()
+	}
+
+	return time.Unix(0, 0).UTC()
+}
+
+// normalizeCPIO strips host-specific information from the header such that
+// the serialized entry only depends on the file's path, mode and contents.
+// Inode numbers are substituted with the position of the entry in the
+// archive.
+func normalizeCPIO(header *cpio.Header, epoch time.Time, inode int64) {
+	header.Uid = 0
+	header.Guid = 0
+	header.Inode = inode
+	header.Links = 1
+
+	if header.ModTime.IsZero() || header.ModTime.After(epoch) {
+		header.ModTime = epoch
+	}
+}
+
+// cpioMode returns the permissions of the file mode in the form of a CPIO
+// header, including the setuid, setgid and sticky bits.
+func cpioMode(mode fs.FileMode) cpio.FileMode {
+	ret := cpio.FileMode(mode.Perm())
+
+	if mode&fs.ModeSetuid != 0 {
+		ret |= cpio.ModeSetuid
+	}
+	if mode&fs.ModeSetgid != 0 {
+		ret |= cpio.ModeSetgid
+	}
+	if mode&fs.ModeSticky != 0 {
+		ret |= cpio.ModeSticky
+	}
+
+	return ret
+}
+
+func walkFiles(ctx context.Context, outputDir string, writer *cpio.Writer, files *[]string, reproducible bool) error {
+	epoch := sourceDateEpoch()
+	var inode int64
+
+	// The inode of the first path of each file with multiple hardlinks.
+	hardlinks := make(map[string]int64)
+
 	// Recursively walk the output directory on successful build and serialize to
-	// the output
+	// the output.  Entries are visited in lexical order, which keeps the order
+	// of the archive stable.
 	return filepath.WalkDir(outputDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return fmt.Errorf("received error before parsing path: %w", err)
@@ -37,11 +89,19 @@ func walkFiles(ctx context.Context, outputDir string, writer *cpio.Writer, files
 			return fmt.Errorf("could not get directory entry info: %w", err)
 		}
 
+		inode++
+
 		if d.Type().IsDir() {
-			if err := writer.WriteHeader(&cpio.Header{
+			header := &cpio.Header{
 				Name: internal,
-				Mode: cpio.FileMode(info.Mode().Perm()) | cpio.TypeDir,
-			}); err != nil {
+				Mode: cpioMode(info.Mode()) | cpio.TypeDir,
+			}
+
+			if reproducible {
+				normalizeCPIO(header, epoch, inode)
+			}
+
+			if err := writer.WriteHeader(header); err != nil {
 				return fmt.Errorf("could not write CPIO header: %w", err)
 			}
 
@@ -54,41 +114,56 @@ func walkFiles(ctx context.Context, outputDir string, writer *cpio.Writer, files
 			WithField("file", internal).
 			Trace("archiving")
 
-		var data []byte
-		targetLink := ""
-		if info.Mode()&os.ModeSymlink != 0 {
-			targetLink, err = os.Readlink(path)
-			data = []byte(targetLink)
-		} else if d.Type().IsRegular() {
-			data, err = os.ReadFile(path)
-		} else {
-			log.G(ctx).Warnf("unsupported file: %s", path)
-			return nil
-		}
-		if err != nil {
-			return fmt.Errorf("could not read file: %w", err)
-		}
-
 		header := &cpio.Header{
 			Name:    internal,
-			Mode:    cpio.FileMode(info.Mode().Perm()),
+			Mode:    cpioMode(info.Mode()),
 			ModTime: info.ModTime(),
 			Size:    info.Size(),
 		}
 
 		// Populate platform specific information
-		populateCPIO(info, header)
+		if reproducible {
+			normalizeCPIO(header, epoch, inode)
+		} else {
+			populateCPIO(info, header)
+		}
 
+		var data []byte
 		switch {
-		case info.Mode().IsDir():
-			header.Mode |= cpio.TypeDir
+		case info.Mode()&fs.ModeSymlink != 0:
+			header.Linkname, err = os.Readlink(path)
+			header.Mode |= cpio.TypeSymlink
+			data = []byte(header.Linkname)
+
+		case info.Mode()&fs.ModeNamedPipe != 0:
+			header.Mode |= cpio.TypeFifo
+			header.Size = 0
 
 		case info.Mode().IsRegular():
 			header.Mode |= cpio.TypeReg
 
-		case info.Mode()&fs.ModeSymlink != 0:
-			header.Mode |= cpio.TypeSymlink
-			header.Linkname = targetLink
+			// Hardlinks share the inode of the first path of the file, which is
+			// the only one which carries the contents.
+			if key, links, ok := hardlinkKey(info); ok {
+				header.Links = links
+
+				if first, ok := hardlinks[key]; ok {
+					header.Inode = first
+					header.Size = 0
+					break
+				}
+
+				hardlinks[key] = header.Inode
+			}
+
+			data, err = os.ReadFile(path)
+
+		default:
+			log.G(ctx).Warnf("unsupported file: %s", path)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not read file: %w", err)
 		}
 
 		if err := writer.WriteHeader(header); err != nil {
@@ -103,7 +178,28 @@ func walkFiles(ctx context.Context, outputDir string, writer *cpio.Writer, files
 	})
 }
 
-func compressFiles(output string, writer *cpio.Writer, reader *os.File) error {
+// collectFiles records the files within the directory in the same form as
+// walkFiles without serializing them.
+func collectFiles(dir string, files *[]string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("received error before parsing path: %w", err)
+		}
+
+		internal := strings.TrimPrefix(path, filepath.Clean(dir))
+		if internal == "" || d.IsDir() {
+			return nil
+		}
+
+		*files = append(*files, "."+filepath.ToSlash(internal))
+
+		return nil
+	})
+}
+
+func compressFiles(opts InitrdOptions, writer *cpio.Writer, reader *os.File) error {
+	output := opts.output
+
 	err := writer.Close()
 	if err != nil {
 		return fmt.Errorf("could not close CPIO writer: %w", err)
@@ -114,20 +210,23 @@ func compressFiles(output string, writer *cpio.Writer, reader *os.File) error {
 		return fmt.Errorf("could not seek to start of file: %w", err)
 	}
 
-	fw, err := os.OpenFile(output+".gz", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	fw, err := os.OpenFile(output+"."+opts.compression.String(), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
 	if err != nil {
 		return fmt.Errorf("could not open initramfs file: %w", err)
 	}
 
-	gw := gzip.NewWriter(fw)
+	cw, err := newCompressor(fw, opts.compression, opts.level)
+	if err != nil {
+		return fmt.Errorf("could not initialize %s compressor: %w", opts.compression, err)
+	}
 
-	if _, err := io.Copy(gw, reader); err != nil {
+	if _, err := io.Copy(cw, reader); err != nil {
 		return fmt.Errorf("could not compress initramfs file: %w", err)
 	}
 
-	err = gw.Close()
+	err = cw.Close()
 	if err != nil {
-		return fmt.Errorf("could not close gzip writer: %w", err)
+		return fmt.Errorf("could not close %s writer: %w", opts.compression, err)
 	}
 
 	err = fw.Close()
@@ -139,9 +238,36 @@ func compressFiles(output string, writer *cpio.Writer, reader *os.File) error {
 		return fmt.Errorf("could not remove uncompressed initramfs: %w", err)
 	}
 
-	if err := os.Rename(output+".gz", output); err != nil {
+	if err := os.Rename(output+"."+opts.compression.String(), output); err != nil {
 		return fmt.Errorf("could not rename compressed initramfs: %w", err)
 	}
 
 	return nil
 }
+
+// copyFile copies the regular file at src to dst with the provided
+// permissions, creating any missing parent directories.
+func copyFile(src, dst string, perm fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_RDWR|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
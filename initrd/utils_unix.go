@@ -8,6 +8,7 @@
 package initrd
 
 import (
+	"fmt"
 	"io/fs"
 	"syscall"
 
@@ -24,3 +25,18 @@ func populateCPIO(info fs.FileInfo, header *cpio.Header) {
 		}
 	}
 }
+
+// hardlinkKey returns a key which is identical for all paths which refer to
+// the same file, and the number of such paths, if the file has more than one.
+func hardlinkKey(info fs.FileInfo) (string, int, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || stat.Nlink < 2 {
+		return "", 0, false
+	}
+
+	return fmt.Sprintf("%d:%d", stat.Dev, stat.Ino), int(stat.Nlink), true
+}
+
+func mkfifo(path string, mode uint32) error {
+	return syscall.Mkfifo(path, mode)
+}
@@ -8,6 +8,7 @@
 package initrd
 
 import (
+	"fmt"
 	"io/fs"
 
 	"github.com/cavaliergopher/cpio"
@@ -15,3 +16,11 @@ import (
 
 func populateCPIO(info fs.FileInfo, header *cpio.Header) {
 }
+
+func hardlinkKey(info fs.FileInfo) (string, int, bool) {
+	return "", 0, false
+}
+
+func mkfifo(path string, mode uint32) error {
+	return fmt.Errorf("fifos are not supported on Windows: %s", path)
+}
@@ -34,6 +34,7 @@ import (
 var ErrContextNotBuildable = fmt.Errorf("could not determine what or how to build from the given context")
 
 type BuildOptions struct {
+	Add          []string       `long:"add" usage:"Add a file to the root file system (SRC:DST[:mode[:uid:gid]])"`
 	All          bool           `long:"all" usage:"Build all targets"`
 	Architecture string         `long:"arch" short:"m" usage:"Filter the creation of the build by architecture of known targets"`
 	DotConfig    string         `long:"config" short:"c" usage:"Override the path to the KConfig .config file"`
@@ -42,6 +43,7 @@ type BuildOptions struct {
 	Jobs         int            `long:"jobs" short:"j" usage:"Allow N jobs at once"`
 	KernelDbg    bool           `long:"dbg" usage:"Build the debuggable (symbolic) kernel image instead of the stripped image"`
 	Kraftfile    string         `long:"kraftfile" short:"K" usage:"Set an alternative path of the Kraftfile"`
+	Locked       bool           `long:"locked" usage:"Fail if the resolved components differ from the Kraftfile.lock"`
 	NoCache      bool           `long:"no-cache" short:"F" usage:"Force a rebuild even if existing intermediate artifacts already exist"`
 	NoConfigure  bool           `long:"no-configure" usage:"Do not run Unikraft's configure step before building"`
 	NoFast       bool           `long:"no-fast" usage:"Do not use maximum parallelization when performing the build"`
@@ -128,7 +130,14 @@ func Build(ctx context.Context, opts *BuildOptions, args ...string) error {
 		return fmt.Errorf("could not complete build: %w", err)
 	}
 
-	if opts.Rootfs, _, _, err = utils.BuildRootfs(ctx, opts.Workdir, opts.Rootfs, false, *opts.Target); err != nil {
+	files := opts.Add
+	if opts.project != nil {
+		files = append(opts.project.RootfsFiles(), files...)
+	}
+
+	if opts.Rootfs, _, _, err = utils.BuildRootfs(ctx, opts.Workdir, opts.Rootfs, utils.RootfsOptions{
+		Files: files,
+	}, *opts.Target); err != nil {
 		return err
 	}
 
@@ -155,6 +164,17 @@ func NewCmd() *cobra.Command {
 
 			The default behaviour of %[1]skraft build%[1]s is to build a project.  Given no
 			arguments, you will be guided through interactive mode.
+
+			The exact versions which the components of the project resolve to are
+			recorded in %[1]sKraftfile.lock%[1]s next to the Kraftfile.  Subsequent builds
+			retrieve the components at their locked versions as long as their version
+			and source in the Kraftfile do not change.  Use %[1]s--locked%[1]s to fail
+			instead of updating the lockfile when the resolution differs from it.
+
+			The versions of the core and of libraries may be semantic version
+			constraints, such as %[1]s^0.16%[1]s or %[1]s>=1.2 <2%[1]s.  A consistent set of
+			versions which satisfies every constraint and the Unikraft compatibility of
+			each library is picked before any component is fetched.
 		`, "`"),
 		Example: heredoc.Doc(`
 			# Build the current project (cwd)
@@ -162,6 +182,9 @@ func NewCmd() *cobra.Command {
 
 			# Build path to a Unikraft project
 			$ kraft build path/to/app
+
+			# Build the current project exactly as it is locked
+			$ kraft build --locked
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "build",
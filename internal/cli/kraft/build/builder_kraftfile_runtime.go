@@ -10,6 +10,7 @@ import (
 
 	"kraftkit.sh/config"
 	"kraftkit.sh/internal/cli/kraft/utils"
+	"kraftkit.sh/lockfile"
 	"kraftkit.sh/log"
 	"kraftkit.sh/pack"
 	"kraftkit.sh/packmanager"
@@ -135,6 +136,13 @@ func (*builderKraftfileRuntime) Prepare(ctx context.Context, opts *BuildOptions,
 	targ := (*selected).(target.Target)
 	opts.Target = &targ
 
+	if _, err := lockfile.Sync(ctx, opts.project, opts.Locked,
+		lockfile.WithRuntime(*selected),
+		lockfile.WithAuthConfig(config.G[config.KraftKit](ctx).Auth),
+	); err != nil {
+		return fmt.Errorf("could not lock components: %w", err)
+	}
+
 	return nil
 }
 
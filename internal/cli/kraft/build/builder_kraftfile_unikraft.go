@@ -16,8 +16,10 @@ import (
 
 	"kraftkit.sh/config"
 	"kraftkit.sh/exec"
+	"kraftkit.sh/hermetic"
 	"kraftkit.sh/iostreams"
 	"kraftkit.sh/kconfig"
+	"kraftkit.sh/lockfile"
 	"kraftkit.sh/log"
 	"kraftkit.sh/make"
 	"kraftkit.sh/pack"
@@ -27,6 +29,7 @@ import (
 	"kraftkit.sh/tui/selection"
 	"kraftkit.sh/unikraft"
 	"kraftkit.sh/unikraft/app"
+	"kraftkit.sh/unikraft/component"
 	"kraftkit.sh/unikraft/export/v0/posixenviron"
 	"kraftkit.sh/unikraft/target"
 )
@@ -89,8 +92,14 @@ func (build *builderKraftfileUnikraft) pull(ctx context.Context, opts *BuildOpti
 	parallel := !config.G[config.KraftKit](ctx).NoParallel
 	auths := config.G[config.KraftKit](ctx).Auth
 
+	// Components are retrieved at their locked versions, if any.
+	previous, err := lockfile.Load(opts.project.WorkingDir())
+	if err != nil {
+		return err
+	}
+
 	if template := opts.project.Template(); template != nil {
-		if stat, err := os.Stat(template.Path()); err != nil || !stat.IsDir() || opts.ForcePull {
+		if stat, err := os.Stat(template.Path()); (err != nil || !stat.IsDir() || opts.ForcePull) && !vendored(opts.project, template) {
 			var templatePack pack.Package
 			var packs []pack.Package
 
@@ -106,11 +115,7 @@ func (build *builderKraftfileUnikraft) pull(ctx context.Context, opts *BuildOpti
 						unikraft.TypeNameVersion(template),
 					), "",
 					func(ctx context.Context) error {
-						p, err := packmanager.G(ctx).Catalog(ctx,
-							packmanager.WithName(template.Name()),
-							packmanager.WithTypes(template.Type()),
-							packmanager.WithVersion(template.Version()),
-							packmanager.WithSource(template.Source()),
+						p, err := previous.Catalog(ctx, template, template.Source(),
 							packmanager.WithRemote(opts.NoCache),
 							packmanager.WithAuthConfig(auths),
 						)
@@ -213,6 +218,12 @@ func (build *builderKraftfileUnikraft) pull(ctx context.Context, opts *BuildOpti
 	if err != nil {
 		return err
 	}
+
+	// Components are retrieved at the versions which are pinned for them, such
+	// that version constraints are resolved consistently before anything is
+	// fetched.
+	var pinned *lockfile.Lockfile
+
 	for _, component := range components {
 		// Skip "finding" the component if path is the same as the source (which
 		// means that the source code is already available as it is a directory on
@@ -222,6 +233,11 @@ func (build *builderKraftfileUnikraft) pull(ctx context.Context, opts *BuildOpti
 			continue
 		}
 
+		// Vendored components are never pulled.
+		if vendored(opts.project, component) {
+			continue
+		}
+
 		// Only continue to find and pull the component if it does not exist
 		// locally or the user has requested to --force-pull.
 		if stat, err := os.Stat(component.Path()); err == nil && stat.IsDir() && !opts.ForcePull {
@@ -240,11 +256,7 @@ func (build *builderKraftfileUnikraft) pull(ctx context.Context, opts *BuildOpti
 				unikraft.TypeNameVersion(component),
 			), "",
 			func(ctx context.Context) error {
-				p, err := packmanager.G(ctx).Catalog(ctx,
-					packmanager.WithName(component.Name()),
-					packmanager.WithTypes(component.Type()),
-					packmanager.WithVersion(component.Version()),
-					packmanager.WithSource(component.Source()),
+				p, err := pinned.Catalog(ctx, component, component.Source(),
 					packmanager.WithRemote(opts.NoCache),
 					packmanager.WithAuthConfig(auths),
 				)
@@ -268,7 +280,31 @@ func (build *builderKraftfileUnikraft) pull(ctx context.Context, opts *BuildOpti
 		))
 	}
 
+	// A project which drifted from its lockfile fails before any of its
+	// components is fetched.
+	if opts.Locked {
+		if _, err := lockfile.Sync(ctx, opts.project, true,
+			lockfile.WithTargets(*opts.Target),
+			lockfile.WithPlatform((*opts.Target).Platform().Name()),
+			lockfile.WithArchitecture((*opts.Target).Architecture().Name()),
+			lockfile.WithRemote(opts.NoCache),
+			lockfile.WithAuthConfig(auths),
+		); err != nil {
+			return fmt.Errorf("could not lock components: %w", err)
+		}
+	}
+
 	if len(searches) > 0 {
+		pinned, err = lockfile.Pin(ctx, opts.project,
+			lockfile.WithPrevious(previous),
+			lockfile.WithTargets(*opts.Target),
+			lockfile.WithRemote(opts.NoCache),
+			lockfile.WithAuthConfig(auths),
+		)
+		if err != nil {
+			return err
+		}
+
 		treemodel, err := processtree.NewProcessTree(
 			ctx,
 			[]processtree.ProcessTreeOption{
@@ -325,9 +361,32 @@ func (build *builderKraftfileUnikraft) pull(ctx context.Context, opts *BuildOpti
 		}
 	}
 
+	// Upstream archives which are vendored are used in place of downloading
+	// them during the build.
+	if err := hermetic.Restore(ctx, opts.project); err != nil {
+		return err
+	}
+
+	if _, err := lockfile.Sync(ctx, opts.project, opts.Locked,
+		lockfile.WithTargets(*opts.Target),
+		lockfile.WithPlatform((*opts.Target).Platform().Name()),
+		lockfile.WithArchitecture((*opts.Target).Architecture().Name()),
+		lockfile.WithRemote(opts.NoCache),
+		lockfile.WithAuthConfig(auths),
+	); err != nil {
+		return fmt.Errorf("could not lock components: %w", err)
+	}
+
 	return nil
 }
 
+// vendored returns whether the project uses the vendored sources of the
+// component.
+func vendored(project app.Application, component component.Component) bool {
+	path, ok := unikraft.VendoredComponent(project.WorkingDir(), component.Type(), component.Name())
+	return ok && component.Path() == path
+}
+
 func (build *builderKraftfileUnikraft) Prepare(ctx context.Context, opts *BuildOptions, args ...string) error {
 	build.nameWidth = -1
 	norender := log.LoggerTypeFromString(config.G[config.KraftKit](ctx).Log.Type) != log.FANCY
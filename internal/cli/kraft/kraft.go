@@ -21,6 +21,7 @@ import (
 	"kraftkit.sh/internal/cli"
 	"kraftkit.sh/internal/cli/kraft/lib"
 	"kraftkit.sh/internal/cli/kraft/pause"
+	"kraftkit.sh/internal/offline"
 	kitupdate "kraftkit.sh/internal/update"
 	kitversion "kraftkit.sh/internal/version"
 	"kraftkit.sh/iostreams"
@@ -32,6 +33,7 @@ import (
 	"kraftkit.sh/internal/cli/kraft/compose"
 	"kraftkit.sh/internal/cli/kraft/events"
 	"kraftkit.sh/internal/cli/kraft/fetch"
+	"kraftkit.sh/internal/cli/kraft/lock"
 	"kraftkit.sh/internal/cli/kraft/login"
 	"kraftkit.sh/internal/cli/kraft/logs"
 	"kraftkit.sh/internal/cli/kraft/menu"
@@ -43,7 +45,9 @@ import (
 	"kraftkit.sh/internal/cli/kraft/set"
 	"kraftkit.sh/internal/cli/kraft/start"
 	"kraftkit.sh/internal/cli/kraft/stop"
+	"kraftkit.sh/internal/cli/kraft/system"
 	"kraftkit.sh/internal/cli/kraft/unset"
+	"kraftkit.sh/internal/cli/kraft/vendoring"
 	"kraftkit.sh/internal/cli/kraft/version"
 	"kraftkit.sh/internal/cli/kraft/volume"
 	"kraftkit.sh/internal/cli/kraft/x"
@@ -80,9 +84,11 @@ func NewCmd() *cobra.Command {
 	cmd.AddCommand(build.NewCmd())
 	cmd.AddCommand(clean.NewCmd())
 	cmd.AddCommand(fetch.NewCmd())
+	cmd.AddCommand(lock.NewCmd())
 	cmd.AddCommand(menu.NewCmd())
 	cmd.AddCommand(set.NewCmd())
 	cmd.AddCommand(unset.NewCmd())
+	cmd.AddCommand(vendoring.NewCmd())
 
 	cmd.AddGroup(&cobra.Group{ID: "lib", Title: "PROJECT LIBRARY COMMANDS"})
 	cmd.AddCommand(lib.NewCmd())
@@ -118,6 +124,9 @@ func NewCmd() *cobra.Command {
 	cmd.AddGroup(&cobra.Group{ID: "vol", Title: "LOCAL VOLUME COMMANDS"})
 	cmd.AddCommand(volume.NewCmd())
 
+	cmd.AddGroup(&cobra.Group{ID: "system", Title: "SYSTEM COMMANDS"})
+	cmd.AddCommand(system.NewCmd())
+
 	cmd.AddCommand(login.NewCmd())
 	cmd.AddCommand(version.NewCmd())
 	cmd.AddCommand(x.NewCmd())
@@ -184,6 +193,11 @@ func Main(args []string) int {
 	// Add the kraftkit version to the debug logs
 	log.G(ctx).Debugf("kraftkit %s", kitversion.Version())
 
+	// Refuse any outbound connection which is not already gated by offline mode.
+	if config.G[config.KraftKit](ctx).Offline {
+		offline.Install()
+	}
+
 	if !config.G[config.KraftKit](ctx).NoCheckUpdates {
 		if err := kitupdate.Check(ctx); err != nil {
 			log.G(ctx).Debugf("could not check for updates: %v", err)
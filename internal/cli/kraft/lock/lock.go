@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package lock
+
+import (
+	"context"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/lock/update"
+)
+
+type LockOptions struct{}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&LockOptions{}, cobra.Command{
+		Short: "Manage the lockfile of a project",
+		Use:   "lock SUBCOMMAND",
+		Long: heredoc.Docf(`
+			Manage the lockfile of a project.
+
+			The %[1]sKraftfile.lock%[1]s records the exact version, Git SHA, archive
+			checksum or OCI digest which each component of the project resolved to,
+			such that subsequent builds of the project are reproducible.
+		`, "`"),
+		Example: heredoc.Doc(`
+			# Refresh the lockfile of the project in the current working directory
+			$ kraft lock update
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "build",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.AddCommand(update.NewCmd())
+
+	return cmd
+}
+
+func (opts *LockOptions) Run(_ context.Context, _ []string) error {
+	return pflag.ErrHelp
+}
@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package update
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/lockfile"
+	"kraftkit.sh/packmanager"
+	"kraftkit.sh/unikraft/app"
+)
+
+type UpdateOptions struct {
+	Architecture string `long:"arch" short:"m" usage:"Set the architecture of the runtime"`
+	Kraftfile    string `long:"kraftfile" short:"K" usage:"Set an alternative path of the Kraftfile"`
+	Platform     string `long:"plat" short:"p" usage:"Set the platform of the runtime"`
+	Update       bool   `long:"update" short:"u" usage:"Perform an update which gathers remote sources"`
+	Workdir      string `long:"workdir" short:"w" usage:"Set a path to working directory of the project"`
+}
+
+// Update refreshes the lockfile of a project.
+func Update(ctx context.Context, opts *UpdateOptions, args ...string) error {
+	if opts == nil {
+		opts = &UpdateOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&UpdateOptions{}, cobra.Command{
+		Short: "Refresh the lockfile of a project",
+		Use:   "update [FLAGS] [COMPONENT...]",
+		Long: heredoc.Docf(`
+			Refresh the %[1]sKraftfile.lock%[1]s of a project.
+
+			Given no arguments, every component of the project is resolved again from
+			its version in the Kraftfile.  Given the names of components, only these
+			components are resolved again and all others keep their locked versions.
+		`, "`"),
+		Example: heredoc.Doc(`
+			# Refresh every component of the project in the current working directory
+			$ kraft lock update
+
+			# Refresh only the lwip library against the remote package indexes
+			$ kraft lock update --update lwip
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "build",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *UpdateOptions) Pre(cmd *cobra.Command, _ []string) error {
+	ctx, err := packmanager.WithDefaultUmbrellaManagerInContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	cmd.SetContext(ctx)
+
+	return nil
+}
+
+func (opts *UpdateOptions) Run(ctx context.Context, args []string) error {
+	var err error
+
+	if len(opts.Workdir) == 0 {
+		opts.Workdir, err = os.Getwd()
+		if err != nil {
+			return err
+		}
+	}
+
+	popts := []app.ProjectOption{
+		app.WithProjectWorkdir(opts.Workdir),
+	}
+
+	if len(opts.Kraftfile) > 0 {
+		popts = append(popts, app.WithProjectKraftfile(opts.Kraftfile))
+	} else {
+		popts = append(popts, app.WithProjectDefaultKraftfiles())
+	}
+
+	project, err := app.NewProjectFromOptions(ctx, popts...)
+	if err != nil && errors.Is(err, app.ErrNoKraftfile) {
+		return fmt.Errorf("cannot lock project directory without a Kraftfile")
+	} else if err != nil {
+		return fmt.Errorf("could not initialize project directory: %w", err)
+	}
+
+	previous, err := lockfile.Load(opts.Workdir)
+	if err != nil {
+		return err
+	}
+
+	ropts := []lockfile.ResolveOption{
+		lockfile.WithRemote(opts.Update),
+		lockfile.WithPlatform(opts.Platform),
+		lockfile.WithArchitecture(opts.Architecture),
+		lockfile.WithAuthConfig(config.G[config.KraftKit](ctx).Auth),
+	}
+
+	// Only the named components are resolved again, whereas all components are
+	// resolved again when none are named.
+	if len(args) > 0 {
+		ropts = append(ropts,
+			lockfile.WithPrevious(previous),
+			lockfile.WithRefresh(args...),
+		)
+	}
+
+	locked, err := lockfile.Resolve(ctx, project, ropts...)
+	if err != nil {
+		return err
+	}
+
+	for _, arg := range args {
+		found := false
+		for _, component := range locked.Components {
+			if component.Name == arg {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("project has no component named '%s'", arg)
+		}
+	}
+
+	changes := locked.Drift(previous)
+	if len(changes) == 0 {
+		fmt.Fprintf(iostreams.G(ctx).Out, "%s is up to date\n", lockfile.FileName)
+		return nil
+	}
+
+	if err := locked.Save(lockfile.PathOf(opts.Workdir)); err != nil {
+		return fmt.Errorf("could not write lockfile: %w", err)
+	}
+
+	for _, change := range changes {
+		fmt.Fprintln(iostreams.G(ctx).Out, change)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package copy implements the `kraft pkg copy` command
+package copy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/oci"
+)
+
+type CopyOptions struct{}
+
+// Copy copies a package from one registry to another.
+func Copy(ctx context.Context, opts *CopyOptions, args ...string) error {
+	if opts == nil {
+		opts = &CopyOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+// NewCmd returns a new copy command
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&CopyOptions{}, cobra.Command{
+		Short:   "Copy a package between registries",
+		Use:     "copy [FLAGS] SOURCE TARGET",
+		Aliases: []string{"cp"},
+		Args:    cmdfactory.ExactArgs(2, "source and target must be specified"),
+		Long: heredoc.Doc(`
+			Copy a package, with the manifests of all of its platforms and
+			architectures, their blobs, signatures and SBOMs, from one registry to
+			another without pulling it.
+
+			The digests of the package do not change, such that signatures remain
+			valid.  When both repositories are in the same registry, blobs are mounted
+			instead of uploaded again if the registry supports it.
+		`),
+		Example: heredoc.Doc(`
+			# Promote a package from staging to production
+			$ kraft pkg copy staging.example.com/nginx:1.25 prod.example.com/nginx:1.25
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "pkg",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+// Run executes the copy command
+func (opts *CopyOptions) Run(ctx context.Context, args []string) error {
+	dgst, err := oci.Copy(ctx, args[0], args[1])
+	if err != nil {
+		return fmt.Errorf("could not copy %s: %w", args[0], err)
+	}
+
+	fmt.Fprintln(iostreams.G(ctx).Out, dgst.String())
+
+	return nil
+}
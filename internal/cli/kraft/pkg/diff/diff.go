@@ -0,0 +1,320 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package diff implements the `kraft pkg diff` command
+package diff
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/packmanager"
+)
+
+type DiffOptions struct {
+	Architecture string `local:"true" long:"arch" short:"m" usage:"Filter packages by architecture"`
+	Output       string `local:"true" long:"output" short:"o" usage:"Set output format. Options: text,json" default:"text"`
+	Platform     string `local:"true" long:"plat" short:"p" usage:"Filter packages by platform"`
+}
+
+// ChangeType describes how an item differs between two packages.
+type ChangeType string
+
+const (
+	ChangeTypeAdded    = ChangeType("added")
+	ChangeTypeRemoved  = ChangeType("removed")
+	ChangeTypeModified = ChangeType("modified")
+)
+
+// Change is an item whose value differs between two packages.
+type Change struct {
+	Type ChangeType `json:"type"`
+	Name string     `json:"name"`
+	Old  string     `json:"old,omitempty"`
+	New  string     `json:"new,omitempty"`
+}
+
+// SizeChange is an item whose size or contents differ between two packages.
+type SizeChange struct {
+	Type    ChangeType `json:"type"`
+	Name    string     `json:"name"`
+	OldSize int64      `json:"old_size"`
+	NewSize int64      `json:"new_size"`
+}
+
+// CommandChange is the command of two packages which differ.
+type CommandChange struct {
+	Old []string `json:"old"`
+	New []string `json:"new"`
+}
+
+// Result is the set of differences between two packages.
+type Result struct {
+	Old       string         `json:"old"`
+	New       string         `json:"new"`
+	KConfig   []Change       `json:"kconfig"`
+	Libraries []Change       `json:"libraries"`
+	Sections  []SizeChange   `json:"sections"`
+	Files     []SizeChange   `json:"files"`
+	Command   *CommandChange `json:"command,omitempty"`
+	Env       []Change       `json:"env"`
+}
+
+// Empty returns whether there are no differences.
+func (result *Result) Empty() bool {
+	return len(result.KConfig) == 0 &&
+		len(result.Libraries) == 0 &&
+		len(result.Sections) == 0 &&
+		len(result.Files) == 0 &&
+		result.Command == nil &&
+		len(result.Env) == 0
+}
+
+// Diff compares two packages or kernel images.
+func Diff(ctx context.Context, opts *DiffOptions, args ...string) (*Result, error) {
+	if opts == nil {
+		opts = &DiffOptions{}
+	}
+
+	oldSnap, err := opts.snapshotOf(ctx, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	newSnap, err := opts.snapshotOf(ctx, args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	result := Result{
+		Old:       oldSnap.name,
+		New:       newSnap.name,
+		KConfig:   compareValues(oldSnap.kconfig, newSnap.kconfig),
+		Libraries: compareValues(oldSnap.libraries, newSnap.libraries),
+		Sections:  compareEntries(oldSnap.sections, newSnap.sections),
+		Files:     compareEntries(oldSnap.files, newSnap.files),
+		Env:       compareValues(oldSnap.env, newSnap.env),
+	}
+
+	if strings.Join(oldSnap.command, "\x00") != strings.Join(newSnap.command, "\x00") {
+		result.Command = &CommandChange{
+			Old: oldSnap.command,
+			New: newSnap.command,
+		}
+	}
+
+	return &result, nil
+}
+
+// NewCmd returns a new diff command
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&DiffOptions{}, cobra.Command{
+		Short: "Compare two packages",
+		Use:   "diff [FLAGS] PACKAGE|KERNEL PACKAGE|KERNEL",
+		Args:  cmdfactory.ExactArgs(2, "two packages or kernels must be specified"),
+		Long: heredoc.Doc(`
+			Compare two unikernel packages or kernel images.
+
+			The differences between the KConfig options, the versions of the core and
+			of the libraries, the sizes of the sections of the kernel, the files of the
+			initramfs, the command and the environment variables are shown.  Options
+			are read from the metadata of the package and from the configuration which
+			is embedded in the kernel at build time.
+		`),
+		Example: heredoc.Doc(`
+			# Compare two versions of a package
+			$ kraft pkg diff unikraft.org/nginx:1.24 unikraft.org/nginx:1.25
+
+			# Compare two versions of a package for a specific target as JSON
+			$ kraft pkg diff --plat qemu --arch x86_64 --output json unikraft.org/nginx:1.24 unikraft.org/nginx:1.25
+
+			# Compare a package with a locally built kernel image
+			$ kraft pkg diff unikraft.org/nginx:latest .unikraft/build/nginx_qemu-x86_64
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "pkg",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *DiffOptions) Pre(cmd *cobra.Command, _ []string) error {
+	ctx, err := packmanager.WithDefaultUmbrellaManagerInContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	cmd.SetContext(ctx)
+
+	switch opts.Output {
+	case "text", "json":
+	default:
+		return fmt.Errorf("unsupported output format '%s'", opts.Output)
+	}
+
+	return nil
+}
+
+// Run executes the diff command
+func (opts *DiffOptions) Run(ctx context.Context, args []string) error {
+	result, err := Diff(ctx, opts, args...)
+	if err != nil {
+		return err
+	}
+
+	out := iostreams.G(ctx).Out
+
+	if opts.Output == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(result)
+	}
+
+	result.print(out)
+
+	return nil
+}
+
+// compareValues returns the items which were added, removed or whose value was
+// modified, sorted by their name.
+func compareValues(old, new map[string]string) []Change {
+	changes := []Change{}
+
+	for name, oldValue := range old {
+		newValue, ok := new[name]
+		if !ok {
+			changes = append(changes, Change{Type: ChangeTypeRemoved, Name: name, Old: oldValue})
+		} else if oldValue != newValue {
+			changes = append(changes, Change{Type: ChangeTypeModified, Name: name, Old: oldValue, New: newValue})
+		}
+	}
+
+	for name, newValue := range new {
+		if _, ok := old[name]; !ok {
+			changes = append(changes, Change{Type: ChangeTypeAdded, Name: name, New: newValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+
+	return changes
+}
+
+// compareEntries returns the items which were added, removed or whose size or
+// digest was modified, sorted by their name.
+func compareEntries(old, new map[string]entry) []SizeChange {
+	changes := []SizeChange{}
+
+	for name, oldEntry := range old {
+		newEntry, ok := new[name]
+		if !ok {
+			changes = append(changes, SizeChange{Type: ChangeTypeRemoved, Name: name, OldSize: oldEntry.size})
+		} else if oldEntry != newEntry {
+			changes = append(changes, SizeChange{Type: ChangeTypeModified, Name: name, OldSize: oldEntry.size, NewSize: newEntry.size})
+		}
+	}
+
+	for name, newEntry := range new {
+		if _, ok := old[name]; !ok {
+			changes = append(changes, SizeChange{Type: ChangeTypeAdded, Name: name, NewSize: newEntry.size})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+
+	return changes
+}
+
+// sizeDelta returns the human-readable difference between two sizes.
+func sizeDelta(old, new int64) string {
+	if new >= old {
+		return "+" + humanize.Bytes(uint64(new-old))
+	}
+
+	return "-" + humanize.Bytes(uint64(old-new))
+}
+
+// print writes the differences in a human-readable format.
+func (result *Result) print(w io.Writer) {
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", result.Old, result.New)
+
+	if result.Empty() {
+		fmt.Fprintln(w, "\nno differences")
+		return
+	}
+
+	printValues := func(title, sep string, changes []Change) {
+		if len(changes) == 0 {
+			return
+		}
+
+		fmt.Fprintf(w, "\n%s:\n", title)
+		for _, change := range changes {
+			switch change.Type {
+			case ChangeTypeAdded:
+				fmt.Fprintf(w, "  + %s%s%s\n", change.Name, sep, change.New)
+			case ChangeTypeRemoved:
+				fmt.Fprintf(w, "  - %s%s%s\n", change.Name, sep, change.Old)
+			default:
+				fmt.Fprintf(w, "  ~ %s%s%s -> %s\n", change.Name, sep, change.Old, change.New)
+			}
+		}
+	}
+
+	printSizes := func(title string, changes []SizeChange) {
+		if len(changes) == 0 {
+			return
+		}
+
+		fmt.Fprintf(w, "\n%s:\n", title)
+		for _, change := range changes {
+			switch change.Type {
+			case ChangeTypeAdded:
+				fmt.Fprintf(w, "  + %s (%s)\n", change.Name, humanize.Bytes(uint64(change.NewSize)))
+			case ChangeTypeRemoved:
+				fmt.Fprintf(w, "  - %s (%s)\n", change.Name, humanize.Bytes(uint64(change.OldSize)))
+			default:
+				fmt.Fprintf(w, "  ~ %s (%s -> %s, %s)\n",
+					change.Name,
+					humanize.Bytes(uint64(change.OldSize)),
+					humanize.Bytes(uint64(change.NewSize)),
+					sizeDelta(change.OldSize, change.NewSize),
+				)
+			}
+		}
+	}
+
+	printValues("KConfig", "=", result.KConfig)
+	printValues("Libraries", " ", result.Libraries)
+	printSizes("Kernel sections", result.Sections)
+	printSizes("Initrd files", result.Files)
+
+	if result.Command != nil {
+		fmt.Fprintln(w, "\nCommand:")
+		fmt.Fprintf(w, "  - %s\n", strings.Join(result.Command.Old, " "))
+		fmt.Fprintf(w, "  + %s\n", strings.Join(result.Command.New, " "))
+	}
+
+	printValues("Env", "=", result.Env)
+}
@@ -0,0 +1,184 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package diff
+
+import (
+	"bytes"
+	"context"
+	"debug/elf"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeKernel writes an ELF file whose sections have the provided sizes.
+func writeKernel(t *testing.T, sections map[string]uint64) string {
+	t.Helper()
+
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	shstrtab := []byte{0}
+	offsets := map[string]uint32{}
+	for _, name := range append(names, ".shstrtab") {
+		offsets[name] = uint32(len(shstrtab))
+		shstrtab = append(append(shstrtab, name...), 0)
+	}
+
+	headerSize := uint64(binary.Size(elf.Header64{}))
+	shoff := headerSize + uint64(len(shstrtab))
+
+	header := elf.Header64{
+		Type:      uint16(elf.ET_EXEC),
+		Machine:   uint16(elf.EM_X86_64),
+		Version:   uint32(elf.EV_CURRENT),
+		Shoff:     shoff,
+		Ehsize:    uint16(headerSize),
+		Shentsize: uint16(binary.Size(elf.Section64{})),
+		Shnum:     uint16(len(names) + 2),
+		Shstrndx:  uint16(len(names) + 1),
+	}
+	copy(header.Ident[:], elf.ELFMAG)
+	header.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	header.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	header.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+
+	headers := []elf.Section64{{}}
+	for _, name := range names {
+		headers = append(headers, elf.Section64{
+			Name: offsets[name],
+			Type: uint32(elf.SHT_NOBITS),
+			Size: sections[name],
+		})
+	}
+
+	headers = append(headers, elf.Section64{
+		Name: offsets[".shstrtab"],
+		Type: uint32(elf.SHT_STRTAB),
+		Off:  headerSize,
+		Size: uint64(len(shstrtab)),
+	})
+
+	var buf bytes.Buffer
+	for _, v := range []any{header, shstrtab, headers} {
+		if err := binary.Write(&buf, binary.LittleEndian, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "kernel")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestDiffKernels(t *testing.T) {
+	oldKernel := writeKernel(t, map[string]uint64{
+		".text":   4096,
+		".rodata": 1024,
+		".data":   512,
+	})
+	newKernel := writeKernel(t, map[string]uint64{
+		".text":   8192,
+		".rodata": 1024,
+		".tbss":   256,
+	})
+
+	result, err := Diff(context.Background(), nil, oldKernel, newKernel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []SizeChange{
+		{Type: ChangeTypeRemoved, Name: ".data", OldSize: 512},
+		{Type: ChangeTypeAdded, Name: ".tbss", NewSize: 256},
+		{Type: ChangeTypeModified, Name: ".text", OldSize: 4096, NewSize: 8192},
+	}
+
+	if !reflect.DeepEqual(result.Sections, expected) {
+		t.Errorf("expected sections %+v, got %+v", expected, result.Sections)
+	}
+
+	var out bytes.Buffer
+	result.print(&out)
+
+	for _, line := range []string{
+		"--- " + oldKernel,
+		"+++ " + newKernel,
+		"  + .tbss (256 B)",
+		"  - .data (512 B)",
+		"  ~ .text (4.1 kB -> 8.2 kB, +4.1 kB)",
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("expected output to contain %q, got:\n%s", line, out.String())
+		}
+	}
+
+	result, err = Diff(context.Background(), nil, oldKernel, oldKernel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Empty() {
+		t.Errorf("expected a kernel not to differ from itself, got %+v", result)
+	}
+}
+
+func TestCompareValues(t *testing.T) {
+	changes := compareValues(
+		map[string]string{
+			"CONFIG_LIBVFSCORE": "y",
+			"CONFIG_LIBUKDEBUG": "y",
+			"CONFIG_STACK_SIZE": "16",
+		},
+		map[string]string{
+			"CONFIG_LIBVFSCORE": "y",
+			"CONFIG_LIBPOSIX":   "y",
+			"CONFIG_STACK_SIZE": "32",
+		},
+	)
+
+	expected := []Change{
+		{Type: ChangeTypeAdded, Name: "CONFIG_LIBPOSIX", New: "y"},
+		{Type: ChangeTypeRemoved, Name: "CONFIG_LIBUKDEBUG", Old: "y"},
+		{Type: ChangeTypeModified, Name: "CONFIG_STACK_SIZE", Old: "16", New: "32"},
+	}
+
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected %+v, got %+v", expected, changes)
+	}
+}
+
+func TestCompareEntries(t *testing.T) {
+	changes := compareEntries(
+		map[string]entry{
+			"/etc/nginx.conf": {size: 10, digest: "a"},
+			"/usr/sbin/nginx": {size: 20, digest: "b"},
+		},
+		map[string]entry{
+			"/etc/nginx.conf": {size: 10, digest: "c"},
+			"/usr/sbin/nginx": {size: 20, digest: "b"},
+		},
+	)
+
+	// Files of the same size are compared by their contents.
+	expected := []SizeChange{
+		{Type: ChangeTypeModified, Name: "/etc/nginx.conf", OldSize: 10, NewSize: 10},
+	}
+
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected %+v, got %+v", expected, changes)
+	}
+}
@@ -0,0 +1,192 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package diff
+
+import (
+	"context"
+	"debug/elf"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cli/kraft/utils"
+	"kraftkit.sh/log"
+	"kraftkit.sh/oci"
+	"kraftkit.sh/pack"
+	uksbom "kraftkit.sh/sbom"
+	"kraftkit.sh/unikraft/app"
+	"kraftkit.sh/unikraft/target"
+)
+
+// entry is a named item of a package which is compared by its size and, if
+// set, by its digest.
+type entry struct {
+	size   int64
+	digest string
+}
+
+// snapshot contains everything which is compared of a package or of a kernel
+// image.
+type snapshot struct {
+	name      string
+	kconfig   map[string]string
+	libraries map[string]string
+	sections  map[string]entry
+	files     map[string]entry
+	command   []string
+	env       map[string]string
+}
+
+// snapshotOf returns the snapshot of the kernel image at the provided path or,
+// if there is no such file, of the package with the provided name.
+func (opts *DiffOptions) snapshotOf(ctx context.Context, arg string) (*snapshot, error) {
+	if fi, err := os.Stat(arg); err == nil && !fi.IsDir() {
+		snap := &snapshot{
+			name:    arg,
+			kconfig: map[string]string{},
+			files:   map[string]entry{},
+			env:     map[string]string{},
+		}
+
+		if err := snap.readKernel(ctx, arg); err != nil {
+			return nil, err
+		}
+
+		return snap, nil
+	}
+
+	p, err := utils.FindPackage(ctx, arg, opts.Architecture, opts.Platform)
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshotOfPackage(ctx, p)
+}
+
+// snapshotOfPackage unpacks the provided package into a temporary directory
+// and returns its snapshot.
+func snapshotOfPackage(ctx context.Context, p pack.Package) (*snapshot, error) {
+	dir, err := os.MkdirTemp(config.G[config.KraftKit](ctx).RuntimeDir, "diff-*")
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.RemoveAll(dir)
+
+	if err := p.Unpack(ctx, dir); err != nil {
+		return nil, fmt.Errorf("could not unpack %s: %w", p.String(), err)
+	}
+
+	targ, ok := p.(target.Target)
+	if !ok {
+		return nil, fmt.Errorf("package does not convert to target")
+	}
+
+	snap := &snapshot{
+		name:    p.String(),
+		kconfig: map[string]string{},
+		files:   map[string]entry{},
+		command: targ.Command(),
+		env:     map[string]string{},
+	}
+
+	if err := snap.readKernel(ctx, targ.Kernel()); err != nil {
+		return nil, err
+	}
+
+	// The options which are recorded in the metadata of the package take
+	// precedence over the configuration embedded in the kernel image.
+	for key, kval := range targ.KConfig() {
+		snap.kconfig[key] = kval.Value
+	}
+
+	if image, ok := p.Metadata().(*ocispec.Image); ok && image != nil {
+		for _, env := range image.Config.Env {
+			key, value, _ := strings.Cut(env, "=")
+			snap.env[key] = value
+		}
+	}
+
+	if initrd := filepath.Join(dir, oci.WellKnownInitrdPath); utils.IsNonEmptyFile(initrd) {
+		bom, err := uksbom.New(ctx, p.Name(), uksbom.WithInitrd(initrd))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range bom.Files {
+			snap.files[file.Name] = entry{
+				size:   file.Size,
+				digest: file.SHA256,
+			}
+		}
+	}
+
+	return snap, nil
+}
+
+// readKernel populates the snapshot with the embedded configuration, the
+// libraries and the sizes of the sections of the kernel image at the provided
+// path.
+func (snap *snapshot) readKernel(ctx context.Context, path string) error {
+	fe, err := elf.Open(path)
+	if err != nil {
+		return fmt.Errorf("could not open kernel image: %w", err)
+	}
+
+	defer fe.Close()
+
+	snap.sections = map[string]entry{}
+	for _, section := range fe.Sections {
+		if section.Name == "" || section.Type == elf.SHT_NULL {
+			continue
+		}
+
+		snap.sections[section.Name] = entry{size: int64(section.Size)}
+	}
+
+	snap.libraries = map[string]string{}
+
+	records, err := app.ComponentInfoRecordsFromKernel(path)
+	if err != nil {
+		log.G(ctx).
+			WithField("kernel", path).
+			Warnf("could not read build information: %v", err)
+		return nil
+	}
+
+	for _, record := range records {
+		version := record.Version
+		if record.UkVersion != "" {
+			version = record.UkVersion
+		}
+		if record.UkFullVersion != "" {
+			version = record.UkFullVersion
+		}
+
+		// The record of the core is the only one without a name.
+		if record.LibName == "" {
+			snap.libraries["unikraft"] = version
+
+			kvmap, err := record.KConfig()
+			if err != nil {
+				return fmt.Errorf("could not parse embedded configuration: %w", err)
+			}
+
+			for key, kval := range kvmap {
+				snap.kconfig[key] = kval.Value
+			}
+
+			continue
+		}
+
+		snap.libraries[record.LibName] = version
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package gc implements the `kraft pkg gc` command
+package gc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/oci"
+)
+
+type GcOptions struct {
+	DryRun bool `local:"true" long:"dry-run" usage:"Only report the blobs which would be removed"`
+}
+
+// Gc removes unreachable blobs from the local package store.
+func Gc(ctx context.Context, opts *GcOptions, args ...string) error {
+	if opts == nil {
+		opts = &GcOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+// NewCmd returns a new gc command
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&GcOptions{}, cobra.Command{
+		Short: "Remove unused data from the local package store",
+		Use:   "gc [FLAGS]",
+		Args:  cobra.NoArgs,
+		Long: heredoc.Doc(`
+			Remove the blobs of the local package store which are no longer used by
+			any package.
+
+			Kernels, initramfs files and other blobs are shared between packages and
+			are kept for as long as any tagged package, or any signature, SBOM or other
+			artifact which refers to such a package, uses them.  Blobs which were
+			written within the last hour are kept as well, since they may belong to a
+			package which is still being created or pulled.
+		`),
+		Example: heredoc.Doc(`
+			# Remove unused blobs
+			$ kraft pkg gc
+
+			# Show how much space would be reclaimed
+			$ kraft pkg gc --dry-run
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "pkg",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+// Run executes the gc command
+func (opts *GcOptions) Run(ctx context.Context, _ []string) error {
+	result, err := oci.GarbageCollect(ctx, opts.DryRun)
+	if err != nil {
+		return fmt.Errorf("could not collect garbage: %w", err)
+	}
+
+	verb := "reclaimed"
+	if opts.DryRun {
+		verb = "would reclaim"
+	}
+
+	fmt.Fprintf(iostreams.G(ctx).Out, "%s %s in %d blobs\n", verb, humanize.Bytes(uint64(result.Bytes)), result.Blobs)
+
+	return nil
+}
@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package load implements the `kraft pkg load` command
+package load
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/oci"
+)
+
+type LoadOptions struct{}
+
+// Load packages from an OCI image-layout tarball.
+func Load(ctx context.Context, opts *LoadOptions, args ...string) error {
+	if opts == nil {
+		opts = &LoadOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+// NewCmd returns a new load command
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&LoadOptions{}, cobra.Command{
+		Short: "Load packages from a tarball",
+		Use:   "load [FLAGS] TARBALL",
+		Args:  cmdfactory.ExactArgs(1, "tarball not specified"),
+		Long: heredoc.Doc(`
+			Load the packages of an OCI image-layout tarball, as written by
+			'kraft pkg save', into the local package store such that they can be
+			run without registry access.
+		`),
+		Example: heredoc.Doc(`
+			# Load the packages of a tarball
+			$ kraft pkg load bundle.tar
+
+			# Run a loaded package
+			$ kraft run unikraft.org/nginx:latest
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "pkg",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+// Run executes the load command
+func (opts *LoadOptions) Run(ctx context.Context, args []string) error {
+	f, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("could not open tarball: %w", err)
+	}
+
+	defer f.Close()
+
+	refs, err := oci.LoadLayout(ctx, f)
+	if err != nil {
+		return fmt.Errorf("could not load %s: %w", args[0], err)
+	}
+
+	for _, ref := range refs {
+		fmt.Fprintln(iostreams.G(ctx).Out, ref)
+	}
+
+	return nil
+}
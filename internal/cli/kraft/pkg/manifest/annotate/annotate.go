@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package annotate implements the `kraft pkg manifest annotate` command
+package annotate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/oci"
+)
+
+type AnnotateOptions struct {
+	Annotations  []string `local:"true" long:"annotation" short:"A" usage:"Set an annotation (KEY=VALUE)"`
+	Architecture string   `local:"true" long:"arch" short:"m" usage:"Annotate the manifests for the architecture"`
+	Digest       string   `local:"true" long:"digest" short:"d" usage:"Annotate the manifest with the digest"`
+	Platform     string   `local:"true" long:"plat" short:"p" usage:"Annotate the manifests for the platform"`
+	Unset        []string `local:"true" long:"unset" usage:"Remove an annotation (KEY)"`
+}
+
+// Annotate sets or removes annotations of the index of a remote package or of
+// some of its manifests.
+func Annotate(ctx context.Context, opts *AnnotateOptions, args ...string) error {
+	if opts == nil {
+		opts = &AnnotateOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+// NewCmd returns a new annotate command
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&AnnotateOptions{}, cobra.Command{
+		Short: "Set annotations of the index of a remote package",
+		Use:   "annotate [FLAGS] PACKAGE",
+		Args:  cmdfactory.ExactArgs(1, "package not specified"),
+		Long: heredoc.Doc(`
+			Set or remove annotations of the index of a remote package.
+
+			When the architecture, the platform or the digest are set, the
+			descriptors of the matching manifests are annotated instead of the
+			index itself.
+		`),
+		Example: heredoc.Doc(`
+			# Annotate the index of a package
+			$ kraft pkg manifest annotate --annotation org.opencontainers.image.vendor=Unikraft unikraft.org/nginx:latest
+
+			# Annotate the manifest for Firecracker on arm64
+			$ kraft pkg manifest annotate --plat fc --arch arm64 --annotation stage=beta unikraft.org/nginx:latest
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "pkg",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *AnnotateOptions) Pre(_ *cobra.Command, _ []string) error {
+	if len(opts.Annotations) == 0 && len(opts.Unset) == 0 {
+		return fmt.Errorf("at least one of `--annotation` or `--unset` must be set")
+	}
+
+	for _, annotation := range opts.Annotations {
+		if key, _, ok := strings.Cut(annotation, "="); !ok || key == "" {
+			return fmt.Errorf("invalid annotation '%s': expected KEY=VALUE", annotation)
+		}
+	}
+
+	return nil
+}
+
+// apply sets and removes the annotations.
+func (opts *AnnotateOptions) apply(annotations map[string]string) map[string]string {
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	for _, annotation := range opts.Annotations {
+		key, value, _ := strings.Cut(annotation, "=")
+		annotations[key] = value
+	}
+
+	for _, key := range opts.Unset {
+		delete(annotations, key)
+	}
+
+	return annotations
+}
+
+// Run executes the annotate command
+func (opts *AnnotateOptions) Run(ctx context.Context, args []string) error {
+	selected := opts.Architecture != "" || opts.Platform != "" || opts.Digest != ""
+
+	dgst, err := oci.UpdateRemoteIndex(ctx, args[0], func(index *ocispec.Index) error {
+		if len(index.Manifests) == 0 {
+			return fmt.Errorf("%s does not exist", args[0])
+		}
+
+		if !selected {
+			index.Annotations = opts.apply(index.Annotations)
+			return nil
+		}
+
+		matched := false
+
+		for i, desc := range index.Manifests {
+			if oci.ManifestMatches(desc, opts.Architecture, opts.Platform, opts.Digest) {
+				index.Manifests[i].Annotations = opts.apply(desc.Annotations)
+				matched = true
+			}
+		}
+
+		if !matched {
+			return fmt.Errorf("no manifest matches")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(iostreams.G(ctx).Out, dgst.String())
+
+	return nil
+}
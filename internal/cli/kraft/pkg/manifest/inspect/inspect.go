@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package inspect implements the `kraft pkg manifest inspect` command
+package inspect
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/oci"
+)
+
+type InspectOptions struct{}
+
+// Inspect prints the index of a remote package.
+func Inspect(ctx context.Context, opts *InspectOptions, args ...string) error {
+	if opts == nil {
+		opts = &InspectOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+// NewCmd returns a new inspect command
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&InspectOptions{}, cobra.Command{
+		Short: "Show the index of a remote package",
+		Use:   "inspect PACKAGE",
+		Args:  cmdfactory.ExactArgs(1, "package not specified"),
+		Example: heredoc.Doc(`
+			# Show the manifests of a multi-platform package
+			$ kraft pkg manifest inspect unikraft.org/nginx:latest
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "pkg",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+// Run executes the inspect command
+func (opts *InspectOptions) Run(ctx context.Context, args []string) error {
+	index, err := oci.FetchRemoteIndex(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(iostreams.G(ctx).Out)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(index)
+}
@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package manifest implements the `kraft pkg manifest` command
+package manifest
+
+import (
+	"context"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/pkg/manifest/annotate"
+	"kraftkit.sh/internal/cli/kraft/pkg/manifest/inspect"
+	"kraftkit.sh/internal/cli/kraft/pkg/manifest/remove"
+)
+
+type ManifestOptions struct{}
+
+// NewCmd returns a new manifest command
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&ManifestOptions{}, cobra.Command{
+		Short: "Manage the index of a remote multi-platform package",
+		Use:   "manifest SUBCOMMAND",
+		Long: heredoc.Doc(`
+			Manage the index of a remote multi-platform package.
+
+			The index of a package refers to one manifest for every combination of
+			architecture, platform and KConfig options which the package was built
+			for.  Changes are made directly to the index in the registry.
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "pkg",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.AddCommand(annotate.NewCmd())
+	cmd.AddCommand(inspect.NewCmd())
+	cmd.AddCommand(remove.NewCmd())
+
+	return cmd
+}
+
+func (opts *ManifestOptions) Run(_ context.Context, _ []string) error {
+	return pflag.ErrHelp
+}
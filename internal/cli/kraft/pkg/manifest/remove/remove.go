@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package remove implements the `kraft pkg manifest rm` command
+package remove
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/oci"
+)
+
+type RemoveOptions struct {
+	Architecture string `local:"true" long:"arch" short:"m" usage:"Remove the manifests for the architecture"`
+	Digest       string `local:"true" long:"digest" short:"d" usage:"Remove the manifest with the digest"`
+	Platform     string `local:"true" long:"plat" short:"p" usage:"Remove the manifests for the platform"`
+}
+
+// Remove removes manifests from the index of a remote package.
+func Remove(ctx context.Context, opts *RemoveOptions, args ...string) error {
+	if opts == nil {
+		opts = &RemoveOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+// NewCmd returns a new remove command
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&RemoveOptions{}, cobra.Command{
+		Short:   "Remove manifests from the index of a remote package",
+		Use:     "rm [FLAGS] PACKAGE",
+		Aliases: []string{"remove"},
+		Args:    cmdfactory.ExactArgs(1, "package not specified"),
+		Long: heredoc.Doc(`
+			Remove the manifests which match the architecture, the platform or the
+			digest from the index of a remote package.
+
+			The manifests themselves remain in the registry and can still be
+			referenced by their digest.
+		`),
+		Example: heredoc.Doc(`
+			# Remove the manifest for QEMU on x86_64
+			$ kraft pkg manifest rm --plat qemu --arch x86_64 unikraft.org/nginx:latest
+
+			# Remove a manifest by its digest
+			$ kraft pkg manifest rm --digest sha256:0b1c... unikraft.org/nginx:latest
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "pkg",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *RemoveOptions) Pre(_ *cobra.Command, _ []string) error {
+	if opts.Architecture == "" && opts.Platform == "" && opts.Digest == "" {
+		return fmt.Errorf("at least one of `--arch`, `--plat` or `--digest` must be set")
+	}
+
+	return nil
+}
+
+// Run executes the remove command
+func (opts *RemoveOptions) Run(ctx context.Context, args []string) error {
+	dgst, err := oci.UpdateRemoteIndex(ctx, args[0], func(index *ocispec.Index) error {
+		var manifests []ocispec.Descriptor
+
+		for _, desc := range index.Manifests {
+			if oci.ManifestMatches(desc, opts.Architecture, opts.Platform, opts.Digest) {
+				log.G(ctx).
+					WithField("digest", desc.Digest.String()).
+					Info("removing manifest")
+				continue
+			}
+
+			manifests = append(manifests, desc)
+		}
+
+		if len(manifests) == len(index.Manifests) {
+			return fmt.Errorf("no manifest matches")
+		}
+
+		index.Manifests = manifests
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(iostreams.G(ctx).Out, dgst.String())
+
+	return nil
+}
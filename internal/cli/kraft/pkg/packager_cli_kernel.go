@@ -12,7 +12,6 @@ import (
 
 	"github.com/mattn/go-shellwords"
 	"kraftkit.sh/config"
-	"kraftkit.sh/internal/cli/kraft/utils"
 	"kraftkit.sh/log"
 	"kraftkit.sh/pack"
 	"kraftkit.sh/packmanager"
@@ -70,7 +69,7 @@ func (p *packagerCliKernel) Pack(ctx context.Context, opts *PkgOptions, args ...
 
 	var cmds []string
 	var envs []string
-	if opts.Rootfs, cmds, envs, err = utils.BuildRootfs(ctx, opts.Workdir, opts.Rootfs, opts.Compress, targ); err != nil {
+	if opts.Rootfs, cmds, envs, err = opts.buildRootfs(ctx, opts.Rootfs, targ); err != nil {
 		return nil, fmt.Errorf("could not build rootfs: %w", err)
 	}
 
@@ -103,12 +102,18 @@ func (p *packagerCliKernel) Pack(ctx context.Context, opts *PkgOptions, args ...
 			func(ctx context.Context) error {
 				popts := append(opts.packopts,
 					packmanager.PackArgs(cmdShellArgs...),
-					packmanager.PackInitrd(opts.Rootfs),
 					packmanager.PackKConfig(!opts.NoKConfig),
 					packmanager.PackName(opts.Name),
 					packmanager.PackOutput(opts.Output),
 				)
 
+				initrdopts, err := packInitrd(opts.Rootfs)
+				if err != nil {
+					return err
+				}
+
+				popts = append(popts, initrdopts...)
+
 				envs := opts.aggregateEnvs()
 				if len(envs) > 0 {
 					popts = append(popts, packmanager.PackWithEnvs(envs))
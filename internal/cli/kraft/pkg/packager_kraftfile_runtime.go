@@ -285,7 +285,7 @@ func (p *packagerKraftfileRuntime) Pack(ctx context.Context, opts *PkgOptions, a
 
 	var cmds []string
 	var envs []string
-	if opts.Rootfs, cmds, envs, err = utils.BuildRootfs(ctx, opts.Workdir, opts.Rootfs, opts.Compress, targ); err != nil {
+	if opts.Rootfs, cmds, envs, err = opts.buildRootfs(ctx, opts.Rootfs, targ); err != nil {
 		return nil, fmt.Errorf("could not build rootfs: %w", err)
 	}
 
@@ -331,12 +331,18 @@ func (p *packagerKraftfileRuntime) Pack(ctx context.Context, opts *PkgOptions, a
 			func(ctx context.Context) error {
 				popts := append(opts.packopts,
 					packmanager.PackArgs(args...),
-					packmanager.PackInitrd(opts.Rootfs),
 					packmanager.PackKConfig(!opts.NoKConfig),
 					packmanager.PackName(opts.Name),
 					packmanager.PackOutput(opts.Output),
 				)
 
+				initrdopts, err := packInitrd(opts.Rootfs)
+				if err != nil {
+					return err
+				}
+
+				popts = append(popts, initrdopts...)
+
 				if ukversion, ok := targ.KConfig().Get(unikraft.UK_FULLVERSION); ok {
 					popts = append(popts,
 						packmanager.PackWithKernelVersion(ukversion.Value),
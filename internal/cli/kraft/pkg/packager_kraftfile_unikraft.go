@@ -14,7 +14,6 @@ import (
 
 	"github.com/mattn/go-shellwords"
 	"kraftkit.sh/config"
-	"kraftkit.sh/internal/cli/kraft/utils"
 	"kraftkit.sh/log"
 	"kraftkit.sh/pack"
 	"kraftkit.sh/packmanager"
@@ -104,7 +103,7 @@ func (p *packagerKraftfileUnikraft) Pack(ctx context.Context, opts *PkgOptions,
 		) {
 			rootfs = ""
 		} else {
-			if rootfs, cmds, envs, err = utils.BuildRootfs(ctx, opts.Workdir, rootfs, opts.Compress, targ); err != nil {
+			if rootfs, cmds, envs, err = opts.buildRootfs(ctx, rootfs, targ); err != nil {
 				return nil, fmt.Errorf("could not build rootfs: %w", err)
 			}
 		}
@@ -150,12 +149,18 @@ func (p *packagerKraftfileUnikraft) Pack(ctx context.Context, opts *PkgOptions,
 			func(ctx context.Context) error {
 				popts := append(baseopts,
 					packmanager.PackArgs(cmdShellArgs...),
-					packmanager.PackInitrd(rootfs),
 					packmanager.PackKConfig(!opts.NoKConfig),
 					packmanager.PackName(opts.Name),
 					packmanager.PackOutput(opts.Output),
 				)
 
+				initrdopts, err := packInitrd(rootfs)
+				if err != nil {
+					return err
+				}
+
+				popts = append(popts, initrdopts...)
+
 				if ukversion, ok := targ.KConfig().Get(unikraft.UK_FULLVERSION); ok {
 					popts = append(popts,
 						packmanager.PackWithKernelVersion(ukversion.Value),
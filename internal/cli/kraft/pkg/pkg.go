@@ -8,14 +8,18 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
 
 	"kraftkit.sh/config"
+	"kraftkit.sh/initrd"
 	"kraftkit.sh/log"
 	"kraftkit.sh/machine/platform"
+	"kraftkit.sh/oci"
 	"kraftkit.sh/pack"
+	ukprovenance "kraftkit.sh/provenance"
 	"kraftkit.sh/tui/processtree"
 	"kraftkit.sh/tui/selection"
 	"kraftkit.sh/unikraft/app"
@@ -23,40 +27,66 @@ import (
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/packmanager"
 
+	"kraftkit.sh/internal/cli/kraft/pkg/copy"
+	"kraftkit.sh/internal/cli/kraft/pkg/diff"
+	"kraftkit.sh/internal/cli/kraft/pkg/gc"
 	"kraftkit.sh/internal/cli/kraft/pkg/info"
 	"kraftkit.sh/internal/cli/kraft/pkg/list"
+	"kraftkit.sh/internal/cli/kraft/pkg/load"
+	"kraftkit.sh/internal/cli/kraft/pkg/manifest"
+	"kraftkit.sh/internal/cli/kraft/pkg/provenance"
 	"kraftkit.sh/internal/cli/kraft/pkg/pull"
 	"kraftkit.sh/internal/cli/kraft/pkg/push"
 	"kraftkit.sh/internal/cli/kraft/pkg/remove"
+	"kraftkit.sh/internal/cli/kraft/pkg/save"
+	"kraftkit.sh/internal/cli/kraft/pkg/sbom"
+	"kraftkit.sh/internal/cli/kraft/pkg/sign"
 	"kraftkit.sh/internal/cli/kraft/pkg/source"
+	"kraftkit.sh/internal/cli/kraft/pkg/tag"
+	"kraftkit.sh/internal/cli/kraft/pkg/tags"
 	"kraftkit.sh/internal/cli/kraft/pkg/unsource"
 	"kraftkit.sh/internal/cli/kraft/pkg/update"
+	"kraftkit.sh/internal/cli/kraft/pkg/verify"
 )
 
 type PkgOptions struct {
-	Architecture string                    `local:"true" long:"arch" short:"m" usage:"Filter the creation of the package by architecture of known targets"`
-	Args         []string                  `local:"true" long:"args" short:"a" usage:"Pass arguments that will be part of the running kernel's command line"`
-	Compress     bool                      `local:"true" long:"compress" short:"c" usage:"Compress the initrd package (experimental)"`
-	Dbg          bool                      `local:"true" long:"dbg" usage:"Package the debuggable (symbolic) kernel image instead of the stripped image"`
-	Env          []string                  `local:"true" long:"env" short:"e" usage:"Set environment variables to be packed into the package"`
-	Force        bool                      `local:"true" long:"force-format" usage:"Force the use of a packaging handler format"`
-	Format       string                    `local:"true" long:"as" short:"M" usage:"Force the packaging despite possible conflicts" default:"oci"`
-	Kernel       string                    `local:"true" long:"kernel" short:"k" usage:"Override the path to the unikernel image"`
-	Kraftfile    string                    `long:"kraftfile" short:"K" usage:"Set an alternative path of the Kraftfile"`
-	Name         string                    `local:"true" long:"name" short:"n" usage:"Specify the name of the package"`
-	NoKConfig    bool                      `local:"true" long:"no-kconfig" usage:"Do not include target .config as metadata"`
-	NoPull       bool                      `local:"true" long:"no-pull" usage:"Do not pull package dependencies before packaging"`
-	Output       string                    `local:"true" long:"output" short:"o" usage:"Save the package at the following output"`
-	Platform     string                    `local:"true" long:"plat" short:"p" usage:"Filter the creation of the package by platform of known targets"`
-	Project      app.Application           `noattribute:"true"`
-	Push         bool                      `local:"true" long:"push" short:"P" usage:"Push the package on if successfully packaged"`
-	Rootfs       string                    `local:"true" long:"rootfs" usage:"Specify a path to use as root file system (can be volume or initramfs)"`
-	Strategy     packmanager.MergeStrategy `noattribute:"true"`
-	Target       string                    `local:"true" long:"target" short:"t" usage:"Package a particular known target"`
-	Workdir      string                    `local:"true" long:"workdir" short:"w" usage:"Set an alternative working directory (default is cwd)"`
-
-	packopts []packmanager.PackOption
-	pm       packmanager.PackageManager
+	Add              []string                  `local:"true" long:"add" usage:"Add a file to the root file system (SRC:DST[:mode[:uid:gid]])"`
+	Append           bool                      `local:"true" long:"append" usage:"Merge the package into the existing remote index when pushing (requires --push)"`
+	Architecture     string                    `local:"true" long:"arch" short:"m" usage:"Filter the creation of the package by architecture of known targets"`
+	Args             []string                  `local:"true" long:"args" short:"a" usage:"Pass arguments that will be part of the running kernel's command line"`
+	Compress         bool                      `local:"true" long:"compress" short:"c" usage:"Compress the initrd package"`
+	Compression      initrd.Compression        `noattribute:"true"`
+	CompressionLevel int                       `local:"true" long:"compression-level" usage:"Set the level of the initrd compression: 1-9 for gzip and lz4, 1-22 for zstd (0 selects the default)"`
+	Dbg              bool                      `local:"true" long:"dbg" usage:"Package the debuggable (symbolic) kernel image instead of the stripped image"`
+	Disks            []string                  `local:"true" long:"disk" usage:"Include a file system image which is mounted when the package is run (SRC[:DEST])"`
+	Env              []string                  `local:"true" long:"env" short:"e" usage:"Set environment variables to be packed into the package"`
+	Force            bool                      `local:"true" long:"force-format" usage:"Force the use of a packaging handler format"`
+	Format           string                    `local:"true" long:"as" short:"M" usage:"Force the packaging despite possible conflicts" default:"oci"`
+	Include          []string                  `local:"true" long:"include" usage:"Include additional host files in a rootfs built from ELF executables (<host>[:<machine>])"`
+	Kernel           string                    `local:"true" long:"kernel" short:"k" usage:"Override the path to the unikernel image"`
+	Kraftfile        string                    `long:"kraftfile" short:"K" usage:"Set an alternative path of the Kraftfile"`
+	Name             string                    `local:"true" long:"name" short:"n" usage:"Specify the name of the package"`
+	NoKConfig        bool                      `local:"true" long:"no-kconfig" usage:"Do not include target .config as metadata"`
+	NoProvenance     bool                      `local:"true" long:"no-provenance" usage:"Do not record the build provenance of the package"`
+	NoPull           bool                      `local:"true" long:"no-pull" usage:"Do not pull package dependencies before packaging"`
+	NoReproducible   bool                      `local:"true" long:"no-reproducible" usage:"Do not normalize the root file system for reproducible builds"`
+	NoRootfsCache    bool                      `local:"true" long:"no-rootfs-cache" usage:"Do not use the cache of previously built root file systems"`
+	Output           string                    `local:"true" long:"output" short:"o" usage:"Save the package at the following output"`
+	Platform         string                    `local:"true" long:"plat" short:"p" usage:"Filter the creation of the package by platform of known targets"`
+	PrintStats       bool                      `local:"true" long:"print-stats" usage:"Print the size of the initrd with each supported compression"`
+	Project          app.Application           `noattribute:"true"`
+	Push             bool                      `local:"true" long:"push" short:"P" usage:"Push the package on if successfully packaged"`
+	Rootfs           string                    `local:"true" long:"rootfs" usage:"Specify a path to use as root file system (can be volume or initramfs)"`
+	RootfsFormat     string                    `local:"true" long:"rootfs-format" usage:"Set the format of the root file system (cpio, erofs, squashfs)" default:"cpio"`
+	Sbom             bool                      `local:"true" long:"sbom" usage:"Attach the SBOM of the package to the pushed OCI index (requires --push)"`
+	Strategy         packmanager.MergeStrategy `noattribute:"true"`
+	Target           string                    `local:"true" long:"target" short:"t" usage:"Package a particular known target"`
+	Workdir          string                    `local:"true" long:"workdir" short:"w" usage:"Set an alternative working directory (default is cwd)"`
+
+	packopts  []packmanager.PackOption
+	pm        packmanager.PackageManager
+	rootfses  map[string]*ukprovenance.Rootfs
+	startedOn time.Time
 }
 
 // Pkg a Unikraft project.
@@ -67,6 +97,8 @@ func Pkg(ctx context.Context, opts *PkgOptions, args ...string) ([]pack.Package,
 		opts = &PkgOptions{}
 	}
 
+	opts.startedOn = time.Now()
+
 	if opts.Workdir == "" {
 		if len(args) == 0 {
 			opts.Workdir, err = os.Getwd()
@@ -84,6 +116,29 @@ func Pkg(ctx context.Context, opts *PkgOptions, args ...string) ([]pack.Package,
 		return nil, fmt.Errorf("cannot package without setting --name")
 	}
 
+	if opts.Sbom && !opts.Push {
+		return nil, fmt.Errorf("the `--sbom` option requires `--push`")
+	}
+
+	if opts.Append && !opts.Push {
+		return nil, fmt.Errorf("the `--append` option requires `--push`")
+	}
+
+	if len(opts.Disks) > 0 {
+		disks, err := opts.parseDisks()
+		if err != nil {
+			return nil, err
+		}
+
+		opts.packopts = append(opts.packopts, packmanager.PackDisks(disks...))
+	}
+
+	// Appending to the remote index implies that the local index is merged too.
+	if opts.Append {
+		opts.Strategy = packmanager.StrategyMerge
+		opts.packopts = append(opts.packopts, packmanager.PackAppend(true))
+	}
+
 	if (len(opts.Architecture) > 0 || len(opts.Platform) > 0) && len(opts.Target) > 0 {
 		return nil, fmt.Errorf("the `--arch` and `--plat` options are not supported in addition to `--target`")
 	}
@@ -201,6 +256,30 @@ func Pkg(ctx context.Context, opts *PkgOptions, args ...string) ([]pack.Package,
 		return nil, fmt.Errorf("could not package: %w", err)
 	}
 
+	// Provenance statements are kept such that they can be pushed along with
+	// their package.
+	statements := make(map[pack.Package][]byte, len(packs))
+
+	if !opts.NoProvenance {
+		finishedOn := time.Now()
+
+		for _, p := range packs {
+			if p.Format() != oci.OCIFormat {
+				log.G(ctx).
+					WithField("package", p.String()).
+					Debug("skipping provenance of non-OCI package")
+				continue
+			}
+
+			statement, err := opts.attestProvenance(ctx, p, finishedOn)
+			if err != nil {
+				return packs, fmt.Errorf("could not record provenance of %s: %w", p.String(), err)
+			}
+
+			statements[p] = statement
+		}
+	}
+
 	if opts.Push {
 		var processes []*processtree.ProcessTreeItem
 
@@ -211,7 +290,21 @@ func Pkg(ctx context.Context, opts *PkgOptions, args ...string) ([]pack.Package,
 				fmt.Sprintf("pushing %s", p.String()),
 				"",
 				func(ctx context.Context) error {
-					return p.Push(ctx)
+					if err := p.Push(ctx); err != nil {
+						return err
+					}
+
+					if statement, ok := statements[p]; ok {
+						if _, err := oci.PushPackageReferrer(ctx, p, ukprovenance.MediaType, statement, nil); err != nil {
+							return fmt.Errorf("could not attach provenance: %w", err)
+						}
+					}
+
+					if opts.Sbom {
+						return sbom.Attach(ctx, p)
+					}
+
+					return nil
 				},
 			))
 		}
@@ -255,6 +348,18 @@ func NewCmd() *cobra.Command {
 		Example: heredoc.Doc(`
 			# Package a project as an OCI archive and embed the target's KConfig.
 			$ kraft pkg --as oci --name unikraft.org/nginx:latest	
+
+			# Package a project without recording its build provenance.
+			$ kraft pkg --name unikraft.org/nginx:latest --no-provenance
+
+			# Package and push a project along with its SBOM.
+			$ kraft pkg --name unikraft.org/nginx:latest --push --sbom
+
+			# Ship a database along with its seed data mounted at /var/lib/db.
+			$ kraft pkg --name unikraft.org/postgres:latest --disk ./seed.ext4:/var/lib/db
+
+			# Add or replace the target's manifest in the existing remote index.
+			$ kraft pkg --name unikraft.org/nginx:latest --plat fc --arch arm64 --push --append
 		`),
 		Annotations: map[string]string{
 			cmdfactory.AnnotationHelpGroup: "pkg",
@@ -264,14 +369,26 @@ func NewCmd() *cobra.Command {
 		panic(err)
 	}
 
+	cmd.AddCommand(copy.NewCmd())
+	cmd.AddCommand(diff.NewCmd())
+	cmd.AddCommand(gc.NewCmd())
 	cmd.AddCommand(info.New())
 	cmd.AddCommand(list.NewCmd())
+	cmd.AddCommand(load.NewCmd())
+	cmd.AddCommand(manifest.NewCmd())
+	cmd.AddCommand(provenance.NewCmd())
 	cmd.AddCommand(pull.NewCmd())
 	cmd.AddCommand(push.NewCmd())
 	cmd.AddCommand(remove.NewCmd())
+	cmd.AddCommand(save.NewCmd())
+	cmd.AddCommand(sbom.NewCmd())
+	cmd.AddCommand(sign.NewCmd())
 	cmd.AddCommand(source.NewCmd())
+	cmd.AddCommand(tag.NewCmd())
+	cmd.AddCommand(tags.NewCmd())
 	cmd.AddCommand(unsource.NewCmd())
 	cmd.AddCommand(update.NewCmd())
+	cmd.AddCommand(verify.NewCmd())
 
 	cmd.Flags().Var(
 		cmdfactory.NewEnumFlag[packmanager.MergeStrategy](
@@ -282,6 +399,15 @@ func NewCmd() *cobra.Command {
 		"When a package of the same name exists, use this strategy when applying targets.",
 	)
 
+	cmd.Flags().Var(
+		cmdfactory.NewEnumFlag[initrd.Compression](
+			initrd.Compressions(),
+			initrd.CompressionGzip,
+		),
+		"compression",
+		"Set the compression algorithm of the initrd when compressing",
+	)
+
 	return cmd
 }
 
@@ -294,6 +420,7 @@ func (opts *PkgOptions) Pre(cmd *cobra.Command, args []string) error {
 	cmd.SetContext(ctx)
 
 	opts.Strategy = packmanager.MergeStrategy(cmd.Flag("strategy").Value.String())
+	opts.Compression = initrd.Compression(cmd.Flag("compression").Value.String())
 
 	return nil
 }
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package provenance implements the `kraft pkg provenance` command
+package provenance
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/utils"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/oci"
+	"kraftkit.sh/packmanager"
+	ukprovenance "kraftkit.sh/provenance"
+)
+
+type ProvenanceOptions struct {
+	Architecture string `local:"true" long:"arch" short:"m" usage:"Filter packages by architecture"`
+	Output       string `local:"true" long:"output" short:"o" usage:"Write the provenance to a file instead of the standard output"`
+	Platform     string `local:"true" long:"plat" short:"p" usage:"Filter packages by platform"`
+}
+
+// Provenance displays the provenance statement of a package.
+func Provenance(ctx context.Context, opts *ProvenanceOptions, args ...string) error {
+	if opts == nil {
+		opts = &ProvenanceOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+// NewCmd returns a new provenance command
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&ProvenanceOptions{}, cobra.Command{
+		Short: "Show the build provenance of a package",
+		Use:   "provenance [FLAGS] PACKAGE",
+		Args:  cmdfactory.ExactArgs(1, "package not specified"),
+		Long: heredoc.Doc(`
+			Show the SLSA build provenance of a unikernel package.
+
+			The provenance is an in-toto statement which is recorded when the package
+			is built with 'kraft pkg' and which is attached to the package as an OCI
+			referrer.  It lists the digest of the Kraftfile, the resolved components,
+			the KConfig, the toolchain, the inputs of the root file system and the
+			version of KraftKit which built the package.
+		`),
+		Example: heredoc.Doc(`
+			# Show the provenance of a package
+			$ kraft pkg provenance unikraft.org/nginx:latest
+
+			# Save the provenance of a package for a specific target
+			$ kraft pkg provenance --plat qemu --arch x86_64 -o nginx.intoto.json unikraft.org/nginx:latest
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "pkg",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *ProvenanceOptions) Pre(cmd *cobra.Command, _ []string) error {
+	ctx, err := packmanager.WithDefaultUmbrellaManagerInContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	cmd.SetContext(ctx)
+
+	return nil
+}
+
+// Run executes the provenance command
+func (opts *ProvenanceOptions) Run(ctx context.Context, args []string) error {
+	selected, err := utils.FindPackage(ctx, args[0], opts.Architecture, opts.Platform)
+	if err != nil {
+		return err
+	}
+
+	blobs, err := oci.PackageReferrers(ctx, selected, ukprovenance.MediaType)
+	if err != nil {
+		return fmt.Errorf("could not retrieve provenance: %w", err)
+	}
+
+	if len(blobs) == 0 {
+		return fmt.Errorf("no provenance found for %s", selected.String())
+	}
+
+	// Packages which were rebuilt with identical contents may have several
+	// statements, in which case the most recent one is shown.
+	var latest *ukprovenance.Statement
+	for _, blob := range blobs {
+		statement, err := ukprovenance.Parse(blob)
+		if err != nil {
+			log.G(ctx).
+				WithError(err).
+				Debug("skipping attestation")
+			continue
+		}
+
+		if latest == nil || statement.FinishedOn().After(latest.FinishedOn()) {
+			latest = statement
+		}
+	}
+
+	if latest == nil {
+		return fmt.Errorf("no SLSA provenance found for %s", selected.String())
+	}
+
+	var out io.Writer = iostreams.G(ctx).Out
+	if opts.Output != "" {
+		f, err := os.Create(opts.Output)
+		if err != nil {
+			return fmt.Errorf("could not create provenance file: %w", err)
+		}
+
+		defer f.Close()
+
+		out = f
+	}
+
+	return latest.Write(out)
+}
@@ -16,6 +16,7 @@ import (
 	"kraftkit.sh/cmdfactory"
 	"kraftkit.sh/config"
 	"kraftkit.sh/iostreams"
+	"kraftkit.sh/lockfile"
 	"kraftkit.sh/log"
 	"kraftkit.sh/machine/platform"
 	"kraftkit.sh/pack"
@@ -34,6 +35,7 @@ type PullOptions struct {
 	Format       string   `long:"as" short:"f" usage:"Set the package format" default:"auto"`
 	KConfig      []string `long:"kconfig" short:"k" usage:"Request a package with specific KConfig options."`
 	Kraftfile    string   `long:"kraftfile" short:"K" usage:"Set an alternative path of the Kraftfile"`
+	Locked       bool     `long:"locked" usage:"Fail if the resolved components differ from the Kraftfile.lock"`
 	NoChecksum   bool     `long:"no-checksum" short:"C" usage:"Do not verify package checksum (if available)"`
 	Output       string   `long:"output" short:"o" usage:"Save the package contents to the provided directory"`
 	Platform     string   `long:"plat" short:"p" usage:"Specify the desired platform"`
@@ -65,6 +67,9 @@ func NewCmd() *cobra.Command {
 			# Pull dependencies for a project at a path
 			$ kraft pkg pull path/to/app
 
+			# Pull the dependencies for a project exactly as they are locked
+			$ kraft pkg pull --locked path/to/app
+
 			# Pull a source repository
 			$ kraft pkg pull github.com/unikraft/app-nginx.git
 
@@ -110,6 +115,7 @@ func (opts *PullOptions) Pre(cmd *cobra.Command, _ []string) error {
 func (opts *PullOptions) Run(ctx context.Context, args []string) error {
 	var err error
 	var project app.Application
+	var lockProject app.Application
 	var processes []*paraprogress.Process
 
 	if len(opts.Workdir) == 0 {
@@ -182,6 +188,12 @@ func (opts *PullOptions) Run(ctx context.Context, args []string) error {
 			return err
 		}
 
+		// Components are retrieved at their locked versions, if any.
+		previous, err := lockfile.Load(opts.Workdir)
+		if err != nil {
+			return err
+		}
+
 		if _, err = project.Components(ctx); err != nil {
 			var pullPack pack.Package
 			var packages []pack.Package
@@ -196,7 +208,7 @@ func (opts *PullOptions) Run(ctx context.Context, args []string) error {
 						qopts := []packmanager.QueryOption{
 							packmanager.WithName(project.Template().Name()),
 							packmanager.WithTypes(unikraft.ComponentTypeApp),
-							packmanager.WithVersion(project.Template().Version()),
+							packmanager.WithVersion(previous.Version(project.Template(), project.Template().Source())),
 							packmanager.WithRemote(opts.Update),
 							packmanager.WithPlatform(opts.Platform),
 							packmanager.WithArchitecture(opts.Architecture),
@@ -312,10 +324,22 @@ func (opts *PullOptions) Run(ctx context.Context, args []string) error {
 		if err != nil {
 			return err
 		}
+
+		// Pick consistent versions of the core and the libraries before any of
+		// them is pulled.
+		pinned, err := lockfile.Pin(ctx, project,
+			lockfile.WithPrevious(previous),
+			lockfile.WithRemote(opts.Update),
+			lockfile.WithAuthConfig(config.G[config.KraftKit](ctx).Auth),
+		)
+		if err != nil {
+			return err
+		}
+
 		for _, c := range components {
 			queries = append(queries, []packmanager.QueryOption{
 				packmanager.WithName(c.Name()),
-				packmanager.WithVersion(c.Version()),
+				packmanager.WithVersion(pinned.Version(c, c.Source())),
 				packmanager.WithSource(c.Source()),
 				packmanager.WithTypes(c.Type()),
 				packmanager.WithRemote(opts.Update),
@@ -324,6 +348,8 @@ func (opts *PullOptions) Run(ctx context.Context, args []string) error {
 			})
 		}
 
+		lockProject = project
+
 		if project.Runtime() != nil {
 			queries = append(queries, []packmanager.QueryOption{
 				packmanager.WithName(project.Runtime().Name()),
@@ -484,6 +510,18 @@ func (opts *PullOptions) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
+	// The runtime cannot be locked when pulling it for all platforms and
+	// architectures.
+	if lockProject != nil && !opts.All {
+		if _, err := lockfile.Sync(ctx, lockProject, opts.Locked,
+			lockfile.WithRemote(opts.Update),
+			lockfile.WithPlatform(opts.Platform),
+			lockfile.WithArchitecture(opts.Architecture),
+		); err != nil {
+			return fmt.Errorf("could not lock components: %w", err)
+		}
+	}
+
 	if project != nil {
 		fmt.Fprint(iostreams.G(ctx).Out, project.PrintInfo(ctx))
 	}
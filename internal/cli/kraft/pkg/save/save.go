@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package save implements the `kraft pkg save` command
+package save
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/log"
+	"kraftkit.sh/oci"
+	"kraftkit.sh/pack"
+	"kraftkit.sh/packmanager"
+)
+
+type SaveOptions struct {
+	Output string `local:"true" long:"output" short:"o" usage:"Path of the tarball to write"`
+}
+
+// Save packages into an OCI image-layout tarball.
+func Save(ctx context.Context, opts *SaveOptions, args ...string) error {
+	if opts == nil {
+		opts = &SaveOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+// NewCmd returns a new save command
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&SaveOptions{}, cobra.Command{
+		Short: "Save packages to a tarball",
+		Use:   "save [FLAGS] PACKAGE [PACKAGE...]",
+		Args:  cmdfactory.MinimumArgs(1, "package name(s) not specified"),
+		Long: heredoc.Doc(`
+			Save one or more packages, including their kernels, root file systems and
+			any referrers such as signatures and SBOMs, to an OCI image-layout tarball.
+
+			Packages which are not available locally are pulled first.  The tarball
+			can be imported on hosts without registry access with 'kraft pkg load'.
+		`),
+		Example: heredoc.Doc(`
+			# Save a package to a tarball
+			$ kraft pkg save unikraft.org/nginx:latest -o bundle.tar
+
+			# Save multiple packages to a tarball
+			$ kraft pkg save unikraft.org/nginx:latest unikraft.org/redis:latest -o bundle.tar
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "pkg",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *SaveOptions) Pre(cmd *cobra.Command, _ []string) error {
+	if opts.Output == "" {
+		return fmt.Errorf("the --output flag must be set")
+	}
+
+	ctx, err := packmanager.WithDefaultUmbrellaManagerInContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	cmd.SetContext(ctx)
+
+	return nil
+}
+
+// Run executes the save command
+func (opts *SaveOptions) Run(ctx context.Context, args []string) error {
+	pm, err := packmanager.G(ctx).From(oci.OCIFormat)
+	if err != nil {
+		return err
+	}
+
+	var refs []string
+	seen := map[string]struct{}{}
+
+	for _, arg := range args {
+		packs, err := find(ctx, pm, arg)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range packs {
+			sep := ":"
+			if strings.HasPrefix(p.Version(), "sha256:") {
+				sep = "@"
+			}
+
+			ref := p.Name() + sep + p.Version()
+			if _, ok := seen[ref]; ok {
+				continue
+			}
+
+			seen[ref] = struct{}{}
+			refs = append(refs, ref)
+		}
+	}
+
+	f, err := os.Create(opts.Output)
+	if err != nil {
+		return fmt.Errorf("could not create tarball: %w", err)
+	}
+
+	defer f.Close()
+
+	if err := oci.SaveLayout(ctx, f, refs...); err != nil {
+		os.Remove(opts.Output)
+		return err
+	}
+
+	log.G(ctx).
+		WithField("output", opts.Output).
+		Infof("saved %s", strings.Join(refs, ", "))
+
+	return f.Close()
+}
+
+// find returns the local packages with the provided name, pulling them if
+// they are not available locally.
+func find(ctx context.Context, pm packmanager.PackageManager, arg string) ([]pack.Package, error) {
+	packs, err := pm.Catalog(ctx,
+		packmanager.WithName(arg),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not query catalog: %w", err)
+	}
+
+	if len(packs) == 0 {
+		packs, err = pm.Catalog(ctx,
+			packmanager.WithName(arg),
+			packmanager.WithRemote(true),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not query catalog: %w", err)
+		}
+	}
+
+	if len(packs) == 0 {
+		return nil, fmt.Errorf("could not find: %s", arg)
+	}
+
+	for _, p := range packs {
+		if exists, _, err := p.PulledAt(ctx); exists && err == nil {
+			continue
+		}
+
+		log.G(ctx).
+			WithField("package", p.String()).
+			Info("pulling")
+
+		if err := p.Pull(ctx); err != nil {
+			return nil, fmt.Errorf("could not pull %s: %w", p.String(), err)
+		}
+	}
+
+	return packs, nil
+}
@@ -0,0 +1,221 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package sbom implements the `kraft pkg sbom` command
+package sbom
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/cli/kraft/utils"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/oci"
+	"kraftkit.sh/pack"
+	"kraftkit.sh/packmanager"
+	uksbom "kraftkit.sh/sbom"
+	"kraftkit.sh/unikraft/target"
+)
+
+type SbomOptions struct {
+	Architecture string        `local:"true" long:"arch" short:"m" usage:"Filter packages by architecture"`
+	Format       uksbom.Format `noattribute:"true"`
+	Initrd       string        `local:"true" long:"initrd" short:"i" usage:"Include the contents of an initramfs when reading a kernel image"`
+	Output       string        `local:"true" long:"output" short:"o" usage:"Write the SBOM to a file instead of the standard output"`
+	Platform     string        `local:"true" long:"plat" short:"p" usage:"Filter packages by platform"`
+}
+
+// Sbom generates the SBOM of a package or kernel image.
+func Sbom(ctx context.Context, opts *SbomOptions, args ...string) error {
+	if opts == nil {
+		opts = &SbomOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+// NewCmd returns a new sbom command
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&SbomOptions{}, cobra.Command{
+		Short: "Generate the software bill of materials of a package",
+		Use:   "sbom [FLAGS] PACKAGE|KERNEL",
+		Args:  cmdfactory.ExactArgs(1, "package or kernel not specified"),
+		Long: heredoc.Doc(`
+			Generate the software bill of materials (SBOM) of a unikernel package or
+			of a kernel image.
+
+			The SBOM lists the Unikraft core and the libraries which were linked into
+			the kernel, as recorded at build time, along with the files of the
+			initramfs and, when the root file system was built from a distribution
+			image, its installed packages.
+		`),
+		Example: heredoc.Doc(`
+			# Generate the SPDX SBOM of a package
+			$ kraft pkg sbom unikraft.org/nginx:latest
+
+			# Generate the CycloneDX SBOM of a package for a specific target
+			$ kraft pkg sbom --format cyclonedx --plat qemu --arch x86_64 unikraft.org/nginx:latest
+
+			# Generate the SBOM of a kernel image and its initramfs
+			$ kraft pkg sbom --initrd .unikraft/build/initramfs-x86_64.cpio .unikraft/build/nginx_qemu-x86_64
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "pkg",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.Flags().Var(
+		cmdfactory.NewEnumFlag[uksbom.Format](
+			uksbom.Formats(),
+			uksbom.FormatSPDX,
+		),
+		"format",
+		"Set the format of the SBOM",
+	)
+
+	return cmd
+}
+
+func (opts *SbomOptions) Pre(cmd *cobra.Command, _ []string) error {
+	ctx, err := packmanager.WithDefaultUmbrellaManagerInContext(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	cmd.SetContext(ctx)
+
+	opts.Format = uksbom.Format(cmd.Flag("format").Value.String())
+
+	return nil
+}
+
+// Run executes the sbom command
+func (opts *SbomOptions) Run(ctx context.Context, args []string) error {
+	if opts.Format == "" {
+		opts.Format = uksbom.FormatSPDX
+	}
+
+	var bom *uksbom.SBOM
+	var err error
+
+	if fi, serr := os.Stat(args[0]); serr == nil && !fi.IsDir() {
+		sopts := []uksbom.SBOMOption{
+			uksbom.WithKernel(args[0]),
+		}
+
+		if opts.Initrd != "" {
+			sopts = append(sopts, uksbom.WithInitrd(opts.Initrd))
+		}
+
+		bom, err = uksbom.New(ctx, filepath.Base(args[0]), sopts...)
+	} else {
+		var selected pack.Package
+
+		selected, err = utils.FindPackage(ctx, args[0], opts.Architecture, opts.Platform)
+		if err != nil {
+			return err
+		}
+
+		bom, err = Generate(ctx, selected)
+	}
+	if err != nil {
+		return fmt.Errorf("could not generate SBOM: %w", err)
+	}
+
+	var out io.Writer = iostreams.G(ctx).Out
+	if opts.Output != "" {
+		f, err := os.Create(opts.Output)
+		if err != nil {
+			return fmt.Errorf("could not create SBOM file: %w", err)
+		}
+
+		defer f.Close()
+
+		out = f
+	}
+
+	return bom.Write(out, opts.Format)
+}
+
+// Generate unpacks the provided package into a temporary directory and
+// generates the SBOM of its kernel and initramfs.
+func Generate(ctx context.Context, p pack.Package) (*uksbom.SBOM, error) {
+	dir, err := os.MkdirTemp(config.G[config.KraftKit](ctx).RuntimeDir, "sbom-*")
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.RemoveAll(dir)
+
+	if err := p.Unpack(ctx, dir); err != nil {
+		return nil, fmt.Errorf("could not unpack %s: %w", p.String(), err)
+	}
+
+	targ, ok := p.(target.Target)
+	if !ok {
+		return nil, fmt.Errorf("package does not convert to target")
+	}
+
+	sopts := []uksbom.SBOMOption{
+		uksbom.WithKernel(targ.Kernel()),
+	}
+
+	if initrd := filepath.Join(dir, oci.WellKnownInitrdPath); utils.IsNonEmptyFile(initrd) {
+		sopts = append(sopts, uksbom.WithInitrd(initrd))
+	}
+
+	return uksbom.New(ctx, p.Name(), sopts...)
+}
+
+// Attach generates the SBOM of the provided package in every supported format
+// and pushes each of them as a referrer of the package in its registry.
+func Attach(ctx context.Context, p pack.Package) error {
+	bom, err := Generate(ctx, p)
+	if err != nil {
+		return fmt.Errorf("could not generate SBOM: %w", err)
+	}
+
+	sep := ":"
+	if strings.HasPrefix(p.Version(), "sha256:") {
+		sep = "@"
+	}
+
+	ref, err := name.ParseReference(p.Name() + sep + p.Version())
+	if err != nil {
+		return fmt.Errorf("could not parse image reference: %w", err)
+	}
+
+	ropts, err := oci.RemoteOptions(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	for _, format := range uksbom.Formats() {
+		var buf bytes.Buffer
+		if err := bom.Write(&buf, format); err != nil {
+			return fmt.Errorf("could not serialize %s SBOM: %w", format, err)
+		}
+
+		if _, err := oci.PushReferrer(ctx, ref, format.MediaType(), buf.Bytes(), nil, ropts...); err != nil {
+			return fmt.Errorf("could not attach %s SBOM: %w", format, err)
+		}
+	}
+
+	return nil
+}
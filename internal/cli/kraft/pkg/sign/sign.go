@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package sign implements the `kraft pkg sign` command
+package sign
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/oci"
+	"kraftkit.sh/oci/trust"
+)
+
+type SignOptions struct {
+	Key string `long:"key" short:"k" usage:"Path to the PEM-encoded private key to sign with"`
+}
+
+// Sign an OCI image in a registry.
+func Sign(ctx context.Context, opts *SignOptions, args ...string) error {
+	if opts == nil {
+		opts = &SignOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+// NewCmd returns a new sign command
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&SignOptions{}, cobra.Command{
+		Short: "Sign a package in a registry",
+		Use:   "sign [FLAGS] PACKAGE",
+		Args:  cmdfactory.MinimumArgs(1, "package name(s) not specified"),
+		Long: heredoc.Doc(`
+			Sign one or more packages in a registry with a local private key.
+
+			The signature is pushed to the registry as an OCI referrer artifact of
+			the package, which can later be checked with 'kraft pkg verify' or
+			via the trust policy.
+		`),
+		Example: heredoc.Doc(`
+			# Sign a package with an ECDSA private key
+			$ kraft pkg sign --key cosign.key unikraft.org/nginx:latest
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "pkg",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *SignOptions) Pre(cmd *cobra.Command, _ []string) error {
+	if opts.Key == "" {
+		return fmt.Errorf("the --key flag must be set")
+	}
+
+	return nil
+}
+
+// Run executes the sign command
+func (opts *SignOptions) Run(ctx context.Context, args []string) error {
+	for _, arg := range args {
+		ref, err := name.ParseReference(arg,
+			name.WithDefaultRegistry(oci.DefaultRegistry),
+			name.WithDefaultTag(oci.DefaultTag),
+		)
+		if err != nil {
+			return fmt.Errorf("could not parse image reference: %w", err)
+		}
+
+		ropts, err := oci.RemoteOptions(ctx, ref)
+		if err != nil {
+			return err
+		}
+
+		dgst, err := trust.Sign(ctx, ref, opts.Key, ropts...)
+		if err != nil {
+			return fmt.Errorf("could not sign %s: %w", arg, err)
+		}
+
+		log.G(ctx).
+			WithField("digest", dgst.String()).
+			Infof("signed %s", ref.Context().Name())
+
+		fmt.Fprintln(iostreams.G(ctx).Out, ref.Context().Digest(dgst.String()).String())
+	}
+
+	return nil
+}
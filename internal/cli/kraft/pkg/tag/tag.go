@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package tag implements the `kraft pkg tag` command
+package tag
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/oci"
+)
+
+type TagOptions struct{}
+
+// Tag creates a new local tag of a package.
+func Tag(ctx context.Context, opts *TagOptions, args ...string) error {
+	if opts == nil {
+		opts = &TagOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+// NewCmd returns a new tag command
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&TagOptions{}, cobra.Command{
+		Short: "Create a tag which refers to a local package",
+		Use:   "tag [FLAGS] SOURCE TARGET",
+		Args:  cmdfactory.ExactArgs(2, "source and target must be specified"),
+		Long: heredoc.Doc(`
+			Create the tag TARGET which refers to the same manifests as the local
+			package SOURCE, without rebuilding or re-packaging it.
+		`),
+		Example: heredoc.Doc(`
+			# Tag a package for a different registry and push it
+			$ kraft pkg tag unikraft.org/nginx:latest registry.example.com/nginx:1.25
+			$ kraft pkg push registry.example.com/nginx:1.25
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "pkg",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+// Run executes the tag command
+func (opts *TagOptions) Run(ctx context.Context, args []string) error {
+	if err := oci.Tag(ctx, args[0], args[1]); err != nil {
+		return fmt.Errorf("could not tag %s: %w", args[0], err)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package tags implements the `kraft pkg tags` command
+package tags
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/oci"
+)
+
+type TagsOptions struct {
+	Architecture string `local:"true" long:"arch" short:"m" usage:"Only list tags with a manifest for the architecture"`
+	Platform     string `local:"true" long:"plat" short:"p" usage:"Only list tags with a manifest for the platform"`
+}
+
+// Tags lists the tags of a remote repository.
+func Tags(ctx context.Context, opts *TagsOptions, args ...string) error {
+	if opts == nil {
+		opts = &TagsOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+// NewCmd returns a new tags command
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&TagsOptions{}, cobra.Command{
+		Short: "List the tags of a remote repository",
+		Use:   "tags [FLAGS] REPOSITORY",
+		Args:  cmdfactory.ExactArgs(1, "repository not specified"),
+		Long: heredoc.Doc(`
+			List the tags of a remote repository.
+
+			When filtering by architecture or platform, the platforms and
+			architectures of the manifests of each matching tag are also shown.
+		`),
+		Example: heredoc.Doc(`
+			# List the tags of a repository
+			$ kraft pkg tags unikraft.org/nginx
+
+			# List the tags which can be run on QEMU for x86_64
+			$ kraft pkg tags --plat qemu --arch x86_64 unikraft.org/nginx
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "pkg",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+// Run executes the tags command
+func (opts *TagsOptions) Run(ctx context.Context, args []string) error {
+	tags, err := oci.ListTags(ctx, args[0], opts.Architecture, opts.Platform)
+	if err != nil {
+		return err
+	}
+
+	out := iostreams.G(ctx).Out
+
+	for _, tag := range tags {
+		if len(tag.Platforms) == 0 {
+			fmt.Fprintln(out, tag.Name)
+			continue
+		}
+
+		platforms := make([]string, 0, len(tag.Platforms))
+		for _, p := range tag.Platforms {
+			platforms = append(platforms, p.OS+"/"+p.Architecture)
+		}
+
+		fmt.Fprintf(out, "%s\t%s\n", tag.Name, strings.Join(platforms, ","))
+	}
+
+	return nil
+}
@@ -6,11 +6,28 @@
 package pkg
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/dustin/go-humanize"
+	"github.com/opencontainers/go-digest"
+
+	"kraftkit.sh/initrd"
+	"kraftkit.sh/internal/cli/kraft/utils"
+	"kraftkit.sh/internal/tableprinter"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/oci"
+	"kraftkit.sh/pack"
+	"kraftkit.sh/packmanager"
+	ukprovenance "kraftkit.sh/provenance"
 	"kraftkit.sh/unikraft/app"
+	"kraftkit.sh/unikraft/target"
 )
 
 // initProject sets up the project based on the provided context and
@@ -65,3 +82,270 @@ func (opts *PkgOptions) aggregateEnvs() []string {
 
 	return env
 }
+
+// compression returns the compression algorithm of the initrd based on the
+// provided options.
+func (opts *PkgOptions) compression() initrd.Compression {
+	if !opts.Compress {
+		return initrd.CompressionNone
+	}
+
+	if opts.Compression == "" {
+		return initrd.CompressionGzip
+	}
+
+	return opts.Compression
+}
+
+// buildRootfs builds the provided rootfs for the target with the rootfs
+// options and, if requested, prints the statistics of the result.
+func (opts *PkgOptions) buildRootfs(ctx context.Context, rootfs string, targ target.Target) (string, []string, []string, error) {
+	files := opts.Add
+	if opts.Project != nil {
+		files = append(opts.Project.RootfsFiles(), files...)
+	}
+
+	source := rootfs
+	ropts := utils.RootfsOptions{
+		Compression:      opts.compression(),
+		CompressionLevel: opts.CompressionLevel,
+		Format:           initrd.Format(opts.RootfsFormat),
+		Reproducible:     !opts.NoReproducible,
+		Includes:         opts.Include,
+		Files:            files,
+		NoCache:          opts.NoRootfsCache,
+	}
+
+	rootfs, cmds, envs, err := utils.BuildRootfs(ctx, opts.Workdir, rootfs, ropts, targ)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	if !opts.NoProvenance && rootfs != "" {
+		opts.recordRootfs(ctx, source, rootfs, ropts, targ)
+	}
+
+	if opts.PrintStats && rootfs != "" {
+		if err := opts.printRootfsStats(ctx, rootfs); err != nil {
+			return "", nil, nil, fmt.Errorf("could not compute rootfs statistics: %w", err)
+		}
+	}
+
+	return rootfs, cmds, envs, nil
+}
+
+// printRootfsStats compares the size of the built rootfs when compressed with
+// each supported compression algorithm.
+func (opts *PkgOptions) printRootfsStats(ctx context.Context, rootfs string) error {
+	if format, err := initrd.DetectFormat(rootfs); err != nil {
+		return err
+	} else if format.BlockDevice() {
+		return fmt.Errorf("statistics are only available for %s archives", initrd.FormatCpio)
+	}
+
+	cs := iostreams.G(ctx).ColorScheme()
+	table, err := tableprinter.NewTablePrinter(ctx,
+		tableprinter.WithMaxWidth(iostreams.G(ctx).TerminalWidth()),
+	)
+	if err != nil {
+		return err
+	}
+
+	table.AddField("COMPRESSION", cs.Bold)
+	table.AddField("SIZE", cs.Bold)
+	table.AddField("RATIO", cs.Bold)
+	table.EndRow()
+
+	var uncompressed int64
+
+	for _, compression := range initrd.Compressions() {
+		reader, err := initrd.Decompress(rootfs)
+		if err != nil {
+			return err
+		}
+
+		size, err := initrd.CompressedSize(reader, compression, opts.CompressionLevel)
+		reader.Close()
+		if err != nil {
+			return fmt.Errorf("could not compress with %s: %w", compression, err)
+		}
+
+		if !compression.Enabled() {
+			uncompressed = size
+		}
+
+		name := compression.String()
+		if compression == opts.compression() {
+			name += " (selected)"
+		}
+
+		ratio := "-"
+		if uncompressed > 0 {
+			ratio = fmt.Sprintf("%.2f%%", float64(size)*100/float64(uncompressed))
+		}
+
+		table.AddField(name, nil)
+		table.AddField(humanize.Bytes(uint64(size)), nil)
+		table.AddField(ratio, nil)
+		table.EndRow()
+	}
+
+	return table.Render(iostreams.G(ctx).Out)
+}
+
+// parseDisks parses the file system images which are included in the package.
+// Each is specified as SRC[:DEST] and is mounted at the absolute path DEST or
+// otherwise at the name of SRC without its extension in the root.
+func (opts *PkgOptions) parseDisks() ([]packmanager.PackDisk, error) {
+	disks := make([]packmanager.PackDisk, 0, len(opts.Disks))
+	destinations := map[string]string{}
+
+	for _, value := range opts.Disks {
+		src, dest, _ := strings.Cut(value, ":")
+		if src == "" {
+			return nil, fmt.Errorf("invalid syntax for --disk=%s expected --disk=SRC[:DEST]", value)
+		}
+
+		if fi, err := os.Stat(src); err != nil {
+			return nil, fmt.Errorf("could not access disk: %w", err)
+		} else if !fi.Mode().IsRegular() {
+			return nil, fmt.Errorf("disk must be a file system image: %s", src)
+		}
+
+		if dest == "" {
+			name := filepath.Base(src)
+			dest = "/" + strings.TrimSuffix(name, filepath.Ext(name))
+		} else if !filepath.IsAbs(dest) {
+			return nil, fmt.Errorf("disk destination must be an absolute path: %s", dest)
+		}
+
+		if other, ok := destinations[dest]; ok {
+			return nil, fmt.Errorf("disks %s and %s are both mounted at %s", other, src, dest)
+		}
+
+		destinations[dest] = src
+
+		mediaType, err := utils.DiskMediaType(src)
+		if err != nil {
+			return nil, fmt.Errorf("could not determine format of disk %s: %w", src, err)
+		}
+
+		disks = append(disks, packmanager.PackDisk{
+			Source:      src,
+			Destination: dest,
+			MediaType:   mediaType,
+		})
+	}
+
+	return disks, nil
+}
+
+// packInitrd returns the options which include the initrd at the provided
+// path, if any, in the package along with the media type of its format.
+func packInitrd(path string) ([]packmanager.PackOption, error) {
+	popts := []packmanager.PackOption{
+		packmanager.PackInitrd(path),
+	}
+
+	if len(path) == 0 {
+		return popts, nil
+	}
+
+	mediaType, err := utils.InitrdMediaType(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not determine initrd format: %w", err)
+	}
+
+	return append(popts, packmanager.PackInitrdMediaType(mediaType)), nil
+}
+
+// recordRootfs records the inputs of the rootfs which was built for the
+// target such that they are included in the provenance of the package.
+func (opts *PkgOptions) recordRootfs(ctx context.Context, source, rootfs string, ropts utils.RootfsOptions, targ target.Target) {
+	record := ukprovenance.Rootfs{
+		Source:      source,
+		Format:      ropts.Format.String(),
+		Compression: ropts.Compression.String(),
+		Includes:    ropts.Includes,
+	}
+
+	if built, err := ukprovenance.FileDescriptor("", rootfs); err == nil {
+		record.Digest = built.Digest
+	} else {
+		log.G(ctx).
+			WithError(err).
+			Debug("could not digest rootfs")
+	}
+
+	files, err := utils.ParseRootfsFiles(ropts.Files)
+	if err != nil {
+		return
+	}
+
+	for _, file := range files {
+		// Directories are recorded without a digest.
+		desc, err := ukprovenance.FileDescriptor(file.Destination, file.Source)
+		if err != nil {
+			desc = ukprovenance.ResourceDescriptor{
+				Name: file.Destination,
+				URI:  file.Source,
+			}
+		}
+
+		record.Files = append(record.Files, desc)
+	}
+
+	if opts.rootfses == nil {
+		opts.rootfses = map[string]*ukprovenance.Rootfs{}
+	}
+
+	opts.rootfses[targ.Architecture().Name()] = &record
+}
+
+// attestProvenance generates the provenance statement of the package and
+// saves it as a referrer of the package in the local store.  The serialized
+// statement is returned such that it can be pushed along with the package.
+func (opts *PkgOptions) attestProvenance(ctx context.Context, p pack.Package, finishedOn time.Time) ([]byte, error) {
+	dgst, err := oci.PackageDigest(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	targ, ok := p.(target.Target)
+	if !ok {
+		return nil, fmt.Errorf("package does not convert to target")
+	}
+
+	popts := []ukprovenance.ProvenanceOption{
+		ukprovenance.WithBuildTime(opts.startedOn, finishedOn),
+		ukprovenance.WithRootfs(opts.rootfses[targ.Architecture().Name()]),
+	}
+
+	if opts.Project != nil {
+		popts = append(popts, ukprovenance.WithProject(opts.Project))
+
+		// Prefer the target of the project, whose configuration file holds the
+		// full KConfig of the build.
+		if targets := target.Filter(opts.Project.Targets(), targ.Architecture().Name(), targ.Platform().Name(), ""); len(targets) == 1 {
+			targ = targets[0]
+		}
+	}
+
+	popts = append(popts, ukprovenance.WithTarget(targ))
+
+	statement, err := ukprovenance.New(ctx, p.Name(), digest.Digest(dgst.String()), popts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := statement.Write(&buf); err != nil {
+		return nil, err
+	}
+
+	if _, err := oci.SaveReferrer(ctx, p, ukprovenance.MediaType, buf.Bytes(), nil); err != nil {
+		return nil, fmt.Errorf("could not save provenance: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package verify implements the `kraft pkg verify` command
+package verify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/oci"
+	"kraftkit.sh/oci/trust"
+)
+
+type VerifyOptions struct {
+	Key string `long:"key" short:"k" usage:"Path to the PEM-encoded public key to verify with (default: from the trust policy)"`
+}
+
+// Verify the signatures of an OCI image in a registry.
+func Verify(ctx context.Context, opts *VerifyOptions, args ...string) error {
+	if opts == nil {
+		opts = &VerifyOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+// NewCmd returns a new verify command
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&VerifyOptions{}, cobra.Command{
+		Short: "Verify the signature of a package in a registry",
+		Use:   "verify [FLAGS] PACKAGE",
+		Args:  cmdfactory.MinimumArgs(1, "package name(s) not specified"),
+		Long: heredoc.Doc(`
+			Verify that one or more packages in a registry carry a signature which
+			was made by the private key of a local public key.
+
+			Without the --key flag, packages are checked against the trust policy.
+		`),
+		Example: heredoc.Doc(`
+			# Verify a package with an ECDSA public key
+			$ kraft pkg verify --key cosign.pub unikraft.org/nginx:latest
+
+			# Check a package against the trust policy
+			$ kraft pkg verify unikraft.org/nginx:latest
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "pkg",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+// Run executes the verify command
+func (opts *VerifyOptions) Run(ctx context.Context, args []string) error {
+	for _, arg := range args {
+		ref, err := name.ParseReference(arg,
+			name.WithDefaultRegistry(oci.DefaultRegistry),
+			name.WithDefaultTag(oci.DefaultTag),
+		)
+		if err != nil {
+			return fmt.Errorf("could not parse image reference: %w", err)
+		}
+
+		ropts, err := oci.RemoteOptions(ctx, ref)
+		if err != nil {
+			return err
+		}
+
+		if opts.Key == "" {
+			if _, err := trust.Enforce(ctx, ref, ropts...); err != nil {
+				return err
+			}
+
+			log.G(ctx).Infof("%s is accepted by the trust policy", ref.String())
+			continue
+		}
+
+		dgst, err := trust.Verify(ctx, ref, opts.Key, ropts...)
+		if err != nil {
+			return fmt.Errorf("could not verify %s: %w", arg, err)
+		}
+
+		log.G(ctx).
+			WithField("digest", dgst.String()).
+			Infof("verified %s", ref.Context().Name())
+
+		fmt.Fprintln(iostreams.G(ctx).Out, ref.Context().Digest(dgst.String()).String())
+	}
+
+	return nil
+}
@@ -186,48 +186,26 @@ func (opts *RemoveOptions) Run(ctx context.Context, args []string) error {
 			}
 		}
 
-		// Update volume information.
+		// Stop the machine before deleting it.
+		if machine.Status.State == machineapi.MachineStateRunning {
+			if _, err := controller.Stop(ctx, &machine); err != nil {
+				log.G(ctx).Errorf("could not stop machine %s: %v", machine.Name, err)
+			}
+		}
+
+		// Release the volumes once the machine no longer uses them.
 		if len(machine.Spec.Volumes) > 0 {
 			volumeController, err := volume.NewVolumeV1alpha1ServiceIterator(ctx)
 			if err != nil {
 				return fmt.Errorf("could not get volume controller: %v", err)
 			}
-			for _, vol := range machine.Spec.Volumes {
-				stillUsed := false
-				allMachines, err := controller.List(ctx, &machineapi.MachineList{})
-				if err != nil {
-					return err
-				}
-				for _, m := range allMachines.Items {
-					if m.ObjectMeta.UID == machine.ObjectMeta.UID {
-						continue
-					}
-					for _, v := range m.Spec.Volumes {
-						if v.ObjectMeta.UID == vol.ObjectMeta.UID {
-							stillUsed = true
-							break
-						}
-					}
 
-					if stillUsed {
-						break
-					}
-				}
-
-				if !stillUsed {
-					vol.Status.State = volumeapi.VolumeStatePending
-					if _, err := volumeController.Update(ctx, &vol); err != nil {
-						log.G(ctx).Warnf("could not update volume %s: %v", vol.Name, err)
-					}
-				}
+			allMachines, err := controller.List(ctx, &machineapi.MachineList{})
+			if err != nil {
+				return err
 			}
-		}
 
-		// Stop the machine before deleting it.
-		if machine.Status.State == machineapi.MachineStateRunning {
-			if _, err := controller.Stop(ctx, &machine); err != nil {
-				log.G(ctx).Errorf("could not stop machine %s: %v", machine.Name, err)
-			}
+			releaseVolumes(ctx, volumeController, machine, allMachines.Items)
 		}
 
 		// Now delete the machine.
@@ -240,3 +218,52 @@ func (opts *RemoveOptions) Run(ctx context.Context, args []string) error {
 
 	return nil
 }
+
+// releaseVolumes detaches the volumes of the removed machine, which are left
+// attached to the remaining machines only.  Volumes which are owned by the
+// removed machine, e.g. the disks of its package whose source lives in the
+// state directory of the machine, are deleted.
+func releaseVolumes(ctx context.Context, volumeController volumeapi.VolumeService, machine machineapi.Machine, machines []machineapi.Machine) {
+	for _, vol := range machine.Spec.Volumes {
+		// Skip pseudo-volumes, e.g. the initramfs, which are not managed by a
+		// volume driver.
+		if _, ok := volume.Strategies()[vol.Spec.Driver]; !ok {
+			continue
+		}
+
+		// Determine the remaining machines the volume is attached to.
+		var attachedTo []string
+		for _, m := range machines {
+			if m.ObjectMeta.UID == machine.ObjectMeta.UID {
+				continue
+			}
+			for _, v := range m.Spec.Volumes {
+				if v.ObjectMeta.UID == vol.ObjectMeta.UID {
+					attachedTo = append(attachedTo, m.Name)
+					break
+				}
+			}
+		}
+
+		if current, err := volumeController.Get(ctx, &vol); err == nil && current != nil {
+			vol = *current
+		}
+
+		vol.Status.AttachedTo = attachedTo
+		if len(attachedTo) == 0 {
+			vol.Status.State = volumeapi.VolumeStatePending
+		}
+
+		if len(attachedTo) == 0 && volume.IsOwnedBy(&vol, machine.Name) {
+			if _, err := volumeController.Delete(ctx, &vol); err != nil {
+				log.G(ctx).Warnf("could not delete volume %s: %v", vol.Name, err)
+			}
+
+			continue
+		}
+
+		if _, err := volumeController.Update(ctx, &vol); err != nil {
+			log.G(ctx).Warnf("could not update volume %s: %v", vol.Name, err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package remove
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	machineapi "kraftkit.sh/api/machine/v1alpha1"
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/machine/volume"
+	"kraftkit.sh/machine/volume/volumetest"
+)
+
+// newVolume returns a bound 9pfs volume attached to the provided machines.
+func newVolume(name string, attachedTo ...string) *volumeapi.Volume {
+	return &volumeapi.Volume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			UID:  types.UID(name),
+		},
+		Spec: volumeapi.VolumeSpec{
+			Driver: "9pfs",
+			Source: "/tmp/" + name,
+		},
+		Status: volumeapi.VolumeStatus{
+			State:      volumeapi.VolumeStateBound,
+			AttachedTo: attachedTo,
+		},
+	}
+}
+
+func TestReleaseVolumes(t *testing.T) {
+	ctx := context.Background()
+
+	owned := newVolume("app-disk0", "app")
+	volume.SetOwner(owned, "app")
+
+	foreign := newVolume("other-disk0", "app")
+	volume.SetOwner(foreign, "other")
+
+	shared := newVolume("shared", "app", "other")
+	sharedOwned := newVolume("app-disk1", "app", "other")
+	volume.SetOwner(sharedOwned, "app")
+
+	unowned := newVolume("data", "app")
+	unowned.Spec.Managed = true
+
+	service := volumetest.NewMemoryService()
+	for _, vol := range []*volumeapi.Volume{owned, foreign, shared, sharedOwned, unowned} {
+		_, _ = service.Create(ctx, vol)
+	}
+
+	app := machineapi.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", UID: "app"},
+		Spec: machineapi.MachineSpec{
+			Volumes: []volumeapi.Volume{*owned, *foreign, *shared, *sharedOwned, *unowned, {
+				ObjectMeta: metav1.ObjectMeta{Name: "fs0"},
+				Spec:       volumeapi.VolumeSpec{Driver: "initrd"},
+			}},
+		},
+	}
+
+	other := machineapi.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", UID: "other"},
+		Spec: machineapi.MachineSpec{
+			Volumes: []volumeapi.Volume{*shared, *sharedOwned},
+		},
+	}
+
+	releaseVolumes(ctx, service, app, []machineapi.Machine{app, other})
+
+	if _, ok := service.Volumes[owned.Name]; ok {
+		t.Errorf("expected volume owned by the machine to be deleted")
+	}
+
+	tests := []struct {
+		name       string
+		state      volumeapi.VolumeState
+		attachedTo []string
+	}{
+		{foreign.Name, volumeapi.VolumeStatePending, nil},
+		{shared.Name, volumeapi.VolumeStateBound, []string{"other"}},
+		{sharedOwned.Name, volumeapi.VolumeStateBound, []string{"other"}},
+		{unowned.Name, volumeapi.VolumeStatePending, nil},
+	}
+
+	for _, tc := range tests {
+		vol, ok := service.Volumes[tc.name]
+		if !ok {
+			t.Errorf("%s: expected volume to be kept", tc.name)
+			continue
+		}
+
+		if vol.Status.State != tc.state {
+			t.Errorf("%s: expected state %s, got %s", tc.name, tc.state, vol.Status.State)
+		}
+
+		if !slices.Equal(vol.Status.AttachedTo, tc.attachedTo) {
+			t.Errorf("%s: expected to be attached to %v, got %v", tc.name, tc.attachedTo, vol.Status.AttachedTo)
+		}
+	}
+}
@@ -22,18 +22,22 @@ import (
 	"kraftkit.sh/internal/cli/kraft/start"
 	"kraftkit.sh/internal/set"
 	"kraftkit.sh/iostreams"
+	"kraftkit.sh/kconfig"
 	"kraftkit.sh/log"
 	mplatform "kraftkit.sh/machine/platform"
+	"kraftkit.sh/pack"
 	"kraftkit.sh/packmanager"
 	"kraftkit.sh/tui/selection"
 	ukarch "kraftkit.sh/unikraft/arch"
 )
 
 type RunOptions struct {
+	Add           []string `long:"add" usage:"Add a file to the root file system (SRC:DST[:mode[:uid:gid]])"`
 	Architecture  string   `long:"arch" short:"m" usage:"Set the architecture"`
 	Detach        bool     `long:"detach" short:"d" usage:"Run unikernel in background"`
 	DisableAccel  bool     `long:"disable-acceleration" short:"W" usage:"Disable acceleration of CPU (usually enables TCG)"`
 	Env           []string `long:"env" short:"e" usage:"Set environment variables, int the format key[=value]"`
+	Include       []string `long:"include" usage:"Include additional host files in a rootfs built from ELF executables (<host>[:<machine>])"`
 	InitRd        string   `long:"initrd" usage:"Use the specified initrd (readonly)" hidden:"true"`
 	IP            string   `long:"ip" usage:"Assign the provided IP address"`
 	KernelArgs    []string `long:"kernel-arg" short:"a" usage:"Set additional kernel arguments"`
@@ -49,13 +53,16 @@ type RunOptions struct {
 	PrefixName    bool     `long:"prefix-name" usage:"Prefix each log line with the machine name"`
 	Remove        bool     `long:"rm" usage:"Automatically remove the unikernel when it shutsdown"`
 	Rootfs        string   `long:"rootfs" usage:"Specify a path to use as root file system (can be volume or initramfs)"`
+	RootfsFormat  string   `long:"rootfs-format" usage:"Set the format of the root file system (cpio, erofs, squashfs)" default:"cpio"`
 	RunAs         string   `long:"as" usage:"Force a specific runner"`
 	Runtime       string   `long:"runtime" short:"r" usage:"Set an alternative unikernel runtime"`
 	Target        string   `long:"target" short:"t" usage:"Explicitly use the defined project target"`
-	Volumes       []string `long:"volume" short:"v" usage:"Bind a volume to the instance"`
+	Volumes       []string `long:"volume" short:"v" usage:"Bind a volume to the instance (<host>:<machine>[:<driver>][:<options>])"`
 	WithKernelDbg bool     `long:"symbolic" usage:"Use the debuggable (symbolic) unikernel"`
 
 	workdir           string
+	disks             []pack.Disk
+	kconfig           kconfig.KeyValueMap
 	platform          mplatform.Platform
 	machineController machineapi.MachineService
 }
@@ -108,12 +115,24 @@ func NewCmd() *cobra.Command {
 			Supply a path which is dynamically serialized into an initramfs CPIO archive:
 			$ kraft run --rootfs ./path/to/rootfs
 
+			Supply a minimal rootfs generated from a dynamically linked host executable and its shared libraries:
+			$ kraft run --rootfs elf:/usr/bin/redis-server --include /etc/redis/redis.conf
+
+			Add a configuration file to the root file system of a prebuilt package without modifying it:
+			$ kraft run --add ./nginx.conf:/nginx/conf/nginx.conf:0644 unikraft.org/nginx:latest
+
 			Mount a bi-directional path from on the host to the unikernel mapped to /dir:
 			$ kraft run -v ./path/to/dir:/dir
 
 			Supply a read-only root file system at / via initramfs CPIO archive and mount a bi-directional volume at /dir:
 			$ kraft run --rootfs ./initramfs.cpio --volume ./path/to/dir:/dir
 
+			Share a path from the host with the unikernel mapped to /dir via virtio-fs:
+			$ kraft run -v ./path/to/dir:/dir:virtiofs
+
+			Attach a path from the host as an ext4 block device mapped to /dir (requires e2fsprogs on the host):
+			$ kraft run --plat fc -v ./path/to/dir:/dir:ext4
+
 			Customize the default content directory of the official Unikraft NGINX OCI-compatible unikernel and map port 8080 to localhost:
 			$ kraft run -v ./path/to/html:/nginx/html -p 8080:80 unikraft.org/nginx:latest
 		`),
@@ -362,6 +381,10 @@ func (opts *RunOptions) Run(ctx context.Context, args []string) error {
 		return err
 	}
 
+	if err := opts.attachDisks(ctx, machine); err != nil {
+		return err
+	}
+
 	if err := opts.prepareRootfs(ctx, machine); err != nil {
 		return err
 	}
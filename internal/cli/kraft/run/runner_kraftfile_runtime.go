@@ -8,6 +8,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -15,6 +16,7 @@ import (
 	machineapi "kraftkit.sh/api/machine/v1alpha1"
 	volumeapi "kraftkit.sh/api/volume/v1alpha1"
 	"kraftkit.sh/config"
+	"kraftkit.sh/initrd"
 	"kraftkit.sh/log"
 	"kraftkit.sh/machine/platform"
 	"kraftkit.sh/pack"
@@ -22,6 +24,7 @@ import (
 	"kraftkit.sh/tui/paraprogress"
 	"kraftkit.sh/tui/processtree"
 	"kraftkit.sh/tui/selection"
+	"kraftkit.sh/unikraft"
 	"kraftkit.sh/unikraft/app"
 	ukarch "kraftkit.sh/unikraft/arch"
 	"kraftkit.sh/unikraft/target"
@@ -266,6 +269,8 @@ func (runner *runnerKraftfileRuntime) Prepare(ctx context.Context, opts *RunOpti
 		opts.Rootfs = runner.project.Rootfs()
 	}
 
+	opts.Add = append(runner.project.RootfsFiles(), opts.Add...)
+
 	// Create a temporary directory where the image can be stored
 	tempDir, err := os.MkdirTemp("", "kraft-run-")
 	if err != nil {
@@ -334,12 +339,21 @@ func (runner *runnerKraftfileRuntime) Prepare(ctx context.Context, opts *RunOpti
 		if runner.project.Rootfs() != "" {
 			opts.Rootfs = runner.project.Rootfs()
 		} else if runtime.Initrd() != nil {
-			machine.Status.InitrdPath, err = runtime.Initrd().Build(ctx)
+			ramfs, err := opts.overlayRootfs(runtime.Initrd(), filepath.Join(
+				opts.workdir,
+				unikraft.BuildDir,
+				fmt.Sprintf(initrd.DefaultInitramfsArchFileName, machine.Spec.Architecture),
+			))
+			if err != nil {
+				return err
+			}
+
+			machine.Status.InitrdPath, err = ramfs.Build(ctx)
 			if err != nil {
 				return err
 			}
 
-			for _, entry := range runtime.Initrd().Env() {
+			for _, entry := range ramfs.Env() {
 				k, v, ok := strings.Cut(entry, "=")
 				if !ok {
 					continue
@@ -348,7 +362,7 @@ func (runner *runnerKraftfileRuntime) Prepare(ctx context.Context, opts *RunOpti
 				machine.Spec.Env[k] = v
 			}
 
-			machine.Spec.ApplicationArgs = runtime.Initrd().Args()
+			machine.Spec.ApplicationArgs = ramfs.Args()
 		}
 	}
 
@@ -358,6 +372,8 @@ func (runner *runnerKraftfileRuntime) Prepare(ctx context.Context, opts *RunOpti
 		machine.Spec.ApplicationArgs = runtime.Command()
 	}
 
+	opts.kconfig = runtime.KConfig()
+
 	// If automounting is enabled, and an initramfs is provided, set it as a
 	// volume if a initram has been provided.
 	if runtime.KConfig().AnyYes(
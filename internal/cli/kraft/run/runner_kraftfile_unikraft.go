@@ -146,6 +146,8 @@ func (runner *runnerKraftfileUnikraft) Prepare(ctx context.Context, opts *RunOpt
 		runner.args = runner.project.Command()
 	}
 
+	opts.kconfig = t.KConfig()
+
 	noEmbedded := t.KConfig().AllNoOrUnset(
 		"CONFIG_LIBVFSCORE_AUTOMOUNT_EINITRD",
 		"CONFIG_LIBVFSCORE_AUTOMOUNT_CI_EINITRD",
@@ -155,6 +157,8 @@ func (runner *runnerKraftfileUnikraft) Prepare(ctx context.Context, opts *RunOpt
 		opts.Rootfs = runner.project.Rootfs()
 	}
 
+	opts.Add = append(runner.project.RootfsFiles(), opts.Add...)
+
 	// If automounting is enabled, and an initramfs is provided, set it as a
 	// volume if a initram has been provided.
 	if t.KConfig().AnyYes(
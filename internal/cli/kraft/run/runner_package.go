@@ -263,6 +263,12 @@ func (runner *runnerPackage) Prepare(ctx context.Context, opts *RunOptions, mach
 		if err := paramodel.Start(); err != nil {
 			return err
 		}
+	} else if verifier, ok := selected.(pack.Verifier); ok {
+		// Packages which were pulled before the trust policy was put in place
+		// are checked as well.
+		if err := verifier.Verify(ctx); err != nil {
+			return err
+		}
 	}
 
 	if err := selected.Unpack(
@@ -302,11 +308,22 @@ func (runner *runnerPackage) Prepare(ctx context.Context, opts *RunOptions, mach
 	if opts.Rootfs == "" && targ.Initrd() != nil {
 		ramfs = targ.Initrd()
 	} else if len(opts.Rootfs) > 0 {
-		ramfs, err = initrd.New(ctx, opts.Rootfs)
+		ramfs, err = initrd.New(ctx, opts.Rootfs,
+			initrd.WithFormat(initrd.Format(opts.RootfsFormat)),
+			initrd.WithIncludes(opts.Include...),
+		)
 		if err != nil {
 			return err
 		}
 	}
+
+	// Files are added to a copy of the rootfs in the machine's state directory
+	// such that the package's rootfs remains untouched.
+	ramfs, err = opts.overlayRootfs(ramfs, filepath.Join(machine.Status.StateDir, initrd.DefaultInitramfsFileName))
+	if err != nil {
+		return err
+	}
+
 	if ramfs != nil {
 		machine.Status.InitrdPath, err = ramfs.Build(ctx)
 		if err != nil {
@@ -321,6 +338,14 @@ func (runner *runnerPackage) Prepare(ctx context.Context, opts *RunOptions, mach
 		machine.Status.KernelPath = targ.Kernel()
 	}
 
+	opts.kconfig = targ.KConfig()
+
+	// File system images shipped with the package are attached once the
+	// machine has been named.
+	if provider, ok := selected.(pack.DiskProvider); ok {
+		opts.disks = provider.Disks()
+	}
+
 	// If automounting is enabled, and an initramfs is provided, set it as a
 	// volume if a initram has been provided.
 	if targ.KConfig().AnyYes(
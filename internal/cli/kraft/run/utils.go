@@ -2,10 +2,13 @@ package run
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/containerd/nerdctl/pkg/strutil"
@@ -22,9 +25,11 @@ import (
 	machinename "kraftkit.sh/machine/name"
 	"kraftkit.sh/machine/network"
 	"kraftkit.sh/machine/volume"
+	"kraftkit.sh/machine/volume/disk"
 	"kraftkit.sh/tui/processtree"
 	"kraftkit.sh/unikraft"
 	"kraftkit.sh/unikraft/app"
+	appvolume "kraftkit.sh/unikraft/app/volume"
 )
 
 // Are we publishing ports? E.g. -p/--ports=127.0.0.1:80:8080/tcp ...
@@ -182,47 +187,115 @@ func (opts *RunOptions) assignName(ctx context.Context, machine *machineapi.Mach
 	return nil
 }
 
+// volumeDriver returns the name of the volume driver to use for the provided
+// source, instantiating its service in the provided map of controllers.  When
+// no driver is specified, the compatible driver which comes first in the order
+// of preference of volume.PlatformDriverNames is selected, such that only
+// drivers whose volumes the platform is able to attach are considered.  The
+// compatibility of the source is checked against the KConfig of the unikernel,
+// if known, such that misconfigurations are reported before the machine is
+// started.
+func (opts *RunOptions) volumeDriver(ctx context.Context, controllers map[string]volumeapi.VolumeService, driver, source string) (string, error) {
+	var err error
+
+	strategies := volume.Strategies()
+
+	if len(driver) > 0 {
+		strategy, exists := strategies[driver]
+		if !exists {
+			return "", fmt.Errorf("unknown volume driver %s specified: expected one of %s", driver, strings.Join(volume.DriverNames(), ", "))
+		}
+
+		if !strategy.SupportsPlatform(opts.platform) {
+			return "", fmt.Errorf("volume driver %s is not supported on %s: expected one of %s", driver, opts.platform, strings.Join(volume.PlatformDriverNames(opts.platform), ", "))
+		}
+
+		if ok, err := strategy.IsCompatible(ctx, source, opts.kconfig); err != nil {
+			return "", fmt.Errorf("volume driver %s is incompatible with source %s: %w", driver, source, err)
+		} else if !ok {
+			return "", fmt.Errorf("volume driver %s is incompatible with source %s", driver, source)
+		}
+	} else {
+		var errs []error
+
+		for _, sname := range volume.PlatformDriverNames(opts.platform) {
+			ok, err := strategies[sname].IsCompatible(ctx, source, opts.kconfig)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			} else if ok {
+				driver = sname
+				break
+			}
+		}
+
+		if len(driver) == 0 {
+			if len(errs) > 0 {
+				return "", fmt.Errorf("could not find compatible volume driver for %s: %w", source, errors.Join(errs...))
+			}
+
+			return "", fmt.Errorf("could not find compatible volume driver for %s", source)
+		}
+	}
+
+	if _, ok := controllers[driver]; !ok {
+		log.G(ctx).WithField("volume strategy", driver).Debug("found volume strategy")
+		controllers[driver], err = strategies[driver].NewVolumeV1alpha1(ctx)
+		if err != nil {
+			return "", fmt.Errorf("could not prepare %s volume service: %w", driver, err)
+		}
+	}
+
+	return driver, nil
+}
+
+// validateVolumeOptions checks that the mount options of the volume can be
+// honoured by its driver.
+func validateVolumeOptions(driver, mode string) error {
+	if len(mode) == 0 {
+		return nil
+	}
+
+	if _, err := strconv.ParseUint(mode, 8, 32); err != nil {
+		return fmt.Errorf("invalid volume mode %s: expected an octal permission such as 0755", mode)
+	}
+
+	if driver != "9pfs" {
+		return fmt.Errorf("the %s volume driver does not support setting the mode: use the 9pfs driver or change the permissions of the source", driver)
+	}
+
+	return nil
+}
+
 // Was a volume specified? E.g. --volume=path:path
 func (opts *RunOptions) parseVolumes(ctx context.Context, machine *machineapi.Machine) error {
 	if len(opts.Volumes) == 0 {
 		return nil
 	}
 
-	var err error
 	controllers := map[string]volumeapi.VolumeService{}
 
 	if machine.Spec.Volumes == nil {
 		machine.Spec.Volumes = make([]volumeapi.Volume, 0)
 	}
 	for _, volLine := range opts.Volumes {
-		var volName, mountPath string
-		split := strings.Split(volLine, ":")
-		if len(split) == 2 {
-			volName = split[0]
-			mountPath = split[1]
-		} else {
-			return fmt.Errorf("invalid syntax for --volume=%s expected --volume=<host>:<machine>", volLine)
+		volcfg, err := appvolume.Parse(volLine)
+		if err != nil {
+			return fmt.Errorf("invalid syntax for --volume=%s: %w", volLine, err)
 		}
 
-		var driver string
+		volName := volcfg.Source()
+		mountPath := volcfg.Destination()
+		mode := volcfg.Mode()
+		readOnly := volcfg.ReadOnly()
 
-		for sname, strategy := range volume.Strategies() {
-			if ok, _ := strategy.IsCompatible(volName, nil); !ok || err != nil {
-				continue
-			}
-
-			if _, ok := controllers[sname]; !ok {
-				controllers[sname], err = strategy.NewVolumeV1alpha1(ctx)
-				if err != nil {
-					return fmt.Errorf("could not prepare %s volume service: %w", sname, err)
-				}
-			}
-
-			driver = sname
+		driver, err := opts.volumeDriver(ctx, controllers, volcfg.Driver(), volName)
+		if err != nil {
+			return err
 		}
 
-		if len(driver) == 0 {
-			return fmt.Errorf("could not find compatible volume driver for %s", volName)
+		if err := validateVolumeOptions(driver, mode); err != nil {
+			return err
 		}
 
 		// Check if this could be a named volume
@@ -236,6 +309,10 @@ func (opts *RunOptions) parseVolumes(ctx context.Context, machine *machineapi.Ma
 		}
 		if vol != nil {
 			vol.Spec.Destination = mountPath
+			vol.Spec.ReadOnly = vol.Spec.ReadOnly || readOnly
+			if len(mode) > 0 {
+				vol.Spec.Mode = mode
+			}
 			machine.Spec.Volumes = append(machine.Spec.Volumes, *vol)
 			continue
 		}
@@ -248,7 +325,8 @@ func (opts *RunOptions) parseVolumes(ctx context.Context, machine *machineapi.Ma
 				Driver:      driver,
 				Source:      volName,
 				Destination: mountPath,
-				ReadOnly:    false, // TODO(nderjung): Options are not yet supported.
+				Mode:        mode,
+				ReadOnly:    readOnly,
 			},
 		})
 		if err != nil {
@@ -261,56 +339,104 @@ func (opts *RunOptions) parseVolumes(ctx context.Context, machine *machineapi.Ma
 	return nil
 }
 
+// attachDisks attaches the file system images which are shipped with the
+// package at their destinations, unless a volume is already mounted there.
+// Images are attached as block devices if the unikernel supports them and are
+// otherwise extracted and shared via 9pfs.  The volumes are owned by the
+// machine and are deleted when it is removed.
+func (opts *RunOptions) attachDisks(ctx context.Context, machine *machineapi.Machine) error {
+	if len(opts.disks) == 0 {
+		return nil
+	}
+
+	controllers := map[string]volumeapi.VolumeService{}
+
+	for i, d := range opts.disks {
+		if slices.ContainsFunc(machine.Spec.Volumes, func(vol volumeapi.Volume) bool {
+			return vol.Spec.Destination == d.Destination
+		}) {
+			log.G(ctx).
+				WithField("dest", d.Destination).
+				Info("disk of the package is overridden by volume")
+			continue
+		}
+
+		source := d.Path
+
+		driver, err := opts.volumeDriver(ctx, controllers, d.Filesystem, source)
+		if err != nil {
+			dir := filepath.Join(machine.Status.StateDir, "disk", strconv.Itoa(i))
+
+			var ninepfsErr error
+			driver, ninepfsErr = opts.volumeDriver(ctx, controllers, "9pfs", dir)
+			if ninepfsErr != nil {
+				return fmt.Errorf("could not attach disk at %s: %w", d.Destination, errors.Join(err, ninepfsErr))
+			}
+
+			log.G(ctx).
+				WithField("dest", d.Destination).
+				Warnf("cannot attach disk as a block device, sharing its extracted contents via 9pfs instead: %v", err)
+
+			if err := disk.ExtractImage(ctx, disk.Filesystem(d.Filesystem), d.Path, dir); err != nil {
+				return err
+			}
+
+			source = dir
+		}
+
+		vol, err := controllers[driver].Create(ctx, &volumeapi.Volume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: fmt.Sprintf("%s-disk%d", machine.ObjectMeta.Name, i),
+			},
+			Spec: volumeapi.VolumeSpec{
+				Driver:      driver,
+				Source:      source,
+				Destination: d.Destination,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create volume: %w", err)
+		}
+
+		// The source of the volume lives in the state directory of the machine,
+		// so the volume is removed along with the machine.
+		volume.SetOwner(vol, machine.ObjectMeta.Name)
+
+		vol, err = controllers[driver].Update(ctx, vol)
+		if err != nil {
+			return fmt.Errorf("failed to update volume: %w", err)
+		}
+
+		log.G(ctx).
+			WithField("driver", driver).
+			WithField("dest", d.Destination).
+			Debug("attaching disk")
+
+		machine.Spec.Volumes = append(machine.Spec.Volumes, *vol)
+	}
+
+	return nil
+}
+
 // Were any volumes supplied in the Kraftfile
 func (opts *RunOptions) parseKraftfileVolumes(ctx context.Context, project app.Application, machine *machineapi.Machine) error {
 	if project.Volumes() == nil {
 		return nil
 	}
 
-	var err error
 	controllers := map[string]volumeapi.VolumeService{}
 	if machine.Spec.Volumes == nil {
 		machine.Spec.Volumes = make([]volumeapi.Volume, 0)
 	}
 
 	for _, volcfg := range project.Volumes() {
-		driver := volcfg.Driver()
-
-		if len(driver) == 0 {
-			for sname, strategy := range volume.Strategies() {
-				if ok, _ := strategy.IsCompatible(volcfg.Source(), nil); !ok || err != nil {
-					continue
-				}
-
-				if _, ok := controllers[sname]; !ok {
-					log.G(ctx).WithField("volume strategy", sname).Debug("found volume strategy")
-					controllers[sname], err = strategy.NewVolumeV1alpha1(ctx)
-					if err != nil {
-						return fmt.Errorf("could not prepare %s volume service: %w", sname, err)
-					}
-				}
-
-				driver = sname
-			}
-		} else {
-			strategy, exists := volume.Strategies()[driver]
-			if !exists {
-				return fmt.Errorf("unknown volume driver %s specified", driver)
-			}
-			if ok, _ := strategy.IsCompatible(volcfg.Source(), nil); !ok || err != nil {
-				return fmt.Errorf("volume driver %s is incompatible with source %s", driver, volcfg.Source())
-			}
-			if _, ok := controllers[driver]; !ok {
-				log.G(ctx).WithField("volume strategy", driver).Debug("found volume strategy")
-				controllers[driver], err = strategy.NewVolumeV1alpha1(ctx)
-				if err != nil {
-					return fmt.Errorf("could not prepare %s volume service: %w", driver, err)
-				}
-			}
+		driver, err := opts.volumeDriver(ctx, controllers, volcfg.Driver(), volcfg.Source())
+		if err != nil {
+			return err
 		}
 
-		if len(driver) == 0 {
-			return fmt.Errorf("could not find compatible volume driver for %s", volcfg.Source())
+		if err := validateVolumeOptions(driver, volcfg.Mode()); err != nil {
+			return err
 		}
 
 		// Check if this could be a named volume
@@ -320,8 +446,12 @@ func (opts *RunOptions) parseKraftfileVolumes(ctx context.Context, project app.A
 			},
 		})
 
-		if err == nil && vol.Spec.Source != "" {
+		if err == nil && vol != nil && vol.Spec.Source != "" {
 			vol.Spec.Destination = volcfg.Destination()
+			vol.Spec.ReadOnly = vol.Spec.ReadOnly || volcfg.ReadOnly()
+			if len(volcfg.Mode()) > 0 {
+				vol.Spec.Mode = volcfg.Mode()
+			}
 			machine.Spec.Volumes = append(machine.Spec.Volumes, *vol)
 			continue
 		}
@@ -334,6 +464,7 @@ func (opts *RunOptions) parseKraftfileVolumes(ctx context.Context, project app.A
 				Driver:      driver,
 				Source:      volcfg.Source(),
 				Destination: volcfg.Destination(),
+				Mode:        volcfg.Mode(),
 				ReadOnly:    volcfg.ReadOnly(),
 			},
 		})
@@ -347,6 +478,26 @@ func (opts *RunOptions) parseKraftfileVolumes(ctx context.Context, project app.A
 	return nil
 }
 
+// overlayRootfs layers the files provided via `--add` on top of the rootfs
+// built by ramfs.  The result is written to output such that the original
+// rootfs, e.g. the one of a cached package, remains untouched.
+func (opts *RunOptions) overlayRootfs(ramfs initrd.Initrd, output string) (initrd.Initrd, error) {
+	if len(opts.Add) == 0 {
+		return ramfs, nil
+	}
+
+	if ramfs == nil {
+		return nil, fmt.Errorf("cannot add files without a rootfs")
+	}
+
+	files, err := utils.ParseRootfsFiles(opts.Add)
+	if err != nil {
+		return nil, err
+	}
+
+	return initrd.NewOverlay(ramfs, files, initrd.WithOutput(output))
+}
+
 // parse the provided `--rootfs` flag which ultimately is passed into the
 // dynamic Initrd interface which either looks up or constructs the archive
 // based on the value of the flag.
@@ -373,11 +524,18 @@ func (opts *RunOptions) prepareRootfs(ctx context.Context, machine *machineapi.M
 			"rootfs-cache",
 		)),
 		initrd.WithArchitecture(machine.Spec.Architecture),
+		initrd.WithFormat(initrd.Format(opts.RootfsFormat)),
+		initrd.WithIncludes(opts.Include...),
 	)
 	if err != nil {
 		return fmt.Errorf("could not prepare initramfs: %w", err)
 	}
 
+	ramfs, err = opts.overlayRootfs(ramfs, machine.Status.InitrdPath)
+	if err != nil {
+		return fmt.Errorf("could not prepare initramfs: %w", err)
+	}
+
 	if machine.Spec.Env == nil {
 		machine.Spec.Env = make(map[string]string)
 	}
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package run
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/internal/offline/offlinetest"
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+func TestVolumeDriver(t *testing.T) {
+	ctx := offlinetest.Context(t)
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file.txt")
+
+	if err := os.WriteFile(file, []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		platform mplatform.Platform
+		driver   string
+		source   string
+		expected string
+	}{
+		{"qemu prefers 9pfs", mplatform.PlatformQEMU, "", dir, "9pfs"},
+		{"firecracker prefers ext4", mplatform.PlatformFirecracker, "", dir, "ext4"},
+		{"unknown platform prefers 9pfs", mplatform.PlatformUnknown, "", dir, "9pfs"},
+		{"explicit driver", mplatform.PlatformQEMU, "virtiofs", dir, "virtiofs"},
+		{"explicit driver on firecracker", mplatform.PlatformFirecracker, "erofs", dir, "erofs"},
+		{"share unsupported on firecracker", mplatform.PlatformFirecracker, "9pfs", dir, ""},
+		{"unknown driver", mplatform.PlatformQEMU, "nfs", dir, ""},
+		{"incompatible source", mplatform.PlatformQEMU, "9pfs", file, ""},
+		{"no compatible driver", mplatform.PlatformFirecracker, "", file, ""},
+	}
+
+	for _, tc := range tests {
+		opts := RunOptions{platform: tc.platform}
+
+		driver, err := opts.volumeDriver(ctx, map[string]volumeapi.VolumeService{}, tc.driver, tc.source)
+		if tc.expected == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error, got driver %s", tc.name, driver)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		} else if driver != tc.expected {
+			t.Errorf("%s: expected driver %s, got %s", tc.name, tc.expected, driver)
+		}
+	}
+}
+
+func TestValidateVolumeOptions(t *testing.T) {
+	tests := []struct {
+		driver string
+		mode   string
+		valid  bool
+	}{
+		{"9pfs", "", true},
+		{"virtiofs", "", true},
+		{"9pfs", "0755", true},
+		{"9pfs", "755", true},
+		{"9pfs", "0x755", false},
+		{"9pfs", "0789", false},
+		{"virtiofs", "0755", false},
+		{"ext4", "0755", false},
+	}
+
+	for _, tc := range tests {
+		err := validateVolumeOptions(tc.driver, tc.mode)
+		if tc.valid && err != nil {
+			t.Errorf("%s with mode %q: %v", tc.driver, tc.mode, err)
+		} else if !tc.valid && err == nil {
+			t.Errorf("%s with mode %q: expected an error", tc.driver, tc.mode)
+		}
+	}
+}
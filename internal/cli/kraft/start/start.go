@@ -8,6 +8,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/MakeNowJust/heredoc"
 	"github.com/spf13/cobra"
@@ -162,7 +163,23 @@ func Start(ctx context.Context, opts *StartOptions, machineNames ...string) erro
 		}
 
 		for _, vol := range machine.Spec.Volumes {
+			// Skip pseudo-volumes, e.g. the initramfs, which are not managed by a
+			// volume driver.
+			if _, ok := volume.Strategies()[vol.Spec.Driver]; !ok {
+				continue
+			}
+
+			// Retrieve the latest state of the volume since it may have been
+			// attached to other machines in the meantime.
+			if current, err := volumeController.Get(ctx, &vol); err == nil && current != nil {
+				vol = *current
+			}
+
 			vol.Status.State = volumeapi.VolumeStateBound
+			if !slices.Contains(vol.Status.AttachedTo, machine.Name) {
+				vol.Status.AttachedTo = append(vol.Status.AttachedTo, machine.Name)
+			}
+
 			if _, err := volumeController.Update(ctx, &vol); err != nil {
 				errGroup = append(errGroup, err)
 			}
@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package prune
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/utils"
+	"kraftkit.sh/log"
+)
+
+type PruneOptions struct{}
+
+// Prune removes cached build artifacts.
+func Prune(ctx context.Context, opts *PruneOptions, args ...string) error {
+	if opts == nil {
+		opts = &PruneOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+// NewCmd returns a new prune command
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&PruneOptions{}, cobra.Command{
+		Short: "Remove cached build artifacts",
+		Use:   "prune [FLAGS]",
+		Args:  cobra.NoArgs,
+		Long: heredoc.Doc(`
+			Remove cached build artifacts, such as the root file systems which were
+			built from directories, Dockerfiles and OCI images and which are
+			otherwise re-used by subsequent builds and packages.
+		`),
+		Example: heredoc.Doc(`
+			# Remove all cached build artifacts
+			$ kraft system prune
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "system",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+// Run executes the prune command
+func (opts *PruneOptions) Run(ctx context.Context, _ []string) error {
+	dir := utils.RootfsCacheDir(ctx)
+
+	var reclaimed uint64
+
+	if err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			reclaimed += uint64(info.Size())
+		}
+
+		return nil
+	}); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not inspect rootfs cache: %w", err)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("could not remove rootfs cache: %w", err)
+	}
+
+	log.G(ctx).
+		WithField("reclaimed", humanize.Bytes(reclaimed)).
+		Info("pruned rootfs cache")
+
+	return nil
+}
@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package system
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/system/prune"
+)
+
+type System struct{}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&System{}, cobra.Command{
+		Short:   "Manage KraftKit's local state",
+		Use:     "system SUBCOMMAND",
+		Aliases: []string{"sys"},
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "system",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.AddCommand(prune.NewCmd())
+
+	return cmd
+}
+
+func (opts *System) Run(_ context.Context, _ []string) error {
+	return pflag.ErrHelp
+}
@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package utils
+
+import (
+	"fmt"
+
+	"kraftkit.sh/initrd"
+	"kraftkit.sh/machine/volume/disk"
+	"kraftkit.sh/oci"
+)
+
+// InitrdMediaType returns the media type which represents the format of the
+// root file system at the provided path.
+func InitrdMediaType(path string) (string, error) {
+	format, err := initrd.DetectFormat(path)
+	if err != nil {
+		return "", err
+	}
+
+	switch format {
+	case initrd.FormatErofs:
+		return oci.MediaTypeInitrdErofs, nil
+	case initrd.FormatSquashfs:
+		return oci.MediaTypeInitrdSquashfs, nil
+	case initrd.FormatCpio:
+		compression, err := initrd.DetectCompression(path)
+		if err != nil {
+			return "", err
+		}
+
+		switch compression {
+		case initrd.CompressionGzip:
+			return oci.MediaTypeInitrdCpioGzip, nil
+		case initrd.CompressionZstd:
+			return oci.MediaTypeInitrdCpioZstd, nil
+		case initrd.CompressionXz:
+			return oci.MediaTypeInitrdCpioXz, nil
+		case initrd.CompressionLz4:
+			return oci.MediaTypeInitrdCpioLz4, nil
+		}
+
+		return oci.MediaTypeInitrdCpio, nil
+	}
+
+	return "", fmt.Errorf("unsupported initrd format: %s", format)
+}
+
+// DiskMediaType returns the media type which represents the file system
+// format of the disk image at the provided path.
+func DiskMediaType(path string) (string, error) {
+	filesystem, err := disk.DetectFilesystem(path)
+	if err != nil {
+		return "", err
+	}
+
+	switch filesystem {
+	case disk.FilesystemExt4:
+		return oci.MediaTypeDiskExt4, nil
+	case disk.FilesystemErofs:
+		return oci.MediaTypeDiskErofs, nil
+	}
+
+	return "", fmt.Errorf("unsupported disk format: %s", filesystem)
+}
@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package utils
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kraftkit.sh/initrd"
+	"kraftkit.sh/oci"
+)
+
+// writeImage writes a file system image which only consists of the magic
+// number of the file system at the provided offset.
+func writeImage(t *testing.T, offset int, magic []byte) string {
+	t.Helper()
+
+	data := make([]byte, 4096)
+	copy(data[offset:], magic)
+
+	path := filepath.Join(t.TempDir(), "disk.img")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestDiskMediaType(t *testing.T) {
+	ext4 := binary.LittleEndian.AppendUint16(nil, 0xef53)
+	erofs := binary.LittleEndian.AppendUint32(nil, 0xe0f5e1e2)
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"ext4", writeImage(t, 1080, ext4), oci.MediaTypeDiskExt4},
+		{"erofs", writeImage(t, 1024, erofs), oci.MediaTypeDiskErofs},
+		{"unknown", writeImage(t, 0, []byte("not a file system")), ""},
+	}
+
+	for _, tc := range tests {
+		mediaType, err := DiskMediaType(tc.path)
+		if tc.expected == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %s", tc.name, mediaType)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		} else if mediaType != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.expected, mediaType)
+		}
+	}
+}
+
+func TestInitrdMediaType(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "hello"), []byte("world"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[initrd.Compression]string{
+		initrd.CompressionNone: oci.MediaTypeInitrdCpio,
+		initrd.CompressionGzip: oci.MediaTypeInitrdCpioGzip,
+		initrd.CompressionZstd: oci.MediaTypeInitrdCpioZstd,
+		initrd.CompressionXz:   oci.MediaTypeInitrdCpioXz,
+		initrd.CompressionLz4:  oci.MediaTypeInitrdCpioLz4,
+	}
+
+	for _, compression := range initrd.Compressions() {
+		output := filepath.Join(t.TempDir(), "initramfs")
+
+		ird, err := initrd.NewFromDirectory(context.Background(), root,
+			initrd.WithOutput(output),
+			initrd.WithCompression(compression),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := ird.Build(context.Background()); err != nil {
+			t.Fatalf("%s: %v", compression, err)
+		}
+
+		mediaType, err := InitrdMediaType(output)
+		if err != nil {
+			t.Errorf("%s: %v", compression, err)
+		} else if mediaType != expected[compression] {
+			t.Errorf("%s: expected %s, got %s", compression, expected[compression], mediaType)
+		}
+	}
+}
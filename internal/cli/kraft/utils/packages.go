@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"kraftkit.sh/log"
+	"kraftkit.sh/pack"
+	"kraftkit.sh/packmanager"
+)
+
+// FindPackage returns the package with the provided name which targets the
+// provided architecture and platform, either of which may be empty to match
+// any.  Local packages are preferred over remote ones and the package is
+// pulled if necessary.
+func FindPackage(ctx context.Context, name, arch, plat string) (pack.Package, error) {
+	packs, err := packmanager.G(ctx).Catalog(ctx,
+		packmanager.WithName(name),
+		packmanager.WithArchitecture(arch),
+		packmanager.WithPlatform(plat),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not query catalog: %w", err)
+	}
+
+	if len(packs) == 0 {
+		packs, err = packmanager.G(ctx).Catalog(ctx,
+			packmanager.WithName(name),
+			packmanager.WithArchitecture(arch),
+			packmanager.WithPlatform(plat),
+			packmanager.WithRemote(true),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not query catalog: %w", err)
+		}
+	}
+
+	if len(packs) == 0 {
+		return nil, fmt.Errorf("could not find: %s", name)
+	} else if len(packs) > 1 {
+		var found []string
+		for _, p := range packs {
+			found = append(found, p.String())
+		}
+
+		return nil, fmt.Errorf("found multiple packages, select one with --plat and --arch: %s", strings.Join(found, ", "))
+	}
+
+	if exists, _, err := packs[0].PulledAt(ctx); !exists || err != nil {
+		log.G(ctx).
+			WithField("package", packs[0].String()).
+			Info("pulling")
+
+		if err := packs[0].Pull(ctx); err != nil {
+			return nil, fmt.Errorf("could not pull %s: %w", name, err)
+		}
+	}
+
+	return packs[0], nil
+}
+
+// IsNonEmptyFile returns whether a non-empty regular file exists at the path,
+// e.g. the initramfs of an unpacked package.
+func IsNonEmptyFile(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.Mode().IsRegular() && fi.Size() > 0
+}
@@ -18,18 +18,89 @@ import (
 	"kraftkit.sh/unikraft/target"
 )
 
-// BuildRootfs generates a rootfs based on the provided working directory and
-// the rootfs entrypoint for the provided target(s).
-func BuildRootfs(ctx context.Context, workdir, rootfs string, compress bool, targ target.Target) (string, []string, []string, error) {
+// RootfsOptions are the settings of the rootfs generated by BuildRootfs.  The
+// zero value builds an uncompressed CPIO archive.
+type RootfsOptions struct {
+	// Compression is the compression algorithm of the archive.
+	Compression initrd.Compression
+
+	// CompressionLevel is the level of the compression algorithm, where 0
+	// selects the algorithm's default.
+	CompressionLevel int
+
+	// Format is the format of the root file system.
+	Format initrd.Format
+
+	// Reproducible normalizes the rootfs and reports its digest.
+	Reproducible bool
+
+	// Includes are additional host files, in the form <host>[:<machine>], to
+	// include in rootfs generated from ELF executables.
+	Includes []string
+
+	// Files are additional files, in the form SRC:DST[:mode[:uid:gid]], which
+	// are layered on top of the rootfs.
+	Files []string
+
+	// NoCache always rebuilds the rootfs rather than re-using the result of a
+	// previous build with identical inputs.
+	NoCache bool
+}
+
+// RootfsCacheDir returns the location of the content-addressed cache of built
+// rootfs.
+func RootfsCacheDir(ctx context.Context) string {
+	return filepath.Join(config.G[config.KraftKit](ctx).Paths.Cache, "rootfs")
+}
+
+// ParseRootfsFiles parses the provided files in the form of
+// SRC:DST[:mode[:uid:gid]] which are added to a rootfs.
+func ParseRootfsFiles(files []string) ([]initrd.File, error) {
+	var ret []initrd.File
+
+	for _, file := range files {
+		parsed, err := initrd.ParseFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, parsed)
+	}
+
+	return ret, nil
+}
+
+// BuildRootfs generates a rootfs with the provided options based on the
+// provided working directory and the rootfs entrypoint for the provided
+// target(s).  When reproducible, the digest of the resulting rootfs is
+// reported.
+func BuildRootfs(ctx context.Context, workdir, rootfs string, ropts RootfsOptions, targ target.Target) (string, []string, []string, error) {
 	if rootfs == "" {
+		if len(ropts.Files) > 0 {
+			return "", nil, nil, fmt.Errorf("cannot add files without a rootfs")
+		}
+
 		return "", nil, nil, nil
 	}
 
+	files, err := ParseRootfsFiles(ropts.Files)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	// File system images are decompressed by the file system driver rather
+	// than when extracting the initramfs.
+	if !ropts.Format.BlockDevice() {
+		if err := ropts.Compression.CheckKConfig(targ.KConfig()); err != nil {
+			return "", nil, nil, err
+		}
+	}
+
 	var processes []*processtree.ProcessTreeItem
 	var cmds []string
 	var envs []string
 
-	ramfs, err := initrd.New(ctx, rootfs,
+	iopts := []initrd.InitrdOption{
 		initrd.WithWorkdir(workdir),
 		initrd.WithOutput(filepath.Join(
 			workdir,
@@ -42,12 +113,29 @@ func BuildRootfs(ctx context.Context, workdir, rootfs string, compress bool, tar
 			"rootfs-cache",
 		)),
 		initrd.WithArchitecture(targ.Architecture().String()),
-		initrd.WithCompression(compress),
-	)
+		initrd.WithCompression(ropts.Compression),
+		initrd.WithCompressionLevel(ropts.CompressionLevel),
+		initrd.WithFormat(ropts.Format),
+		initrd.WithReproducible(ropts.Reproducible),
+		initrd.WithIncludes(ropts.Includes...),
+	}
+
+	if !ropts.NoCache {
+		iopts = append(iopts, initrd.WithBuildCache(RootfsCacheDir(ctx)))
+	}
+
+	ramfs, err := initrd.New(ctx, rootfs, iopts...)
 	if err != nil {
 		return "", nil, nil, fmt.Errorf("could not initialize initramfs builder: %w", err)
 	}
 
+	if len(files) > 0 {
+		ramfs, err = initrd.NewOverlay(ramfs, files, iopts...)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("could not initialize initramfs builder: %w", err)
+		}
+	}
+
 	processes = append(processes,
 		processtree.NewProcessTreeItem(
 			"building rootfs",
@@ -84,5 +172,16 @@ func BuildRootfs(ctx context.Context, workdir, rootfs string, compress bool, tar
 		return "", nil, nil, err
 	}
 
+	if ropts.Reproducible {
+		dgst, err := initrd.Digest(rootfs)
+		if err != nil {
+			return "", nil, nil, err
+		}
+
+		log.G(ctx).
+			WithField("digest", dgst.String()).
+			Info("built reproducible rootfs")
+	}
+
 	return rootfs, cmds, envs, nil
 }
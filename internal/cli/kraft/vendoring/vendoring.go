@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package vendoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/exec"
+	"kraftkit.sh/hermetic"
+	"kraftkit.sh/internal/cli/kraft/vendoring/verify"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/log"
+	"kraftkit.sh/make"
+	"kraftkit.sh/unikraft"
+	"kraftkit.sh/unikraft/app"
+	"kraftkit.sh/unikraft/target"
+)
+
+type VendorOptions struct {
+	Architecture string `long:"arch" short:"m" usage:"Filter the targets by architecture"`
+	Kraftfile    string `long:"kraftfile" short:"K" usage:"Set an alternative path of the Kraftfile"`
+	NoFetch      bool   `long:"no-fetch" usage:"Do not run Unikraft's fetch step before vendoring"`
+	Platform     string `long:"plat" short:"p" usage:"Filter the targets by platform"`
+	Target       string `long:"target" short:"t" usage:"Vendor the sources of a particular known target"`
+}
+
+// Vendor copies the sources of a project into its vendor directory.
+func Vendor(ctx context.Context, opts *VendorOptions, args ...string) error {
+	if opts == nil {
+		opts = &VendorOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&VendorOptions{}, cobra.Command{
+		Short: "Vendor the sources of a project",
+		Use:   "vendor [FLAGS] [DIR]",
+		Args:  cmdfactory.MaxDirArgs(1),
+		Long: heredoc.Docf(`
+			Vendor the sources of a project.
+
+			The sources of the Unikraft core, the template and the libraries of the
+			project, as well as the upstream archives which they fetch, are copied into
+			the %[1]svendor/%[1]s directory of the project and their checksums are
+			recorded in %[1]svendor/checksums.yaml%[1]s.  Subsequent builds use the
+			vendored sources in place of retrieving anything.
+
+			Components must have been pulled beforehand, e.g. with %[1]skraft build%[1]s.
+			Remove the %[1]svendor/%[1]s directory and build the project again to update
+			the vendored sources.
+		`, "`"),
+		Example: heredoc.Doc(`
+			# Vendor the sources of the project in the current working directory
+			$ kraft vendor
+
+			# Check the vendored sources against their recorded checksums
+			$ kraft vendor verify
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "build",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	cmd.AddCommand(verify.NewCmd())
+
+	return cmd
+}
+
+func (opts *VendorOptions) Run(ctx context.Context, args []string) error {
+	var workdir string
+	var err error
+
+	if len(args) == 0 {
+		workdir, err = os.Getwd()
+		if err != nil {
+			return err
+		}
+	} else {
+		workdir = args[0]
+	}
+
+	project, err := loadProject(ctx, workdir, opts.Kraftfile)
+	if err != nil {
+		return err
+	}
+
+	targets := target.Filter(project.Targets(), opts.Architecture, opts.Platform, opts.Target)
+
+	if !opts.NoFetch {
+		for _, tc := range targets {
+			if !project.IsConfigured(tc) {
+				log.G(ctx).
+					WithField("target", tc.Name()).
+					Warn("skipping fetch of unconfigured target, its upstream archives are only vendored once it is built")
+				continue
+			}
+
+			if err := project.Fetch(ctx, tc,
+				make.WithExecOptions(
+					exec.WithStdout(log.G(ctx).Writer()),
+					exec.WithStderr(log.G(ctx).WriterLevel(logrus.WarnLevel)),
+				),
+			); err != nil {
+				return fmt.Errorf("could not fetch upstream archives of %s: %w", tc.Name(), err)
+			}
+		}
+	}
+
+	manifest, err := hermetic.Vendor(ctx, project, targets...)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(iostreams.G(ctx).Out, "vendored %d sources into %s\n", len(manifest.Entries), unikraft.HermeticDir)
+
+	return nil
+}
+
+// loadProject returns the project in the provided working directory, merged
+// with its template if it has one.
+func loadProject(ctx context.Context, workdir, kraftfile string) (app.Application, error) {
+	popts := []app.ProjectOption{
+		app.WithProjectWorkdir(workdir),
+	}
+
+	if len(kraftfile) > 0 {
+		popts = append(popts, app.WithProjectKraftfile(kraftfile))
+	} else {
+		popts = append(popts, app.WithProjectDefaultKraftfiles())
+	}
+
+	project, err := app.NewProjectFromOptions(ctx, popts...)
+	if err != nil && errors.Is(err, app.ErrNoKraftfile) {
+		return nil, fmt.Errorf("cannot vendor project directory without a Kraftfile")
+	} else if err != nil {
+		return nil, fmt.Errorf("could not initialize project directory: %w", err)
+	}
+
+	if template := project.Template(); template != nil {
+		templateProject, err := app.NewProjectFromOptions(ctx,
+			app.WithProjectWorkdir(template.Path()),
+			app.WithProjectDefaultKraftfiles(),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not read template %s, run 'kraft build' first: %w", unikraft.TypeNameVersion(template), err)
+		}
+
+		project, err = project.MergeTemplate(ctx, templateProject)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return project, nil
+}
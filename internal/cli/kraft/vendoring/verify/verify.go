@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package verify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/hermetic"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/unikraft"
+	"kraftkit.sh/unikraft/app"
+	"kraftkit.sh/unikraft/target"
+)
+
+type VerifyOptions struct {
+	Architecture string `long:"arch" short:"m" usage:"Filter the targets by architecture"`
+	Kraftfile    string `long:"kraftfile" short:"K" usage:"Set an alternative path of the Kraftfile"`
+	Platform     string `long:"plat" short:"p" usage:"Filter the targets by platform"`
+	Target       string `long:"target" short:"t" usage:"Verify the sources of a particular known target"`
+}
+
+// Verify checks the vendored sources of a project against their checksums.
+func Verify(ctx context.Context, opts *VerifyOptions, args ...string) error {
+	if opts == nil {
+		opts = &VerifyOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&VerifyOptions{}, cobra.Command{
+		Short: "Verify the vendored sources of a project",
+		Use:   "verify [FLAGS] [DIR]",
+		Args:  cmdfactory.MaxDirArgs(1),
+		Long: heredoc.Docf(`
+			Verify the vendored sources of a project.
+
+			Every component of the project must be vendored at the version which is
+			requested by the Kraftfile, and the vendored sources and upstream archives
+			must match the checksums which are recorded in %[1]svendor/checksums.yaml%[1]s.
+		`, "`"),
+		Example: heredoc.Doc(`
+			# Verify the vendored sources of the project in the current working directory
+			$ kraft vendor verify
+
+			# Verify the vendored sources of a project at a given path
+			$ kraft vendor verify path/to/app
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "build",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *VerifyOptions) Run(ctx context.Context, args []string) error {
+	var workdir string
+	var err error
+
+	if len(args) == 0 {
+		workdir, err = os.Getwd()
+		if err != nil {
+			return err
+		}
+	} else {
+		workdir = args[0]
+	}
+
+	popts := []app.ProjectOption{
+		app.WithProjectWorkdir(workdir),
+	}
+
+	if len(opts.Kraftfile) > 0 {
+		popts = append(popts, app.WithProjectKraftfile(opts.Kraftfile))
+	} else {
+		popts = append(popts, app.WithProjectDefaultKraftfiles())
+	}
+
+	project, err := app.NewProjectFromOptions(ctx, popts...)
+	if err != nil && errors.Is(err, app.ErrNoKraftfile) {
+		return fmt.Errorf("cannot verify project directory without a Kraftfile")
+	} else if err != nil {
+		return fmt.Errorf("could not initialize project directory: %w", err)
+	}
+
+	if template := project.Template(); template != nil {
+		templateProject, err := app.NewProjectFromOptions(ctx,
+			app.WithProjectWorkdir(template.Path()),
+			app.WithProjectDefaultKraftfiles(),
+		)
+		if err != nil {
+			return fmt.Errorf("could not read template %s: %w", unikraft.TypeNameVersion(template), err)
+		}
+
+		project, err = project.MergeTemplate(ctx, templateProject)
+		if err != nil {
+			return err
+		}
+	}
+
+	targets := target.Filter(project.Targets(), opts.Architecture, opts.Platform, opts.Target)
+
+	manifest, err := hermetic.Verify(ctx, project, targets...)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(iostreams.G(ctx).Out, "verified %d vendored sources\n", len(manifest.Entries))
+
+	return nil
+}
@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file expect in compliance with the License.
+package clone
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/volume/export"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/machine/volume"
+)
+
+type CloneOptions struct{}
+
+// Clone creates a copy of a volume.
+func Clone(ctx context.Context, opts *CloneOptions, args ...string) error {
+	if opts == nil {
+		opts = &CloneOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&CloneOptions{}, cobra.Command{
+		Short: "Create a copy of a volume",
+		Use:   "clone SOURCE NAME",
+		Args:  cobra.ExactArgs(2),
+		Example: heredoc.Doc(`
+			# Create a copy of a volume
+			$ kraft volume clone my-volume my-volume-copy
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "volume",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *CloneOptions) Pre(cmd *cobra.Command, _ []string) error {
+	return nil
+}
+
+func (opts *CloneOptions) Run(ctx context.Context, args []string) error {
+	iterator, err := volume.NewVolumeV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return err
+	}
+
+	src, err := export.Lookup(ctx, iterator, args[0])
+	if err != nil {
+		return err
+	}
+
+	existing, err := iterator.Get(ctx, &volumeapi.Volume{
+		ObjectMeta: v1.ObjectMeta{
+			Name: args[1],
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		return fmt.Errorf("volume %s already exists", args[1])
+	}
+
+	// The copy uses the same driver as the original volume.
+	strategy, ok := volume.Strategies()[src.Spec.Driver]
+	if !ok {
+		return fmt.Errorf("unsupported volume driver strategy: %v", src.Spec.Driver)
+	}
+
+	controller, err := strategy.NewVolumeV1alpha1(ctx)
+	if err != nil {
+		return err
+	}
+
+	vol, err := volume.Clone(ctx, controller, src, args[1])
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(iostreams.G(ctx).Out, vol.Name)
+
+	return nil
+}
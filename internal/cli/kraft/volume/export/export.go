@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file expect in compliance with the License.
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/machine/volume"
+)
+
+type ExportOptions struct {
+	Output string `long:"output" short:"o" usage:"Write the tarball to a file instead of stdout"`
+}
+
+// Export the contents of a volume as a tarball.
+func Export(ctx context.Context, opts *ExportOptions, args ...string) error {
+	if opts == nil {
+		opts = &ExportOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&ExportOptions{}, cobra.Command{
+		Short: "Export the contents of a volume as a tarball",
+		Use:   "export [FLAGS] VOLUME",
+		Args:  cobra.ExactArgs(1),
+		Example: heredoc.Doc(`
+			# Export a volume to a tarball
+			$ kraft volume export my-volume > my-volume.tar
+
+			# Export a volume to a file
+			$ kraft volume export -o my-volume.tar my-volume
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "volume",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *ExportOptions) Pre(cmd *cobra.Command, _ []string) error {
+	return nil
+}
+
+func (opts *ExportOptions) Run(ctx context.Context, args []string) error {
+	controller, err := volume.NewVolumeV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return err
+	}
+
+	vol, err := Lookup(ctx, controller, args[0])
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = iostreams.G(ctx).Out
+
+	if len(opts.Output) > 0 {
+		f, err := os.Create(opts.Output)
+		if err != nil {
+			return fmt.Errorf("could not create output file: %w", err)
+		}
+
+		defer f.Close()
+
+		out = f
+	}
+
+	return volume.Export(ctx, vol, out)
+}
+
+// Lookup returns the volume whose name or UID matches the provided argument.
+func Lookup(ctx context.Context, controller volumeapi.VolumeService, nameOrUID string) (*volumeapi.Volume, error) {
+	volumes, err := controller.List(ctx, &volumeapi.VolumeList{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, vol := range volumes.Items {
+		if vol.Name == nameOrUID || string(vol.UID) == nameOrUID {
+			return &vol, nil
+		}
+	}
+
+	return nil, fmt.Errorf("volume %s not found", nameOrUID)
+}
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file expect in compliance with the License.
+package importvol
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	volumeapi "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/iostreams"
+	"kraftkit.sh/machine/volume"
+)
+
+type ImportOptions struct {
+	Driver   string `noattribute:"true"`
+	Name     string `long:"name" short:"n" usage:"Name of the new volume"`
+	ReadOnly bool   `long:"read-only" usage:"Mark the new volume as read-only"`
+}
+
+// Import a tarball as a new volume.
+func Import(ctx context.Context, opts *ImportOptions, args ...string) error {
+	if opts == nil {
+		opts = &ImportOptions{}
+	}
+
+	return opts.Run(ctx, args)
+}
+
+func NewCmd() *cobra.Command {
+	cmd, err := cmdfactory.New(&ImportOptions{}, cobra.Command{
+		Short: "Create a volume from a tarball",
+		Use:   "import [FLAGS] FILE|-",
+		Args:  cobra.ExactArgs(1),
+		Example: heredoc.Doc(`
+			# Create a volume from a tarball
+			$ kraft volume import --name my-volume my-volume.tar
+
+			# Create a volume from a tarball read from stdin
+			$ kraft volume import --name my-volume - < my-volume.tar
+		`),
+		Annotations: map[string]string{
+			cmdfactory.AnnotationHelpGroup: "volume",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func (opts *ImportOptions) Pre(cmd *cobra.Command, _ []string) error {
+	opts.Driver = cmd.Flag("driver").Value.String()
+	return nil
+}
+
+func (opts *ImportOptions) Run(ctx context.Context, args []string) error {
+	strategy, ok := volume.Strategies()[opts.Driver]
+	if !ok {
+		return fmt.Errorf("unsupported volume driver strategy: %v (contributions welcome!)", opts.Driver)
+	}
+
+	controller, err := strategy.NewVolumeV1alpha1(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(opts.Name) > 0 {
+		existing, err := controller.Get(ctx, &volumeapi.Volume{
+			ObjectMeta: v1.ObjectMeta{
+				Name: opts.Name,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		if existing != nil {
+			return fmt.Errorf("volume %s already exists", opts.Name)
+		}
+	}
+
+	var in io.Reader = iostreams.G(ctx).In
+
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("could not open tarball: %w", err)
+		}
+
+		defer f.Close()
+
+		in = f
+	}
+
+	vol, err := volume.Import(ctx, controller, &volumeapi.Volume{
+		ObjectMeta: v1.ObjectMeta{
+			Name: opts.Name,
+		},
+		Spec: volumeapi.VolumeSpec{
+			Driver:   opts.Driver,
+			ReadOnly: opts.ReadOnly,
+		},
+	}, in)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(iostreams.G(ctx).Out, vol.Name)
+
+	return nil
+}
@@ -7,7 +7,9 @@ package list
 import (
 	"context"
 	"fmt"
+	"strings"
 
+	"github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
 
 	volumeapi "kraftkit.sh/api/volume/v1alpha1"
@@ -81,22 +83,26 @@ func (opts *List) Run(ctx context.Context, args []string) error {
 	}
 
 	type volTableEntry struct {
-		driver string
-		id     string
-		name   string
-		source string
-		status volumeapi.VolumeState
+		driver   string
+		id       string
+		name     string
+		source   string
+		status   volumeapi.VolumeState
+		size     int64
+		attached []string
 	}
 
 	var items []volTableEntry
 
 	for _, volume := range volumes.Items {
 		items = append(items, volTableEntry{
-			driver: opts.driver,
-			id:     string(volume.UID),
-			name:   volume.Name,
-			source: volume.Spec.Source,
-			status: volume.Status.State,
+			driver:   opts.driver,
+			id:       string(volume.UID),
+			name:     volume.Name,
+			source:   volume.Spec.Source,
+			status:   volume.Status.State,
+			size:     volume.Status.Size,
+			attached: volume.Status.AttachedTo,
 		})
 	}
 
@@ -127,6 +133,10 @@ func (opts *List) Run(ctx context.Context, args []string) error {
 		table.AddField("VOLUME ID", cs.Bold)
 	}
 	table.AddField("STATUS", cs.Bold)
+	table.AddField("SIZE", cs.Bold)
+	if opts.Long {
+		table.AddField("ATTACHED TO", cs.Bold)
+	}
 	table.AddField("SOURCE", cs.Bold)
 	table.EndRow()
 
@@ -137,6 +147,10 @@ func (opts *List) Run(ctx context.Context, args []string) error {
 			table.AddField(item.id, nil)
 		}
 		table.AddField(item.status.String(), VolumeStateColor[item.status])
+		table.AddField(humanize.Bytes(uint64(item.size)), nil)
+		if opts.Long {
+			table.AddField(strings.Join(item.attached, ","), nil)
+		}
 		table.AddField(item.source, nil)
 		table.EndRow()
 	}
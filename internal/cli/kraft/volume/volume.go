@@ -12,7 +12,10 @@ import (
 	"github.com/spf13/pflag"
 
 	"kraftkit.sh/cmdfactory"
+	"kraftkit.sh/internal/cli/kraft/volume/clone"
 	"kraftkit.sh/internal/cli/kraft/volume/create"
+	"kraftkit.sh/internal/cli/kraft/volume/export"
+	importvol "kraftkit.sh/internal/cli/kraft/volume/import"
 	"kraftkit.sh/internal/cli/kraft/volume/inspect"
 	"kraftkit.sh/internal/cli/kraft/volume/list"
 	"kraftkit.sh/internal/cli/kraft/volume/remove"
@@ -38,7 +41,10 @@ func NewCmd() *cobra.Command {
 		panic(err)
 	}
 
+	cmd.AddCommand(clone.NewCmd())
 	cmd.AddCommand(create.NewCmd())
+	cmd.AddCommand(export.NewCmd())
+	cmd.AddCommand(importvol.NewCmd())
 	cmd.AddCommand(inspect.NewCmd())
 	cmd.AddCommand(list.NewCmd())
 	cmd.AddCommand(remove.NewCmd())
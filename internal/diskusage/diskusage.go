@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package diskusage computes the number of bytes occupied by files on the
+// host.
+package diskusage
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Of returns the accumulated size of the file at the provided path or, if the
+// path is a directory, of all regular files within it.
+func Of(path string) (int64, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	if !fi.IsDir() {
+		return fi.Size(), nil
+	}
+
+	var size int64
+
+	err = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		size += info.Size()
+
+		return nil
+	})
+
+	return size, err
+}
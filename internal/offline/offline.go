@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package offline implements KraftKit's offline mode, in which only locally
+// cached artifacts are used and the network is never accessed.
+package offline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"kraftkit.sh/config"
+)
+
+// ErrOffline is returned by operations which require network access while
+// offline mode is enabled.
+var ErrOffline = errors.New("offline mode is enabled")
+
+// Enabled returns whether offline mode is enabled in the provided context.
+func Enabled(ctx context.Context) bool {
+	return config.G[config.KraftKit](ctx).Offline
+}
+
+// NotCachedError is returned when an artifact is not available in the local
+// cache and cannot be retrieved since offline mode is enabled.
+type NotCachedError struct {
+	// Artifact names the missing artifact, e.g. an image reference or a URL.
+	Artifact string
+}
+
+// Error implements error.
+func (err *NotCachedError) Error() string {
+	return fmt.Sprintf("%s is not available in the local cache: retrieve it without --offline first", err.Artifact)
+}
+
+// Unwrap returns ErrOffline such that errors.Is(err, ErrOffline) holds.
+func (err *NotCachedError) Unwrap() error {
+	return ErrOffline
+}
+
+// NotCached returns the error of the artifact which is missing from the local
+// cache.
+func NotCached(artifact string) error {
+	return &NotCachedError{Artifact: artifact}
+}
+
+// DialContext refuses to open any connection other than to a unix socket.  It
+// has the signature of net.Dialer.DialContext.
+func DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	switch network {
+	case "unix", "unixgram", "unixpacket":
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	return nil, fmt.Errorf("%w: refusing to connect to %s", ErrOffline, addr)
+}
+
+// Install makes the default HTTP transport and the default transport of the
+// go-containerregistry remote package, as well as every transport which is
+// cloned from either of them after Install is called, refuse outbound
+// connections.  This acts as a safety net for network accesses which are not
+// explicitly gated by Enabled.  Transports which are created from scratch,
+// e.g. by the registry client of containers/image, are not covered and must be
+// gated by Enabled instead.
+func Install() {
+	for _, rt := range []http.RoundTripper{http.DefaultTransport, remote.DefaultTransport} {
+		transport, ok := rt.(*http.Transport)
+		if !ok {
+			continue
+		}
+
+		transport.DialContext = DialContext
+		transport.DialTLSContext = nil
+		transport.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package offline_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	golog "log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"kraftkit.sh/internal/offline"
+	"kraftkit.sh/internal/offline/offlinetest"
+	"kraftkit.sh/oci/handler"
+)
+
+func TestNotCached(t *testing.T) {
+	err := offline.NotCached("unikraft.org/nginx:latest")
+
+	if !errors.Is(err, offline.ErrOffline) {
+		t.Errorf("expected error to wrap ErrOffline: %v", err)
+	}
+
+	var notCached *offline.NotCachedError
+	if !errors.As(err, &notCached) || notCached.Artifact != "unikraft.org/nginx:latest" {
+		t.Errorf("expected error to name the missing artifact: %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "unikraft.org/nginx:latest") {
+		t.Errorf("expected message to name the missing artifact: %s", err)
+	}
+}
+
+func TestDialContextRefusesNetwork(t *testing.T) {
+	for _, network := range []string{"tcp", "tcp4", "tcp6", "udp"} {
+		if _, err := offline.DialContext(context.Background(), network, "unikraft.org:443"); !errors.Is(err, offline.ErrOffline) {
+			t.Errorf("expected dial over %s to be refused, got: %v", network, err)
+		}
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	offlinetest.DenyDial(t)
+
+	if !offline.Enabled(offlinetest.Context(t)) {
+		t.Errorf("expected offline mode to be enabled")
+	}
+}
+
+func TestInstall(t *testing.T) {
+	server := httptest.NewServer(registry.New(registry.Logger(golog.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	ref := strings.TrimPrefix(server.URL, "http://") + "/unikraft/nginx:latest"
+
+	img, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := name.ParseReference(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := remote.Write(r, img); err != nil {
+		t.Fatal(err)
+	}
+
+	dgst, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Install is permanent, restore the transports once the test has finished.
+	for _, rt := range []http.RoundTripper{http.DefaultTransport, remote.DefaultTransport} {
+		transport := rt.(*http.Transport)
+		dial := transport.DialContext
+		dialTLS := transport.DialTLSContext
+
+		t.Cleanup(func() {
+			transport.DialContext = dial
+			transport.DialTLSContext = dialTLS
+		})
+	}
+
+	offline.Install()
+
+	handle, err := handler.NewDirectoryHandler(t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Blobs are pulled through the default transport of the remote package.
+	err = handle.PullDigest(context.Background(),
+		ocispec.MediaTypeImageManifest,
+		ref,
+		digest.Digest(dgst.String()),
+		&ocispec.Platform{},
+		nil,
+	)
+	if err == nil || !strings.Contains(err.Error(), offline.ErrOffline.Error()) {
+		t.Errorf("expected pull to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package offlinetest provides a test mode which fails tests that reach the
+// network.
+package offlinetest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/offline"
+)
+
+// DenyDial fails the test on any outbound dial made through the default HTTP
+// transport, the default transport of the go-containerregistry remote package,
+// or any transport cloned from either of them, until the test has finished.
+func DenyDial(tb testing.TB) {
+	tb.Helper()
+
+	deny := func(_ context.Context, network, addr string) (net.Conn, error) {
+		tb.Errorf("unexpected outbound dial to %s over %s", addr, network)
+		return nil, fmt.Errorf("%w: refusing to connect to %s", offline.ErrOffline, addr)
+	}
+
+	for _, rt := range []http.RoundTripper{http.DefaultTransport, remote.DefaultTransport} {
+		transport, ok := rt.(*http.Transport)
+		if !ok {
+			tb.Fatalf("default transport %T is not an *http.Transport", rt)
+		}
+
+		dial := transport.DialContext
+		dialTLS := transport.DialTLSContext
+
+		// Idle connections would otherwise be re-used without dialing.
+		transport.CloseIdleConnections()
+		transport.DialContext = deny
+		transport.DialTLSContext = deny
+
+		tb.Cleanup(func() {
+			transport.DialContext = dial
+			transport.DialTLSContext = dialTLS
+		})
+	}
+}
+
+// Context returns a context whose configuration has offline mode enabled and
+// whose caches are kept in a temporary directory of the test.
+func Context(tb testing.TB) context.Context {
+	tb.Helper()
+
+	cfg, err := config.NewDefaultKraftKitConfig()
+	if err != nil {
+		tb.Fatalf("could not create default config: %v", err)
+	}
+
+	dir := tb.TempDir()
+
+	cfg.Offline = true
+	cfg.Paths.Manifests = filepath.Join(dir, "manifests")
+	cfg.Paths.Sources = filepath.Join(dir, "sources")
+	cfg.Paths.Cache = filepath.Join(dir, "cache")
+	cfg.RuntimeDir = filepath.Join(dir, "runtime")
+
+	cfgm, err := config.NewConfigManager(cfg)
+	if err != nil {
+		tb.Fatalf("could not create config manager: %v", err)
+	}
+
+	return config.WithConfigManager(context.Background(), cfgm)
+}
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"kraftkit.sh/config"
+	"kraftkit.sh/internal/offline"
 	"kraftkit.sh/internal/version"
 	"kraftkit.sh/iostreams"
 
@@ -84,6 +85,11 @@ Or use the globally accessible flag '--no-check-updates'.
 		return nil
 	}
 
+	// The latest version is only refreshed when the network may be accessed.
+	if offline.Enabled(ctx) {
+		return nil
+	}
+
 	go func() {
 		client := &http.Client{}
 
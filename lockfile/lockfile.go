@@ -0,0 +1,305 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package lockfile records the exact artifacts which the components of a
+// project resolve to, such that builds of the same Kraftfile are reproducible.
+package lockfile
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"kraftkit.sh/unikraft"
+)
+
+const (
+	// FileName is the name of the lockfile which is kept in the working
+	// directory of the project, next to its Kraftfile.
+	FileName = "Kraftfile.lock"
+
+	// SpecVersion is the version of the format of the lockfile.
+	SpecVersion = "v1"
+)
+
+// Kind is the role of a component within the project.
+type Kind string
+
+const (
+	KindCore     Kind = "core"
+	KindTemplate Kind = "template"
+	KindLibrary  Kind = "library"
+	KindRuntime  Kind = "runtime"
+)
+
+// kindOrder is the order in which components are written to the lockfile.
+var kindOrder = map[Kind]int{
+	KindCore:     0,
+	KindTemplate: 1,
+	KindLibrary:  2,
+	KindRuntime:  3,
+}
+
+// KindOf returns the kind of the component based on its type.
+func KindOf(component unikraft.Nameable) Kind {
+	switch component.Type() {
+	case unikraft.ComponentTypeCore:
+		return KindCore
+	case unikraft.ComponentTypeLib:
+		return KindLibrary
+	case unikraft.ComponentTypeApp:
+		return KindTemplate
+	default:
+		return Kind(component.Type())
+	}
+}
+
+// Component is the locked resolution of a component of the project.
+type Component struct {
+	// Kind is the role of the component within the project.
+	Kind Kind `yaml:"kind"`
+
+	// Name of the component.
+	Name string `yaml:"name"`
+
+	// Version is the version of the component as it is requested in the
+	// Kraftfile, e.g. a channel, a branch or a release.
+	Version string `yaml:"version,omitempty"`
+
+	// Source is the source of the component as it is requested in the
+	// Kraftfile.
+	Source string `yaml:"source,omitempty"`
+
+	// Resolved is the exact version which the requested version resolved to.
+	Resolved string `yaml:"resolved,omitempty"`
+
+	// Resource is the location from which the component was retrieved.
+	Resource string `yaml:"resource,omitempty"`
+
+	// Commit is the Git SHA of the component.
+	Commit string `yaml:"commit,omitempty"`
+
+	// Sha256 is the checksum of the archive of the component.
+	Sha256 string `yaml:"sha256,omitempty"`
+
+	// Digest is the digest of the OCI manifest of the component.
+	Digest string `yaml:"digest,omitempty"`
+}
+
+// String implements fmt.Stringer.
+func (component Component) String() string {
+	if len(component.Version) == 0 {
+		return fmt.Sprintf("%s %s", component.Kind, component.Name)
+	}
+
+	return fmt.Sprintf("%s %s:%s", component.Kind, component.Name, component.Version)
+}
+
+// Applies returns whether the locked resolution still applies to the
+// component as it is requested, i.e. whether neither its version nor its
+// source have changed in the Kraftfile.
+func (component Component) Applies(requested Component) bool {
+	return component.Kind == requested.Kind &&
+		component.Name == requested.Name &&
+		component.Version == requested.Version &&
+		component.Source == requested.Source
+}
+
+// Lockfile is the set of locked resolutions of the components of a project.
+type Lockfile struct {
+	// Spec is the version of the format of the lockfile.
+	Spec string `yaml:"spec"`
+
+	// Components are the locked resolutions of the components.
+	Components []Component `yaml:"components"`
+}
+
+// New returns an empty lockfile.
+func New() *Lockfile {
+	return &Lockfile{
+		Spec: SpecVersion,
+	}
+}
+
+// PathOf returns the path of the lockfile of the project in the provided
+// working directory.
+func PathOf(workdir string) string {
+	return filepath.Join(workdir, FileName)
+}
+
+// NewLockfileFromPath reads the lockfile at the provided path.  If it does
+// not exist, the returned error satisfies errors.Is(err, os.ErrNotExist).
+func NewLockfileFromPath(path string) (*Lockfile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	lockfile := Lockfile{}
+	if err := yaml.Unmarshal(b, &lockfile); err != nil {
+		return nil, fmt.Errorf("could not parse lockfile '%s': %w", path, err)
+	}
+
+	if lockfile.Spec != SpecVersion {
+		return nil, fmt.Errorf("unsupported lockfile spec '%s' in '%s'", lockfile.Spec, path)
+	}
+
+	return &lockfile, nil
+}
+
+// Save writes the lockfile to the provided path.
+func (lockfile *Lockfile) Save(path string) error {
+	lockfile.sort()
+
+	var buf bytes.Buffer
+	buf.WriteString("# This file is generated by kraft.  Do not edit it manually and use\n")
+	buf.WriteString("# 'kraft lock update' to refresh it instead.\n")
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(lockfile); err != nil {
+		return fmt.Errorf("could not serialize lockfile: %w", err)
+	}
+
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("could not serialize lockfile: %w", err)
+	}
+
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// Lookup returns the locked resolution of the component with the provided
+// kind and name, or nil if it is not locked.
+func (lockfile *Lockfile) Lookup(kind Kind, name string) *Component {
+	if lockfile == nil {
+		return nil
+	}
+
+	for i := range lockfile.Components {
+		if lockfile.Components[i].Kind == kind && lockfile.Components[i].Name == name {
+			return &lockfile.Components[i]
+		}
+	}
+
+	return nil
+}
+
+// Set adds the locked resolution of the component, replacing any previous
+// resolution of the same component.
+func (lockfile *Lockfile) Set(component Component) {
+	if existing := lockfile.Lookup(component.Kind, component.Name); existing != nil {
+		*existing = component
+		return
+	}
+
+	lockfile.Components = append(lockfile.Components, component)
+}
+
+// Version returns the version with which the component should be retrieved.
+// This is the locked version if the lock still applies to the component as it
+// is requested, and otherwise the requested version.
+func (lockfile *Lockfile) Version(component unikraft.Nameable, source string) string {
+	requested := Component{
+		Kind:    KindOf(component),
+		Name:    component.Name(),
+		Version: component.Version(),
+		Source:  source,
+	}
+
+	if locked := lockfile.Lookup(requested.Kind, requested.Name); locked != nil && locked.Applies(requested) && len(locked.Resolved) > 0 {
+		return locked.Resolved
+	}
+
+	return component.Version()
+}
+
+// Drift returns the human-readable differences between the lockfile and the
+// provided previous lockfile.
+func (lockfile *Lockfile) Drift(previous *Lockfile) []string {
+	var changes []string
+
+	if previous == nil {
+		previous = New()
+	}
+
+	for _, component := range lockfile.Components {
+		locked := previous.Lookup(component.Kind, component.Name)
+		if locked == nil {
+			changes = append(changes, fmt.Sprintf("%s is not locked", component.String()))
+			continue
+		}
+
+		for _, field := range []struct {
+			name     string
+			locked   string
+			resolved string
+		}{
+			{"version", locked.Version, component.Version},
+			{"source", locked.Source, component.Source},
+			{"resolved version", locked.Resolved, component.Resolved},
+			{"commit", locked.Commit, component.Commit},
+			{"sha256", locked.Sha256, component.Sha256},
+			{"digest", locked.Digest, component.Digest},
+		} {
+			// The commit of components is only known when they are on disk.
+			if field.name == "commit" && len(field.resolved) == 0 {
+				continue
+			}
+
+			if field.locked != field.resolved {
+				changes = append(changes, fmt.Sprintf("%s %s %s: locked '%s' but resolved '%s'",
+					component.Kind, component.Name, field.name, field.locked, field.resolved,
+				))
+			}
+		}
+	}
+
+	for _, locked := range previous.Components {
+		if lockfile.Lookup(locked.Kind, locked.Name) == nil {
+			changes = append(changes, fmt.Sprintf("%s is locked but no longer required", locked.String()))
+		}
+	}
+
+	return changes
+}
+
+// sort orders the components by their kind and name.
+func (lockfile *Lockfile) sort() {
+	sort.SliceStable(lockfile.Components, func(i, j int) bool {
+		a, b := lockfile.Components[i], lockfile.Components[j]
+		if a.Kind != b.Kind {
+			if kindOrder[a.Kind] != kindOrder[b.Kind] {
+				return kindOrder[a.Kind] < kindOrder[b.Kind]
+			}
+
+			return a.Kind < b.Kind
+		}
+
+		return a.Name < b.Name
+	})
+}
+
+// DriftError is returned when the resolved components of a project differ from
+// its lockfile.
+type DriftError struct {
+	// Path is the path of the lockfile.
+	Path string
+
+	// Changes are the human-readable differences to the lockfile.
+	Changes []string
+}
+
+// Error implements error.
+func (err *DriftError) Error() string {
+	return fmt.Sprintf("%s is out of date, run 'kraft lock update' to refresh it:\n  - %s",
+		err.Path, strings.Join(err.Changes, "\n  - "),
+	)
+}
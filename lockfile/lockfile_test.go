@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package lockfile
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"kraftkit.sh/unikraft"
+)
+
+type nameable struct {
+	typ     unikraft.ComponentType
+	name    string
+	version string
+}
+
+func (n nameable) Type() unikraft.ComponentType { return n.typ }
+func (n nameable) Name() string                 { return n.name }
+func (n nameable) Version() string              { return n.version }
+func (n nameable) String() string               { return n.name }
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+
+	lockfile := New()
+	lockfile.Set(Component{Kind: KindLibrary, Name: "musl", Version: "stable", Resolved: "0.16.1", Sha256: "abc"})
+	lockfile.Set(Component{Kind: KindCore, Name: "unikraft", Version: "stable", Resolved: "0.16.1", Sha256: "def"})
+
+	if err := lockfile.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := NewLockfileFromPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(lockfile, loaded) {
+		t.Errorf("expected %#v, got %#v", lockfile, loaded)
+	}
+
+	if loaded.Components[0].Kind != KindCore {
+		t.Errorf("expected the core to be written first, got %s", loaded.Components[0].String())
+	}
+}
+
+func TestVersion(t *testing.T) {
+	lockfile := New()
+	lockfile.Set(Component{Kind: KindLibrary, Name: "lwip", Version: "stable", Resolved: "0.16.1"})
+
+	tests := []struct {
+		name      string
+		component nameable
+		source    string
+		expected  string
+	}{
+		{
+			name:      "locked",
+			component: nameable{unikraft.ComponentTypeLib, "lwip", "stable"},
+			expected:  "0.16.1",
+		},
+		{
+			name:      "version changed",
+			component: nameable{unikraft.ComponentTypeLib, "lwip", "staging"},
+			expected:  "staging",
+		},
+		{
+			name:      "source changed",
+			component: nameable{unikraft.ComponentTypeLib, "lwip", "stable"},
+			source:    "https://github.com/unikraft/lib-lwip.git",
+			expected:  "stable",
+		},
+		{
+			name:      "not locked",
+			component: nameable{unikraft.ComponentTypeLib, "musl", "stable"},
+			expected:  "stable",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if version := lockfile.Version(tc.component, tc.source); version != tc.expected {
+				t.Errorf("expected version %s, got %s", tc.expected, version)
+			}
+		})
+	}
+
+	var missing *Lockfile
+	if version := missing.Version(nameable{unikraft.ComponentTypeLib, "lwip", "stable"}, ""); version != "stable" {
+		t.Errorf("expected the requested version without a lockfile, got %s", version)
+	}
+}
+
+func TestDrift(t *testing.T) {
+	previous := New()
+	previous.Set(Component{Kind: KindCore, Name: "unikraft", Version: "stable", Resolved: "0.16.1", Sha256: "abc"})
+	previous.Set(Component{Kind: KindLibrary, Name: "musl", Version: "stable", Resolved: "0.16.1"})
+
+	resolved := New()
+	resolved.Set(Component{Kind: KindCore, Name: "unikraft", Version: "stable", Resolved: "0.16.1", Sha256: "def"})
+	resolved.Set(Component{Kind: KindLibrary, Name: "lwip", Version: "stable", Resolved: "0.16.1"})
+
+	expected := []string{
+		"core unikraft sha256: locked 'abc' but resolved 'def'",
+		"library lwip:stable is not locked",
+		"library musl:stable is locked but no longer required",
+	}
+
+	if changes := resolved.Drift(previous); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected %q, got %q", expected, changes)
+	}
+
+	if changes := previous.Drift(previous); len(changes) != 0 {
+		t.Errorf("expected no drift, got %q", changes)
+	}
+}
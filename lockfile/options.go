@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package lockfile
+
+import (
+	"kraftkit.sh/config"
+	"kraftkit.sh/pack"
+	"kraftkit.sh/unikraft/target"
+)
+
+// ResolveOptions are the options of the resolution of a project.
+type ResolveOptions struct {
+	previous     *Lockfile
+	refresh      []string
+	remote       bool
+	targets      []target.Target
+	platform     string
+	architecture string
+	runtime      pack.Package
+	auths        map[string]config.AuthConfig
+
+	// workdir is the working directory of the project which is resolved.
+	workdir string
+}
+
+// ResolveOption is a function which modifies the options of the resolution of
+// a project.
+type ResolveOption func(*ResolveOptions) error
+
+// WithPrevious sets the previous lockfile, whose locked versions are used for
+// the components whose version and source have not changed.
+func WithPrevious(previous *Lockfile) ResolveOption {
+	return func(opts *ResolveOptions) error {
+		opts.previous = previous
+		return nil
+	}
+}
+
+// WithRefresh sets the names of the components which are resolved to their
+// requested version regardless of the previous lockfile.
+func WithRefresh(names ...string) ResolveOption {
+	return func(opts *ResolveOptions) error {
+		opts.refresh = append(opts.refresh, names...)
+		return nil
+	}
+}
+
+// WithRemote sets whether remote package indexes are queried.
+func WithRemote(remote bool) ResolveOption {
+	return func(opts *ResolveOptions) error {
+		opts.remote = remote
+		return nil
+	}
+}
+
+// WithTargets sets the targets whose target-specific components are resolved.
+func WithTargets(targets ...target.Target) ResolveOption {
+	return func(opts *ResolveOptions) error {
+		opts.targets = targets
+		return nil
+	}
+}
+
+// WithPlatform sets the platform of the runtime of the project.
+func WithPlatform(platform string) ResolveOption {
+	return func(opts *ResolveOptions) error {
+		opts.platform = platform
+		return nil
+	}
+}
+
+// WithArchitecture sets the architecture of the runtime of the project.
+func WithArchitecture(architecture string) ResolveOption {
+	return func(opts *ResolveOptions) error {
+		opts.architecture = architecture
+		return nil
+	}
+}
+
+// WithRuntime sets the package which the runtime of the project has already
+// been resolved to.
+func WithRuntime(runtime pack.Package) ResolveOption {
+	return func(opts *ResolveOptions) error {
+		opts.runtime = runtime
+		return nil
+	}
+}
+
+// WithAuthConfig sets the credentials which are used to query package
+// indexes.
+func WithAuthConfig(auths map[string]config.AuthConfig) ResolveOption {
+	return func(opts *ResolveOptions) error {
+		opts.auths = auths
+		return nil
+	}
+}
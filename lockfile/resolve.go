@@ -0,0 +1,485 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package lockfile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/go-git/go-git/v5"
+
+	"kraftkit.sh/log"
+	"kraftkit.sh/manifest"
+	"kraftkit.sh/pack"
+	"kraftkit.sh/packmanager"
+	"kraftkit.sh/unikraft"
+	"kraftkit.sh/unikraft/app"
+	"kraftkit.sh/unikraft/component"
+)
+
+// Load returns the lockfile of the project in the provided working directory,
+// or nil if the project does not have a lockfile.
+func Load(workdir string) (*Lockfile, error) {
+	lockfile, err := NewLockfileFromPath(PathOf(workdir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return lockfile, nil
+}
+
+// Catalog queries the package manager for the component, using its locked
+// version if the lock still applies to the component.
+func (lockfile *Lockfile) Catalog(ctx context.Context, component unikraft.Nameable, source string, qopts ...packmanager.QueryOption) ([]pack.Package, error) {
+	qopts = append(qopts,
+		packmanager.WithName(component.Name()),
+		packmanager.WithTypes(component.Type()),
+		packmanager.WithSource(source),
+	)
+
+	version := lockfile.Version(component, source)
+
+	packs, err := packmanager.G(ctx).Catalog(ctx, append(qopts, packmanager.WithVersion(version))...)
+	if err != nil {
+		return nil, err
+	}
+
+	// The locked version may not be listed by the package index, e.g. when it
+	// is only known as the latest version of a channel.  In this case the
+	// requested version is used and any difference is reported as drift.
+	if len(packs) == 0 && version != component.Version() {
+		log.G(ctx).
+			WithField("component", unikraft.TypeNameVersion(component)).
+			WithField("locked", version).
+			Debug("locked version not found")
+
+		return packmanager.G(ctx).Catalog(ctx, append(qopts, packmanager.WithVersion(component.Version()))...)
+	}
+
+	return packs, nil
+}
+
+// Resolve resolves the template, the components and the runtime of the
+// project to the exact artifacts which they refer to.  Components are only
+// looked up in the package indexes and are not pulled.
+func Resolve(ctx context.Context, project app.Application, opts ...ResolveOption) (*Lockfile, error) {
+	ropts := ResolveOptions{}
+	for _, opt := range opts {
+		if err := opt(&ropts); err != nil {
+			return nil, err
+		}
+	}
+
+	ropts.workdir = project.WorkingDir()
+
+	lockfile := New()
+
+	if template := project.Template(); template != nil {
+		component, err := ropts.resolve(ctx, KindTemplate, template, template.Source(), template.Path(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		lockfile.Set(component)
+	}
+
+	components, err := project.Components(ctx, ropts.targets...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get list of components: %w", err)
+	}
+
+	pinned, err := ropts.pin(ctx, components)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range components {
+		component, err := ropts.resolve(ctx, KindOf(c), c, c.Source(), c.Path(), pinned)
+		if err != nil {
+			return nil, err
+		}
+
+		lockfile.Set(component)
+	}
+
+	if runtime := project.Runtime(); runtime != nil {
+		component := Component{
+			Kind:    KindRuntime,
+			Name:    runtime.Name(),
+			Version: runtime.Version(),
+			Source:  runtime.Source(),
+		}
+
+		p := ropts.runtime
+		if p == nil {
+			p, err = ropts.runtimePackage(ctx, runtime)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if err := resolvePackage(ctx, &component, p); err != nil {
+			return nil, err
+		}
+
+		lockfile.Set(component)
+	}
+
+	return lockfile, nil
+}
+
+// Pin picks the versions of the core and the libraries of the project before
+// any of them is fetched.  Semantic version constraints are resolved to the
+// newest versions which are consistent with each other, whereas other versions
+// keep their locked version.  The returned lockfile only records the picked
+// versions and is meant to be used with Catalog and Version.  Conflicts are
+// returned as a *manifest.ConflictError.
+func Pin(ctx context.Context, project app.Application, opts ...ResolveOption) (*Lockfile, error) {
+	ropts := ResolveOptions{}
+	for _, opt := range opts {
+		if err := opt(&ropts); err != nil {
+			return nil, err
+		}
+	}
+
+	ropts.workdir = project.WorkingDir()
+
+	components, err := project.Components(ctx, ropts.targets...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get list of components: %w", err)
+	}
+
+	return ropts.pin(ctx, components)
+}
+
+// Sync resolves the project and writes its lockfile if the resolution differs
+// from the existing lockfile.  When locked is set, any difference is returned
+// as a *DriftError and the lockfile is left untouched.
+func Sync(ctx context.Context, project app.Application, locked bool, opts ...ResolveOption) (*Lockfile, error) {
+	path := PathOf(project.WorkingDir())
+
+	previous, err := Load(project.WorkingDir())
+	if err != nil {
+		return nil, err
+	}
+
+	if locked {
+		if previous == nil {
+			return nil, fmt.Errorf("cannot use --locked without %s, run 'kraft lock update' to create it", path)
+		}
+
+		return Check(ctx, project, previous, opts...)
+	}
+
+	lockfile, err := Resolve(ctx, project, append([]ResolveOption{WithPrevious(previous)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := lockfile.Drift(previous)
+	if len(changes) == 0 {
+		return lockfile, nil
+	}
+
+	for _, change := range changes {
+		log.G(ctx).
+			WithField("lockfile", path).
+			Debug(change)
+	}
+
+	if err := lockfile.Save(path); err != nil {
+		return nil, fmt.Errorf("could not write lockfile: %w", err)
+	}
+
+	return lockfile, nil
+}
+
+// Check resolves the project and returns a *DriftError if the resolution
+// differs from the provided lockfile.  Components whose version or source in
+// the Kraftfile no longer match their lock are reported before any component
+// is looked up, such that nothing is retrieved for a project which drifted.
+func Check(ctx context.Context, project app.Application, previous *Lockfile, opts ...ResolveOption) (*Lockfile, error) {
+	ropts := ResolveOptions{}
+	for _, opt := range opts {
+		if err := opt(&ropts); err != nil {
+			return nil, err
+		}
+	}
+
+	path := PathOf(project.WorkingDir())
+
+	components, err := project.Components(ctx, ropts.targets...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get list of components: %w", err)
+	}
+
+	var requests []Component
+	if template := project.Template(); template != nil {
+		requests = append(requests, Component{
+			Kind:    KindTemplate,
+			Name:    template.Name(),
+			Version: template.Version(),
+			Source:  template.Source(),
+		})
+	}
+
+	for _, c := range components {
+		requests = append(requests, Component{
+			Kind:    KindOf(c),
+			Name:    c.Name(),
+			Version: c.Version(),
+			Source:  c.Source(),
+		})
+	}
+
+	if runtime := project.Runtime(); runtime != nil {
+		requests = append(requests, Component{
+			Kind:    KindRuntime,
+			Name:    runtime.Name(),
+			Version: runtime.Version(),
+			Source:  runtime.Source(),
+		})
+	}
+
+	// Components take their locked resolution, such that only the changes to
+	// the Kraftfile are reported.
+	requested := New()
+	for _, component := range requests {
+		if locked := previous.Lookup(component.Kind, component.Name); locked != nil {
+			version, source := component.Version, component.Source
+			component = *locked
+			component.Version, component.Source = version, source
+		}
+
+		requested.Set(component)
+	}
+
+	if changes := requested.Drift(previous); len(changes) > 0 {
+		return nil, &DriftError{
+			Path:    path,
+			Changes: changes,
+		}
+	}
+
+	lockfile, err := Resolve(ctx, project, append([]ResolveOption{WithPrevious(previous)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if changes := lockfile.Drift(previous); len(changes) > 0 {
+		return nil, &DriftError{
+			Path:    path,
+			Changes: changes,
+		}
+	}
+
+	return lockfile, nil
+}
+
+// pin returns the lockfile of the picked versions of the components.
+func (ropts *ResolveOptions) pin(ctx context.Context, components []component.Component) (*Lockfile, error) {
+	pinned := New()
+
+	var reqs []manifest.Requirement
+	var pins []Component
+	for _, c := range components {
+		if c.Type() != unikraft.ComponentTypeCore && c.Type() != unikraft.ComponentTypeLib {
+			continue
+		}
+
+		// Components on disk are used as-is and are not resolved.
+		if f, err := os.Stat(c.Source()); len(c.Source()) > 0 && err == nil && f.IsDir() {
+			continue
+		}
+
+		if vendored, ok := unikraft.VendoredComponent(ropts.workdir, c.Type(), c.Name()); ok && c.Path() == vendored {
+			continue
+		}
+
+		previous := ropts.previous
+		if slices.Contains(ropts.refresh, c.Name()) {
+			previous = nil
+		}
+
+		reqs = append(reqs, manifest.Requirement{
+			Type:    c.Type(),
+			Name:    c.Name(),
+			Version: previous.Version(c, c.Source()),
+			Source:  c.Source(),
+		})
+
+		pins = append(pins, Component{
+			Kind:    KindOf(c),
+			Name:    c.Name(),
+			Version: c.Version(),
+			Source:  c.Source(),
+		})
+	}
+
+	if len(reqs) == 0 {
+		return pinned, nil
+	}
+
+	versions, err := manifest.ResolveVersions(ctx, reqs,
+		packmanager.WithRemote(ropts.remote),
+		packmanager.WithAuthConfig(ropts.auths),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pin := range pins {
+		pin.Resolved = versions[pin.Name]
+		pinned.Set(pin)
+	}
+
+	return pinned, nil
+}
+
+// resolve returns the locked resolution of the component.  The component is
+// looked up by its pinned version if it is provided.
+func (ropts *ResolveOptions) resolve(ctx context.Context, kind Kind, c unikraft.Nameable, source, path string, pinned *Lockfile) (Component, error) {
+	component := Component{
+		Kind:    kind,
+		Name:    c.Name(),
+		Version: c.Version(),
+		Source:  source,
+	}
+
+	// Components whose source is a directory on disk are used as-is, e.g. when
+	// the component is developed alongside the project.
+	if f, err := os.Stat(source); len(source) > 0 && err == nil && f.IsDir() {
+		component.Commit = commitOf(source)
+		return component, nil
+	}
+
+	// Vendored components are used as-is and keep their locked resolution, such
+	// that nothing is retrieved for them.
+	if vendored, ok := unikraft.VendoredComponent(ropts.workdir, c.Type(), c.Name()); ok && path == vendored {
+		if locked := ropts.previous.Lookup(kind, component.Name); locked != nil && locked.Applies(component) {
+			return *locked, nil
+		}
+
+		return component, nil
+	}
+
+	previous := ropts.previous
+	if slices.Contains(ropts.refresh, c.Name()) {
+		previous = nil
+	}
+
+	query := previous
+	if pinned.Lookup(kind, c.Name()) != nil {
+		query = pinned
+	}
+
+	packs, err := query.Catalog(ctx, c, source,
+		packmanager.WithRemote(ropts.remote),
+		packmanager.WithAuthConfig(ropts.auths),
+	)
+	if err != nil {
+		return component, fmt.Errorf("could not resolve %s: %w", unikraft.TypeNameVersion(c), err)
+	}
+
+	if len(packs) == 0 {
+		return component, fmt.Errorf("could not find: %s", unikraft.TypeNameVersion(c))
+	} else if len(packs) > 1 {
+		return component, fmt.Errorf("too many options for %s", unikraft.TypeNameVersion(c))
+	}
+
+	if err := resolvePackage(ctx, &component, packs[0]); err != nil {
+		return component, err
+	}
+
+	// Components which are cloned with Git may track a branch, in which case the
+	// commit which is checked out is recorded.  Components which have not been
+	// retrieved yet keep their previously locked commit.
+	if len(component.Commit) == 0 {
+		component.Commit = commitOf(path)
+	}
+
+	if locked := previous.Lookup(kind, component.Name); len(component.Commit) == 0 && locked != nil && locked.Applies(component) && locked.Resolved == component.Resolved {
+		component.Commit = locked.Commit
+	}
+
+	return component, nil
+}
+
+// runtimePackage returns the package of the runtime of the project.
+func (ropts *ResolveOptions) runtimePackage(ctx context.Context, runtime unikraft.Nameable) (pack.Package, error) {
+	qopts := []packmanager.QueryOption{
+		packmanager.WithName(runtime.Name()),
+		packmanager.WithVersion(runtime.Version()),
+		packmanager.WithPlatform(ropts.platform),
+		packmanager.WithArchitecture(ropts.architecture),
+		packmanager.WithAuthConfig(ropts.auths),
+	}
+
+	packs, err := packmanager.G(ctx).Catalog(ctx, append(qopts, packmanager.WithRemote(ropts.remote))...)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve runtime %s: %w", runtime.String(), err)
+	}
+
+	if len(packs) == 0 && !ropts.remote {
+		packs, err = packmanager.G(ctx).Catalog(ctx, append(qopts, packmanager.WithRemote(true))...)
+		if err != nil {
+			return nil, fmt.Errorf("could not resolve runtime %s: %w", runtime.String(), err)
+		}
+	}
+
+	if len(packs) == 0 {
+		return nil, fmt.Errorf("could not find runtime '%s:%s'", runtime.Name(), runtime.Version())
+	} else if len(packs) > 1 {
+		return nil, fmt.Errorf("multiple packages match runtime '%s:%s', select one with --plat and --arch", runtime.Name(), runtime.Version())
+	}
+
+	return packs[0], nil
+}
+
+// resolvePackage records the exact artifact which the package refers to.
+func resolvePackage(ctx context.Context, component *Component, p pack.Package) error {
+	resolver, ok := p.(pack.Resolver)
+	if !ok {
+		return fmt.Errorf("cannot resolve %s: unsupported package format '%s'", p.String(), p.Format())
+	}
+
+	resolution, err := resolver.Resolve(ctx)
+	if err != nil {
+		return err
+	}
+
+	component.Resolved = resolution.Version
+	component.Resource = resolution.Resource
+	component.Commit = resolution.Commit
+	component.Sha256 = resolution.Sha256
+	component.Digest = resolution.Digest
+
+	return nil
+}
+
+// commitOf returns the Git SHA which is checked out at the provided path, if
+// it is a Git repository.
+func commitOf(path string) string {
+	if len(path) == 0 {
+		return ""
+	}
+
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return ""
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return ""
+	}
+
+	return head.Hash().String()
+}
@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package lockfile
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"kraftkit.sh/pack"
+	"kraftkit.sh/packmanager"
+	"kraftkit.sh/unikraft/app"
+	"kraftkit.sh/unikraft/lib"
+)
+
+// catalogCounter is a package manager which counts the queries of its catalog
+// and finds nothing.
+type catalogCounter struct {
+	packmanager.PackageManager
+	queries int
+}
+
+func (pm *catalogCounter) Catalog(context.Context, ...packmanager.QueryOption) ([]pack.Package, error) {
+	pm.queries++
+	return nil, nil
+}
+
+// newProject returns a project in the provided directory which requires musl
+// at the provided version.
+func newProject(t *testing.T, workdir, version string) app.Application {
+	t.Helper()
+
+	musl, err := lib.NewLibraryConfigFromOptions(
+		lib.WithName("musl"),
+		lib.WithVersion(version),
+		lib.WithSource("https://github.com/unikraft/lib-musl.git"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := app.NewApplicationFromOptions(
+		app.WithName("helloworld"),
+		app.WithWorkingDir(workdir),
+		app.WithLibraries(map[string]*lib.LibraryConfig{"musl": musl}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return project
+}
+
+func TestSyncLocked(t *testing.T) {
+	pm := &catalogCounter{}
+	ctx := packmanager.WithPackageManager(context.Background(), pm)
+	workdir := t.TempDir()
+
+	if _, err := Sync(ctx, newProject(t, workdir, "stable"), true); err == nil {
+		t.Errorf("expected an error without a lockfile")
+	}
+
+	previous := New()
+	previous.Set(Component{
+		Kind:     KindLibrary,
+		Name:     "musl",
+		Version:  "stable",
+		Source:   "https://github.com/unikraft/lib-musl.git",
+		Resolved: "0.16.1",
+		Sha256:   "abc",
+	})
+
+	if err := previous.Save(PathOf(workdir)); err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := os.ReadFile(PathOf(workdir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Sync(ctx, newProject(t, workdir, "staging"), true)
+
+	var drift *DriftError
+	if !errors.As(err, &drift) {
+		t.Fatalf("expected a drift error, got %v", err)
+	}
+
+	expected := []string{
+		"library musl version: locked 'stable' but resolved 'staging'",
+	}
+
+	if !reflect.DeepEqual(drift.Changes, expected) {
+		t.Errorf("expected %q, got %q", expected, drift.Changes)
+	}
+
+	// Nothing is looked up, let alone retrieved, for a project which drifted.
+	if pm.queries > 0 {
+		t.Errorf("expected no catalog queries, got %d", pm.queries)
+	}
+
+	after, err := os.ReadFile(PathOf(workdir))
+	if err != nil {
+		t.Fatal(err)
+	} else if string(after) != string(before) {
+		t.Errorf("expected the lockfile to be left untouched")
+	}
+}
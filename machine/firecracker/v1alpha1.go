@@ -27,10 +27,12 @@ import (
 	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
 	"kraftkit.sh/config"
 	"kraftkit.sh/exec"
+	"kraftkit.sh/initrd"
 	"kraftkit.sh/internal/logtail"
 	"kraftkit.sh/internal/run"
 	"kraftkit.sh/log"
 	"kraftkit.sh/machine/network/macaddr"
+	"kraftkit.sh/machine/volume/disk"
 	"kraftkit.sh/unikraft/export/v0/posixenviron"
 	"kraftkit.sh/unikraft/export/v0/ukargparse"
 	"kraftkit.sh/unikraft/export/v0/uknetdev"
@@ -106,10 +108,72 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 	}
 
 	var fstab []string
+	var drives []*models.Drive
+
+	// Root file system images are attached as the first block device and
+	// mounted in place instead of being extracted into memory.
+	var rootfsFormat initrd.Format
+	if len(machine.Status.InitrdPath) > 0 {
+		var err error
+		rootfsFormat, err = initrd.DetectFormat(machine.Status.InitrdPath)
+		if err != nil {
+			return machine, fmt.Errorf("could not determine rootfs format: %w", err)
+		}
+	}
+
+	initrdPath := machine.Status.InitrdPath
+	if rootfsFormat.BlockDevice() {
+		initrdPath = ""
+		drives = append(drives, &models.Drive{
+			DriveID:      firecracker.String(disk.BlockDeviceName(len(drives))),
+			PathOnHost:   firecracker.String(machine.Status.InitrdPath),
+			IsRootDevice: firecracker.Bool(false),
+			IsReadOnly:   firecracker.Bool(true),
+		})
+	}
 
 	for _, vol := range machine.Spec.Volumes {
 		switch vol.Spec.Driver {
+		case disk.FilesystemExt4.String(), disk.FilesystemErofs.String():
+			cfg, err := disk.DriverConfigFromStatus(vol.Status.DriverConfig)
+			if err != nil {
+				return machine, fmt.Errorf("volume %s: %w", vol.Name, err)
+			}
+
+			var flags string
+			if vol.Spec.ReadOnly {
+				flags = vfscore.FstabFlagReadOnly
+			}
+
+			fstab = append(fstab, vfscore.NewFstabEntry(
+				disk.BlockDeviceName(len(drives)),
+				vol.Spec.Destination,
+				vol.Spec.Driver,
+				flags,
+				"",
+				"mkmp",
+			).String())
+
+			drives = append(drives, &models.Drive{
+				DriveID:      firecracker.String(disk.BlockDeviceName(len(drives))),
+				PathOnHost:   firecracker.String(cfg.Image),
+				IsRootDevice: firecracker.Bool(false),
+				IsReadOnly:   firecracker.Bool(vol.Spec.ReadOnly),
+			})
+
 		case "initrd":
+			if rootfsFormat.BlockDevice() {
+				fstab = append(fstab, vfscore.NewFstabEntry(
+					disk.BlockDeviceName(0),
+					vol.Spec.Destination,
+					rootfsFormat.String(),
+					vfscore.FstabFlagReadOnly,
+					"",
+					"",
+				).String())
+				continue
+			}
+
 			fstab = append(fstab, vfscore.NewFstabEntry(
 				"initrd0",
 				vol.Spec.Destination,
@@ -241,6 +305,12 @@ watch:
 		return machine, err
 	}
 
+	for _, drive := range drives {
+		if _, err := client.PutGuestDriveByID(ctx, *drive.DriveID, drive); err != nil {
+			return machine, fmt.Errorf("could not attach drive %s: %w", *drive.DriveID, err)
+		}
+	}
+
 	if len(fstab) > 0 {
 		kernelArgs = append(kernelArgs,
 			vfscore.ParamVfsFstab.WithValue(fstab),
@@ -329,7 +399,7 @@ watch:
 	// Set the boot source configuration.
 	if _, err := client.PutGuestBootSource(ctx, &models.BootSource{
 		KernelImagePath: &machine.Status.KernelPath,
-		InitrdPath:      machine.Status.InitrdPath,
+		InitrdPath:      initrdPath,
 		BootArgs:        run.BootArgsPrepare(args...),
 	}); err != nil {
 		return machine, err
@@ -15,6 +15,7 @@ type QemuConfig struct {
 	Daemonize  bool                   `flag:"-daemonize"   json:"daemonize,omitempty"`
 	Devices    []QemuDevice           `flag:"-device"      json:"device,omitempty"`
 	Display    QemuDisplay            `flag:"-display"     json:"display,omitempty"`
+	Drives     []QemuDrive            `flag:"-drive"       json:"drive,omitempty"`
 	EnableKVM  bool                   `flag:"-enable-kvm"  json:"enable_kvm,omitempty"`
 	FsDevs     []QemuFsDev            `flag:"-fsdev"       json:"fsdev,omitempty"`
 	InitRd     string                 `flag:"-initrd"      json:"initrd,omitempty"`
@@ -30,6 +31,8 @@ type QemuConfig struct {
 	NoReboot   bool                   `flag:"-no-reboot"   json:"no_reboot,omitempty"`
 	NoShutdown bool                   `flag:"-no-shutdown" json:"no_shutdown,omitempty"`
 	NoStart    bool                   `flag:"-S"           json:"no_start,omitempty"`
+	Numa       QemuNumaNode           `flag:"-numa"        json:"numa,omitempty"`
+	Objects    []QemuObject           `flag:"-object"      json:"object,omitempty"`
 	Parallel   QemuHostCharDev        `flag:"-parallel"    json:"parallel,omitempty"`
 	PidFile    string                 `flag:"-pidfile"     json:"pidfile,omitempty"`
 	QMP        []QemuHostCharDev      `flag:"-qmp"         json:"qmp,omitempty"`
@@ -121,6 +124,18 @@ func WithDisplay(display QemuDisplay) QemuOption {
 	}
 }
 
+func WithDrive(drive QemuDrive) QemuOption {
+	return func(qc *QemuConfig) error {
+		if qc.Drives == nil {
+			qc.Drives = make([]QemuDrive, 0)
+		}
+
+		qc.Drives = append(qc.Drives, drive)
+
+		return nil
+	}
+}
+
 func WithEnableKVM(enableKVM bool) QemuOption {
 	return func(qc *QemuConfig) error {
 		qc.EnableKVM = enableKVM
@@ -236,6 +251,25 @@ func WithNoStart(noStart bool) QemuOption {
 	}
 }
 
+func WithNuma(numa QemuNumaNode) QemuOption {
+	return func(qc *QemuConfig) error {
+		qc.Numa = numa
+		return nil
+	}
+}
+
+func WithObject(object QemuObject) QemuOption {
+	return func(qc *QemuConfig) error {
+		if qc.Objects == nil {
+			qc.Objects = make([]QemuObject, 0)
+		}
+
+		qc.Objects = append(qc.Objects, object)
+
+		return nil
+	}
+}
+
 func WithParallel(chardev QemuHostCharDev) QemuOption {
 	return func(qc *QemuConfig) error {
 		qc.Parallel = chardev
@@ -19,7 +19,7 @@ func init() {
 	// Character devices
 	// gob.Register(QemuCharDevNull{})
 	// gob.Register(QemuCharDevSocketTCP{})
-	// gob.Register(QemuCharDevSocketUnix{})
+	gob.Register(QemuCharDevSocketUnix{})
 	// gob.Register(QemuCharDevUdp{})
 	// gob.Register(QemuCharDevVirtualConsole{})
 	// gob.Register(QemuCharDevRingBuf{})
@@ -388,7 +388,7 @@ func init() {
 	// gob.Register(QemuDeviceVhostUserBlkPciNonTransitional{})
 	// gob.Register(QemuDeviceVhostUserBlkPciTransitional{})
 	// gob.Register(QemuDeviceVhostUserFsDevice{})
-	// gob.Register(QemuDeviceVhostUserFsPci{})
+	gob.Register(QemuDeviceVhostUserFsPci{})
 	// gob.Register(QemuDeviceVhostUserScsi{})
 	// gob.Register(QemuDeviceVhostUserScsiPci{})
 	// gob.Register(QemuDeviceVhostUserScsiPciNonTransitional{})
@@ -398,7 +398,7 @@ func init() {
 	// gob.Register(QemuDeviceVirtio9pPciNonTransitional{})
 	// gob.Register(QemuDeviceVirtio9pPciTransitional{})
 	// gob.Register(QemuDeviceVirtioBlkDevice{})
-	// gob.Register(QemuDeviceVirtioBlkPci{})
+	gob.Register(QemuDeviceVirtioBlkPci{})
 	// gob.Register(QemuDeviceVirtioBlkPciNonTransitional{})
 	// gob.Register(QemuDeviceVirtioBlkPciTransitional{})
 	// gob.Register(QemuDeviceVirtioScsiDevice{})
@@ -472,6 +472,13 @@ func init() {
 	// gob.Register(QemuFsDevSynth{})
 	gob.Register(QemuFsDevLocalSecurityModelPassthrough)
 
+	// Drives
+	gob.Register(QemuDriveFile{})
+
+	// Objects
+	gob.Register(QemuObjectMemoryBackendFile{})
+	gob.Register(QemuObjectMemoryBackendMemfd{})
+
 	// CLI configuration
 	gob.Register(QemuConfig{})
 }
@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"fmt"
+	"strings"
+)
+
+type QemuDrive interface {
+	fmt.Stringer
+}
+
+type QemuDriveInterface string
+
+const (
+	QemuDriveInterfaceNone   = QemuDriveInterface("none")
+	QemuDriveInterfaceVirtio = QemuDriveInterface("virtio")
+)
+
+type QemuDriveFormat string
+
+const (
+	QemuDriveFormatRaw   = QemuDriveFormat("raw")
+	QemuDriveFormatQcow2 = QemuDriveFormat("qcow2")
+)
+
+type QemuDriveFile struct {
+	Id        string             `json:"id,omitempty"`
+	File      string             `json:"file,omitempty"`
+	Interface QemuDriveInterface `json:"if,omitempty"`
+	Format    QemuDriveFormat    `json:"format,omitempty"`
+	Readonly  bool               `json:"readonly,omitempty"`
+}
+
+// String returns a QEMU command-line compatible drive string with the format:
+// file=file,id=id[,if=type][,format=f][,readonly=on|off]
+func (d QemuDriveFile) String() string {
+	var ret strings.Builder
+
+	ret.WriteString("file=")
+	ret.WriteString(d.File)
+
+	if len(d.Id) > 0 {
+		ret.WriteString(",id=")
+		ret.WriteString(d.Id)
+	}
+	if len(d.Interface) > 0 {
+		ret.WriteString(",if=")
+		ret.WriteString(string(d.Interface))
+	}
+	if len(d.Format) > 0 {
+		ret.WriteString(",format=")
+		ret.WriteString(string(d.Format))
+	}
+	if d.Readonly {
+		ret.WriteString(",readonly=on")
+	}
+
+	return ret.String()
+}
@@ -83,7 +83,7 @@ type QemuFsDevLocal struct {
 
 // String returns a QEMU command-line compatible fsdev string with the format:
 // local,id=id,path=path,security_model=mapped-xattr|mapped-file|passthrough|none
-// [,writeout=immediate][,readonly][,fmode=fmode][,dmode=dmode]
+// [,writeout=immediate][,readonly=on][,fmode=fmode][,dmode=dmode]
 // [[,throttling.bps-total=b]|[[,throttling.bps-read=r][,throttling.bps-write=w]]]
 // [[,throttling.iops-total=i]|[[,throttling.iops-read=r][,throttling.iops-write=w]]]
 // [[,throttling.bps-total-max=bm]|[[,throttling.bps-read-max=rm][,throttling.bps-write-max=wm]]]
@@ -105,7 +105,7 @@ func (fd QemuFsDevLocal) String() string {
 		ret.WriteString(fd.Writeout)
 	}
 	if fd.Readonly {
-		ret.WriteString(",readonly")
+		ret.WriteString(",readonly=on")
 	}
 	if len(fd.Fmode) > 0 {
 		ret.WriteString(",fmode=")
@@ -225,7 +225,7 @@ func (fd QemuFsDevProxy) String() string {
 		ret.WriteString(fd.Writeout)
 	}
 	if fd.Readonly {
-		ret.WriteString(",readonly")
+		ret.WriteString(",readonly=on")
 	}
 
 	return ret.String()
@@ -0,0 +1,28 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import "strings"
+
+type QemuNumaNode struct {
+	// MemDev is the ID of the memory backend object which backs the node.
+	MemDev string `json:"memdev,omitempty"`
+}
+
+// String returns a QEMU command-line compatible -numa flag value in the
+// format:
+// node,memdev=id
+func (qn QemuNumaNode) String() string {
+	if len(qn.MemDev) == 0 {
+		return ""
+	}
+
+	var ret strings.Builder
+
+	ret.WriteString("node,memdev=")
+	ret.WriteString(qn.MemDev)
+
+	return ret.String()
+}
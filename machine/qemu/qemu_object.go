@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package qemu
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type QemuObject interface {
+	fmt.Stringer
+}
+
+type QemuObjectType string
+
+const (
+	QemuObjectTypeMemoryBackendFile  = QemuObjectType("memory-backend-file")
+	QemuObjectTypeMemoryBackendMemfd = QemuObjectType("memory-backend-memfd")
+)
+
+type QemuObjectMemoryBackendMemfd struct {
+	Id    string         `json:"id,omitempty"`
+	Size  uint64         `json:"size,omitempty"`
+	Unit  QemuMemoryUnit `json:"unit,omitempty"`
+	Share bool           `json:"share,omitempty"`
+}
+
+// String returns a QEMU command-line compatible object string with the format:
+// memory-backend-memfd,id=id,size=size[,share=on|off]
+func (obj QemuObjectMemoryBackendMemfd) String() string {
+	if len(obj.Id) == 0 {
+		return ""
+	}
+
+	var ret strings.Builder
+
+	ret.WriteString(string(QemuObjectTypeMemoryBackendMemfd))
+	ret.WriteString(",id=")
+	ret.WriteString(obj.Id)
+	ret.WriteString(",size=")
+	ret.WriteString(strconv.FormatUint(obj.Size, 10))
+	ret.WriteString(string(obj.Unit))
+
+	if obj.Share {
+		ret.WriteString(",share=on")
+	} else {
+		ret.WriteString(",share=off")
+	}
+
+	return ret.String()
+}
+
+type QemuObjectMemoryBackendFile struct {
+	Id      string         `json:"id,omitempty"`
+	Size    uint64         `json:"size,omitempty"`
+	Unit    QemuMemoryUnit `json:"unit,omitempty"`
+	MemPath string         `json:"mem_path,omitempty"`
+	Share   bool           `json:"share,omitempty"`
+}
+
+// String returns a QEMU command-line compatible object string with the format:
+// memory-backend-file,id=id,size=size,mem-path=dir[,share=on|off]
+func (obj QemuObjectMemoryBackendFile) String() string {
+	if len(obj.Id) == 0 {
+		return ""
+	}
+
+	var ret strings.Builder
+
+	ret.WriteString(string(QemuObjectTypeMemoryBackendFile))
+	ret.WriteString(",id=")
+	ret.WriteString(obj.Id)
+	ret.WriteString(",size=")
+	ret.WriteString(strconv.FormatUint(obj.Size, 10))
+	ret.WriteString(string(obj.Unit))
+	ret.WriteString(",mem-path=")
+	ret.WriteString(obj.MemPath)
+
+	if obj.Share {
+		ret.WriteString(",share=on")
+	} else {
+		ret.WriteString(",share=off")
+	}
+
+	return ret.String()
+}
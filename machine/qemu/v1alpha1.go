@@ -30,11 +30,15 @@ import (
 	machinev1alpha1 "kraftkit.sh/api/machine/v1alpha1"
 	"kraftkit.sh/config"
 	"kraftkit.sh/exec"
+	"kraftkit.sh/initrd"
 	"kraftkit.sh/internal/logtail"
 	"kraftkit.sh/internal/retrytimeout"
+	"kraftkit.sh/log"
 	"kraftkit.sh/machine/network/macaddr"
 	"kraftkit.sh/machine/qemu/qmp"
 	qmpapi "kraftkit.sh/machine/qemu/qmp/v7alpha2"
+	"kraftkit.sh/machine/volume/disk"
+	"kraftkit.sh/machine/volume/virtiofs"
 	"kraftkit.sh/unikraft/export/v0/posixenviron"
 	"kraftkit.sh/unikraft/export/v0/ukargparse"
 	"kraftkit.sh/unikraft/export/v0/uknetdev"
@@ -66,6 +70,93 @@ func NewMachineV1alpha1Service(ctx context.Context, opts ...any) (machinev1alpha
 
 // Create implements kraftkit.sh/api/machine/v1alpha1.MachineService.Create
 func (service *machineV1alpha1Service) Create(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
+	ret, err := service.create(ctx, machine)
+	if err != nil && len(machine.Status.StateDir) > 0 {
+		// Do not leave behind the daemons of the volumes of a machine which could
+		// not be created.
+		if err2 := virtiofs.StopDaemons(machine.Status.StateDir); err2 != nil {
+			err = errors.Join(err, err2)
+		}
+	}
+
+	return ret, err
+}
+
+// virtiofsDaemon returns the virtiofsd instance which serves the volume of the
+// machine at the provided index.
+func virtiofsDaemon(machine *machinev1alpha1.Machine, i int) virtiofs.Daemon {
+	return virtiofs.Daemon{
+		Source:     machine.Spec.Volumes[i].Spec.Source,
+		SocketPath: filepath.Join(machine.Status.StateDir, fmt.Sprintf("virtiofsd%d.sock", i+1)),
+		PidFile:    filepath.Join(machine.Status.StateDir, fmt.Sprintf("virtiofsd%d.pid", i+1)),
+		LogFile:    filepath.Join(machine.Status.StateDir, fmt.Sprintf("virtiofsd%d.log", i+1)),
+		ReadOnly:   machine.Spec.Volumes[i].Spec.ReadOnly,
+	}
+}
+
+// daemonSupervisionInterval is the interval at which the virtiofsd instances
+// of a watched machine are checked for having exited.
+const daemonSupervisionInterval = 2 * time.Second
+
+// startDaemons starts the virtiofsd instances of the machine which are not
+// running, e.g. because the machine was stopped or because they crashed.
+func startDaemons(ctx context.Context, machine *machinev1alpha1.Machine) error {
+	for i, vol := range machine.Spec.Volumes {
+		if vol.Spec.Driver != "virtiofs" {
+			continue
+		}
+
+		daemon := virtiofsDaemon(machine, i)
+		if daemon.Running() {
+			continue
+		}
+
+		log.G(ctx).
+			WithField("volume", vol.Name).
+			Debugf("starting %s", virtiofs.VirtiofsdBin)
+
+		if err := daemon.Start(ctx); err != nil {
+			return fmt.Errorf("volume %s: %w", vol.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// superviseDaemons periodically restarts the virtiofsd instances of the
+// machine which have exited whilst the VMM is alive, such that it can
+// reconnect to them, until either the VMM exits or the context is cancelled.
+func superviseDaemons(ctx context.Context, machine *machinev1alpha1.Machine, pidFile string, errs chan<- error) {
+	ticker := time.NewTicker(daemonSupervisionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		process, err := processFromPidFile(pidFile)
+		if err != nil {
+			return
+		}
+
+		if running, err := process.IsRunning(); err != nil || !running {
+			return
+		}
+
+		if err := startDaemons(ctx, machine); err != nil {
+			select {
+			case errs <- err:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+func (service *machineV1alpha1Service) create(ctx context.Context, machine *machinev1alpha1.Machine) (*machinev1alpha1.Machine, error) {
 	if machine.Status.KernelPath == "" {
 		return machine, fmt.Errorf("empty kernel path")
 	}
@@ -228,8 +319,34 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 		WithParallel(QemuHostCharDevNone{}),
 	}
 
-	// TODO: Parse Rootfs types
+	var rootfsFormat initrd.Format
 	if len(machine.Status.InitrdPath) > 0 {
+		rootfsFormat, err = initrd.DetectFormat(machine.Status.InitrdPath)
+		if err != nil {
+			return machine, fmt.Errorf("could not determine rootfs format: %w", err)
+		}
+	}
+
+	var nblk int
+
+	// Root file system images are attached as the first block device and
+	// mounted in place instead of being extracted into memory.
+	if rootfsFormat.BlockDevice() {
+		qopts = append(qopts,
+			WithDrive(QemuDriveFile{
+				Id:        "hrootfs",
+				File:      machine.Status.InitrdPath,
+				Interface: QemuDriveInterfaceNone,
+				Format:    QemuDriveFormatRaw,
+				Readonly:  true,
+			}),
+			WithDevice(QemuDeviceVirtioBlkPci{
+				Drive: "hrootfs",
+			}),
+		)
+
+		nblk++
+	} else if len(machine.Status.InitrdPath) > 0 {
 		qopts = append(qopts,
 			WithInitRd(machine.Status.InitrdPath),
 		)
@@ -323,18 +440,35 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 	}
 
 	var fstab []string
+	var nvirtiofs int
 
 	for i, vol := range machine.Spec.Volumes {
+		var flags string
+		if vol.Spec.ReadOnly {
+			flags = vfscore.FstabFlagReadOnly
+		}
+
 		switch vol.Spec.Driver {
 		case "9pfs":
 			hvirtioid := fmt.Sprintf("hvirtio%d", i+1)
 			mounttag := fmt.Sprintf("fs%d", i+1)
+			fsdev := QemuFsDevLocal{
+				SecurityModel: QemuFsDevLocalSecurityModelPassthrough,
+				Id:            hvirtioid,
+				Path:          vol.Spec.Source,
+				Readonly:      vol.Spec.ReadOnly,
+			}
+
+			// QEMU only applies the creation mode of files and directories when
+			// their permissions are mapped rather than passed through.
+			if len(vol.Spec.Mode) > 0 {
+				fsdev.SecurityModel = QemuFsDevLocalSecurityModelMappedXattr
+				fsdev.Fmode = vol.Spec.Mode
+				fsdev.Dmode = vol.Spec.Mode
+			}
+
 			qopts = append(qopts,
-				WithFsDevice(QemuFsDevLocal{
-					SecurityModel: QemuFsDevLocalSecurityModelPassthrough,
-					Id:            hvirtioid,
-					Path:          vol.Spec.Source,
-				}),
+				WithFsDevice(fsdev),
 				WithDevice(QemuDeviceVirtio9pPci{
 					Fsdev:    hvirtioid,
 					MountTag: mounttag,
@@ -345,15 +479,90 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 				mounttag,
 				vol.Spec.Destination,
 				vol.Spec.Driver,
-				// TODO(nderjung): Options (such as ro/rw) are yet supported by
-				// Unikraft:
-				"",
+				flags,
 				"",
 				// By default, create the directory if it does not exist when mounting.
 				"mkmp",
 			).String())
 
+		case disk.FilesystemExt4.String(), disk.FilesystemErofs.String():
+			cfg, err := disk.DriverConfigFromStatus(vol.Status.DriverConfig)
+			if err != nil {
+				return machine, fmt.Errorf("volume %s: %w", vol.Name, err)
+			}
+
+			hblkid := fmt.Sprintf("hblk%d", i+1)
+			qopts = append(qopts,
+				WithDrive(QemuDriveFile{
+					Id:        hblkid,
+					File:      cfg.Image,
+					Interface: QemuDriveInterfaceNone,
+					Format:    QemuDriveFormatRaw,
+					Readonly:  vol.Spec.ReadOnly,
+				}),
+				WithDevice(QemuDeviceVirtioBlkPci{
+					Drive: hblkid,
+				}),
+			)
+
+			fstab = append(fstab, vfscore.NewFstabEntry(
+				disk.BlockDeviceName(nblk),
+				vol.Spec.Destination,
+				vol.Spec.Driver,
+				flags,
+				"",
+				"mkmp",
+			).String())
+
+			nblk++
+
+		case "virtiofs":
+			daemon := virtiofsDaemon(machine, i)
+
+			if err := daemon.Start(ctx); err != nil {
+				return machine, fmt.Errorf("volume %s: %w", vol.Name, err)
+			}
+
+			// QEMU reconnects to the socket of the daemon if it is restarted.
+			hvirtiofsid := fmt.Sprintf("hvirtiofs%d", i+1)
+			mounttag := fmt.Sprintf("fs%d", i+1)
+			qopts = append(qopts,
+				WithCharDevice(QemuCharDevSocketUnix{
+					Id:        hvirtiofsid,
+					Path:      daemon.SocketPath,
+					Reconnect: 1,
+				}),
+				WithDevice(QemuDeviceVhostUserFsPci{
+					Chardev:   hvirtiofsid,
+					Tag:       mounttag,
+					QueueSize: 1024,
+				}),
+			)
+
+			fstab = append(fstab, vfscore.NewFstabEntry(
+				mounttag,
+				vol.Spec.Destination,
+				vol.Spec.Driver,
+				flags,
+				"",
+				"mkmp",
+			).String())
+
+			nvirtiofs++
+
 		case "initrd":
+			if rootfsFormat.BlockDevice() {
+				fstab = append(fstab, vfscore.NewFstabEntry(
+					disk.BlockDeviceName(0),
+					vol.Spec.Destination,
+					rootfsFormat.String(),
+					vfscore.FstabFlagReadOnly,
+					"",
+					"",
+				).String())
+				continue
+			}
+
 			fstab = append(fstab, vfscore.NewFstabEntry(
 				"initrd0",
 				vol.Spec.Destination,
@@ -367,6 +576,22 @@ func (service *machineV1alpha1Service) Create(ctx context.Context, machine *mach
 		}
 	}
 
+	// vhost-user devices require the guest memory to be shared with the
+	// virtiofsd process.
+	if nvirtiofs > 0 {
+		qopts = append(qopts,
+			WithObject(QemuObjectMemoryBackendMemfd{
+				Id:    "mem",
+				Size:  uint64(machine.Spec.Resources.Requests.Memory().Value() / QemuMemoryScale),
+				Unit:  QemuMemoryUnitMB,
+				Share: true,
+			}),
+			WithNuma(QemuNumaNode{
+				MemDev: "mem",
+			}),
+		)
+	}
+
 	if len(fstab) > 0 {
 		kernelArgs = append(kernelArgs,
 			vfscore.ParamVfsFstab.WithValue(fstab),
@@ -635,6 +860,14 @@ func (service *machineV1alpha1Service) Watch(ctx context.Context, machine *machi
 		return nil, nil, err
 	}
 
+	// Restart the daemons of the volumes for as long as the machine is watched.
+	for _, vol := range machine.Spec.Volumes {
+		if vol.Spec.Driver == "virtiofs" {
+			go superviseDaemons(ctx, machine, qcfg.PidFile, errs)
+			break
+		}
+	}
+
 	// firstCall is used to initialize the channel with the current state of the
 	// machine, so that it can be immediately acted upon.
 	firstCall := true
@@ -721,6 +954,11 @@ func (service *machineV1alpha1Service) Start(ctx context.Context, machine *machi
 	}
 
 	defer qmpClient.Close()
+
+	if err := startDaemons(ctx, machine); err != nil {
+		return machine, fmt.Errorf("could not start qemu instance: %w", err)
+	}
+
 	_, err = qmpClient.Cont(qmpapi.ContRequest{})
 	if err != nil {
 		return machine, err
@@ -951,7 +1189,7 @@ func (service *machineV1alpha1Service) Stop(ctx context.Context, machine *machin
 		if strings.HasSuffix(err.Error(), "connect: no such file or directory") {
 			machine.Status.State = machinev1alpha1.MachineStateExited
 			machine.Status.ExitedAt = time.Now()
-			return machine, nil
+			return machine, virtiofs.StopDaemons(machine.Status.StateDir)
 		}
 
 		return machine, fmt.Errorf("could not stop qemu instance: %v", err)
@@ -980,6 +1218,10 @@ func (service *machineV1alpha1Service) Stop(ctx context.Context, machine *machin
 		return machine, err
 	}
 
+	if err := virtiofs.StopDaemons(machine.Status.StateDir); err != nil {
+		return machine, err
+	}
+
 	return machine, nil
 }
 
@@ -992,6 +1234,10 @@ func (service *machineV1alpha1Service) Delete(ctx context.Context, machine *mach
 
 	var errs merr.Errors
 
+	if err := virtiofs.StopDaemons(machine.Status.StateDir); err != nil {
+		errs = append(errs, err)
+	}
+
 	err := os.RemoveAll(machine.Status.StateDir)
 	if err != nil {
 		errs = append(errs, fmt.Errorf("error deleting QEMU's state directory %s: %w", machine.Status.StateDir, err))
@@ -9,16 +9,22 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"k8s.io/apimachinery/pkg/util/uuid"
 
 	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
 	"kraftkit.sh/config"
+	"kraftkit.sh/internal/diskusage"
 	"kraftkit.sh/log"
+	"kraftkit.sh/machine/volume/watcher"
 )
 
 type v1alpha1Volume struct{}
 
+// watch notifies the watchers of volumes managed by this driver of updates.
+var watch watcher.Watcher
+
 func NewVolumeServiceV1alpha1(ctx context.Context, opts ...any) (volumev1alpha1.VolumeService, error) {
 	return &v1alpha1Volume{}, nil
 }
@@ -71,13 +77,13 @@ func (*v1alpha1Volume) Create(ctx context.Context, volume *volumev1alpha1.Volume
 
 	volume.Status.State = volumev1alpha1.VolumeStatePending
 
-	return volume, nil
+	return refresh(ctx, volume)
 }
 
 // Delete implements kraftkit.sh/api/volume/v1alpha1.Delete
 func (*v1alpha1Volume) Delete(_ context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
 	if len(volume.Spec.Driver) == 0 || volume.Spec.Driver != "9pfs" {
-		return nil, nil
+		return volume, volumev1alpha1.ErrVolumeNotOwned
 	}
 
 	if len(volume.Spec.Source) == 0 {
@@ -97,8 +103,20 @@ func (*v1alpha1Volume) Delete(_ context.Context, volume *volumev1alpha1.Volume)
 	return nil, nil
 }
 
+// refresh populates the live status of the volume.
+func refresh(_ context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+	size, err := diskusage.Of(volume.Spec.Source)
+	if err != nil {
+		return volume, fmt.Errorf("cannot determine size of volume: %w", err)
+	}
+
+	volume.Status.Size = size
+
+	return volume, nil
+}
+
 // Get implements kraftkit.sh/api/volume/v1alpha1.Get
-func (*v1alpha1Volume) Get(_ context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+func (*v1alpha1Volume) Get(ctx context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
 	if len(volume.Spec.Driver) == 0 || volume.Spec.Driver != "9pfs" {
 		return nil, nil
 	}
@@ -107,20 +125,37 @@ func (*v1alpha1Volume) Get(_ context.Context, volume *volumev1alpha1.Volume) (*v
 		return nil, nil
 	}
 
-	return volume, nil
+	return refresh(ctx, volume)
 }
 
 // List implements kraftkit.sh/api/volume/v1alpha1.List
 func (*v1alpha1Volume) List(_ context.Context, volumes *volumev1alpha1.VolumeList) (*volumev1alpha1.VolumeList, error) {
+	// The store is shared amongst all drivers, only return the volumes which
+	// are managed by this one.
+	volumes.Items = slices.DeleteFunc(volumes.Items, func(volume volumev1alpha1.Volume) bool {
+		return volume.Spec.Driver != "9pfs"
+	})
+
 	return volumes, nil
 }
 
 // Update implements kraftkit.sh/api/volume/v1alpha1.Update
-func (*v1alpha1Volume) Update(_ context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+func (*v1alpha1Volume) Update(ctx context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+	if volume.Spec.Driver != "9pfs" {
+		return volume, fmt.Errorf("cannot update %s volume with 9pfs driver", volume.Spec.Driver)
+	}
+
+	volume, err := refresh(ctx, volume)
+	if err != nil {
+		return volume, err
+	}
+
+	watch.Notify(volume)
+
 	return volume, nil
 }
 
 // Watch implements kraftkit.sh/api/volume/v1alpha1.Watch
-func (*v1alpha1Volume) Watch(context.Context, *volumev1alpha1.Volume) (chan *volumev1alpha1.Volume, chan error, error) {
-	panic("not implemented: kraftkit.sh/machine/volume/9pfs.v1alpha1Volume.Watch")
+func (*v1alpha1Volume) Watch(ctx context.Context, volume *volumev1alpha1.Volume) (chan *volumev1alpha1.Volume, chan error, error) {
+	return watch.Watch(ctx, volume, refresh)
 }
@@ -0,0 +1,350 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package disk provides volume drivers which convert a host directory or a
+// tarball into a raw file system image that can be attached to a machine as a
+// block device.
+//
+// Images are generated and extracted with the tools of the file system which
+// must be installed on the host: mkfs.ext4 and debugfs of e2fsprogs for ext4,
+// and mkfs.erofs and fsck.erofs of erofs-utils for EROFS.  Existing images are
+// attached as-is and do not require these tools.
+package disk
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	plainexec "os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"kraftkit.sh/archive"
+	"kraftkit.sh/exec"
+	"kraftkit.sh/internal/diskusage"
+	"kraftkit.sh/log"
+)
+
+// Filesystem is the on-disk format of the generated image.
+type Filesystem string
+
+const (
+	FilesystemExt4  = Filesystem("ext4")
+	FilesystemErofs = Filesystem("erofs")
+)
+
+// String implements fmt.Stringer
+func (fs Filesystem) String() string {
+	return string(fs)
+}
+
+// Filesystems returns the list of supported file system image formats.
+func Filesystems() []Filesystem {
+	return []Filesystem{
+		FilesystemExt4,
+		FilesystemErofs,
+	}
+}
+
+// ReadOnly returns whether the file system format can only be mounted
+// read-only.
+func (fs Filesystem) ReadOnly() bool {
+	return fs == FilesystemErofs
+}
+
+// KConfig returns the KConfig options the unikernel must be built with to be
+// able to mount an image of the file system format.
+func (fs Filesystem) KConfig() []string {
+	options := []string{
+		"CONFIG_LIBUKBLKDEV",
+		"CONFIG_LIBVIRTIO_BLK",
+	}
+
+	if fs == FilesystemExt4 {
+		options = append(options, "CONFIG_LIBLWEXT4")
+	}
+
+	return options
+}
+
+// toolPackages maps the host programs which build and extract images to the
+// package which provides them.
+var toolPackages = map[string]string{
+	"mkfs.ext4":  "e2fsprogs",
+	"debugfs":    "e2fsprogs",
+	"mkfs.erofs": "erofs-utils",
+	"fsck.erofs": "erofs-utils",
+}
+
+// lookTool returns an error naming the package to install if the host program
+// which handles images of the file system format is missing.
+func lookTool(filesystem Filesystem, bin string) error {
+	if _, err := plainexec.LookPath(bin); err != nil {
+		return fmt.Errorf("the %s volume driver requires %s to be installed on the host: %s not found", filesystem, toolPackages[bin], bin)
+	}
+
+	return nil
+}
+
+// DefaultImageSize is the minimum size of a writable image.  Images which are
+// seeded from a directory are grown to accommodate its contents.
+const DefaultImageSize = 64 * 1024 * 1024
+
+// DriverConfig is populated into the volume's status and contains the
+// location of the generated block device image.
+type DriverConfig struct {
+	// Filesystem is the format of the image.
+	Filesystem Filesystem `json:"filesystem,omitempty"`
+
+	// Image is the path to the raw disk image on the host.
+	Image string `json:"image,omitempty"`
+}
+
+func init() {
+	gob.Register(DriverConfig{})
+}
+
+// DriverConfigFromStatus returns the disk-specific driver configuration from
+// the provided volume status attribute.
+func DriverConfigFromStatus(driverConfig interface{}) (*DriverConfig, error) {
+	switch cfg := driverConfig.(type) {
+	case *DriverConfig:
+		return cfg, nil
+	case DriverConfig:
+		return &cfg, nil
+	}
+
+	return nil, fmt.Errorf("could not cast disk driver config from store")
+}
+
+// BlockDeviceName returns the name under which the nth attached block device
+// is known to Unikraft's vfscore when automounting.
+func BlockDeviceName(n int) string {
+	return fmt.Sprintf("blk%d", n)
+}
+
+// IsTarball returns whether the provided path is a file which can be used to
+// seed an image.
+func IsTarball(path string) bool {
+	return strings.HasSuffix(path, ".tar") ||
+		strings.HasSuffix(path, ".tar.gz") ||
+		strings.HasSuffix(path, ".tgz")
+}
+
+const (
+	// ext4Magic is found at ext4MagicOffset of an ext2, ext3 or ext4 image.
+	ext4Magic       = 0xef53
+	ext4MagicOffset = 1080
+
+	// erofsMagic is found at erofsMagicOffset of an EROFS image.
+	erofsMagic       = 0xe0f5e1e2
+	erofsMagicOffset = 1024
+)
+
+// DetectFilesystem returns the file system format of the raw image at the
+// provided path.
+func DetectFilesystem(path string) (Filesystem, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close()
+
+	header := make([]byte, ext4MagicOffset+2)
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", fmt.Errorf("could not read %s: %w", path, err)
+	}
+
+	header = header[:n]
+
+	if len(header) >= erofsMagicOffset+4 && binary.LittleEndian.Uint32(header[erofsMagicOffset:]) == erofsMagic {
+		return FilesystemErofs, nil
+	}
+
+	if len(header) == ext4MagicOffset+2 && binary.LittleEndian.Uint16(header[ext4MagicOffset:]) == ext4Magic {
+		return FilesystemExt4, nil
+	}
+
+	return "", fmt.Errorf("%s is not a supported file system image", path)
+}
+
+// IsImage returns whether the provided path is a raw image of the file system
+// format, which is attached as-is rather than used to seed a new image.
+func IsImage(path string, filesystem Filesystem) bool {
+	fi, err := os.Stat(path)
+	if err != nil || !fi.Mode().IsRegular() || IsTarball(path) {
+		return false
+	}
+
+	detected, err := DetectFilesystem(path)
+
+	return err == nil && detected == filesystem
+}
+
+// BuildImage generates a raw image of the provided file system format at the
+// destination path.  The source may either be a directory or a tarball, which
+// is first extracted into a temporary location.
+func BuildImage(ctx context.Context, filesystem Filesystem, source, dest string) error {
+	if IsTarball(source) {
+		tmp, err := os.MkdirTemp("", "kraftkit-disk-*")
+		if err != nil {
+			return fmt.Errorf("could not create temporary directory: %w", err)
+		}
+
+		defer os.RemoveAll(tmp)
+
+		if strings.HasSuffix(source, ".tar") {
+			f, err := os.Open(source)
+			if err != nil {
+				return fmt.Errorf("could not open tarball: %w", err)
+			}
+
+			err = archive.Untar(f, tmp)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("could not extract tarball: %w", err)
+			}
+		} else if err := archive.UntarGz(source, tmp); err != nil {
+			return fmt.Errorf("could not extract tarball: %w", err)
+		}
+
+		source = tmp
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return fmt.Errorf("could not create image directory: %w", err)
+	}
+
+	var bin string
+	var args []string
+
+	switch filesystem {
+	case FilesystemExt4:
+		size, err := diskusage.Of(source)
+		if err != nil {
+			return fmt.Errorf("could not determine size of %s: %w", source, err)
+		}
+
+		// Leave enough room for file system metadata and for the machine to
+		// write into the volume.
+		size = size * 2
+		if size < DefaultImageSize {
+			size = DefaultImageSize
+		}
+
+		f, err := os.Create(dest)
+		if err != nil {
+			return fmt.Errorf("could not create image: %w", err)
+		}
+
+		err = f.Truncate(size)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("could not allocate image: %w", err)
+		}
+
+		bin = "mkfs.ext4"
+		args = []string{
+			"-q",
+			"-F",
+			"-E", "root_owner=0:0",
+			"-d", source,
+			dest,
+			strconv.FormatInt(size/1024, 10) + "k",
+		}
+
+	case FilesystemErofs:
+		bin = "mkfs.erofs"
+		args = []string{
+			"--all-root",
+			dest,
+			source,
+		}
+
+	default:
+		return fmt.Errorf("unsupported disk file system: %s", filesystem)
+	}
+
+	if err := lookTool(filesystem, bin); err != nil {
+		_ = os.Remove(dest)
+		return err
+	}
+
+	var out bytes.Buffer
+
+	process, err := exec.NewProcess(bin, args,
+		exec.WithStdout(&out),
+	)
+	if err != nil {
+		_ = os.Remove(dest)
+		return fmt.Errorf("could not prepare %s: %w", bin, err)
+	}
+
+	if err := process.StartAndWait(ctx); err != nil {
+		log.G(ctx).Debug(out.String())
+		_ = os.Remove(dest)
+		return fmt.Errorf("could not generate %s image: %w", filesystem, err)
+	}
+
+	return nil
+}
+
+// ExtractImage copies the contents of the image of the provided file system
+// format into the destination directory.
+func ExtractImage(ctx context.Context, filesystem Filesystem, image, dest string) error {
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		return fmt.Errorf("could not create directory: %w", err)
+	}
+
+	var bin string
+	var args []string
+
+	switch filesystem {
+	case FilesystemExt4:
+		bin = "debugfs"
+		args = []string{
+			"-R", "rdump / " + dest,
+			image,
+		}
+
+	case FilesystemErofs:
+		bin = "fsck.erofs"
+		args = []string{
+			"--extract=" + dest,
+			image,
+		}
+
+	default:
+		return fmt.Errorf("unsupported disk file system: %s", filesystem)
+	}
+
+	if err := lookTool(filesystem, bin); err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+
+	process, err := exec.NewProcess(bin, args,
+		exec.WithStdout(&out),
+	)
+	if err != nil {
+		return fmt.Errorf("could not prepare %s: %w", bin, err)
+	}
+
+	if err := process.StartAndWait(ctx); err != nil {
+		log.G(ctx).Debug(out.String())
+		return fmt.Errorf("could not extract %s image: %w", filesystem, err)
+	}
+
+	return nil
+}
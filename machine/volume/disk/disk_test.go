@@ -0,0 +1,215 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package disk
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeHeader writes a file of the provided size whose only content is the
+// magic number of a file system at the given offset.
+func writeHeader(t *testing.T, path string, size, offset int, magic []byte) {
+	t.Helper()
+
+	header := make([]byte, size)
+	copy(header[offset:], magic)
+
+	if err := os.WriteFile(path, header, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIsTarball(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"rootfs.tar", true},
+		{"rootfs.tar.gz", true},
+		{"rootfs.tgz", true},
+		{"rootfs.tar.xz", false},
+		{"rootfs.img", false},
+		{"rootfs", false},
+	}
+
+	for _, tc := range tests {
+		if got := IsTarball(tc.path); got != tc.expected {
+			t.Errorf("%s: expected %t, got %t", tc.path, tc.expected, got)
+		}
+	}
+}
+
+func TestDetectFilesystem(t *testing.T) {
+	dir := t.TempDir()
+
+	ext4 := binary.LittleEndian.AppendUint16(nil, ext4Magic)
+	erofs := binary.LittleEndian.AppendUint32(nil, erofsMagic)
+
+	writeHeader(t, filepath.Join(dir, "ext4.img"), 4096, ext4MagicOffset, ext4)
+	writeHeader(t, filepath.Join(dir, "erofs.img"), 4096, erofsMagicOffset, erofs)
+	writeHeader(t, filepath.Join(dir, "short-ext4.img"), ext4MagicOffset+1, ext4MagicOffset, ext4[:1])
+	writeHeader(t, filepath.Join(dir, "short-erofs.img"), erofsMagicOffset+4, erofsMagicOffset, erofs)
+	writeHeader(t, filepath.Join(dir, "zero.img"), 4096, 0, nil)
+	writeHeader(t, filepath.Join(dir, "empty.img"), 0, 0, nil)
+
+	tests := []struct {
+		name     string
+		expected Filesystem
+	}{
+		{"ext4.img", FilesystemExt4},
+		{"erofs.img", FilesystemErofs},
+		{"short-ext4.img", ""},
+		{"short-erofs.img", FilesystemErofs},
+		{"zero.img", ""},
+		{"empty.img", ""},
+		{"missing.img", ""},
+	}
+
+	for _, tc := range tests {
+		filesystem, err := DetectFilesystem(filepath.Join(dir, tc.name))
+		if tc.expected == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %s", tc.name, filesystem)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		} else if filesystem != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.expected, filesystem)
+		}
+	}
+}
+
+func TestIsImage(t *testing.T) {
+	dir := t.TempDir()
+
+	ext4 := binary.LittleEndian.AppendUint16(nil, ext4Magic)
+
+	writeHeader(t, filepath.Join(dir, "rootfs.img"), 4096, ext4MagicOffset, ext4)
+	writeHeader(t, filepath.Join(dir, "rootfs.tar"), 4096, ext4MagicOffset, ext4)
+
+	tests := []struct {
+		path       string
+		filesystem Filesystem
+		expected   bool
+	}{
+		{"rootfs.img", FilesystemExt4, true},
+		{"rootfs.img", FilesystemErofs, false},
+		{"rootfs.tar", FilesystemExt4, false}, // Tarballs seed new images.
+		{".", FilesystemExt4, false},
+		{"missing.img", FilesystemExt4, false},
+	}
+
+	for _, tc := range tests {
+		if got := IsImage(filepath.Join(dir, tc.path), tc.filesystem); got != tc.expected {
+			t.Errorf("%s as %s: expected %t, got %t", tc.path, tc.filesystem, tc.expected, got)
+		}
+	}
+}
+
+func TestMissingTools(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	ctx := context.Background()
+	source := t.TempDir()
+
+	tests := []struct {
+		filesystem Filesystem
+		pkg        string
+	}{
+		{FilesystemExt4, "e2fsprogs"},
+		{FilesystemErofs, "erofs-utils"},
+	}
+
+	for _, tc := range tests {
+		image := filepath.Join(t.TempDir(), "rootfs.img")
+
+		err := BuildImage(ctx, tc.filesystem, source, image)
+		if err == nil || !strings.Contains(err.Error(), tc.pkg) {
+			t.Errorf("%s: expected build error naming %s, got %v", tc.filesystem, tc.pkg, err)
+		}
+
+		if _, err := os.Stat(image); !os.IsNotExist(err) {
+			t.Errorf("%s: expected no image to be left behind", tc.filesystem)
+		}
+
+		err = ExtractImage(ctx, tc.filesystem, image, t.TempDir())
+		if err == nil || !strings.Contains(err.Error(), tc.pkg) {
+			t.Errorf("%s: expected extract error naming %s, got %v", tc.filesystem, tc.pkg, err)
+		}
+	}
+}
+
+func TestBuildAndExtractImage(t *testing.T) {
+	ctx := context.Background()
+
+	source := t.TempDir()
+	files := map[string]string{
+		"entrypoint.sh":  "#!/bin/sh\necho hello\n",
+		"etc/app.conf":   "key=value\n",
+		"var/lib/app/db": strings.Repeat("x", 64*1024),
+	}
+
+	for name, content := range files {
+		path := filepath.Join(source, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		filesystem Filesystem
+		tools      []string
+	}{
+		{FilesystemExt4, []string{"mkfs.ext4", "debugfs"}},
+		{FilesystemErofs, []string{"mkfs.erofs", "fsck.erofs"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.filesystem.String(), func(t *testing.T) {
+			for _, tool := range tc.tools {
+				if _, err := exec.LookPath(tool); err != nil {
+					t.Skipf("%s is not installed", tool)
+				}
+			}
+
+			image := filepath.Join(t.TempDir(), "rootfs.img")
+
+			if err := BuildImage(ctx, tc.filesystem, source, image); err != nil {
+				t.Fatal("BuildImage:", err)
+			}
+
+			if !IsImage(image, tc.filesystem) {
+				t.Errorf("expected %s to be detected as a %s image", image, tc.filesystem)
+			}
+
+			dest := t.TempDir()
+			if err := ExtractImage(ctx, tc.filesystem, image, dest); err != nil {
+				t.Fatal("ExtractImage:", err)
+			}
+
+			for name, content := range files {
+				got, err := os.ReadFile(filepath.Join(dest, name))
+				if err != nil {
+					t.Errorf("%s: %v", name, err)
+				} else if string(got) != content {
+					t.Errorf("%s: unexpected content after round-trip", name)
+				}
+			}
+		})
+	}
+}
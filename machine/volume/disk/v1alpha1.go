@@ -0,0 +1,220 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package disk
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+
+	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/diskusage"
+	"kraftkit.sh/log"
+	"kraftkit.sh/machine/volume/watcher"
+)
+
+type v1alpha1Volume struct {
+	filesystem Filesystem
+}
+
+// watch notifies the watchers of volumes managed by this driver of updates.
+var watch watcher.Watcher
+
+// NewVolumeServiceV1alpha1 returns a volume service which generates block
+// device images of the file system set via WithFilesystem.
+func NewVolumeServiceV1alpha1(ctx context.Context, opts ...any) (volumev1alpha1.VolumeService, error) {
+	service := v1alpha1Volume{
+		filesystem: FilesystemExt4,
+	}
+
+	for _, opt := range opts {
+		vopt, ok := opt.(VolumeServiceV1alpha1Option)
+		if !ok {
+			panic("cannot apply non-VolumeServiceV1alpha1Option type methods")
+		}
+
+		if err := vopt(&service); err != nil {
+			return nil, err
+		}
+	}
+
+	return &service, nil
+}
+
+// Create implements kraftkit.sh/api/volume/v1alpha1.Create
+func (service *v1alpha1Volume) Create(ctx context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+	var err error
+
+	if len(volume.Spec.Driver) == 0 {
+		volume.Spec.Driver = service.filesystem.String()
+	} else if volume.Spec.Driver != service.filesystem.String() {
+		return volume, fmt.Errorf("cannot use %s driver when driver set to %s", service.filesystem, volume.Spec.Driver)
+	}
+
+	if volume.ObjectMeta.UID == "" {
+		volume.ObjectMeta.UID = uuid.NewUUID()
+	}
+
+	if volume.ObjectMeta.Name == "" {
+		volume.ObjectMeta.Name = string(volume.ObjectMeta.UID)
+	}
+
+	volumesDir := filepath.Join(config.G[config.KraftKit](ctx).RuntimeDir, "volumes")
+
+	if len(volume.Spec.Source) == 0 {
+		// If no Source is specified, seed the image from an empty directory in
+		// the runtime store.
+		log.G(ctx).Debugf("creating new volume entry in the runtime store %s", volume.ObjectMeta.UID)
+		volume.Spec.Source = filepath.Join(volumesDir, string(volume.ObjectMeta.UID))
+		volume.Spec.Managed = true
+
+		if err := os.MkdirAll(volume.Spec.Source, 0o755); err != nil {
+			return volume, fmt.Errorf("cannot create volume directory: %w", err)
+		}
+	} else {
+		volume.Spec.Managed = false
+	}
+
+	volume.Spec.Source, err = filepath.Abs(volume.Spec.Source)
+	if err != nil {
+		return volume, fmt.Errorf("cannot get absolute path for volume source: %w", err)
+	}
+
+	fi, err := os.Stat(volume.Spec.Source)
+	if err != nil {
+		return volume, fmt.Errorf("cannot stat volume source: %w", err)
+	}
+
+	if service.filesystem.ReadOnly() {
+		volume.Spec.ReadOnly = true
+	}
+
+	// Existing images of the file system format are attached as-is.
+	if IsImage(volume.Spec.Source, service.filesystem) {
+		volume.Status.DriverConfig = DriverConfig{
+			Filesystem: service.filesystem,
+			Image:      volume.Spec.Source,
+		}
+		volume.Status.State = volumev1alpha1.VolumeStatePending
+
+		return refresh(ctx, volume)
+	}
+
+	if !fi.IsDir() && !IsTarball(volume.Spec.Source) {
+		return volume, fmt.Errorf("volume source is neither a directory, a tarball nor a %s image: %s", service.filesystem, volume.Spec.Source)
+	}
+
+	image := filepath.Join(volumesDir, string(volume.ObjectMeta.UID)+".img")
+
+	log.G(ctx).
+		WithField("source", volume.Spec.Source).
+		WithField("image", image).
+		Debugf("generating %s image", service.filesystem)
+
+	if err := BuildImage(ctx, service.filesystem, volume.Spec.Source, image); err != nil {
+		return volume, err
+	}
+
+	volume.Status.DriverConfig = DriverConfig{
+		Filesystem: service.filesystem,
+		Image:      image,
+	}
+	volume.Status.State = volumev1alpha1.VolumeStatePending
+
+	return refresh(ctx, volume)
+}
+
+// Delete implements kraftkit.sh/api/volume/v1alpha1.Delete
+func (service *v1alpha1Volume) Delete(_ context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+	if volume.Spec.Driver != service.filesystem.String() {
+		return volume, volumev1alpha1.ErrVolumeNotOwned
+	}
+
+	if volume.Status.State == volumev1alpha1.VolumeStateBound {
+		return volume, fmt.Errorf("cannot delete volume in state %s", volume.Status.State)
+	}
+
+	// Images which were attached as-is belong to the user and are kept.
+	if cfg, err := DriverConfigFromStatus(volume.Status.DriverConfig); err == nil && len(cfg.Image) > 0 && cfg.Image != volume.Spec.Source {
+		if err := os.Remove(cfg.Image); err != nil && !os.IsNotExist(err) {
+			return volume, fmt.Errorf("cannot remove volume image: %w", err)
+		}
+	}
+
+	if volume.Spec.Managed && len(volume.Spec.Source) > 0 {
+		if err := os.RemoveAll(volume.Spec.Source); err != nil {
+			return volume, fmt.Errorf("cannot remove volume directory: %w", err)
+		}
+	}
+
+	return nil, nil
+}
+
+// refresh populates the live status of the volume.
+func refresh(_ context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+	cfg, err := DriverConfigFromStatus(volume.Status.DriverConfig)
+	if err != nil {
+		return volume, err
+	}
+
+	size, err := diskusage.Of(cfg.Image)
+	if err != nil {
+		return volume, fmt.Errorf("cannot determine size of volume: %w", err)
+	}
+
+	volume.Status.Size = size
+
+	return volume, nil
+}
+
+// Get implements kraftkit.sh/api/volume/v1alpha1.Get
+func (service *v1alpha1Volume) Get(ctx context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+	if volume.Spec.Driver != service.filesystem.String() {
+		return nil, nil
+	}
+
+	if len(volume.Spec.Source) == 0 {
+		return nil, nil
+	}
+
+	return refresh(ctx, volume)
+}
+
+// List implements kraftkit.sh/api/volume/v1alpha1.List
+func (service *v1alpha1Volume) List(_ context.Context, volumes *volumev1alpha1.VolumeList) (*volumev1alpha1.VolumeList, error) {
+	// The store is shared amongst all drivers, only return the volumes which
+	// are managed by this one.
+	volumes.Items = slices.DeleteFunc(volumes.Items, func(volume volumev1alpha1.Volume) bool {
+		return volume.Spec.Driver != service.filesystem.String()
+	})
+
+	return volumes, nil
+}
+
+// Update implements kraftkit.sh/api/volume/v1alpha1.Update
+func (service *v1alpha1Volume) Update(ctx context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+	if volume.Spec.Driver != service.filesystem.String() {
+		return volume, fmt.Errorf("cannot update %s volume with %s driver", volume.Spec.Driver, service.filesystem)
+	}
+
+	volume, err := refresh(ctx, volume)
+	if err != nil {
+		return volume, err
+	}
+
+	watch.Notify(volume)
+
+	return volume, nil
+}
+
+// Watch implements kraftkit.sh/api/volume/v1alpha1.Watch
+func (*v1alpha1Volume) Watch(ctx context.Context, volume *volumev1alpha1.Volume) (chan *volumev1alpha1.Volume, chan error, error) {
+	return watch.Watch(ctx, volume, refresh)
+}
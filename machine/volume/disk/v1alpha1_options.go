@@ -0,0 +1,26 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package disk
+
+import "fmt"
+
+// VolumeServiceV1alpha1Option represents an option-method handler for the
+// volumev1alpha1 service.
+type VolumeServiceV1alpha1Option func(*v1alpha1Volume) error
+
+// WithFilesystem sets the file system format of the images generated by the
+// volume service.
+func WithFilesystem(filesystem Filesystem) VolumeServiceV1alpha1Option {
+	return func(service *v1alpha1Volume) error {
+		for _, fs := range Filesystems() {
+			if fs == filesystem {
+				service.filesystem = filesystem
+				return nil
+			}
+		}
+
+		return fmt.Errorf("unsupported disk file system: %s", filesystem)
+	}
+}
@@ -6,6 +6,7 @@ package volume
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	zip "api.zip"
@@ -78,7 +79,10 @@ func (iterator *volumeV1alpha1ServiceIterator) Delete(ctx context.Context, volum
 
 	for _, strategy := range iterator.strategies {
 		ret, err := strategy.Delete(ctx, volume)
-		if err != nil {
+		if errors.Is(err, volumev1alpha1.ErrVolumeNotOwned) {
+			// Keep iterating until the driver which manages the volume is found.
+			continue
+		} else if err != nil {
 			errs = append(errs, err)
 			continue
 		}
@@ -86,6 +90,10 @@ func (iterator *volumeV1alpha1ServiceIterator) Delete(ctx context.Context, volum
 		return ret, nil
 	}
 
+	if len(errs) == 0 {
+		return volume, fmt.Errorf("no volume driver manages volume %s", volume.Name)
+	}
+
 	return volume, fmt.Errorf("all iterated drivers failed: %w", merr.NewErrors(errs...))
 }
 
@@ -98,11 +106,18 @@ func (iterator *volumeV1alpha1ServiceIterator) Get(ctx context.Context, volume *
 		if err != nil {
 			errs = append(errs, err)
 			continue
+		} else if ret == nil {
+			// The volume is not managed by this driver.
+			continue
 		}
 
 		return ret, nil
 	}
 
+	if len(errs) == 0 {
+		return nil, nil
+	}
+
 	return volume, fmt.Errorf("all iterated drivers failed: %w", merr.NewErrors(errs...))
 }
 
@@ -123,3 +138,24 @@ func (iterator *volumeV1alpha1ServiceIterator) List(ctx context.Context, cached
 
 	return cached, nil
 }
+
+// Watch implements kraftkit.sh/api/volume/v1alpha1.Watch
+func (iterator *volumeV1alpha1ServiceIterator) Watch(ctx context.Context, volume *volumev1alpha1.Volume) (chan *volumev1alpha1.Volume, chan error, error) {
+	if len(volume.Spec.Driver) == 0 {
+		found, err := iterator.Get(ctx, volume)
+		if err != nil {
+			return nil, nil, err
+		} else if found == nil {
+			return nil, nil, fmt.Errorf("volume not found: %s", volume.Name)
+		}
+
+		volume = found
+	}
+
+	strategy, ok := iterator.strategies[volume.Spec.Driver]
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported volume driver: %s", volume.Spec.Driver)
+	}
+
+	return strategy.Watch(ctx, volume)
+}
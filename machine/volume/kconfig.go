@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package volume
+
+import (
+	"fmt"
+	"strings"
+
+	"kraftkit.sh/kconfig"
+)
+
+// kconfigAutomount are the KConfig options, either of which allows vfscore to
+// mount volumes which are passed via the `vfs.fstab` kernel parameter.
+var kconfigAutomount = []string{
+	"CONFIG_LIBVFSCORE_AUTOMOUNT_UP",
+	"CONFIG_LIBVFSCORE_FSTAB", // Deprecated
+}
+
+// checkKConfig returns an error listing the provided KConfig options which are
+// not enabled in the unikernel's configuration and that are required by the
+// named volume driver.  An empty configuration means the unikernel's
+// configuration is not known, in which case no error is returned.
+func checkKConfig(driver string, kconf kconfig.KeyValueMap, options ...string) error {
+	if len(kconf) == 0 {
+		return nil
+	}
+
+	var missing []string
+
+	if !kconf.AnyYes(kconfigAutomount...) {
+		missing = append(missing, kconfigAutomount[0])
+	}
+
+	for _, option := range options {
+		if !kconf.AnyYes(option) {
+			missing = append(missing, option)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("the %s volume driver requires the unikernel to be built with %s: enable via `kraft menu` or the Kraftfile's kconfig and rebuild", driver, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
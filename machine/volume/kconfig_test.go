@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package volume
+
+import (
+	"strings"
+	"testing"
+
+	"kraftkit.sh/kconfig"
+)
+
+func TestCheckKConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []interface{}
+		options []string
+		missing []string
+	}{
+		{
+			name:    "unknown configuration",
+			options: []string{"CONFIG_LIB9PFS"},
+		},
+		{
+			name:    "all enabled",
+			values:  []interface{}{"CONFIG_LIBVFSCORE_AUTOMOUNT_UP=y", "CONFIG_LIB9PFS=y"},
+			options: []string{"CONFIG_LIB9PFS"},
+		},
+		{
+			name:    "deprecated fstab",
+			values:  []interface{}{"CONFIG_LIBVFSCORE_FSTAB=y", "CONFIG_LIB9PFS=y"},
+			options: []string{"CONFIG_LIB9PFS"},
+		},
+		{
+			name:    "missing automount",
+			values:  []interface{}{"CONFIG_LIB9PFS=y"},
+			options: []string{"CONFIG_LIB9PFS"},
+			missing: []string{"CONFIG_LIBVFSCORE_AUTOMOUNT_UP"},
+		},
+		{
+			name:    "missing driver options",
+			values:  []interface{}{"CONFIG_LIBVFSCORE_AUTOMOUNT_UP=y", "CONFIG_LIBUK9P=n"},
+			options: []string{"CONFIG_LIBUK9P", "CONFIG_LIB9PFS"},
+			missing: []string{"CONFIG_LIBUK9P", "CONFIG_LIB9PFS"},
+		},
+	}
+
+	for _, tc := range tests {
+		kconf, err := kconfig.NewKeyValueMapFromSlice(tc.values...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = checkKConfig("9pfs", kconf, tc.options...)
+		if len(tc.missing) == 0 {
+			if err != nil {
+				t.Errorf("%s: %v", tc.name, err)
+			}
+			continue
+		}
+
+		if err == nil {
+			t.Errorf("%s: expected an error listing %v", tc.name, tc.missing)
+			continue
+		}
+
+		if expected := strings.Join(tc.missing, ", "); !strings.Contains(err.Error(), " "+expected+":") {
+			t.Errorf("%s: expected error to list %s, got %v", tc.name, expected, err)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package volume
+
+import (
+	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
+)
+
+// OwnerLabel is the label of volumes which only exist for the lifetime of a
+// machine, e.g. the disks shipped with a package.  Its value is the name of
+// the machine.
+const OwnerLabel = "kraftkit.sh/volume.owner"
+
+// SetOwner marks the volume as managed and owned by the machine with the
+// provided name, such that it is deleted along with the machine.
+func SetOwner(volume *volumev1alpha1.Volume, machine string) {
+	if volume.ObjectMeta.Labels == nil {
+		volume.ObjectMeta.Labels = map[string]string{}
+	}
+
+	volume.ObjectMeta.Labels[OwnerLabel] = machine
+	volume.Spec.Managed = true
+}
+
+// IsOwnedBy returns whether the volume is managed and owned by the machine
+// with the provided name.
+func IsOwnedBy(volume *volumev1alpha1.Volume, machine string) bool {
+	return volume.Spec.Managed && volume.ObjectMeta.Labels[OwnerLabel] == machine
+}
@@ -6,30 +6,79 @@ package volume
 
 import (
 	"context"
-	"path/filepath"
+	"fmt"
+	"os"
 
 	zip "api.zip"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
-	"kraftkit.sh/config"
 	"kraftkit.sh/kconfig"
+	mplatform "kraftkit.sh/machine/platform"
 	ninepfs "kraftkit.sh/machine/volume/9pfs"
+	"kraftkit.sh/machine/volume/disk"
+	"kraftkit.sh/machine/volume/virtiofs"
+	"kraftkit.sh/machine/volume/watcher"
 	"kraftkit.sh/store"
 )
 
 var defaultStrategyName = "9pfs"
 
+// strategyPreference is the order in which the drivers are selected when none
+// is specified.  Drivers which share directories of the host come first and
+// erofs comes last since it can only provide read-only volumes.
+var strategyPreference = []string{"9pfs", "virtiofs", "ext4", "erofs"}
+
+// namedVolume reports whether the source is the name of a volume in the volume
+// store, returning an error if the volume is managed by a different driver.
+func namedVolume(ctx context.Context, driver, source string) (bool, error) {
+	iterator, err := NewVolumeV1alpha1ServiceIterator(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	volume, err := iterator.Get(ctx, &volumev1alpha1.Volume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: source,
+		},
+	})
+	if err != nil {
+		return false, err
+	} else if volume == nil {
+		return false, nil
+	}
+
+	if volume.Spec.Driver != driver {
+		return true, fmt.Errorf("volume %s is managed by the %s volume driver", source, volume.Spec.Driver)
+	}
+
+	return true, nil
+}
+
 // hostSupportedStrategies returns the map of known supported drivers for the
 // given host.
 func hostSupportedStrategies() map[string]*Strategy {
-	return map[string]*Strategy{
+	strategies := map[string]*Strategy{
 		"9pfs": {
-			IsCompatible: func(source string, _ kconfig.KeyValueMap) (bool, error) {
-				// TODO(nderjung): For now, it is OK to return true because this is the
-				// only supported driver.  In the future, we should a). check if the
-				// provided source is a readable directory and b). check if the supplied
-				// KConfig of the machine indicates that 9pfs is indeed part of the
-				// build configuration.
+			IsCompatible: func(ctx context.Context, source string, kconf kconfig.KeyValueMap) (bool, error) {
+				named, err := namedVolume(ctx, "9pfs", source)
+				if err != nil {
+					return false, err
+				}
+
+				// A non-existent source is a directory which is created on demand.
+				if fi, err := os.Stat(source); !named && err == nil && !fi.IsDir() {
+					return false, fmt.Errorf("the 9pfs volume driver can only share directories: %s is not a directory", source)
+				}
+
+				if err := checkKConfig("9pfs", kconf,
+					"CONFIG_LIBUK9P",
+					"CONFIG_LIB9PFS",
+					"CONFIG_LIBVIRTIO_9P",
+				); err != nil {
+					return false, err
+				}
+
 				return true, nil
 			},
 			NewVolumeV1alpha1: func(ctx context.Context, opts ...any) (volumev1alpha1.VolumeService, error) {
@@ -38,22 +87,104 @@ func hostSupportedStrategies() map[string]*Strategy {
 					return nil, err
 				}
 
-				embeddedStore, err := store.NewEmbeddedStore[volumev1alpha1.VolumeSpec, volumev1alpha1.VolumeStatus](
-					filepath.Join(
-						config.G[config.KraftKit](ctx).RuntimeDir,
-						"volumev1alpha1",
-					),
+				return newVolumeServiceHandler(ctx, service)
+			},
+			Platforms: []mplatform.Platform{mplatform.PlatformQEMU},
+		},
+	}
+
+	strategies["virtiofs"] = &Strategy{
+		IsCompatible: func(ctx context.Context, source string, kconf kconfig.KeyValueMap) (bool, error) {
+			named, err := namedVolume(ctx, "virtiofs", source)
+			if err != nil {
+				return false, err
+			}
+
+			if !named {
+				fi, err := os.Stat(source)
+				if err != nil {
+					return false, err
+				}
+
+				if !fi.IsDir() {
+					return false, fmt.Errorf("the virtiofs volume driver can only share directories: %s is not a directory", source)
+				}
+			}
+
+			if err := checkKConfig("virtiofs", kconf, virtiofs.KConfigVirtiofs); err != nil {
+				return false, err
+			}
+
+			return true, nil
+		},
+		NewVolumeV1alpha1: func(ctx context.Context, opts ...any) (volumev1alpha1.VolumeService, error) {
+			service, err := virtiofs.NewVolumeServiceV1alpha1(ctx, opts...)
+			if err != nil {
+				return nil, err
+			}
+
+			return newVolumeServiceHandler(ctx, service)
+		},
+		Platforms: []mplatform.Platform{mplatform.PlatformQEMU},
+	}
+
+	for _, filesystem := range disk.Filesystems() {
+		strategies[filesystem.String()] = &Strategy{
+			IsCompatible: func(ctx context.Context, source string, kconf kconfig.KeyValueMap) (bool, error) {
+				named, err := namedVolume(ctx, filesystem.String(), source)
+				if err != nil {
+					return false, err
+				}
+
+				// Disk images are seeded either from a directory or a tarball, or are
+				// existing images of the file system format.
+				if !named {
+					fi, err := os.Stat(source)
+					if err != nil {
+						return false, err
+					}
+
+					if !fi.IsDir() && !disk.IsTarball(source) && !disk.IsImage(source, filesystem) {
+						return false, fmt.Errorf("the %s volume driver requires a directory, a tarball or a %s image: %s is neither", filesystem, filesystem, source)
+					}
+				}
+
+				if err := checkKConfig(filesystem.String(), kconf, filesystem.KConfig()...); err != nil {
+					return false, err
+				}
+
+				return true, nil
+			},
+			NewVolumeV1alpha1: func(ctx context.Context, opts ...any) (volumev1alpha1.VolumeService, error) {
+				service, err := disk.NewVolumeServiceV1alpha1(ctx,
+					append(opts, disk.WithFilesystem(filesystem))...,
 				)
 				if err != nil {
 					return nil, err
 				}
 
-				return volumev1alpha1.NewVolumeServiceHandler(
-					ctx,
-					service,
-					zip.WithStore[volumev1alpha1.VolumeSpec, volumev1alpha1.VolumeStatus](embeddedStore, zip.StoreRehydrationSpecNil),
-				)
+				return newVolumeServiceHandler(ctx, service)
 			},
-		},
+			Platforms: []mplatform.Platform{mplatform.PlatformQEMU, mplatform.PlatformFirecracker},
+		}
+	}
+
+	return strategies
+}
+
+// newVolumeServiceHandler wraps the provided volume service implementation
+// with the shared embedded volume store.
+func newVolumeServiceHandler(ctx context.Context, service volumev1alpha1.VolumeService) (volumev1alpha1.VolumeService, error) {
+	embeddedStore, err := store.NewEmbeddedStore[volumev1alpha1.VolumeSpec, volumev1alpha1.VolumeStatus](
+		watcher.StoreDir(ctx),
+	)
+	if err != nil {
+		return nil, err
 	}
+
+	return volumev1alpha1.NewVolumeServiceHandler(
+		ctx,
+		service,
+		zip.WithStore[volumev1alpha1.VolumeSpec, volumev1alpha1.VolumeStatus](embeddedStore, zip.StoreRehydrationSpecNil),
+	)
 }
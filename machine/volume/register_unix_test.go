@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package volume
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kraftkit.sh/internal/offline/offlinetest"
+)
+
+func TestIsCompatible(t *testing.T) {
+	ctx := offlinetest.Context(t)
+	dir := t.TempDir()
+
+	// A file which only carries the magic number of an ext4 superblock.
+	ext4 := make([]byte, 4096)
+	binary.LittleEndian.PutUint16(ext4[1080:], 0xef53)
+
+	sources := map[string][]byte{
+		"rootfs.tar": {},
+		"rootfs.img": ext4,
+		"file.txt":   []byte("hello\n"),
+	}
+
+	for name, content := range sources {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		driver   string
+		source   string
+		expected bool
+	}{
+		{"9pfs", ".", true},
+		{"9pfs", "missing", true}, // Created on demand.
+		{"9pfs", "file.txt", false},
+		{"virtiofs", ".", true},
+		{"virtiofs", "missing", false},
+		{"virtiofs", "rootfs.tar", false},
+		{"ext4", ".", true},
+		{"ext4", "rootfs.tar", true},
+		{"ext4", "rootfs.img", true},
+		{"ext4", "file.txt", false},
+		{"ext4", "missing", false},
+		{"erofs", ".", true},
+		{"erofs", "rootfs.tar", true},
+		{"erofs", "rootfs.img", false},
+	}
+
+	strategies := Strategies()
+
+	for _, tc := range tests {
+		ok, err := strategies[tc.driver].IsCompatible(ctx, filepath.Join(dir, tc.source), nil)
+		if tc.expected && (!ok || err != nil) {
+			t.Errorf("%s with %s: expected compatible, got %t: %v", tc.driver, tc.source, ok, err)
+		} else if !tc.expected && ok {
+			t.Errorf("%s with %s: expected incompatible", tc.driver, tc.source)
+		}
+	}
+}
@@ -6,9 +6,12 @@ package volume
 
 import (
 	"context"
+	"slices"
+	"sort"
 
 	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
 	"kraftkit.sh/kconfig"
+	mplatform "kraftkit.sh/machine/platform"
 )
 
 // NewStrategyConstructor is a prototype for the instantiation function of a
@@ -28,8 +31,23 @@ func DefaultStrategyName() string {
 // Strategy represents canonical reference of a machine driver and their
 // platform.
 type Strategy struct {
-	IsCompatible      func(string, kconfig.KeyValueMap) (bool, error)
+	IsCompatible      func(context.Context, string, kconfig.KeyValueMap) (bool, error)
 	NewVolumeV1alpha1 NewStrategyConstructor[volumev1alpha1.VolumeService]
+
+	// Platforms is the list of machine platforms which are able to attach
+	// volumes of the driver.  An empty list means any platform.
+	Platforms []mplatform.Platform
+}
+
+// SupportsPlatform returns whether machines of the provided platform are able
+// to attach volumes of the driver.  Volumes which are not (yet) attached to a
+// machine, i.e. whose platform is unknown, are supported by any driver.
+func (strategy *Strategy) SupportsPlatform(platform mplatform.Platform) bool {
+	if len(strategy.Platforms) == 0 || platform == mplatform.PlatformUnknown || platform == "" {
+		return true
+	}
+
+	return slices.Contains(strategy.Platforms, platform)
 }
 
 // Strategies returns the list of registered platform implementations.
@@ -43,12 +61,37 @@ func Strategies() map[string]*Strategy {
 }
 
 // DriverNames returns the list of registered platform driver implementation
-// names.
+// names in order of preference, followed by any other registered drivers in
+// alphabetical order.
 func DriverNames() []string {
 	ret := []string{}
-	for plat := range Strategies() {
-		ret = append(ret, plat)
+	var others []string
+
+	registered := Strategies()
+	for _, name := range strategyPreference {
+		if _, ok := registered[name]; ok {
+			ret = append(ret, name)
+		}
 	}
 
-	return ret
+	for name := range registered {
+		if !slices.Contains(strategyPreference, name) {
+			others = append(others, name)
+		}
+	}
+
+	sort.Strings(others)
+
+	return append(ret, others...)
+}
+
+// PlatformDriverNames returns the names of the registered drivers whose
+// volumes machines of the provided platform are able to attach, in the order
+// of preference of DriverNames.
+func PlatformDriverNames(platform mplatform.Platform) []string {
+	registered := Strategies()
+
+	return slices.DeleteFunc(DriverNames(), func(name string) bool {
+		return !registered[name].SupportsPlatform(platform)
+	})
 }
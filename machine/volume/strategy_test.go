@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package volume
+
+import (
+	"slices"
+	"testing"
+
+	mplatform "kraftkit.sh/machine/platform"
+)
+
+func TestDriverNames(t *testing.T) {
+	expected := []string{"9pfs", "virtiofs", "ext4", "erofs"}
+
+	if names := DriverNames(); !slices.Equal(names, expected) {
+		t.Errorf("expected drivers in order %v, got %v", expected, names)
+	}
+}
+
+func TestPlatformDriverNames(t *testing.T) {
+	tests := []struct {
+		platform mplatform.Platform
+		expected []string
+	}{
+		{mplatform.PlatformQEMU, []string{"9pfs", "virtiofs", "ext4", "erofs"}},
+		{mplatform.PlatformFirecracker, []string{"ext4", "erofs"}},
+		{mplatform.PlatformUnknown, []string{"9pfs", "virtiofs", "ext4", "erofs"}},
+		{"", []string{"9pfs", "virtiofs", "ext4", "erofs"}},
+	}
+
+	for _, tc := range tests {
+		if names := PlatformDriverNames(tc.platform); !slices.Equal(names, tc.expected) {
+			t.Errorf("%q: expected drivers %v, got %v", tc.platform, tc.expected, names)
+		}
+	}
+}
+
+func TestSupportsPlatform(t *testing.T) {
+	strategies := Strategies()
+
+	tests := []struct {
+		driver   string
+		platform mplatform.Platform
+		expected bool
+	}{
+		{"9pfs", mplatform.PlatformQEMU, true},
+		{"9pfs", mplatform.PlatformFirecracker, false},
+		{"virtiofs", mplatform.PlatformQEMU, true},
+		{"virtiofs", mplatform.PlatformFirecracker, false},
+		{"ext4", mplatform.PlatformQEMU, true},
+		{"ext4", mplatform.PlatformFirecracker, true},
+		{"erofs", mplatform.PlatformFirecracker, true},
+		{"9pfs", mplatform.PlatformUnknown, true},
+	}
+
+	for _, tc := range tests {
+		if supported := strategies[tc.driver].SupportsPlatform(tc.platform); supported != tc.expected {
+			t.Errorf("%s on %s: expected %t, got %t", tc.driver, tc.platform, tc.expected, supported)
+		}
+	}
+}
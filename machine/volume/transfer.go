@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package volume
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+
+	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/archive"
+	"kraftkit.sh/config"
+	"kraftkit.sh/machine/volume/disk"
+)
+
+// Contents returns a directory on the host which contains the files of the
+// volume.  The returned function must be called once the directory is no
+// longer used.
+func Contents(ctx context.Context, volume *volumev1alpha1.Volume) (string, func(), error) {
+	for _, filesystem := range disk.Filesystems() {
+		if volume.Spec.Driver != filesystem.String() {
+			continue
+		}
+
+		cfg, err := disk.DriverConfigFromStatus(volume.Status.DriverConfig)
+		if err != nil {
+			return "", nil, err
+		}
+
+		tmp, err := os.MkdirTemp("", "kraftkit-volume-*")
+		if err != nil {
+			return "", nil, fmt.Errorf("could not create temporary directory: %w", err)
+		}
+
+		cleanup := func() { _ = os.RemoveAll(tmp) }
+
+		if err := disk.ExtractImage(ctx, filesystem, cfg.Image, tmp); err != nil {
+			cleanup()
+			return "", nil, err
+		}
+
+		return tmp, cleanup, nil
+	}
+
+	fi, err := os.Stat(volume.Spec.Source)
+	if err != nil {
+		return "", nil, fmt.Errorf("cannot stat volume source: %w", err)
+	}
+
+	if !fi.IsDir() {
+		return "", nil, fmt.Errorf("volume source is not a directory: %s", volume.Spec.Source)
+	}
+
+	return volume.Spec.Source, func() {}, nil
+}
+
+// Export writes the contents of the volume as a tarball to the provided
+// writer.
+func Export(ctx context.Context, volume *volumev1alpha1.Volume, w io.Writer) error {
+	dir, cleanup, err := Contents(ctx, volume)
+	if err != nil {
+		return err
+	}
+
+	defer cleanup()
+
+	tw := tar.NewWriter(w)
+
+	if err := archive.TarDirWriter(ctx, dir, "", tw, archive.WithSkipRoot(true)); err != nil {
+		return fmt.Errorf("could not archive volume %s: %w", volume.Name, err)
+	}
+
+	return tw.Close()
+}
+
+// Import creates a new volume using the provided service which is seeded from
+// the tarball read from the provided reader.  The contents of the volume are
+// managed by KraftKit and are removed alongside the volume.
+func Import(ctx context.Context, service volumev1alpha1.VolumeService, volume *volumev1alpha1.Volume, r io.Reader) (*volumev1alpha1.Volume, error) {
+	if volume.ObjectMeta.UID == "" {
+		volume.ObjectMeta.UID = uuid.NewUUID()
+	}
+
+	source := filepath.Join(config.G[config.KraftKit](ctx).RuntimeDir, "volumes", string(volume.ObjectMeta.UID))
+
+	if err := os.MkdirAll(source, 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create volume directory: %w", err)
+	}
+
+	if err := archive.Untar(r, source); err != nil {
+		_ = os.RemoveAll(source)
+		return nil, fmt.Errorf("could not extract tarball: %w", err)
+	}
+
+	volume.Spec.Source = source
+
+	created, err := service.Create(ctx, volume)
+	if err != nil {
+		_ = os.RemoveAll(source)
+		return nil, err
+	}
+
+	created.Spec.Managed = true
+
+	return service.Update(ctx, created)
+}
+
+// Clone creates a new volume with the provided name using the provided service
+// whose contents are a copy of the provided volume.
+func Clone(ctx context.Context, service volumev1alpha1.VolumeService, volume *volumev1alpha1.Volume, name string) (*volumev1alpha1.Volume, error) {
+	pr, pw := io.Pipe()
+
+	go func() {
+		pw.CloseWithError(Export(ctx, volume, pw))
+	}()
+
+	defer pr.Close()
+
+	return Import(ctx, service, &volumev1alpha1.Volume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: volumev1alpha1.VolumeSpec{
+			Driver:   volume.Spec.Driver,
+			Mode:     volume.Spec.Mode,
+			ReadOnly: volume.Spec.ReadOnly,
+		},
+	}, pr)
+}
@@ -0,0 +1,264 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package volume
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/offline/offlinetest"
+	"kraftkit.sh/machine/volume/disk"
+	"kraftkit.sh/machine/volume/volumetest"
+)
+
+// testFiles are the contents of the volumes which are transferred.
+var testFiles = map[string]string{
+	"entrypoint.sh":  "#!/bin/sh\necho hello\n",
+	"etc/app.conf":   "key=value\n",
+	"var/lib/app/db": strings.Repeat("x", 64*1024),
+}
+
+// populate writes the test files into the provided directory.
+func populate(t *testing.T, dir string) {
+	t.Helper()
+
+	for name, content := range testFiles {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// checkFiles verifies that the provided directory holds the test files.
+func checkFiles(t *testing.T, dir string) {
+	t.Helper()
+
+	for name, content := range testFiles {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if string(got) != content {
+			t.Errorf("%s: unexpected content after transfer", name)
+		}
+	}
+}
+
+// tarballEntries returns the names of the entries of the tarball.
+func tarballEntries(t *testing.T, r io.Reader) []string {
+	t.Helper()
+
+	var names []string
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		names = append(names, header.Name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+func TestExportImport(t *testing.T) {
+	ctx := offlinetest.Context(t)
+
+	source := t.TempDir()
+	populate(t, source)
+
+	volume := &volumev1alpha1.Volume{
+		ObjectMeta: metav1.ObjectMeta{Name: "data"},
+		Spec: volumev1alpha1.VolumeSpec{
+			Driver: "9pfs",
+			Source: source,
+		},
+	}
+
+	var tarball bytes.Buffer
+	if err := Export(ctx, volume, &tarball); err != nil {
+		t.Fatal("Export:", err)
+	}
+
+	expected := []string{
+		"entrypoint.sh",
+		"etc",
+		"etc/app.conf",
+		"var",
+		"var/lib",
+		"var/lib/app",
+		"var/lib/app/db",
+	}
+
+	if names := tarballEntries(t, bytes.NewReader(tarball.Bytes())); !slices.Equal(names, expected) {
+		t.Errorf("expected tarball entries %v, got %v", expected, names)
+	}
+
+	service := volumetest.NewMemoryService()
+
+	imported, err := Import(ctx, service, &volumev1alpha1.Volume{
+		ObjectMeta: metav1.ObjectMeta{Name: "imported"},
+		Spec:       volumev1alpha1.VolumeSpec{Driver: "9pfs"},
+	}, &tarball)
+	if err != nil {
+		t.Fatal("Import:", err)
+	}
+
+	if !imported.Spec.Managed {
+		t.Errorf("expected imported volume to be managed")
+	}
+
+	runtimeDir := config.G[config.KraftKit](ctx).RuntimeDir
+	if expected := filepath.Join(runtimeDir, "volumes", string(imported.UID)); imported.Spec.Source != expected {
+		t.Errorf("expected source %s, got %s", expected, imported.Spec.Source)
+	}
+
+	checkFiles(t, imported.Spec.Source)
+
+	if _, err := Import(ctx, service, &volumev1alpha1.Volume{
+		ObjectMeta: metav1.ObjectMeta{Name: "invalid"},
+	}, strings.NewReader("not a tarball")); err == nil {
+		t.Errorf("expected importing an invalid tarball to fail")
+	}
+
+	if _, ok := service.Volumes["invalid"]; ok {
+		t.Errorf("expected no volume to be created from an invalid tarball")
+	}
+}
+
+func TestClone(t *testing.T) {
+	ctx := offlinetest.Context(t)
+
+	source := t.TempDir()
+	populate(t, source)
+
+	volume := &volumev1alpha1.Volume{
+		ObjectMeta: metav1.ObjectMeta{Name: "data"},
+		Spec: volumev1alpha1.VolumeSpec{
+			Driver:   "virtiofs",
+			Source:   source,
+			ReadOnly: true,
+		},
+	}
+
+	service := volumetest.NewMemoryService()
+
+	cloned, err := Clone(ctx, service, volume, "copy")
+	if err != nil {
+		t.Fatal("Clone:", err)
+	}
+
+	if cloned.Name != "copy" || cloned.Spec.Driver != "virtiofs" || !cloned.Spec.ReadOnly {
+		t.Errorf("expected read-only virtiofs volume named copy, got %s: %+v", cloned.Name, cloned.Spec)
+	}
+
+	if cloned.Spec.Source == source {
+		t.Errorf("expected clone to have its own source")
+	}
+
+	checkFiles(t, cloned.Spec.Source)
+
+	// Cloning a volume whose source is gone fails without creating a volume.
+	volume.Spec.Source = filepath.Join(source, "missing")
+
+	if _, err := Clone(ctx, service, volume, "broken"); err == nil {
+		t.Errorf("expected cloning a volume without source to fail")
+	}
+
+	if _, ok := service.Volumes["broken"]; ok {
+		t.Errorf("expected no volume to be created when cloning fails")
+	}
+}
+
+func TestExportImage(t *testing.T) {
+	for _, tool := range []string{"mkfs.ext4", "debugfs"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s is not installed", tool)
+		}
+	}
+
+	ctx := offlinetest.Context(t)
+
+	source := t.TempDir()
+	populate(t, source)
+
+	image := filepath.Join(t.TempDir(), "data.img")
+	if err := disk.BuildImage(ctx, disk.FilesystemExt4, source, image); err != nil {
+		t.Fatal("BuildImage:", err)
+	}
+
+	volume := &volumev1alpha1.Volume{
+		ObjectMeta: metav1.ObjectMeta{Name: "data"},
+		Spec: volumev1alpha1.VolumeSpec{
+			Driver: disk.FilesystemExt4.String(),
+			Source: source,
+		},
+		Status: volumev1alpha1.VolumeStatus{
+			DriverConfig: disk.DriverConfig{
+				Filesystem: disk.FilesystemExt4,
+				Image:      image,
+			},
+		},
+	}
+
+	var tarball bytes.Buffer
+	if err := Export(ctx, volume, &tarball); err != nil {
+		t.Fatal("Export:", err)
+	}
+
+	dest := t.TempDir()
+
+	tr := tar.NewReader(&tarball)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		path := filepath.Join(dest, header.Name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkFiles(t, dest)
+}
@@ -0,0 +1,249 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package virtiofs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"kraftkit.sh/exec"
+	"kraftkit.sh/internal/retrytimeout"
+)
+
+const (
+	// VirtiofsdBin is the name of the vhost-user file system daemon.
+	VirtiofsdBin = "virtiofsd"
+
+	// KConfigVirtiofs is the Unikraft KConfig option which must be enabled for
+	// the unikernel to be able to mount a virtio-fs volume.
+	KConfigVirtiofs = "CONFIG_LIBUKFS_VIRTIOFS"
+)
+
+// ExecConfig represents the command-line arguments for the virtiofsd binary.
+type ExecConfig struct {
+	// Tag-less socket path which QEMU connects to as the vhost-user frontend.
+	SocketPath string `flag:"--socket-path"`
+
+	// Shared directory path.
+	SharedDir string `flag:"--shared-dir"`
+
+	// The caching policy the file system should use (auto, always, never).
+	Cache string `flag:"--cache"`
+
+	// Sandbox mechanism to isolate the daemon process (namespace, chroot,
+	// none).
+	Sandbox string `flag:"--sandbox"`
+
+	// Whether the shared directory is exported read-only.
+	ReadOnly bool `flag:"--readonly"`
+}
+
+// Daemon represents a single virtiofsd instance which serves one volume.
+type Daemon struct {
+	// Source is the host directory which is shared with the machine.
+	Source string
+
+	// SocketPath is the vhost-user socket the daemon listens on.
+	SocketPath string
+
+	// PidFile is the file in which the PID of the daemon is stored.
+	PidFile string
+
+	// LogFile is the file to which the daemon's output is written.
+	LogFile string
+
+	// ReadOnly exports the shared directory read-only.
+	ReadOnly bool
+}
+
+// Start spawns the daemon in the background and waits until its socket is
+// ready to accept the connection from the VMM.
+func (daemon *Daemon) Start(ctx context.Context) error {
+	e, err := exec.NewExecutable(VirtiofsdBin, ExecConfig{
+		SocketPath: daemon.SocketPath,
+		SharedDir:  daemon.Source,
+		Cache:      "auto",
+		Sandbox:    "none",
+		ReadOnly:   daemon.ReadOnly,
+	})
+	if err != nil {
+		return fmt.Errorf("could not prepare %s executable: %w", VirtiofsdBin, err)
+	}
+
+	// Remove the socket of a previous instance, which would otherwise be
+	// mistaken for the socket of this one.
+	if err := os.Remove(daemon.SocketPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	// The log is appended to such that the output of a previous instance which
+	// has exited is kept when it is restarted.
+	logFile, err := os.OpenFile(daemon.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	defer logFile.Close()
+
+	process, err := exec.NewProcessFromExecutable(e,
+		exec.WithStdout(logFile),
+		exec.WithDetach(true),
+	)
+	if err != nil {
+		return fmt.Errorf("could not prepare %s process: %w", VirtiofsdBin, err)
+	}
+
+	if err := process.Start(ctx); err != nil {
+		return fmt.Errorf("could not start %s (is it installed?): %w", VirtiofsdBin, err)
+	}
+
+	pid, err := process.Pid()
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(daemon.PidFile, []byte(strconv.Itoa(pid)), 0o644); err != nil {
+		return fmt.Errorf("could not write %s pid file: %w", VirtiofsdBin, err)
+	}
+
+	if err := process.Release(); err != nil {
+		return err
+	}
+
+	if err := retrytimeout.RetryTimeout(5*time.Second, func() error {
+		if !daemon.Running() {
+			return fmt.Errorf("%s exited prematurely, see %s", VirtiofsdBin, daemon.LogFile)
+		}
+
+		if _, err := os.Stat(daemon.SocketPath); err != nil {
+			return fmt.Errorf("waiting for %s socket: %w", VirtiofsdBin, err)
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// pid returns the process ID of the daemon from its pid file.
+func (daemon *Daemon) pid() (int, error) {
+	data, err := os.ReadFile(daemon.PidFile)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// procDir is the mount point of the proc file system of the host.
+var procDir = "/proc"
+
+// Running returns whether the daemon process is still alive.  The process is
+// inspected via the proc file system rather than signalled, since signalling
+// succeeds both for zombie processes and for unrelated processes which have
+// re-used the PID of an exited daemon.
+func (daemon *Daemon) Running() bool {
+	pid, err := daemon.pid()
+	if err != nil {
+		return false
+	}
+
+	dir := filepath.Join(procDir, strconv.Itoa(pid))
+
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return false
+	}
+
+	// The state follows the name of the executable, which is enclosed in
+	// parentheses and may itself contain parentheses.
+	i := bytes.LastIndexByte(stat, ')')
+	if i < 0 || i+2 >= len(stat) {
+		return false
+	}
+
+	switch stat[i+2] {
+	case 'Z', 'X', 'x':
+		return false
+	}
+
+	// The process must be the daemon which listens on the socket.
+	cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline"))
+	if err != nil {
+		return false
+	}
+
+	args := strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00")
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == "--socket-path" && args[i+1] == daemon.SocketPath {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Stop terminates the daemon and removes its ephemeral files.  Stopping an
+// already exited daemon is not considered an error.
+func (daemon *Daemon) Stop() error {
+	defer func() {
+		_ = os.Remove(daemon.PidFile)
+		_ = os.Remove(daemon.SocketPath)
+	}()
+
+	if !daemon.Running() {
+		return nil
+	}
+
+	pid, err := daemon.pid()
+	if err != nil {
+		return err
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+
+	if err := process.Signal(syscall.SIGTERM); err != nil {
+		return fmt.Errorf("could not stop %s: %w", VirtiofsdBin, err)
+	}
+
+	return nil
+}
+
+// StopDaemons terminates all daemons whose pid files reside in the provided
+// machine state directory.
+func StopDaemons(stateDir string) error {
+	pidFiles, err := filepath.Glob(filepath.Join(stateDir, VirtiofsdBin+"*.pid"))
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, pidFile := range pidFiles {
+		daemon := Daemon{
+			PidFile:    pidFile,
+			SocketPath: strings.TrimSuffix(pidFile, ".pid") + ".sock",
+		}
+
+		if err := daemon.Stop(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
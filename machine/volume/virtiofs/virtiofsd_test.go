@@ -0,0 +1,165 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package virtiofs
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeProcess populates the proc directory with the stat and cmdline of a
+// process.
+func fakeProcess(t *testing.T, pid, stat string, args ...string) {
+	t.Helper()
+
+	dir := filepath.Join(procDir, pid)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmdline := strings.Join(args, "\x00") + "\x00"
+	if err := os.WriteFile(filepath.Join(dir, "cmdline"), []byte(cmdline), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDaemonRunning(t *testing.T) {
+	original := procDir
+	procDir = t.TempDir()
+	t.Cleanup(func() { procDir = original })
+
+	stateDir := t.TempDir()
+	socketPath := filepath.Join(stateDir, "virtiofsd1.sock")
+	daemonArgs := []string{"/usr/libexec/virtiofsd", "--socket-path", socketPath, "--shared-dir", "/data"}
+
+	fakeProcess(t, "100", "100 (virtiofsd) S 1 100 100 0 -1", daemonArgs...)
+	fakeProcess(t, "101", "101 (virtiofsd) Z 1 101 101 0 -1", daemonArgs...)
+	fakeProcess(t, "102", "102 (sleep) S 1 102 102 0 -1", "sleep", "60")
+	fakeProcess(t, "103", "103 (virtiofsd) R 1 103 103 0 -1", "/usr/libexec/virtiofsd", "--socket-path", filepath.Join(stateDir, "virtiofsd2.sock"))
+	fakeProcess(t, "104", "104 (a) b) (c) S 1 104 104 0 -1", daemonArgs...)
+
+	tests := []struct {
+		name     string
+		pid      string
+		expected bool
+	}{
+		{"sleeping daemon", "100", true},
+		{"zombie daemon", "101", false},
+		{"re-used pid", "102", false},
+		{"daemon of another socket", "103", false},
+		{"name with parentheses", "104", true},
+		{"exited process", "105", false},
+		{"malformed pid file", "abc", false},
+	}
+
+	for _, tc := range tests {
+		daemon := Daemon{
+			SocketPath: socketPath,
+			PidFile:    filepath.Join(stateDir, "virtiofsd1.pid"),
+		}
+
+		if err := os.WriteFile(daemon.PidFile, []byte(tc.pid+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if running := daemon.Running(); running != tc.expected {
+			t.Errorf("%s: expected running to be %t, got %t", tc.name, tc.expected, running)
+		}
+	}
+
+	// A missing pid file means the daemon was never started.
+	daemon := Daemon{PidFile: filepath.Join(stateDir, "missing.pid")}
+	if daemon.Running() {
+		t.Errorf("expected daemon without pid file not to be running")
+	}
+}
+
+func TestDaemonRunningProcess(t *testing.T) {
+	if _, err := os.Stat(filepath.Join(procDir, "self", "stat")); err != nil {
+		t.Skip("proc file system is not available")
+	}
+
+	stateDir := t.TempDir()
+	daemon := Daemon{
+		SocketPath: filepath.Join(stateDir, "virtiofsd1.sock"),
+		PidFile:    filepath.Join(stateDir, "virtiofsd1.pid"),
+	}
+
+	// The arguments following the script are only used to identify the
+	// process.
+	cmd := exec.Command("sh", "-c", "sleep 60", "--socket-path", daemon.SocketPath)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	if err := os.WriteFile(daemon.PidFile, []byte(strconv.Itoa(cmd.Process.Pid)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if !daemon.Running() {
+		t.Fatalf("expected live process to be running")
+	}
+
+	// The process is a zombie until it is waited on, which signalling does not
+	// detect.
+	if err := cmd.Process.Kill(); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for daemon.Running() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if daemon.Running() {
+		t.Errorf("expected zombie process not to be running")
+	}
+}
+
+func TestStopDaemons(t *testing.T) {
+	original := procDir
+	procDir = t.TempDir()
+	t.Cleanup(func() { procDir = original })
+
+	stateDir := t.TempDir()
+
+	// Daemons which have already exited are cleaned up without error.
+	for _, name := range []string{"virtiofsd1", "virtiofsd2"} {
+		if err := os.WriteFile(filepath.Join(stateDir, name+".pid"), []byte("999999"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(stateDir, name+".sock"), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := StopDaemons(stateDir); err != nil {
+		t.Fatal(err)
+	}
+
+	remaining, err := filepath.Glob(filepath.Join(stateDir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(remaining) > 0 {
+		t.Errorf("expected pid files and sockets to be removed, got %v", remaining)
+	}
+}
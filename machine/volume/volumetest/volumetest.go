@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package volumetest provides an in-memory volume service for tests.
+package volumetest
+
+import (
+	"context"
+	"errors"
+
+	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
+)
+
+// MemoryService is a volume service which keeps volumes in memory, keyed by
+// their name.
+type MemoryService struct {
+	Volumes map[string]*volumev1alpha1.Volume
+}
+
+// NewMemoryService returns an empty in-memory volume service.
+func NewMemoryService() *MemoryService {
+	return &MemoryService{
+		Volumes: map[string]*volumev1alpha1.Volume{},
+	}
+}
+
+// Create implements kraftkit.sh/api/volume/v1alpha1.Create
+func (service *MemoryService) Create(_ context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+	if _, ok := service.Volumes[volume.Name]; ok {
+		return nil, errors.New("volume already exists")
+	}
+
+	service.Volumes[volume.Name] = volume
+	return volume, nil
+}
+
+// Delete implements kraftkit.sh/api/volume/v1alpha1.Delete
+func (service *MemoryService) Delete(_ context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+	if volume.Status.State == volumev1alpha1.VolumeStateBound {
+		return volume, errors.New("volume is bound")
+	}
+
+	delete(service.Volumes, volume.Name)
+	return nil, nil
+}
+
+// Get implements kraftkit.sh/api/volume/v1alpha1.Get
+func (service *MemoryService) Get(_ context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+	found, ok := service.Volumes[volume.Name]
+	if !ok {
+		return nil, nil
+	}
+
+	copied := *found
+	return &copied, nil
+}
+
+// List implements kraftkit.sh/api/volume/v1alpha1.List
+func (service *MemoryService) List(_ context.Context, volumes *volumev1alpha1.VolumeList) (*volumev1alpha1.VolumeList, error) {
+	for _, volume := range service.Volumes {
+		volumes.Items = append(volumes.Items, *volume)
+	}
+
+	return volumes, nil
+}
+
+// Update implements kraftkit.sh/api/volume/v1alpha1.Update
+func (service *MemoryService) Update(_ context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+	service.Volumes[volume.Name] = volume
+	return volume, nil
+}
+
+// Watch implements kraftkit.sh/api/volume/v1alpha1.Watch
+func (service *MemoryService) Watch(context.Context, *volumev1alpha1.Volume) (chan *volumev1alpha1.Volume, chan error, error) {
+	return nil, nil, errors.New("not implemented")
+}
@@ -0,0 +1,240 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package watcher provides a shared implementation of the Watch method of the
+// volume drivers.
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apiserver/pkg/storage"
+
+	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/config"
+	"kraftkit.sh/store"
+)
+
+const (
+	// DefaultInterval is the period at which the shared volume store is re-read
+	// to pick up the changes made to a watched volume by other processes.
+	DefaultInterval = 5 * time.Second
+
+	// DefaultUsageInterval is the period at which the disk usage of a watched
+	// volume is recomputed.  Walking the contents of a large volume is costly,
+	// so this is otherwise only done when the volume changes state.
+	DefaultUsageInterval = time.Minute
+)
+
+// StoreDir returns the path of the embedded store which is shared by all
+// volume drivers.
+func StoreDir(ctx context.Context) string {
+	return filepath.Join(
+		config.G[config.KraftKit](ctx).RuntimeDir,
+		"volumev1alpha1",
+	)
+}
+
+// RefreshFunc returns the live state of the provided volume.
+type RefreshFunc func(context.Context, *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error)
+
+// Watcher broadcasts changes of volumes to subscribers, either when they are
+// explicitly notified of an update within the same process or when the state
+// of the volume, which is periodically re-read from the shared volume store
+// and refreshed, differs from the last one seen.
+type Watcher struct {
+	// Interval is the period at which the shared volume store is re-read.  It
+	// defaults to DefaultInterval.
+	Interval time.Duration
+
+	// UsageInterval is the period at which the disk usage of a watched volume is
+	// recomputed.  It defaults to DefaultUsageInterval.
+	UsageInterval time.Duration
+
+	mu          sync.Mutex
+	subscribers map[types.UID][]chan *volumev1alpha1.Volume
+}
+
+// Notify sends the provided volume to all of its subscribers.
+func (w *Watcher) Notify(volume *volumev1alpha1.Volume) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, sub := range w.subscribers[volume.UID] {
+		select {
+		case sub <- clone(volume):
+		default:
+			// Drop the notification if the subscriber is still processing the
+			// previous one, it will catch up on the next refresh.
+		}
+	}
+}
+
+func (w *Watcher) subscribe(uid types.UID) chan *volumev1alpha1.Volume {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.subscribers == nil {
+		w.subscribers = make(map[types.UID][]chan *volumev1alpha1.Volume)
+	}
+
+	sub := make(chan *volumev1alpha1.Volume, 1)
+	w.subscribers[uid] = append(w.subscribers[uid], sub)
+
+	return sub
+}
+
+func (w *Watcher) unsubscribe(uid types.UID, sub chan *volumev1alpha1.Volume) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.subscribers[uid] = slices.DeleteFunc(w.subscribers[uid], func(c chan *volumev1alpha1.Volume) bool {
+		return c == sub
+	})
+}
+
+// clone returns a copy of the volume whose status can be modified without
+// affecting the original.
+func clone(volume *volumev1alpha1.Volume) *volumev1alpha1.Volume {
+	ret := *volume
+	ret.Status.AttachedTo = slices.Clone(volume.Status.AttachedTo)
+	return &ret
+}
+
+// load returns the volume as it is recorded in the shared volume store, or nil
+// if it is not recorded.  This picks up the changes which have been made to
+// the volume by other processes, e.g. when it is attached to a machine.
+func load(ctx context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+	embeddedStore, err := store.NewEmbeddedStore[volumev1alpha1.VolumeSpec, volumev1alpha1.VolumeStatus](StoreDir(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	volumes := volumev1alpha1.VolumeList{}
+	if err := embeddedStore.GetList(ctx, "", storage.ListOptions{}, &volumes); err != nil {
+		return nil, fmt.Errorf("could not read volume %s from store: %w", volume.Name, err)
+	}
+
+	for i, found := range volumes.Items {
+		if (len(volume.UID) > 0 && found.UID == volume.UID) ||
+			(len(volume.UID) == 0 && found.Name == volume.Name) {
+			return &volumes.Items[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// changed returns whether the attributes of the volume which are of interest
+// to watchers differ.
+func changed(a, b *volumev1alpha1.Volume) bool {
+	return stateChanged(a, b) || a.Status.Size != b.Status.Size
+}
+
+// stateChanged returns whether the state or the attachments of the volume
+// differ, which is cheap to determine in contrast to its disk usage.
+func stateChanged(a, b *volumev1alpha1.Volume) bool {
+	return a.Status.State != b.Status.State ||
+		!slices.Equal(a.Status.AttachedTo, b.Status.AttachedTo)
+}
+
+// Watch returns a channel which first receives the current state of the volume
+// and subsequently every change to it until the context is cancelled.
+func (w *Watcher) Watch(ctx context.Context, volume *volumev1alpha1.Volume, refresh RefreshFunc) (chan *volumev1alpha1.Volume, chan error, error) {
+	events := make(chan *volumev1alpha1.Volume)
+	errs := make(chan error)
+
+	last, err := refresh(ctx, clone(volume))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sub := w.subscribe(volume.UID)
+
+	go func() {
+		defer w.unsubscribe(volume.UID, sub)
+
+		interval := w.Interval
+		if interval <= 0 {
+			interval = DefaultInterval
+		}
+
+		usageInterval := w.UsageInterval
+		if usageInterval <= 0 {
+			usageInterval = DefaultUsageInterval
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		usageTicker := time.NewTicker(usageInterval)
+		defer usageTicker.Stop()
+
+		// Initialize the channel with the current state of the volume, so that it
+		// can be immediately acted upon.
+		select {
+		case events <- last:
+		case <-ctx.Done():
+			return
+		}
+
+		for {
+			var next *volumev1alpha1.Volume
+			var err error
+
+			select {
+			case <-ctx.Done():
+				return
+
+			case next = <-sub:
+
+			case <-ticker.C:
+				var current *volumev1alpha1.Volume
+				current, err = load(ctx, last)
+				if err != nil || current == nil || !stateChanged(last, current) {
+					break
+				}
+
+				// The contents of the volume are likely to have changed alongside its
+				// state, e.g. when it is detached from a machine which wrote to it.
+				next, err = refresh(ctx, current)
+
+			case <-usageTicker.C:
+				next, err = refresh(ctx, clone(last))
+			}
+
+			if err != nil {
+				select {
+				case errs <- err:
+				case <-ctx.Done():
+					return
+				}
+				continue
+			} else if next == nil {
+				continue
+			}
+
+			if !changed(last, next) {
+				continue
+			}
+
+			last = next
+
+			select {
+			case events <- next:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, errs, nil
+}
@@ -0,0 +1,225 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package watcher
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	volumev1alpha1 "kraftkit.sh/api/volume/v1alpha1"
+	"kraftkit.sh/internal/offline/offlinetest"
+	"kraftkit.sh/store"
+)
+
+// sizeRefresher returns a refresh function which reports the provided size
+// and counts how many times it has been called.
+func sizeRefresher(size *atomic.Int64, calls *atomic.Int64) RefreshFunc {
+	return func(_ context.Context, volume *volumev1alpha1.Volume) (*volumev1alpha1.Volume, error) {
+		calls.Add(1)
+		volume.Status.Size = size.Load()
+		return volume, nil
+	}
+}
+
+// saveVolume records the volume in the shared volume store of the context.
+func saveVolume(t *testing.T, ctx context.Context, volume *volumev1alpha1.Volume) {
+	t.Helper()
+
+	embeddedStore, err := store.NewEmbeddedStore[volumev1alpha1.VolumeSpec, volumev1alpha1.VolumeStatus](StoreDir(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := embeddedStore.Create(ctx, volume.Name, nil, volume, 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// receive returns the next event of the watch or fails the test when none is
+// received in time.
+func receive(t *testing.T, events chan *volumev1alpha1.Volume, errs chan error) *volumev1alpha1.Volume {
+	t.Helper()
+
+	select {
+	case volume := <-events:
+		return volume
+	case err := <-errs:
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for volume event")
+	}
+
+	return nil
+}
+
+// expectNone fails the test if an event is received within the provided
+// duration.
+func expectNone(t *testing.T, events chan *volumev1alpha1.Volume, errs chan error, d time.Duration) {
+	t.Helper()
+
+	select {
+	case volume := <-events:
+		t.Errorf("expected no event, got %+v", volume.Status)
+	case err := <-errs:
+		t.Fatal(err)
+	case <-time.After(d):
+	}
+}
+
+// watchContext returns a context for the watch which is cancelled at the end of
+// the test, after which the test waits for the watch of the volume to stop so
+// that it no longer accesses the temporary directories of the test.
+func watchContext(t *testing.T, w *Watcher, uid types.UID) context.Context {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(offlinetest.Context(t))
+
+	t.Cleanup(func() {
+		cancel()
+
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			w.mu.Lock()
+			remaining := len(w.subscribers[uid])
+			w.mu.Unlock()
+
+			if remaining == 0 {
+				return
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		t.Errorf("timed out waiting for the watch to stop")
+	})
+
+	return ctx
+}
+
+func testVolume() *volumev1alpha1.Volume {
+	return &volumev1alpha1.Volume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "data",
+			UID:  "5a1b0c6e-0000-4000-8000-000000000001",
+		},
+		Status: volumev1alpha1.VolumeStatus{
+			State: volumev1alpha1.VolumeStatePending,
+		},
+	}
+}
+
+func TestWatchNotify(t *testing.T) {
+	var size, calls atomic.Int64
+	size.Store(4096)
+
+	w := Watcher{Interval: time.Hour, UsageInterval: time.Hour}
+	volume := testVolume()
+	ctx := watchContext(t, &w, volume.UID)
+
+	events, errs, err := w.Watch(ctx, volume, sizeRefresher(&size, &calls))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if initial := receive(t, events, errs); initial.Status.Size != 4096 {
+		t.Errorf("expected initial size 4096, got %d", initial.Status.Size)
+	}
+
+	// An unchanged volume is not broadcast.
+	unchanged := *volume
+	unchanged.Status.Size = 4096
+	w.Notify(&unchanged)
+	expectNone(t, events, errs, 50*time.Millisecond)
+
+	attached := unchanged
+	attached.Status.State = volumev1alpha1.VolumeStateBound
+	attached.Status.AttachedTo = []string{"my-machine"}
+	w.Notify(&attached)
+
+	next := receive(t, events, errs)
+	if next.Status.State != volumev1alpha1.VolumeStateBound || !slices.Equal(next.Status.AttachedTo, []string{"my-machine"}) {
+		t.Errorf("expected volume bound to my-machine, got %+v", next.Status)
+	}
+
+	// The broadcast volume is a copy of the notified one.
+	attached.Status.AttachedTo[0] = "other-machine"
+	if next.Status.AttachedTo[0] != "my-machine" {
+		t.Errorf("expected event to be unaffected by changes to the notified volume")
+	}
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected usage to be computed once, got %d", n)
+	}
+}
+
+func TestWatchStore(t *testing.T) {
+	var size, calls atomic.Int64
+	size.Store(4096)
+
+	w := Watcher{Interval: 10 * time.Millisecond, UsageInterval: time.Hour}
+	volume := testVolume()
+	ctx := watchContext(t, &w, volume.UID)
+
+	saveVolume(t, ctx, volume)
+
+	events, errs, err := w.Watch(ctx, volume, sizeRefresher(&size, &calls))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = receive(t, events, errs)
+
+	// Re-reading an unchanged store does not recompute the usage.
+	expectNone(t, events, errs, 100*time.Millisecond)
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected usage to be computed once while unchanged, got %d", n)
+	}
+
+	// Another process attaches the volume and writes to it.
+	size.Store(8192)
+
+	attached := testVolume()
+	attached.Status.State = volumev1alpha1.VolumeStateBound
+	attached.Status.AttachedTo = []string{"my-machine"}
+	saveVolume(t, ctx, attached)
+
+	next := receive(t, events, errs)
+	if next.Status.State != volumev1alpha1.VolumeStateBound {
+		t.Errorf("expected state %s, got %s", volumev1alpha1.VolumeStateBound, next.Status.State)
+	}
+
+	if next.Status.Size != 8192 {
+		t.Errorf("expected usage to be recomputed on state change, got size %d", next.Status.Size)
+	}
+}
+
+func TestWatchUsage(t *testing.T) {
+	var size, calls atomic.Int64
+	size.Store(4096)
+
+	w := Watcher{Interval: time.Hour, UsageInterval: 10 * time.Millisecond}
+	volume := testVolume()
+	ctx := watchContext(t, &w, volume.UID)
+
+	events, errs, err := w.Watch(ctx, volume, sizeRefresher(&size, &calls))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = receive(t, events, errs)
+
+	size.Store(8192)
+
+	if next := receive(t, events, errs); next.Status.Size != 8192 {
+		t.Errorf("expected size 8192, got %d", next.Status.Size)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"kraftkit.sh/log"
 	"kraftkit.sh/pack"
 
+	"kraftkit.sh/internal/offline"
 	"kraftkit.sh/internal/version"
 )
 
@@ -145,6 +146,10 @@ func NewManifestIndexFromURL(ctx context.Context, path string, mopts ...Manifest
 		return nil, err
 	}
 
+	if offline.Enabled(ctx) {
+		return nil, offline.NotCached(path)
+	}
+
 	client := &http.Client{}
 
 	head, err := http.NewRequestWithContext(ctx, "HEAD", path, nil)
@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 	"unicode"
 
@@ -19,6 +20,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"kraftkit.sh/config"
+	"kraftkit.sh/internal/offline"
 	"kraftkit.sh/log"
 	"kraftkit.sh/pack"
 	"kraftkit.sh/packmanager"
@@ -71,6 +73,10 @@ func NewManifestManager(ctx context.Context, opts ...any) (packmanager.PackageMa
 
 // update retrieves and returns a cache of the upstream manifest registry
 func (m *manifestManager) update(ctx context.Context) (*ManifestIndex, error) {
+	if offline.Enabled(ctx) {
+		return nil, fmt.Errorf("cannot update manifests: %w", offline.ErrOffline)
+	}
+
 	if len(m.manifests) == 0 {
 		// In this scenario, re-attempt all manifests provided within the config
 		// space which were not remotely probed during initialization.
@@ -285,6 +291,16 @@ func (m *manifestManager) Catalog(ctx context.Context, qopts ...packmanager.Quer
 	var manifests []*Manifest
 
 	query := packmanager.NewQuery(qopts...)
+
+	// In offline mode, remote queries are only answered from the local index.
+	offlineRemote := offline.Enabled(ctx) && query.Remote()
+	if offlineRemote {
+		query = packmanager.NewQuery(append(qopts,
+			packmanager.WithRemote(false),
+			packmanager.WithLocal(true),
+		)...)
+	}
+
 	mopts := []ManifestOption{
 		WithAuthConfig(query.Auths()),
 		WithCacheDir(config.G[config.KraftKit](ctx).Paths.Sources),
@@ -293,40 +309,9 @@ func (m *manifestManager) Catalog(ctx context.Context, qopts ...packmanager.Quer
 
 	log.G(ctx).WithFields(query.Fields()).Debug("querying manifest catalog")
 
-	if len(query.Source()) > 0 {
-		provider, err := NewProvider(ctx, query.Source(), mopts...)
-		if err != nil {
-			return nil, err
-		}
-
-		manifests, err = provider.Manifests()
-		if err != nil {
-			return nil, err
-		}
-	} else if query.Remote() {
-		// If Catalog is executed in multiple successive calls, which occurs when
-		// searching for multiple packages sequentially, check if the cacheIndex has
-		// been set.  Even if UseCache set has been set, it means that at least once
-		// call to Catalog has properly updated the index.
-		if m.indexCache == nil {
-			indexCache, err := m.update(ctx)
-			if err != nil {
-				return nil, err
-			}
-
-			m.indexCache = &ManifestIndex{}
-			*m.indexCache = *indexCache
-		}
-
-		manifests = m.indexCache.Manifests
-	} else if query.Local() {
-		m.indexCache, err = NewManifestIndexFromFile(m.LocalManifestIndex(ctx))
-		if err == nil {
-			manifests, err = FindManifestsFromSource(ctx, m.indexCache.Origin, mopts...)
-			if err != nil {
-				return nil, err
-			}
-		}
+	manifests, err = m.catalogManifests(ctx, query, mopts...)
+	if err != nil {
+		return nil, err
 	}
 
 	var packages []pack.Package
@@ -464,9 +449,63 @@ func (m *manifestManager) Catalog(ctx context.Context, qopts ...packmanager.Quer
 
 	log.G(ctx).Debugf("found %d/%d matching packages in manifest catalog", len(packages), len(manifests))
 
+	if offlineRemote && len(packages) == 0 && len(query.Name()) > 0 && !strings.ContainsRune(query.Name(), '*') {
+		missing := query.Name()
+		if len(query.Version()) > 0 {
+			missing += ":" + query.Version()
+		}
+
+		return nil, offline.NotCached(missing)
+	}
+
 	return packages, nil
 }
 
+// catalogManifests returns the manifests of the source of the query or otherwise of
+// the remote or local index.
+func (m *manifestManager) catalogManifests(ctx context.Context, query *packmanager.Query, mopts ...ManifestOption) ([]*Manifest, error) {
+	var err error
+	var manifests []*Manifest
+
+	if len(query.Source()) > 0 {
+		provider, err := NewProvider(ctx, query.Source(), mopts...)
+		if err != nil {
+			return nil, err
+		}
+
+		manifests, err = provider.Manifests()
+		if err != nil {
+			return nil, err
+		}
+	} else if query.Remote() {
+		// If Catalog is executed in multiple successive calls, which occurs when
+		// searching for multiple packages sequentially, check if the cacheIndex has
+		// been set.  Even if UseCache set has been set, it means that at least once
+		// call to Catalog has properly updated the index.
+		if m.indexCache == nil {
+			indexCache, err := m.update(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			m.indexCache = &ManifestIndex{}
+			*m.indexCache = *indexCache
+		}
+
+		manifests = m.indexCache.Manifests
+	} else if query.Local() {
+		m.indexCache, err = NewManifestIndexFromFile(m.LocalManifestIndex(ctx))
+		if err == nil {
+			manifests, err = FindManifestsFromSource(ctx, m.indexCache.Origin, mopts...)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return manifests, nil
+}
+
 func (m *manifestManager) IsCompatible(ctx context.Context, source string, qopts ...packmanager.QueryOption) (packmanager.PackageManager, bool, error) {
 	log.G(ctx).WithFields(logrus.Fields{
 		"source": source,
@@ -22,6 +22,7 @@ import (
 	"kraftkit.sh/pack"
 	"kraftkit.sh/unikraft"
 
+	"kraftkit.sh/internal/offline"
 	"kraftkit.sh/internal/version"
 )
 
@@ -268,6 +269,10 @@ func NewManifestFromURL(ctx context.Context, path string, mopts ...ManifestOptio
 		return nil, fmt.Errorf("provided path was not a valid URL")
 	}
 
+	if offline.Enabled(ctx) {
+		return nil, offline.NotCached(path)
+	}
+
 	var contents []byte
 	client := &http.Client{}
 
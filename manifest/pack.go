@@ -176,6 +176,39 @@ func (mp mpack) Save(ctx context.Context) error {
 	return nil
 }
 
+// Resolve implements pack.Resolver.
+func (mp mpack) Resolve(context.Context) (*pack.Resolution, error) {
+	if len(mp.manifest.Channels) == 1 {
+		channel := mp.manifest.Channels[0]
+		resolution := &pack.Resolution{
+			Version:  channel.Name,
+			Resource: channel.Resource,
+			Sha256:   channel.Sha256,
+		}
+
+		if len(channel.Latest) > 0 {
+			resolution.Version = channel.Latest
+		}
+
+		return resolution, nil
+	} else if len(mp.manifest.Versions) == 1 {
+		version := mp.manifest.Versions[0]
+		resolution := &pack.Resolution{
+			Version:  version.Version,
+			Resource: version.Resource,
+			Sha256:   version.Sha256,
+		}
+
+		if version.Type == ManifestVersionGitSha {
+			resolution.Commit = version.Version
+		}
+
+		return resolution, nil
+	}
+
+	return nil, fmt.Errorf("cannot resolve %s: too many options", mp.ID())
+}
+
 // resourceCacheChecksum returns the resource path, checksum and the cache
 // location for a given Manifestt which only has one channel or one version.  If
 // the Manifest has more than one, then it is not possible to determine which
@@ -18,6 +18,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"kraftkit.sh/archive"
+	"kraftkit.sh/internal/offline"
 	"kraftkit.sh/internal/version"
 	"kraftkit.sh/log"
 	"kraftkit.sh/pack"
@@ -59,7 +60,18 @@ func pullArchive(ctx context.Context, manifest *Manifest, opts ...pack.PullOptio
 		downloaded: 0,
 	}
 
-	if f, err := os.Stat(cache); !popts.UseCache() || err != nil || f.Size() == 0 {
+	// In offline mode a previously downloaded archive is always used since it
+	// cannot be retrieved again.
+	useCache := popts.UseCache()
+	if offline.Enabled(ctx) {
+		if f, err := os.Stat(cache); err != nil || f.Size() == 0 {
+			return offline.NotCached(resource)
+		}
+
+		useCache = true
+	}
+
+	if f, err := os.Stat(cache); !useCache || err != nil || f.Size() == 0 {
 		u, err := url.Parse(resource)
 		if err != nil {
 			return err
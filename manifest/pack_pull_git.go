@@ -20,6 +20,7 @@ import (
 	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
 
+	"kraftkit.sh/internal/offline"
 	"kraftkit.sh/log"
 	"kraftkit.sh/pack"
 	"kraftkit.sh/unikraft"
@@ -116,6 +117,10 @@ func pullGit(ctx context.Context, manifest *Manifest, opts ...pack.PullOption) e
 		return fmt.Errorf("requesting Git with empty repository in manifest")
 	}
 
+	if offline.Enabled(ctx) {
+		return offline.NotCached(manifest.Origin)
+	}
+
 	completeWorker := make(chan struct{})
 	completeParent := make(chan struct{})
 
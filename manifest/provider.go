@@ -12,6 +12,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"kraftkit.sh/internal/offline"
 	"kraftkit.sh/log"
 	"kraftkit.sh/pack"
 )
@@ -72,6 +73,11 @@ func NewProvider(ctx context.Context, path string, mopts ...ManifestOption) (Pro
 		return provider, nil
 	}
 
+	// Remote repositories cannot be probed without network access.
+	if offline.Enabled(ctx) {
+		return nil, offline.NotCached(path)
+	}
+
 	// First attempt to detect whether the provided input is a Git repository.  If
 	// it is, it could potentially be from GitHub as well.
 	log.G(ctx).WithFields(logrus.Fields{
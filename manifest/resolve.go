@@ -0,0 +1,421 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package manifest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/packmanager"
+	"kraftkit.sh/unikraft"
+)
+
+// Requirement is the version of a component which is requested by a project.
+type Requirement struct {
+	// Type of the component.
+	Type unikraft.ComponentType
+
+	// Name of the component.
+	Name string
+
+	// Version is either a literal version, a channel or a Git reference, or a
+	// semantic version constraint such as `^0.16` or `>=1.2 <2`.
+	Version string
+
+	// Source of the component, if it is not retrieved from the index.
+	Source string
+}
+
+// String implements fmt.Stringer.
+func (req Requirement) String() string {
+	return fmt.Sprintf("%s/%s:%s", req.Type, req.Name, req.Version)
+}
+
+// key identifies the component of the requirement.
+func (req Requirement) key() string {
+	return string(req.Type) + "/" + req.Name
+}
+
+// IsConstraint returns whether the version is a semantic version constraint
+// rather than a literal version, a channel or a Git reference.
+func IsConstraint(version string) bool {
+	if !strings.ContainsAny(version, "^~<>=!*, |") {
+		return false
+	}
+
+	_, err := semver.NewConstraint(version)
+	return err == nil
+}
+
+// ConflictError is returned when no set of versions satisfies the
+// requirements of a project.
+type ConflictError struct {
+	// Conflicts are the human-readable reasons for which versions were
+	// rejected.
+	Conflicts []string
+}
+
+// Error implements error.
+func (err *ConflictError) Error() string {
+	return fmt.Sprintf("could not resolve a consistent set of versions:\n  - %s",
+		strings.Join(err.Conflicts, "\n  - "),
+	)
+}
+
+// ResolveVersions picks a version for every requirement such that the version
+// constraints of the project, the Unikraft compatibility of each library
+// version and the requirements between libraries are all satisfied.  Newer
+// versions are preferred.  The picked versions are returned by the name of the
+// component, where requirements with literal versions keep their version.
+// Conflicts are returned as a *ConflictError.
+func ResolveVersions(ctx context.Context, reqs []Requirement, qopts ...packmanager.QueryOption) (map[string]string, error) {
+	// Without a manifest package manager nothing is known about the versions
+	// of the components, which is only an error for constraints.
+	manager, merr := managerOf(ctx)
+
+	query := packmanager.NewQuery(qopts...)
+	mopts := []ManifestOption{
+		WithAuthConfig(query.Auths()),
+		WithCacheDir(config.G[config.KraftKit](ctx).Paths.Sources),
+		WithUpdate(query.Remote()),
+	}
+
+	var index []*Manifest
+	candidates := map[string][]candidate{}
+
+	for _, req := range reqs {
+		var err error
+
+		manifests := index
+		if manager == nil {
+			err = merr
+		} else if len(req.Source) > 0 {
+			// Custom sources are only probed when their versions are needed, since
+			// this may require contacting the source.
+			manifests = nil
+			if IsConstraint(req.Version) {
+				manifests, err = manager.catalogManifests(ctx, packmanager.NewQuery(append(qopts, packmanager.WithSource(req.Source))...), mopts...)
+			}
+		} else if index == nil {
+			index, err = manager.catalogManifests(ctx, query, mopts...)
+			manifests = index
+		}
+
+		// Only constraints depend on the versions which are known about a
+		// component, whereas literal versions are looked up when they are pulled.
+		if err != nil && IsConstraint(req.Version) {
+			return nil, fmt.Errorf("could not retrieve versions of %s: %w", req.String(), err)
+		}
+
+		var found *Manifest
+		for _, manifest := range manifests {
+			if manifest.Type == req.Type && manifest.Name == req.Name {
+				found = manifest
+				break
+			}
+		}
+
+		candidates[req.key()] = candidatesOf(req, found)
+	}
+
+	picked, err := solve(reqs, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[string]string, len(picked))
+	for _, req := range reqs {
+		versions[req.Name] = picked[req.key()].version
+	}
+
+	return versions, nil
+}
+
+// managerOf returns the manifest package manager of the context.
+func managerOf(ctx context.Context) (*manifestManager, error) {
+	pm := packmanager.G(ctx)
+	if manager, ok := pm.(*manifestManager); ok {
+		return manager, nil
+	}
+
+	sub, err := pm.From(ManifestFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	manager, ok := sub.(*manifestManager)
+	if !ok {
+		return nil, fmt.Errorf("unexpected manifest package manager")
+	}
+
+	return manager, nil
+}
+
+// candidate is a version which may be picked for a requirement.
+type candidate struct {
+	// version is the exact version, or the literal version of the requirement.
+	version string
+
+	// semver is the semantic version of the candidate, or nil if it is not
+	// known, e.g. for channels without a latest version or Git references.
+	semver *semver.Version
+
+	// unikraft is the version or constraint of the core which the candidate is
+	// compatible with.
+	unikraft string
+
+	// requires are the version constraints of the candidate on other libraries.
+	requires map[string]string
+}
+
+// candidatesOf returns the versions of the manifest which may be picked for the
+// requirement, from the newest to the oldest.
+func candidatesOf(req Requirement, manifest *Manifest) []candidate {
+	if !IsConstraint(req.Version) {
+		// A literal version is kept as-is and only the information which is known
+		// about it is used to check the compatibility of other versions.
+		literal := candidate{version: req.Version}
+
+		if manifest != nil {
+			for _, version := range manifest.Versions {
+				if version.Version == req.Version {
+					literal = candidateOf(version)
+					break
+				}
+			}
+
+			for _, channel := range manifest.Channels {
+				if channel.Name == req.Version {
+					if v, err := semver.NewVersion(channel.Latest); err == nil {
+						literal.semver = v
+					}
+					break
+				}
+			}
+		}
+
+		return []candidate{literal}
+	}
+
+	if manifest == nil {
+		return nil
+	}
+
+	constraint, _ := semver.NewConstraint(req.Version)
+
+	var candidates []candidate
+	for _, version := range manifest.Versions {
+		if version.Type == ManifestVersionGitSha {
+			continue
+		}
+
+		c := candidateOf(version)
+		if c.semver == nil || !constraint.Check(c.semver) {
+			continue
+		}
+
+		candidates = append(candidates, c)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].semver.GreaterThan(candidates[j].semver)
+	})
+
+	return candidates
+}
+
+// candidateOf returns the candidate of a version of a manifest.
+func candidateOf(version ManifestVersion) candidate {
+	c := candidate{
+		version:  version.Version,
+		unikraft: version.Unikraft,
+		requires: version.Requires,
+	}
+
+	if version.Type != ManifestVersionGitSha {
+		if v, err := semver.NewVersion(version.Version); err == nil {
+			c.semver = v
+		}
+	}
+
+	return c
+}
+
+// compatible returns whether a library which declares compatibility with the
+// provided core version or constraint can be used with the core.  A version
+// is compatible with every core of the same minor release.
+func compatible(unikraft string, core *semver.Version) bool {
+	if len(unikraft) == 0 || core == nil {
+		return true
+	}
+
+	if IsConstraint(unikraft) {
+		constraint, _ := semver.NewConstraint(unikraft)
+		return constraint.Check(core)
+	}
+
+	v, err := semver.NewVersion(unikraft)
+	if err != nil {
+		return true
+	}
+
+	return v.Major() == core.Major() && v.Minor() == core.Minor()
+}
+
+// satisfies returns whether the candidate satisfies the constraint.  Candidates
+// whose semantic version is not known are assumed to satisfy any constraint.
+func satisfies(c candidate, constraint string) bool {
+	if c.semver == nil {
+		return true
+	}
+
+	parsed, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return c.version == constraint
+	}
+
+	return parsed.Check(c.semver)
+}
+
+// solve picks a candidate for every requirement using a depth-first search
+// which starts with the core and prefers the newest versions.
+func solve(reqs []Requirement, candidates map[string][]candidate) (map[string]candidate, error) {
+	var conflicts []string
+
+	ordered := make([]Requirement, len(reqs))
+	copy(ordered, reqs)
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if (ordered[i].Type == unikraft.ComponentTypeCore) != (ordered[j].Type == unikraft.ComponentTypeCore) {
+			return ordered[i].Type == unikraft.ComponentTypeCore
+		}
+
+		return ordered[i].key() < ordered[j].key()
+	})
+
+	libraries := map[string]Requirement{}
+	var core *Requirement
+	for i, req := range ordered {
+		switch req.Type {
+		case unikraft.ComponentTypeCore:
+			core = &ordered[i]
+		case unikraft.ComponentTypeLib:
+			libraries[req.Name] = req
+		}
+
+		if len(candidates[req.key()]) == 0 {
+			conflicts = append(conflicts, fmt.Sprintf("no version of %s/%s satisfies '%s'", req.Type, req.Name, req.Version))
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return nil, &ConflictError{Conflicts: conflicts}
+	}
+
+	picked := map[string]candidate{}
+	deepest := -1
+
+	// check returns why the candidate cannot be picked for the requirement given
+	// the candidates which have been picked so far.
+	check := func(req Requirement, c candidate) []string {
+		var reasons []string
+
+		if req.Type == unikraft.ComponentTypeLib && core != nil {
+			if uk, ok := picked[core.key()]; ok && !compatible(c.unikraft, uk.semver) {
+				reasons = append(reasons, fmt.Sprintf("%s/%s %s requires unikraft '%s' but unikraft %s is picked",
+					req.Type, req.Name, c.version, c.unikraft, uk.version,
+				))
+			}
+		}
+
+		for _, other := range ordered {
+			picker, ok := picked[other.key()]
+			if !ok {
+				continue
+			}
+
+			if constraint, ok := picker.requires[req.Name]; ok && req.Type == unikraft.ComponentTypeLib && !satisfies(c, constraint) {
+				reasons = append(reasons, fmt.Sprintf("%s/%s %s requires %s '%s' but %s is considered",
+					other.Type, other.Name, picker.version, req.Name, constraint, c.version,
+				))
+			}
+		}
+
+		for name, constraint := range c.requires {
+			lib, ok := libraries[name]
+			if !ok {
+				reasons = append(reasons, fmt.Sprintf("%s/%s %s requires %s '%s' which is not a library of the project",
+					req.Type, req.Name, c.version, name, constraint,
+				))
+				continue
+			}
+
+			if dep, ok := picked[lib.key()]; ok && !satisfies(dep, constraint) {
+				reasons = append(reasons, fmt.Sprintf("%s/%s %s requires %s '%s' but %s %s is picked",
+					req.Type, req.Name, c.version, name, constraint, name, dep.version,
+				))
+			}
+		}
+
+		return reasons
+	}
+
+	var search func(int) bool
+	search = func(i int) bool {
+		if i == len(ordered) {
+			return true
+		}
+
+		if i > deepest {
+			deepest = i
+			conflicts = nil
+		}
+
+		req := ordered[i]
+		for _, c := range candidates[req.key()] {
+			if reasons := check(req, c); len(reasons) > 0 {
+				if i == deepest {
+					conflicts = append(conflicts, reasons...)
+				}
+				continue
+			}
+
+			picked[req.key()] = c
+			if search(i + 1) {
+				return true
+			}
+
+			delete(picked, req.key())
+		}
+
+		return false
+	}
+
+	if !search(0) {
+		return nil, &ConflictError{Conflicts: dedup(conflicts)}
+	}
+
+	return picked, nil
+}
+
+// dedup removes repeated entries while keeping their order.
+func dedup(entries []string) []string {
+	seen := map[string]bool{}
+
+	var ret []string
+	for _, entry := range entries {
+		if !seen[entry] {
+			seen[entry] = true
+			ret = append(ret, entry)
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package manifest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"kraftkit.sh/unikraft"
+)
+
+func TestIsConstraint(t *testing.T) {
+	for version, expected := range map[string]bool{
+		"^0.16":     true,
+		">=1.2 <2":  true,
+		"~0.16.1":   true,
+		"0.16.1":    false,
+		"v0.16.1":   false,
+		"stable":    false,
+		"staging":   false,
+		"a1b2c3d":   false,
+		"1.2.x":     false,
+		">=foo":     false,
+		"1.0 || ^2": true,
+	} {
+		if actual := IsConstraint(version); actual != expected {
+			t.Errorf("IsConstraint(%q): expected %t, got %t", version, expected, actual)
+		}
+	}
+}
+
+func TestResolveConsistentSet(t *testing.T) {
+	reqs := []Requirement{
+		{Type: unikraft.ComponentTypeCore, Name: "unikraft", Version: "^0.16"},
+		{Type: unikraft.ComponentTypeLib, Name: "lwip", Version: ">=0.15"},
+		{Type: unikraft.ComponentTypeLib, Name: "musl", Version: "^0.16"},
+	}
+
+	core := &Manifest{Type: unikraft.ComponentTypeCore, Name: "unikraft", Versions: []ManifestVersion{
+		{Version: "0.16.0", Type: ManifestVersionSemver},
+		{Version: "0.16.3", Type: ManifestVersionSemver},
+		{Version: "0.17.0", Type: ManifestVersionSemver},
+	}}
+	lwip := &Manifest{Type: unikraft.ComponentTypeLib, Name: "lwip", Versions: []ManifestVersion{
+		{Version: "0.15.0", Type: ManifestVersionSemver, Unikraft: "0.15.0"},
+		{Version: "0.16.0", Type: ManifestVersionSemver, Unikraft: "0.16.0"},
+		{Version: "0.17.0", Type: ManifestVersionSemver, Unikraft: "0.17.0"},
+	}}
+	musl := &Manifest{Type: unikraft.ComponentTypeLib, Name: "musl", Versions: []ManifestVersion{
+		{Version: "0.16.0", Type: ManifestVersionSemver, Unikraft: "0.16.0", Requires: map[string]string{"lwip": "<0.16.0"}},
+		{Version: "0.16.1", Type: ManifestVersionSemver, Unikraft: "0.16.0", Requires: map[string]string{"lwip": "^0.16"}},
+	}}
+
+	picked, err := solve(reqs, map[string][]candidate{
+		reqs[0].key(): candidatesOf(reqs[0], core),
+		reqs[1].key(): candidatesOf(reqs[1], lwip),
+		reqs[2].key(): candidatesOf(reqs[2], musl),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	versions := map[string]string{}
+	for key, c := range picked {
+		versions[key] = c.version
+	}
+
+	expected := map[string]string{
+		"core/unikraft": "0.16.3",
+		"lib/lwip":      "0.16.0",
+		"lib/musl":      "0.16.1",
+	}
+
+	if !reflect.DeepEqual(versions, expected) {
+		t.Errorf("expected %v, got %v", expected, versions)
+	}
+}
+
+func TestResolveConflict(t *testing.T) {
+	reqs := []Requirement{
+		{Type: unikraft.ComponentTypeCore, Name: "unikraft", Version: "0.16.0"},
+		{Type: unikraft.ComponentTypeLib, Name: "lwip", Version: "^0.17"},
+	}
+
+	core := &Manifest{Type: unikraft.ComponentTypeCore, Name: "unikraft", Versions: []ManifestVersion{
+		{Version: "0.16.0", Type: ManifestVersionSemver},
+	}}
+	lwip := &Manifest{Type: unikraft.ComponentTypeLib, Name: "lwip", Versions: []ManifestVersion{
+		{Version: "0.17.0", Type: ManifestVersionSemver, Unikraft: "0.17.0"},
+	}}
+
+	_, err := solve(reqs, map[string][]candidate{
+		reqs[0].key(): candidatesOf(reqs[0], core),
+		reqs[1].key(): candidatesOf(reqs[1], lwip),
+	})
+
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+
+	expected := []string{"lib/lwip 0.17.0 requires unikraft '0.17.0' but unikraft 0.16.0 is picked"}
+	if !reflect.DeepEqual(conflict.Conflicts, expected) {
+		t.Errorf("expected %q, got %q", expected, conflict.Conflicts)
+	}
+}
+
+func TestResolveUnsatisfiable(t *testing.T) {
+	reqs := []Requirement{
+		{Type: unikraft.ComponentTypeLib, Name: "lwip", Version: "^1.0"},
+	}
+
+	lwip := &Manifest{Type: unikraft.ComponentTypeLib, Name: "lwip", Versions: []ManifestVersion{
+		{Version: "0.17.0", Type: ManifestVersionSemver},
+	}}
+
+	_, err := solve(reqs, map[string][]candidate{
+		reqs[0].key(): candidatesOf(reqs[0], lwip),
+	})
+
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || len(conflict.Conflicts) != 1 {
+		t.Fatalf("expected a single conflict, got %v", err)
+	}
+}
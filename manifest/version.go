@@ -48,6 +48,10 @@ type ManifestVersion struct {
 	Sha256   string              `yaml:"sha256,omitempty"`
 	Type     ManifestVersionType `yaml:"type,omitempty"`
 	Unikraft string              `yaml:"unikraft,omitempty"`
+
+	// Requires are the semantic version constraints of this version on other
+	// libraries, by the name of the library.
+	Requires map[string]string `yaml:"requires,omitempty"`
 }
 
 func (mv *ManifestVersion) ShortGitSha() (string, error) {
@@ -20,4 +20,5 @@ const (
 	AnnotationFilesystemPath       = "org.unikraft.filesystem"
 	AnnotationDiskIndexPathPattern = "org.unikraft.disk-%d"
 	AnnotationKraftKitVersion      = "sh.kraftkit.version"
+	AnnotationReferrerOf           = "sh.kraftkit.referrer.of"
 )
@@ -0,0 +1,29 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package oci
+
+import (
+	"context"
+	"fmt"
+
+	"kraftkit.sh/oci/handler"
+)
+
+// GarbageCollect removes the blobs of the local store which are no longer
+// reachable from any tagged package.  If dryRun is set, the blobs are only
+// reported.
+func GarbageCollect(ctx context.Context, dryRun bool) (*handler.GarbageCollectResult, error) {
+	ctx, handle, err := newHandle(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	collector, ok := handle.(handler.GarbageCollector)
+	if !ok {
+		return nil, fmt.Errorf("garbage collection is not supported by the configured handler")
+	}
+
+	return collector.GarbageCollect(ctx, dryRun)
+}
@@ -114,6 +114,24 @@ func (handle *ContainerdHandler) DigestInfo(ctx context.Context, dgst digest.Dig
 	return &info, nil
 }
 
+// FetchDigest implements DigestFetcher.
+func (handle *ContainerdHandler) FetchDigest(ctx context.Context, dgst digest.Digest) (io.ReadCloser, error) {
+	readerAt, err := handle.client.ContentStore().ReaderAt(ctx, ocispec.Descriptor{
+		Digest: dgst,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{
+		Reader: content.NewReader(readerAt),
+		Closer: readerAt,
+	}, nil
+}
+
 // PullDigest implements DigestPuller.
 func (handle *ContainerdHandler) PullDigest(ctx context.Context, mediaType, fullref string, dgst digest.Digest, plat *ocispec.Platform, onProgress func(float64)) error {
 	progress := make(chan struct{})
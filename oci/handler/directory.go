@@ -34,7 +34,6 @@ import (
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
-	"github.com/google/go-containerregistry/pkg/v1/types"
 	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
@@ -90,8 +89,25 @@ func (handle *DirectoryHandler) DigestInfo(ctx context.Context, needle digest.Di
 	}, nil
 }
 
+// FetchDigest implements DigestFetcher.
+func (handle *DirectoryHandler) FetchDigest(ctx context.Context, dgst digest.Digest) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(
+		handle.path,
+		DirectoryHandlerDigestsDir,
+		dgst.Algorithm().String(),
+		dgst.Encoded(),
+	))
+}
+
 // PullDigest implements DigestPuller.
 func (handle *DirectoryHandler) PullDigest(ctx context.Context, mediaType, fullref string, dgst digest.Digest, plat *ocispec.Platform, onProgress func(float64)) error {
+	unlock, err := handle.lockShared()
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
 	ref, err := name.ParseReference(fullref)
 	if err != nil {
 		return err
@@ -109,16 +125,21 @@ func (handle *DirectoryHandler) PullDigest(ctx context.Context, mediaType, fullr
 		}),
 	}
 
+	// Blobs are downloaded directly such that they can be resumed, for which the
+	// authenticator and transport are retained.
+	var authenticator authn.Authenticator = authn.Anonymous
+	var roundTripper http.RoundTripper = remote.DefaultTransport
+
 	// Annoyingly convert between regtypes and authn.
 	if auth, ok := handle.auths[ref.Context().RegistryStr()]; ok {
 		authConfig.Username = auth.User
 		authConfig.Password = auth.Token
 
-		ropts = append(ropts,
-			remote.WithAuth(&simpleauth.SimpleAuthenticator{
-				Auth: authConfig,
-			}),
-		)
+		authenticator = &simpleauth.SimpleAuthenticator{
+			Auth: authConfig,
+		}
+
+		ropts = append(ropts, remote.WithAuth(authenticator))
 
 		if !auth.VerifySSL {
 			transport := remote.DefaultTransport.(*http.Transport).Clone()
@@ -126,6 +147,7 @@ func (handle *DirectoryHandler) PullDigest(ctx context.Context, mediaType, fullr
 				InsecureSkipVerify: true,
 			}
 
+			roundTripper = transport
 			ropts = append(ropts, remote.WithTransport(transport))
 		}
 	}
@@ -145,11 +167,14 @@ func (handle *DirectoryHandler) PullDigest(ctx context.Context, mediaType, fullr
 		localManifests := []ocispec.Descriptor{}
 		var indexTagPath string
 
-		if !strings.ContainsRune(fullref, '@') && len(strings.SplitN(fullref, ":", 2)) == 2 {
+		// The digest of a tag may be pinned, e.g. `repo:tag@sha256:...`, in which
+		// case the index is still recorded under its tag.
+		tagref, _, _ := strings.Cut(fullref, "@")
+		if strings.LastIndex(tagref, ":") > strings.LastIndex(tagref, "/") {
 			indexTagPath = filepath.Join(
 				handle.path,
 				DirectoryHandlerIndexesDir,
-				strings.ReplaceAll(fullref, ":", string(filepath.Separator)),
+				strings.ReplaceAll(tagref, ":", string(filepath.Separator)),
 			)
 
 			if indexFi, err := os.Stat(indexTagPath); err == nil {
@@ -475,95 +500,80 @@ func (handle *DirectoryHandler) PullDigest(ctx context.Context, mediaType, fullr
 			totalSize += layer.Size
 		}
 
-		for _, layer := range manifest.Layers {
-			if err := handle.PullDigest(ctx,
-				ocispec.MediaTypeImageLayer,
-				fullref,
-				layer.Digest,
-				plat,
-				func(size float64) {
-					onProgress(size / float64(totalSize))
-				},
-			); err != nil {
-				return fmt.Errorf("could not pull layer from digest: %w", err)
-			}
+		parallel := maxParallelLayers
+		if config.G[config.KraftKit](ctx).NoParallel {
+			parallel = 1
 		}
 
-	case ocispec.MediaTypeImageLayer, ocispec.MediaTypeImageLayerGzip:
-		log.G(ctx).
-			WithField("digest", dgst.String()).
-			Debugf("pulling layer")
+		// Track the progress of each layer, which are pulled concurrently, such
+		// that the overall progress can be reported.
+		var mu sync.Mutex
+		pulled := make([]float64, len(manifest.Layers))
 
-		fullref = fmt.Sprintf("%s/%s@%s",
-			ref.Context().RegistryStr(),
-			ref.Context().RepositoryStr(),
-			dgst.String(),
-		)
+		eg, egCtx := errgroup.WithContext(ctx)
+		eg.SetLimit(parallel)
 
-		layerV1Dgst, err := name.NewDigest(fullref)
-		if err != nil {
-			return fmt.Errorf("could not generate full digest: %w", err)
-		}
+		for i, layer := range manifest.Layers {
+			i, layer := i, layer
 
-		layer, err := remote.Layer(layerV1Dgst, ropts...)
-		if err != nil {
-			return fmt.Errorf("could not get remote layer: %w", err)
-		}
+			eg.Go(func() error {
+				log.G(egCtx).
+					WithField("digest", layer.Digest.String()).
+					Debugf("pulling layer")
 
-		mediaType, err := layer.MediaType()
-		if err != nil {
-			return fmt.Errorf("could not get media type of layer: %w", err)
-		}
+				if err := handle.pullBlob(egCtx, ref, layer, authenticator, roundTripper,
+					func(size float64) {
+						if onProgress == nil || totalSize == 0 {
+							return
+						}
 
-		var reader io.ReadCloser
+						mu.Lock()
+						defer mu.Unlock()
 
-		switch mediaType {
-		case ocispec.MediaTypeImageLayer, types.DockerUncompressedLayer:
-			reader, err = layer.Uncompressed()
-		case ocispec.MediaTypeImageLayerGzip, types.DockerLayer:
-			reader, err = layer.Compressed()
-		default:
-			return fmt.Errorf("unsupported layer mediatype '%s'", mediaType)
-		}
-		if err != nil {
-			return fmt.Errorf("could not open layer reader: %w", err)
+						pulled[i] = size
+
+						var sum float64
+						for _, p := range pulled {
+							sum += p
+						}
+
+						onProgress(sum / float64(totalSize))
+					},
+				); err != nil {
+					return fmt.Errorf("could not pull layer from digest: %w", err)
+				}
+
+				return nil
+			})
 		}
 
-		var progresReader io.Reader
-		if onProgress != nil {
-			progresReader = &progressWriter{
-				Reader:     reader,
-				onProgress: onProgress,
-			}
-		} else {
-			progresReader = reader
+		if err := eg.Wait(); err != nil {
+			return err
 		}
 
-		layerPath := filepath.Join(
-			handle.path,
-			DirectoryHandlerDigestsDir,
-			dgst.Algorithm().String(),
-			dgst.Encoded(),
-		)
+	case ocispec.MediaTypeImageLayer, ocispec.MediaTypeImageLayerGzip:
+		log.G(ctx).
+			WithField("digest", dgst.String()).
+			Debugf("pulling layer")
 
-		if err = os.MkdirAll(filepath.Dir((layerPath)), 0o775); err != nil {
-			return fmt.Errorf("could not make directory: %w", err)
+		// Without a manifest, the size of the layer is retrieved from the registry
+		// such that the download can be bounded.
+		layer, err := remote.Layer(ref.Context().Digest(dgst.String()), ropts...)
+		if err != nil {
+			return fmt.Errorf("could not retrieve remote layer: %w", err)
 		}
 
-		blob, err := os.OpenFile(layerPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o664)
+		size, err := layer.Size()
 		if err != nil {
-			return fmt.Errorf("could not create layer: %w", err)
+			return fmt.Errorf("could not get layer size: %w", err)
 		}
-		defer blob.Close()
 
-		if _, err := io.Copy(blob, progresReader); err != nil {
-			if err2 := blob.Close(); err2 != nil {
-				return fmt.Errorf("%w: could not close blob: %w", err, err2)
-			}
-			if err2 := os.RemoveAll(layerPath); err2 != nil {
-				return fmt.Errorf("%w: could not remove blob: %w", err, err2)
-			}
-			return fmt.Errorf("could not write layer: %w", err)
+		if err := handle.pullBlob(ctx, ref, ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    dgst,
+			Size:      size,
+		}, authenticator, roundTripper, onProgress); err != nil {
+			return err
 		}
 
 	default:
@@ -575,6 +585,13 @@ func (handle *DirectoryHandler) PullDigest(ctx context.Context, mediaType, fullr
 
 // SaveDescriptor implements DescriptorSaver.
 func (handle *DirectoryHandler) SaveDescriptor(ctx context.Context, ref string, desc ocispec.Descriptor, reader io.Reader, onProgress func(float64)) error {
+	unlock, err := handle.lockShared()
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
 	blobPath := filepath.Join(
 		handle.path,
 		DirectoryHandlerDigestsDir,
@@ -582,6 +599,29 @@ func (handle *DirectoryHandler) SaveDescriptor(ctx context.Context, ref string,
 		desc.Digest.Encoded(),
 	)
 
+	// Blobs are content-addressed, so an existing blob of the same size is the
+	// same blob and is not written again, since it may be in use by another
+	// manifest.  Its modification time is refreshed such that garbage
+	// collection considers it in use until the image which is being saved is
+	// tagged.
+	if fi, err := os.Stat(blobPath); err == nil && desc.Size > 0 && fi.Size() == desc.Size {
+		log.G(ctx).
+			WithField("ref", ref).
+			WithField("digest", desc.Digest.String()).
+			Trace("blob already exists")
+
+		now := time.Now()
+		if err := os.Chtimes(blobPath, now, now); err != nil {
+			return fmt.Errorf("could not refresh blob: %w", err)
+		}
+
+		if onProgress != nil {
+			onProgress(1)
+		}
+
+		return handle.tagIndex(ref, desc, blobPath)
+	}
+
 	// Create the parent directory if it does not exist
 	if err := os.MkdirAll(filepath.Dir(blobPath), 0o774); err != nil {
 		return fmt.Errorf("could not make parent directory: %w", err)
@@ -619,7 +659,12 @@ func (handle *DirectoryHandler) SaveDescriptor(ctx context.Context, ref string,
 		return err
 	}
 
-	// Create a symbolic representing the tag if this is an index.
+	return handle.tagIndex(ref, desc, blobPath)
+}
+
+// tagIndex creates a symbolic link representing the tag of the reference if
+// the descriptor is an index.
+func (handle *DirectoryHandler) tagIndex(ref string, desc ocispec.Descriptor, blobPath string) error {
 	switch desc.MediaType {
 	case ocispec.MediaTypeImageIndex:
 		if !strings.ContainsRune(ref, '@') && len(strings.SplitN(ref, ":", 2)) == 2 {
@@ -629,7 +674,7 @@ func (handle *DirectoryHandler) SaveDescriptor(ctx context.Context, ref string,
 				strings.ReplaceAll(ref, ":", string(filepath.Separator)),
 			)
 
-			if _, err := os.Stat(indexTagPath); err == nil {
+			if _, err := os.Lstat(indexTagPath); err == nil {
 				if err := os.RemoveAll(indexTagPath); err != nil {
 					return fmt.Errorf("could not create symbolic link to new index: %w", err)
 				}
@@ -858,7 +903,21 @@ func (handle *DirectoryHandler) DeleteManifest(ctx context.Context, fullref stri
 		return err
 	}
 
+	// Blobs are shared between manifests, e.g. the same kernel in two different
+	// tags, and must only be deleted once nothing else refers to them.
+	inUse, err := handle.referencedExcept(dgst)
+	if err != nil {
+		return err
+	}
+
 	for _, layer := range manifest.Layers {
+		if _, ok := inUse[layer.Digest]; ok {
+			log.G(ctx).
+				WithField("digest", layer.Digest.String()).
+				Trace("keeping shared layer")
+			continue
+		}
+
 		layerPath := filepath.Join(
 			handle.path,
 			DirectoryHandlerDigestsDir,
@@ -875,19 +934,21 @@ func (handle *DirectoryHandler) DeleteManifest(ctx context.Context, fullref stri
 		}
 	}
 
-	configPath := filepath.Join(
-		handle.path,
-		DirectoryHandlerDigestsDir,
-		manifest.Config.Digest.Algorithm().String(),
-		manifest.Config.Digest.Hex(),
-	)
+	if _, ok := inUse[manifest.Config.Digest]; !ok {
+		configPath := filepath.Join(
+			handle.path,
+			DirectoryHandlerDigestsDir,
+			manifest.Config.Digest.Algorithm().String(),
+			manifest.Config.Digest.Hex(),
+		)
 
-	log.G(ctx).
-		WithField("digest", manifest.Config.Digest.String()).
-		Trace("deleting config")
+		log.G(ctx).
+			WithField("digest", manifest.Config.Digest.String()).
+			Trace("deleting config")
 
-	if err := os.RemoveAll(configPath); err != nil {
-		return fmt.Errorf("could not delete config digest from manifest '%s': %w", dgst.String(), err)
+		if err := os.RemoveAll(configPath); err != nil {
+			return fmt.Errorf("could not delete config digest from manifest '%s': %w", dgst.String(), err)
+		}
 	}
 
 	// Update the index manifest such that the specific manifests do not exit.  If
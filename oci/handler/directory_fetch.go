@@ -0,0 +1,355 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"kraftkit.sh/internal/lockedfile"
+	"kraftkit.sh/internal/version"
+	"kraftkit.sh/log"
+)
+
+// DirectoryHandlerIngestDir contains the partially downloaded blobs which are
+// resumed when a pull is retried.
+const DirectoryHandlerIngestDir = "ingest"
+
+var (
+	// blobPullAttempts is the number of times the download of a blob is
+	// attempted before giving up.
+	blobPullAttempts = 5
+
+	// blobPullBackoff is the delay before the first retry of a download, which
+	// doubles with every subsequent retry up to blobPullMaxBackoff.
+	blobPullBackoff    = 500 * time.Millisecond
+	blobPullMaxBackoff = 10 * time.Second
+
+	// maxParallelLayers is the number of layers of a manifest which are pulled
+	// concurrently.
+	maxParallelLayers = 4
+)
+
+// transientError is an error after which the download of a blob can be
+// retried.
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string {
+	return e.err.Error()
+}
+
+func (e *transientError) Unwrap() error {
+	return e.err
+}
+
+// pullBlob downloads the blob of the descriptor from the repository of the
+// reference into the store.  The blob is written to the ingest directory first
+// such that an interrupted download is resumed by the next attempt and is only
+// moved into the store once its digest has been verified.  The onProgress
+// callback receives the number of bytes of the blob which have been downloaded
+// so far.
+func (handle *DirectoryHandler) pullBlob(ctx context.Context, ref name.Reference, desc ocispec.Descriptor, auth authn.Authenticator, rt http.RoundTripper, onProgress func(float64)) error {
+	dgst := desc.Digest
+	blobPath := handle.blobPath(dgst)
+
+	// Blobs are only moved into the store once verified, so an existing blob is
+	// complete.
+	if handle.blobExists(blobPath, onProgress) {
+		return nil
+	}
+
+	repo := ref.Context()
+
+	rt, err := transport.NewWithContext(ctx,
+		repo.Registry,
+		auth,
+		transport.NewUserAgent(rt, version.UserAgent()),
+		[]string{repo.Scope(transport.PullScope)},
+	)
+	if err != nil {
+		return fmt.Errorf("could not authenticate with registry: %w", err)
+	}
+
+	client := &http.Client{Transport: rt}
+	url := fmt.Sprintf("%s://%s/v2/%s/blobs/%s",
+		repo.Scheme(),
+		repo.RegistryStr(),
+		repo.RepositoryStr(),
+		dgst.String(),
+	)
+
+	ingestPath := filepath.Join(
+		handle.path,
+		DirectoryHandlerIngestDir,
+		dgst.Algorithm().String(),
+		dgst.Encoded(),
+	)
+
+	if err := os.MkdirAll(filepath.Dir(ingestPath), 0o775); err != nil {
+		return fmt.Errorf("could not make ingest directory: %w", err)
+	}
+
+	// The partial blob is shared by all pulls of the same digest, be it by other
+	// processes or by layers of the same manifest, which therefore take turns.
+	// The lock file is kept since removing it would race with waiting pulls.
+	unlock, err := lockedfile.MutexAt(ingestPath + ".lock").Lock()
+	if err != nil {
+		return fmt.Errorf("could not lock partial blob: %w", err)
+	}
+
+	defer unlock()
+
+	// The blob may have been pulled whilst waiting for the lock.
+	if handle.blobExists(blobPath, onProgress) {
+		return nil
+	}
+
+	backoff := blobPullBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := fetchBlob(ctx, client, url, ingestPath, desc.Size, onProgress)
+		if err == nil {
+			err = verifyBlob(ingestPath, dgst)
+		}
+		if err == nil {
+			break
+		}
+
+		var terr *transientError
+		if !errors.As(err, &terr) || attempt >= blobPullAttempts {
+			return fmt.Errorf("could not pull blob '%s': %w", dgst.String(), err)
+		}
+
+		log.G(ctx).
+			WithField("digest", dgst.String()).
+			WithField("attempt", attempt).
+			Debugf("retrying pull in %s: %v", backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, blobPullMaxBackoff)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(blobPath), 0o775); err != nil {
+		return fmt.Errorf("could not make directory: %w", err)
+	}
+
+	if err := os.Rename(ingestPath, blobPath); err != nil {
+		return fmt.Errorf("could not move blob into store: %w", err)
+	}
+
+	return nil
+}
+
+// blobExists reports whether the blob at the provided path is in the store, in
+// which case its size is reported as the progress.
+func (handle *DirectoryHandler) blobExists(path string, onProgress func(float64)) bool {
+	fi, err := os.Stat(path)
+	if err != nil || !fi.Mode().IsRegular() {
+		return false
+	}
+
+	if onProgress != nil {
+		onProgress(float64(fi.Size()))
+	}
+
+	return true
+}
+
+// fetchBlob appends the remainder of the blob at the URL to the partially
+// downloaded file at the provided path, up to the size of the blob.
+func fetchBlob(ctx context.Context, client *http.Client, url, path string, size int64, onProgress func(float64)) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o664)
+	if err != nil {
+		return fmt.Errorf("could not open partial blob: %w", err)
+	}
+
+	defer f.Close()
+
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	// The partial blob is complete, which is subsequently verified.
+	if offset == size {
+		return nil
+	} else if offset > size {
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+
+		return &transientError{fmt.Errorf("partial blob exceeds size of %d bytes", size)}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		return &transientError{err}
+	}
+
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+		start, err := contentRangeStart(resp.Header.Get("Content-Range"))
+		if err != nil || start != offset {
+			if err := f.Truncate(0); err != nil {
+				return err
+			}
+
+			return &transientError{fmt.Errorf("unexpected content range '%s'", resp.Header.Get("Content-Range"))}
+		}
+
+		log.G(ctx).
+			WithField("url", url).
+			WithField("offset", offset).
+			Trace("resuming pull")
+
+	case resp.StatusCode == http.StatusOK:
+		// The registry does not support ranges and sends the blob in its entirety.
+		if offset > 0 {
+			if err := f.Truncate(0); err != nil {
+				return err
+			}
+
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+
+			offset = 0
+		}
+
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// The partial blob is already complete, which is subsequently verified.
+		return nil
+
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= http.StatusInternalServerError:
+		return &transientError{fmt.Errorf("unexpected status: %s", resp.Status)}
+
+	default:
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	var reader io.Reader = resp.Body
+	if onProgress != nil {
+		reader = &offsetProgressReader{
+			Reader:     resp.Body,
+			offset:     offset,
+			onProgress: onProgress,
+		}
+	}
+
+	// Reading one byte past the remainder tells an oversized blob apart.
+	n, err := io.Copy(f, io.LimitReader(reader, size-offset+1))
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		return &transientError{err}
+	}
+
+	if offset+n > size {
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+
+		return fmt.Errorf("blob exceeds size of %d bytes", size)
+	}
+
+	return nil
+}
+
+// verifyBlob checks that the contents of the file at the provided path match
+// the digest.  A file which does not match is removed, such that it is
+// downloaded again from the start by the next attempt.
+func verifyBlob(path string, dgst digest.Digest) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	actual, err := dgst.Algorithm().FromReader(f)
+	if err != nil {
+		return err
+	}
+
+	if actual == dgst {
+		return nil
+	}
+
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+
+	return &transientError{fmt.Errorf("digest mismatch: got %s", actual.String())}
+}
+
+// contentRangeStart returns the first byte of a Content-Range header value,
+// e.g. "bytes 100-199/200".
+func contentRangeStart(value string) (int64, error) {
+	value, ok := strings.CutPrefix(value, "bytes ")
+	if !ok {
+		return 0, fmt.Errorf("unsupported unit")
+	}
+
+	start, _, ok := strings.Cut(value, "-")
+	if !ok {
+		return 0, fmt.Errorf("malformed range")
+	}
+
+	return strconv.ParseInt(start, 10, 64)
+}
+
+// offsetProgressReader wraps an existing io.Reader and reports the total
+// number of bytes of a blob which have been downloaded, including those of
+// previous attempts.
+type offsetProgressReader struct {
+	io.Reader
+	offset     int64
+	onProgress func(float64)
+}
+
+func (pr *offsetProgressReader) Read(p []byte) (int, error) {
+	n, err := pr.Reader.Read(p)
+	pr.offset += int64(n)
+	pr.onProgress(float64(pr.offset))
+	return n, err
+}
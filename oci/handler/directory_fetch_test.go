@@ -0,0 +1,284 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// flakyRegistry serves a single blob and drops the connection half way
+// through the body of the first drops requests.  The size of the blob is
+// reported as size, if set.
+type flakyRegistry struct {
+	blob        []byte
+	size        int
+	drops       int
+	ignoreRange bool
+	mu          sync.Mutex
+	ranges      []string
+}
+
+func (reg *flakyRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/v2/" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if !strings.HasPrefix(r.URL.Path, "/v2/unikraft/test/blobs/") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if r.Method == http.MethodHead {
+		size := reg.size
+		if size == 0 {
+			size = len(reg.blob)
+		}
+
+		w.Header().Set("Content-Length", strconv.Itoa(size))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	reg.mu.Lock()
+	reg.ranges = append(reg.ranges, r.Header.Get("Range"))
+	drop := reg.drops > 0
+	reg.drops--
+	reg.mu.Unlock()
+
+	start := 0
+	status := http.StatusOK
+
+	if value := r.Header.Get("Range"); value != "" && !reg.ignoreRange {
+		var err error
+		start, err = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(value, "bytes="), "-"))
+		if err != nil || start >= len(reg.blob) {
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(reg.blob)-1, len(reg.blob)))
+		status = http.StatusPartialContent
+	}
+
+	body := reg.blob[start:]
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+
+	if drop {
+		_, _ = w.Write(body[:len(body)/2])
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+
+	_, _ = w.Write(body)
+}
+
+func pullFromFlakyRegistry(t *testing.T, reg *flakyRegistry, dgst digest.Digest) (*DirectoryHandler, error) {
+	t.Helper()
+
+	handle, err := NewDirectoryHandler(t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return handle, pullWithHandler(t, handle, reg, dgst)
+}
+
+func pullWithHandler(t *testing.T, handle *DirectoryHandler, reg *flakyRegistry, dgst digest.Digest) error {
+	t.Helper()
+
+	blobPullBackoff = time.Millisecond
+	blobPullMaxBackoff = time.Millisecond
+
+	server := httptest.NewServer(reg)
+	t.Cleanup(server.Close)
+
+	fullref := strings.TrimPrefix(server.URL, "http://") + "/unikraft/test:latest"
+
+	return handle.PullDigest(context.Background(),
+		ocispec.MediaTypeImageLayer,
+		fullref,
+		dgst,
+		&ocispec.Platform{},
+		nil,
+	)
+}
+
+func randomBlob(t *testing.T) []byte {
+	t.Helper()
+
+	blob := make([]byte, 1<<20)
+	if _, err := rand.Read(blob); err != nil {
+		t.Fatal(err)
+	}
+
+	return blob
+}
+
+func TestPullDigestResumesInterruptedDownload(t *testing.T) {
+	blob := randomBlob(t)
+	dgst := digest.FromBytes(blob)
+	reg := &flakyRegistry{blob: blob, drops: 2}
+
+	handle, err := pullFromFlakyRegistry(t, reg, dgst)
+	if err != nil {
+		t.Fatalf("expected pull to succeed: %v", err)
+	}
+
+	got, err := os.ReadFile(handle.blobPath(dgst))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, blob) {
+		t.Fatalf("pulled blob does not match")
+	}
+
+	if len(reg.ranges) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(reg.ranges))
+	}
+
+	for i, value := range reg.ranges[1:] {
+		if !strings.HasPrefix(value, "bytes=") {
+			t.Fatalf("expected retry %d to resume with a range, got %q", i+1, value)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(handle.path, DirectoryHandlerIngestDir, dgst.Algorithm().String(), dgst.Encoded())); !os.IsNotExist(err) {
+		t.Fatalf("expected partial blob to be removed: %v", err)
+	}
+}
+
+func TestPullDigestRestartsWithoutRangeSupport(t *testing.T) {
+	blob := randomBlob(t)
+	dgst := digest.FromBytes(blob)
+	reg := &flakyRegistry{blob: blob, drops: 1, ignoreRange: true}
+
+	handle, err := pullFromFlakyRegistry(t, reg, dgst)
+	if err != nil {
+		t.Fatalf("expected pull to succeed: %v", err)
+	}
+
+	got, err := os.ReadFile(handle.blobPath(dgst))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, blob) {
+		t.Fatalf("pulled blob does not match")
+	}
+}
+
+func TestPullDigestGivesUpAfterRetries(t *testing.T) {
+	blob := randomBlob(t)
+	dgst := digest.FromBytes(blob)
+	reg := &flakyRegistry{blob: blob, drops: blobPullAttempts, ignoreRange: true}
+
+	if _, err := pullFromFlakyRegistry(t, reg, dgst); err == nil {
+		t.Fatalf("expected pull to fail")
+	}
+
+	if len(reg.ranges) != blobPullAttempts {
+		t.Fatalf("expected %d attempts, got %d", blobPullAttempts, len(reg.ranges))
+	}
+}
+
+func TestPullDigestRejectsDigestMismatch(t *testing.T) {
+	blob := randomBlob(t)
+	dgst := digest.FromString("not the blob")
+	reg := &flakyRegistry{blob: blob}
+
+	handle, err := pullFromFlakyRegistry(t, reg, dgst)
+	if err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Fatalf("expected digest mismatch, got: %v", err)
+	}
+
+	if _, err := os.Stat(handle.blobPath(dgst)); !os.IsNotExist(err) {
+		t.Fatalf("expected blob not to be stored: %v", err)
+	}
+}
+
+func TestPullDigestRejectsOversizedBlob(t *testing.T) {
+	blob := randomBlob(t)
+	dgst := digest.FromBytes(blob)
+	reg := &flakyRegistry{blob: blob, size: len(blob) / 2}
+
+	handle, err := pullFromFlakyRegistry(t, reg, dgst)
+	if err == nil || !strings.Contains(err.Error(), "exceeds size") {
+		t.Fatalf("expected oversized blob to be rejected, got: %v", err)
+	}
+
+	if len(reg.ranges) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(reg.ranges))
+	}
+
+	partial, err := os.Stat(filepath.Join(handle.path, DirectoryHandlerIngestDir, dgst.Algorithm().String(), dgst.Encoded()))
+	if err != nil {
+		t.Fatal(err)
+	} else if partial.Size() != 0 {
+		t.Fatalf("expected partial blob to be truncated, got %d bytes", partial.Size())
+	}
+}
+
+func TestPullDigestConcurrently(t *testing.T) {
+	blob := randomBlob(t)
+	dgst := digest.FromBytes(blob)
+
+	handle, err := NewDirectoryHandler(t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each pull uses its own registry such that both drop their connections.
+	regs := []*flakyRegistry{
+		{blob: blob, drops: 2},
+		{blob: blob, drops: 2},
+	}
+
+	errs := make([]error, len(regs))
+
+	var wg sync.WaitGroup
+	for i, reg := range regs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = pullWithHandler(t, handle, reg, dgst)
+		}()
+	}
+
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("expected pull %d to succeed: %v", i, err)
+		}
+	}
+
+	got, err := os.ReadFile(handle.blobPath(dgst))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, blob) {
+		t.Fatalf("pulled blob does not match")
+	}
+}
@@ -0,0 +1,372 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"kraftkit.sh/internal/lockedfile"
+	"kraftkit.sh/log"
+)
+
+const (
+	// DirectoryHandlerLockFile is the file which is locked exclusively during
+	// garbage collection and shared whilst blobs are being written.
+	DirectoryHandlerLockFile = ".lock"
+
+	// maxManifestSize is the size above which a blob is never considered to be
+	// a manifest or an index.  This avoids reading layers in their entirety
+	// whilst looking for references.
+	maxManifestSize = 4 << 20
+
+	// gcGracePeriod is the age below which blobs are never removed by garbage
+	// collection.  Packaging and pushing write the layers and manifests of an
+	// image one by one before its index is tagged, during which they are not
+	// yet reachable.
+	gcGracePeriod = time.Hour
+)
+
+// descriptorRefs contains the fields of manifests and indexes which refer to
+// other blobs.
+type descriptorRefs struct {
+	Manifests []ocispec.Descriptor `json:"manifests,omitempty"`
+	Config    *ocispec.Descriptor  `json:"config,omitempty"`
+	Layers    []ocispec.Descriptor `json:"layers,omitempty"`
+	Blobs     []ocispec.Descriptor `json:"blobs,omitempty"`
+	Subject   *ocispec.Descriptor  `json:"subject,omitempty"`
+}
+
+// children returns the digests of the blobs which are referred to.
+func (refs *descriptorRefs) children() []digest.Digest {
+	var children []digest.Digest
+
+	for _, desc := range refs.Manifests {
+		children = append(children, desc.Digest)
+	}
+
+	if refs.Config != nil {
+		children = append(children, refs.Config.Digest)
+	}
+
+	for _, desc := range refs.Layers {
+		children = append(children, desc.Digest)
+	}
+
+	for _, desc := range refs.Blobs {
+		children = append(children, desc.Digest)
+	}
+
+	return children
+}
+
+// lockShared acquires a shared lock on the store which prevents garbage
+// collection from running concurrently.
+func (handle *DirectoryHandler) lockShared() (func(), error) {
+	f, err := lockedfile.OpenFile(filepath.Join(handle.path, DirectoryHandlerLockFile), os.O_RDONLY|os.O_CREATE, 0o666)
+	if err != nil {
+		return nil, fmt.Errorf("could not lock local oci cache directory: %w", err)
+	}
+
+	return func() { f.Close() }, nil
+}
+
+// blobPath returns the path to the blob with the provided digest.
+func (handle *DirectoryHandler) blobPath(dgst digest.Digest) string {
+	return filepath.Join(
+		handle.path,
+		DirectoryHandlerDigestsDir,
+		dgst.Algorithm().String(),
+		dgst.Encoded(),
+	)
+}
+
+// readRefs returns the references of the blob with the provided digest, if it
+// is a manifest or an index.
+func (handle *DirectoryHandler) readRefs(dgst digest.Digest) (*descriptorRefs, bool) {
+	path := handle.blobPath(dgst)
+
+	fi, err := os.Stat(path)
+	if err != nil || fi.Size() > maxManifestSize {
+		return nil, false
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	refs := descriptorRefs{}
+	if err := json.Unmarshal(raw, &refs); err != nil {
+		return nil, false
+	}
+
+	return &refs, true
+}
+
+// blobs returns the digests and file information of all blobs in the store.
+func (handle *DirectoryHandler) blobs() (map[digest.Digest]fs.FileInfo, error) {
+	digestsDir := filepath.Join(handle.path, DirectoryHandlerDigestsDir)
+	blobs := map[digest.Digest]fs.FileInfo{}
+
+	if err := filepath.WalkDir(digestsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(digestsDir, path)
+		if err != nil {
+			return err
+		}
+
+		algorithm, encoded, ok := strings.Cut(filepath.ToSlash(rel), "/")
+		if !ok {
+			return nil
+		}
+
+		dgst := digest.NewDigestFromEncoded(digest.Algorithm(algorithm), encoded)
+		if dgst.Validate() != nil {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		blobs[dgst] = info
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("could not walk digests directory: %w", err)
+	}
+
+	return blobs, nil
+}
+
+// referencedExcept returns the digests of the blobs which are referred to by
+// any manifest or index in the store other than the one with the provided
+// digest.
+func (handle *DirectoryHandler) referencedExcept(except digest.Digest) (map[digest.Digest]struct{}, error) {
+	blobs, err := handle.blobs()
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := map[digest.Digest]struct{}{}
+
+	for dgst := range blobs {
+		if dgst == except {
+			continue
+		}
+
+		refs, ok := handle.readRefs(dgst)
+		if !ok {
+			continue
+		}
+
+		for _, child := range refs.children() {
+			referenced[child] = struct{}{}
+		}
+	}
+
+	return referenced, nil
+}
+
+// roots returns the digests of the indexes which are referred to by a tag.
+// Tags whose index no longer exists are removed unless dryRun is set.
+func (handle *DirectoryHandler) roots(ctx context.Context, dryRun bool) ([]digest.Digest, error) {
+	indexesDir := filepath.Join(handle.path, DirectoryHandlerIndexesDir)
+	digestsDir := filepath.Join(handle.path, DirectoryHandlerDigestsDir)
+	if resolved, err := filepath.EvalSymlinks(digestsDir); err == nil {
+		digestsDir = resolved
+	}
+
+	var roots []digest.Digest
+
+	if err := filepath.WalkDir(indexesDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		target, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			log.G(ctx).
+				WithField("tag", strings.TrimPrefix(path, indexesDir+"/")).
+				Debug("removing dangling tag")
+
+			if !dryRun {
+				if err := os.Remove(path); err != nil {
+					return fmt.Errorf("could not remove dangling tag: %w", err)
+				}
+			}
+
+			return nil
+		}
+
+		// Tags are normally symbolic links to an index within the digests
+		// directory, but the index may also have been written in place.
+		if rel, err := filepath.Rel(digestsDir, target); err == nil && !strings.HasPrefix(rel, "..") {
+			algorithm, encoded, _ := strings.Cut(filepath.ToSlash(rel), "/")
+			roots = append(roots, digest.NewDigestFromEncoded(digest.Algorithm(algorithm), encoded))
+			return nil
+		}
+
+		raw, err := os.ReadFile(target)
+		if err != nil {
+			return err
+		}
+
+		refs := descriptorRefs{}
+		if err := json.Unmarshal(raw, &refs); err != nil {
+			return nil
+		}
+
+		roots = append(roots, refs.children()...)
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("could not walk indexes directory: %w", err)
+	}
+
+	return roots, nil
+}
+
+// GarbageCollect implements GarbageCollector.
+//
+// Blobs are marked as reachable by walking from every tagged index to its
+// manifests and from each manifest to its config and layers.  Manifests whose
+// subject is reachable, i.e. signatures, SBOMs and other referrers, are also
+// reachable.  Blobs which were written within the grace period are considered
+// to be in use by an ongoing operation and are reachable, as are the blobs they
+// refer to.  All other blobs are removed.
+func (handle *DirectoryHandler) GarbageCollect(ctx context.Context, dryRun bool) (*GarbageCollectResult, error) {
+	unlock, err := lockedfile.MutexAt(filepath.Join(handle.path, DirectoryHandlerLockFile)).Lock()
+	if err != nil {
+		return nil, fmt.Errorf("could not lock local oci cache directory: %w", err)
+	}
+
+	defer unlock()
+
+	blobs, err := handle.blobs()
+	if err != nil {
+		return nil, err
+	}
+
+	roots, err := handle.roots(ctx, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	marked := map[digest.Digest]struct{}{}
+
+	mark := func(queue []digest.Digest) {
+		for len(queue) > 0 {
+			dgst := queue[0]
+			queue = queue[1:]
+
+			if _, ok := marked[dgst]; ok {
+				continue
+			}
+
+			if _, ok := blobs[dgst]; !ok {
+				continue
+			}
+
+			marked[dgst] = struct{}{}
+
+			if refs, ok := handle.readRefs(dgst); ok {
+				queue = append(queue, refs.children()...)
+			}
+		}
+	}
+
+	for dgst, info := range blobs {
+		if time.Since(info.ModTime()) < gcGracePeriod {
+			roots = append(roots, dgst)
+		}
+	}
+
+	mark(roots)
+
+	// Referrers are not referred to by their subject, so look for unmarked
+	// manifests whose subject has been marked until there are no more.
+	subjects := map[digest.Digest]digest.Digest{}
+	for dgst := range blobs {
+		if _, ok := marked[dgst]; ok {
+			continue
+		}
+
+		if refs, ok := handle.readRefs(dgst); ok && refs.Subject != nil {
+			subjects[dgst] = refs.Subject.Digest
+		}
+	}
+
+	for {
+		var referrers []digest.Digest
+
+		for dgst, subject := range subjects {
+			if _, ok := marked[subject]; ok {
+				referrers = append(referrers, dgst)
+				delete(subjects, dgst)
+			}
+		}
+
+		if len(referrers) == 0 {
+			break
+		}
+
+		mark(referrers)
+	}
+
+	result := GarbageCollectResult{}
+
+	for dgst, info := range blobs {
+		if _, ok := marked[dgst]; ok {
+			continue
+		}
+
+		size := info.Size()
+
+		log.G(ctx).
+			WithField("digest", dgst.String()).
+			WithField("size", size).
+			Debug("removing unreachable blob")
+
+		if !dryRun {
+			if err := os.Remove(handle.blobPath(dgst)); err != nil && !os.IsNotExist(err) {
+				return &result, fmt.Errorf("could not remove blob '%s': %w", dgst.String(), err)
+			}
+		}
+
+		result.Blobs++
+		result.Bytes += size
+	}
+
+	return &result, nil
+}
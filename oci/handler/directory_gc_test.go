@@ -0,0 +1,211 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const gcTestRef = "unikraft.org/test:latest"
+
+// saveBlob writes the provided content to the store and returns its
+// descriptor.
+func saveBlob(t *testing.T, handle *DirectoryHandler, mediaType string, raw []byte) ocispec.Descriptor {
+	t.Helper()
+
+	desc := ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(raw),
+		Size:      int64(len(raw)),
+	}
+
+	if err := handle.SaveDescriptor(context.Background(), gcTestRef, desc, bytes.NewReader(raw), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	return desc
+}
+
+// saveJSON serializes the provided value and writes it to the store.
+func saveJSON(t *testing.T, handle *DirectoryHandler, mediaType string, v any) ocispec.Descriptor {
+	t.Helper()
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return saveBlob(t, handle, mediaType, raw)
+}
+
+// ageBlobs moves the modification time of the blobs past the grace period.
+func ageBlobs(t *testing.T, handle *DirectoryHandler, descs ...ocispec.Descriptor) {
+	t.Helper()
+
+	old := time.Now().Add(-2 * gcGracePeriod)
+	for _, desc := range descs {
+		if err := os.Chtimes(handle.blobPath(desc.Digest), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func assertBlobs(t *testing.T, handle *DirectoryHandler, exist bool, descs ...ocispec.Descriptor) {
+	t.Helper()
+
+	for _, desc := range descs {
+		_, err := os.Stat(handle.blobPath(desc.Digest))
+		if exist && err != nil {
+			t.Errorf("expected blob %s to be kept: %v", desc.Digest, err)
+		} else if !exist && !os.IsNotExist(err) {
+			t.Errorf("expected blob %s to be removed, got %v", desc.Digest, err)
+		}
+	}
+}
+
+func TestGarbageCollect(t *testing.T) {
+	ctx := context.Background()
+
+	handle, err := NewDirectoryHandler(t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	layer := saveBlob(t, handle, ocispec.MediaTypeImageLayer, []byte("kernel"))
+	config := saveJSON(t, handle, ocispec.MediaTypeImageConfig, ocispec.Image{})
+	manifest := saveJSON(t, handle, ocispec.MediaTypeImageManifest, ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    config,
+		Layers:    []ocispec.Descriptor{layer},
+	})
+	signature := saveJSON(t, handle, ocispec.MediaTypeImageManifest, ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    config,
+		Subject:   &manifest,
+	})
+	index := saveJSON(t, handle, ocispec.MediaTypeImageIndex, ocispec.Index{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{manifest},
+	})
+	unreachable := saveBlob(t, handle, ocispec.MediaTypeImageLayer, []byte("stale"))
+	recent := saveBlob(t, handle, ocispec.MediaTypeImageLayer, []byte("recent"))
+
+	ageBlobs(t, handle, layer, config, manifest, signature, index, unreachable)
+
+	result, err := handle.GarbageCollect(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Blobs != 1 || result.Bytes != unreachable.Size {
+		t.Errorf("expected 1 blob of %d bytes to be reported, got %d of %d bytes", unreachable.Size, result.Blobs, result.Bytes)
+	}
+
+	assertBlobs(t, handle, true, unreachable)
+
+	if _, err := handle.GarbageCollect(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+
+	assertBlobs(t, handle, true, layer, config, manifest, signature, index, recent)
+	assertBlobs(t, handle, false, unreachable)
+}
+
+func TestGarbageCollectDuringSave(t *testing.T) {
+	ctx := context.Background()
+
+	handle, err := NewDirectoryHandler(t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A layer which is left over from a removed package and which is reused by
+	// the package being saved.
+	reused := saveBlob(t, handle, ocispec.MediaTypeImageLayer, []byte("initrd"))
+	ageBlobs(t, handle, reused)
+
+	// Collect garbage continuously whilst the package is saved.
+	done := make(chan struct{})
+	errs := make(chan error, 1)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			if _, err := handle.GarbageCollect(ctx, false); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+
+	// The blobs of the package are saved one by one, as when packaging or
+	// pulling, and are only reachable once the index is tagged.
+	var saved []ocispec.Descriptor
+	save := func(desc ocispec.Descriptor) ocispec.Descriptor {
+		saved = append(saved, desc)
+
+		if _, err := handle.GarbageCollect(ctx, false); err != nil {
+			t.Fatal(err)
+		}
+
+		assertBlobs(t, handle, true, saved...)
+
+		return desc
+	}
+
+	layer := save(saveBlob(t, handle, ocispec.MediaTypeImageLayer, []byte("kernel")))
+	initrd := save(saveBlob(t, handle, ocispec.MediaTypeImageLayer, []byte("initrd")))
+	config := save(saveJSON(t, handle, ocispec.MediaTypeImageConfig, ocispec.Image{}))
+	manifest := save(saveJSON(t, handle, ocispec.MediaTypeImageManifest, ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    config,
+		Layers:    []ocispec.Descriptor{layer, initrd},
+	}))
+	save(saveJSON(t, handle, ocispec.MediaTypeImageIndex, ocispec.Index{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{manifest},
+	}))
+
+	close(done)
+	wg.Wait()
+
+	select {
+	case err := <-errs:
+		t.Fatal(err)
+	default:
+	}
+
+	if initrd.Digest != reused.Digest {
+		t.Fatalf("expected the initrd to reuse blob %s, got %s", reused.Digest, initrd.Digest)
+	}
+
+	// Once tagged, the package is kept after the grace period has passed.
+	ageBlobs(t, handle, saved...)
+
+	if _, err := handle.GarbageCollect(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+
+	assertBlobs(t, handle, true, saved...)
+}
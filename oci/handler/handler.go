@@ -17,6 +17,12 @@ type DigestResolver interface {
 	DigestInfo(context.Context, digest.Digest) (*content.Info, error)
 }
 
+type DigestFetcher interface {
+	// FetchDigest returns a reader of the locally stored blob with the provided
+	// digest.
+	FetchDigest(context.Context, digest.Digest) (io.ReadCloser, error)
+}
+
 type DigestPuller interface {
 	// PullDigest retrieves the provided mediaType, full canonically referencable
 	// image and its digest for the given platform and returns the progress of
@@ -69,8 +75,25 @@ type ImageUnpacker interface {
 	UnpackImage(context.Context, string, digest.Digest, string) (*ocispec.Image, error)
 }
 
+// GarbageCollectResult contains the blobs which were reclaimed by garbage
+// collection.
+type GarbageCollectResult struct {
+	// Blobs is the number of blobs which were removed.
+	Blobs int
+
+	// Bytes is the total size of the blobs which were removed.
+	Bytes int64
+}
+
+type GarbageCollector interface {
+	// GarbageCollect removes all blobs which are not reachable from a tagged
+	// index or, if dryRun is set, only reports them.
+	GarbageCollect(ctx context.Context, dryRun bool) (*GarbageCollectResult, error)
+}
+
 type Handler interface {
 	DigestResolver
+	DigestFetcher
 	DigestPuller
 	DescriptorSaver
 	DescriptorPusher
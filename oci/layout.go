@@ -0,0 +1,643 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package oci
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/images"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+
+	"kraftkit.sh/log"
+	"kraftkit.sh/oci/handler"
+)
+
+// newHandle returns the handler which KraftKit is configured to use.
+func newHandle(ctx context.Context) (context.Context, handler.Handler, error) {
+	auths, err := defaultAuths(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not gather authentication details: %w", err)
+	}
+
+	manager := ociManager{
+		auths: auths,
+	}
+
+	if err := WithDetectHandler()(ctx, &manager); err != nil {
+		return nil, nil, err
+	}
+
+	return manager.handle(ctx)
+}
+
+// layoutWriter serializes blobs of the local store into an OCI image-layout
+// tarball.
+type layoutWriter struct {
+	handle  handler.Handler
+	tw      *tar.Writer
+	written map[digest.Digest]struct{}
+	index   ocispec.Index
+}
+
+// SaveLayout writes the indexes at the provided references, their manifests
+// and blobs as well as any of their referrers as an OCI image-layout tarball.
+// Referrers are retrieved from the local store and, if it is reachable, from
+// the registry of each reference.
+func SaveLayout(ctx context.Context, w io.Writer, refs ...string) error {
+	ctx, handle, err := newHandle(ctx)
+	if err != nil {
+		return err
+	}
+
+	lw := layoutWriter{
+		handle:  handle,
+		tw:      tar.NewWriter(w),
+		written: map[digest.Digest]struct{}{},
+		index: ocispec.Index{
+			MediaType: ocispec.MediaTypeImageIndex,
+		},
+	}
+	lw.index.SchemaVersion = 2
+
+	for _, ref := range refs {
+		if err := lw.saveIndex(ctx, ref); err != nil {
+			return fmt.Errorf("could not save %s: %w", ref, err)
+		}
+	}
+
+	layout, err := json.Marshal(ocispec.ImageLayout{
+		Version: ocispec.ImageLayoutVersion,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := lw.writeFile(ocispec.ImageLayoutFile, layout); err != nil {
+		return err
+	}
+
+	index, err := json.Marshal(lw.index)
+	if err != nil {
+		return err
+	}
+
+	if err := lw.writeFile(ocispec.ImageIndexFile, index); err != nil {
+		return err
+	}
+
+	return lw.tw.Close()
+}
+
+// saveIndex writes the index at the provided reference, its manifests and
+// their referrers.
+func (lw *layoutWriter) saveIndex(ctx context.Context, ref string) error {
+	index, err := lw.handle.ResolveIndex(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	// Indexes are stored in their marshalled form by the handlers.
+	raw, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+
+	subjects := map[digest.Digest]struct{}{}
+
+	for _, manifest := range index.Manifests {
+		if _, err := lw.handle.DigestInfo(ctx, manifest.Digest); err != nil {
+			log.G(ctx).
+				WithField("ref", ref).
+				WithField("digest", manifest.Digest.String()).
+				Warn("skipping manifest which has not been pulled")
+			continue
+		}
+
+		if err := lw.saveManifest(ctx, manifest); err != nil {
+			return err
+		}
+
+		subjects[manifest.Digest] = struct{}{}
+	}
+
+	desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageIndex, raw)
+	if err := lw.writeBytes(desc, raw); err != nil {
+		return err
+	}
+
+	subjects[desc.Digest] = struct{}{}
+
+	desc.Annotations = map[string]string{
+		ocispec.AnnotationRefName:  ref,
+		images.AnnotationImageName: ref,
+	}
+	lw.index.Manifests = append(lw.index.Manifests, desc)
+
+	return lw.saveReferrers(ctx, ref, subjects)
+}
+
+// saveManifest writes the manifest with the provided descriptor and its
+// config and layers from the local store.
+func (lw *layoutWriter) saveManifest(ctx context.Context, desc ocispec.Descriptor) error {
+	reader, err := lw.handle.FetchDigest(ctx, desc.Digest)
+	if err != nil {
+		return fmt.Errorf("could not read manifest %s: %w", desc.Digest.String(), err)
+	}
+
+	raw, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("could not read manifest %s: %w", desc.Digest.String(), err)
+	}
+
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return fmt.Errorf("could not parse manifest %s: %w", desc.Digest.String(), err)
+	}
+
+	for _, blob := range append([]ocispec.Descriptor{manifest.Config}, manifest.Layers...) {
+		if _, ok := lw.written[blob.Digest]; ok {
+			continue
+		}
+
+		reader, err := lw.handle.FetchDigest(ctx, blob.Digest)
+		if err != nil {
+			return fmt.Errorf("could not read blob %s: %w", blob.Digest.String(), err)
+		}
+
+		err = lw.writeBlob(blob, reader)
+		reader.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return lw.writeBytes(desc, raw)
+}
+
+// saveReferrers writes the manifests which refer to any of the provided
+// subjects.
+func (lw *layoutWriter) saveReferrers(ctx context.Context, ref string, subjects map[digest.Digest]struct{}) error {
+	manifests, err := lw.handle.ListManifests(ctx)
+	if err != nil {
+		return err
+	}
+
+	for dgst, manifest := range manifests {
+		if manifest.Subject == nil {
+			continue
+		}
+
+		if _, ok := subjects[manifest.Subject.Digest]; !ok {
+			continue
+		}
+
+		if _, ok := lw.written[digest.Digest(dgst)]; ok {
+			continue
+		}
+
+		info, err := lw.handle.DigestInfo(ctx, digest.Digest(dgst))
+		if err != nil {
+			return err
+		}
+
+		desc := ocispec.Descriptor{
+			MediaType:    ocispec.MediaTypeImageManifest,
+			Digest:       info.Digest,
+			Size:         info.Size,
+			ArtifactType: manifest.ArtifactType,
+		}
+
+		if desc.ArtifactType == "" {
+			desc.ArtifactType = manifest.Config.MediaType
+		}
+
+		if err := lw.saveManifest(ctx, desc); err != nil {
+			return err
+		}
+
+		lw.addReferrer(desc, ref)
+	}
+
+	if err := lw.saveRemoteReferrers(ctx, ref, subjects); err != nil {
+		log.G(ctx).
+			WithField("ref", ref).
+			Debugf("skipping referrers of registry: %s", err)
+	}
+
+	return nil
+}
+
+// saveRemoteReferrers writes the manifests in the registry of the provided
+// reference which refer to its remote index or any of the provided subjects.
+func (lw *layoutWriter) saveRemoteReferrers(ctx context.Context, ref string, subjects map[digest.Digest]struct{}) error {
+	r, err := name.ParseReference(ref)
+	if err != nil {
+		return err
+	}
+
+	ropts, err := RemoteOptions(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	var remoteSubjects []digest.Digest
+	for subject := range subjects {
+		remoteSubjects = append(remoteSubjects, subject)
+	}
+
+	// The local index only lists the manifests which have been pulled and thus
+	// differs from the index in the registry.
+	head, err := remote.Head(r, ropts...)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := subjects[digest.Digest(head.Digest.String())]; !ok {
+		remoteSubjects = append(remoteSubjects, digest.Digest(head.Digest.String()))
+	}
+
+	for _, subject := range remoteSubjects {
+		referrers, err := remote.Referrers(r.Context().Digest(subject.String()), ropts...)
+		if err != nil {
+			return err
+		}
+
+		manifest, err := referrers.IndexManifest()
+		if err != nil {
+			return err
+		}
+
+		for _, desc := range manifest.Manifests {
+			if _, ok := lw.written[digest.Digest(desc.Digest.String())]; ok {
+				continue
+			}
+
+			img, err := remote.Image(r.Context().Digest(desc.Digest.String()), ropts...)
+			if err != nil {
+				return err
+			}
+
+			m, err := img.Manifest()
+			if err != nil {
+				return err
+			}
+
+			config, err := img.RawConfigFile()
+			if err != nil {
+				return err
+			}
+
+			if err := lw.writeBytes(ocispec.Descriptor{
+				MediaType: string(m.Config.MediaType),
+				Digest:    digest.Digest(m.Config.Digest.String()),
+				Size:      m.Config.Size,
+			}, config); err != nil {
+				return err
+			}
+
+			for _, l := range m.Layers {
+				layer, err := img.LayerByDigest(l.Digest)
+				if err != nil {
+					return err
+				}
+
+				reader, err := layer.Compressed()
+				if err != nil {
+					return err
+				}
+
+				err = lw.writeBlob(ocispec.Descriptor{
+					MediaType: string(l.MediaType),
+					Digest:    digest.Digest(l.Digest.String()),
+					Size:      l.Size,
+				}, reader)
+				reader.Close()
+				if err != nil {
+					return err
+				}
+			}
+
+			raw, err := img.RawManifest()
+			if err != nil {
+				return err
+			}
+
+			referrer := ocispec.Descriptor{
+				MediaType:    string(desc.MediaType),
+				Digest:       digest.Digest(desc.Digest.String()),
+				Size:         desc.Size,
+				ArtifactType: desc.ArtifactType,
+			}
+
+			if err := lw.writeBytes(referrer, raw); err != nil {
+				return err
+			}
+
+			lw.addReferrer(referrer, ref)
+		}
+	}
+
+	return nil
+}
+
+// addReferrer lists the referrer in the index of the layout along with the
+// reference of the package it belongs to.
+func (lw *layoutWriter) addReferrer(desc ocispec.Descriptor, ref string) {
+	desc.Annotations = map[string]string{
+		AnnotationReferrerOf: ref,
+	}
+
+	lw.index.Manifests = append(lw.index.Manifests, desc)
+}
+
+// writeFile writes a file at the root of the layout.
+func (lw *layoutWriter) writeFile(name string, data []byte) error {
+	if err := lw.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     int64(len(data)),
+		Mode:     0o644,
+		ModTime:  time.Unix(0, 0),
+	}); err != nil {
+		return fmt.Errorf("could not write %s: %w", name, err)
+	}
+
+	if _, err := lw.tw.Write(data); err != nil {
+		return fmt.Errorf("could not write %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// writeBytes writes the blob with the provided contents unless it has already
+// been written.
+func (lw *layoutWriter) writeBytes(desc ocispec.Descriptor, data []byte) error {
+	if _, ok := lw.written[desc.Digest]; ok {
+		return nil
+	}
+
+	lw.written[desc.Digest] = struct{}{}
+
+	return lw.writeFile(path.Join(ocispec.ImageBlobsDir, desc.Digest.Algorithm().String(), desc.Digest.Encoded()), data)
+}
+
+// writeBlob streams the blob with the provided descriptor unless it has
+// already been written.
+func (lw *layoutWriter) writeBlob(desc ocispec.Descriptor, reader io.Reader) error {
+	if _, ok := lw.written[desc.Digest]; ok {
+		return nil
+	}
+
+	lw.written[desc.Digest] = struct{}{}
+
+	name := path.Join(ocispec.ImageBlobsDir, desc.Digest.Algorithm().String(), desc.Digest.Encoded())
+
+	if err := lw.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     desc.Size,
+		Mode:     0o644,
+		ModTime:  time.Unix(0, 0),
+	}); err != nil {
+		return fmt.Errorf("could not write %s: %w", name, err)
+	}
+
+	if _, err := io.CopyN(lw.tw, reader, desc.Size); err != nil {
+		return fmt.Errorf("could not write %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// LoadLayout imports the packages of the OCI image-layout tarball into the
+// local store and returns their references.  Packages are identified by the
+// `io.containerd.image.name` or `org.opencontainers.image.ref.name`
+// annotation of the layout's index.
+func LoadLayout(ctx context.Context, r io.Reader) ([]string, error) {
+	dir, err := os.MkdirTemp("", "kraftkit-layout-*")
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.RemoveAll(dir)
+
+	if err := extractLayout(r, dir); err != nil {
+		return nil, err
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, ocispec.ImageIndexFile))
+	if err != nil {
+		return nil, fmt.Errorf("not an OCI image layout: %w", err)
+	}
+
+	var index ocispec.Index
+	if err := json.Unmarshal(raw, &index); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", ocispec.ImageIndexFile, err)
+	}
+
+	ctx, handle, err := newHandle(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []string
+	var referrers []ocispec.Descriptor
+
+	// Packages are imported before their referrers.
+	for _, desc := range index.Manifests {
+		ref := desc.Annotations[images.AnnotationImageName]
+		if ref == "" {
+			ref = desc.Annotations[ocispec.AnnotationRefName]
+		}
+
+		if ref == "" {
+			referrers = append(referrers, desc)
+			continue
+		}
+
+		log.G(ctx).
+			WithField("ref", ref).
+			WithField("digest", desc.Digest.String()).
+			Debug("loading")
+
+		if err := loadDescriptor(ctx, handle, dir, ref, stripAnnotations(desc)); err != nil {
+			return nil, fmt.Errorf("could not load %s: %w", ref, err)
+		}
+
+		refs = append(refs, ref)
+	}
+
+	for _, desc := range referrers {
+		ref := desc.Annotations[AnnotationReferrerOf]
+		if ref == "" {
+			log.G(ctx).
+				WithField("digest", desc.Digest.String()).
+				Warn("skipping unnamed manifest")
+			continue
+		}
+
+		r, err := name.ParseReference(ref)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := loadDescriptor(ctx, handle, dir, r.Context().Digest(desc.Digest.String()).String(), stripAnnotations(desc)); err != nil {
+			return nil, fmt.Errorf("could not load referrer %s: %w", desc.Digest.String(), err)
+		}
+	}
+
+	return refs, nil
+}
+
+// stripAnnotations returns the descriptor without the annotations which are
+// only meaningful in the index of the layout.
+func stripAnnotations(desc ocispec.Descriptor) ocispec.Descriptor {
+	return ocispec.Descriptor{
+		MediaType:    desc.MediaType,
+		Digest:       desc.Digest,
+		Size:         desc.Size,
+		ArtifactType: desc.ArtifactType,
+		Platform:     desc.Platform,
+	}
+}
+
+// extractLayout extracts the files of the layout into the directory and
+// checks the digests of all blobs.
+func extractLayout(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("could not read layout: %w", err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean("/" + hdr.Name)[1:]
+		dst := filepath.Join(dir, filepath.FromSlash(name))
+
+		var verifier digest.Verifier
+		if dir, file := path.Split(name); path.Dir(path.Clean(dir)) == ocispec.ImageBlobsDir {
+			dgst := digest.NewDigestFromEncoded(digest.Algorithm(path.Base(dir)), file)
+			if err := dgst.Validate(); err != nil {
+				return fmt.Errorf("invalid blob %s: %w", name, err)
+			}
+
+			verifier = dgst.Verifier()
+		} else if name != ocispec.ImageIndexFile && name != ocispec.ImageLayoutFile {
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+
+		f, err := os.Create(dst)
+		if err != nil {
+			return err
+		}
+
+		var w io.Writer = f
+		if verifier != nil {
+			w = io.MultiWriter(f, verifier)
+		}
+
+		_, err = io.Copy(w, tr)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("could not extract %s: %w", name, err)
+		}
+
+		if verifier != nil && !verifier.Verified() {
+			return fmt.Errorf("blob %s does not match its digest", name)
+		}
+	}
+
+	return nil
+}
+
+// loadDescriptor imports the blob with the provided descriptor and all blobs
+// it references which are part of the layout.
+func loadDescriptor(ctx context.Context, handle handler.Handler, dir, ref string, desc ocispec.Descriptor) error {
+	blob := filepath.Join(dir, ocispec.ImageBlobsDir, desc.Digest.Algorithm().String(), desc.Digest.Encoded())
+
+	switch desc.MediaType {
+	case ocispec.MediaTypeImageIndex:
+		raw, err := os.ReadFile(blob)
+		if err != nil {
+			return err
+		}
+
+		var index ocispec.Index
+		if err := json.Unmarshal(raw, &index); err != nil {
+			return fmt.Errorf("could not parse index %s: %w", desc.Digest.String(), err)
+		}
+
+		for _, manifest := range index.Manifests {
+			if _, err := os.Stat(filepath.Join(dir, ocispec.ImageBlobsDir, manifest.Digest.Algorithm().String(), manifest.Digest.Encoded())); err != nil {
+				log.G(ctx).
+					WithField("ref", ref).
+					WithField("digest", manifest.Digest.String()).
+					Warn("skipping manifest which is not part of the layout")
+				continue
+			}
+
+			if err := loadDescriptor(ctx, handle, dir, ref, manifest); err != nil {
+				return err
+			}
+		}
+
+	case ocispec.MediaTypeImageManifest:
+		raw, err := os.ReadFile(blob)
+		if err != nil {
+			return err
+		}
+
+		var manifest ocispec.Manifest
+		if err := json.Unmarshal(raw, &manifest); err != nil {
+			return fmt.Errorf("could not parse manifest %s: %w", desc.Digest.String(), err)
+		}
+
+		for _, child := range append([]ocispec.Descriptor{manifest.Config}, manifest.Layers...) {
+			if err := loadDescriptor(ctx, handle, dir, "", child); err != nil {
+				return err
+			}
+		}
+	}
+
+	f, err := os.Open(blob)
+	if err != nil {
+		return fmt.Errorf("could not open blob %s: %w", desc.Digest.String(), err)
+	}
+
+	defer f.Close()
+
+	if err := handle.SaveDescriptor(ctx, ref, desc, f, nil); err != nil && !errors.Is(err, errdefs.ErrAlreadyExists) {
+		return fmt.Errorf("could not save %s: %w", desc.Digest.String(), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,237 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/offline/offlinetest"
+	"kraftkit.sh/oci/handler"
+)
+
+const layoutTestRef = "unikraft.org/nginx:latest"
+
+// localStore returns the directory handler which is used in the context.
+func localStore(t *testing.T, ctx context.Context) *handler.DirectoryHandler {
+	t.Helper()
+
+	handle, err := handler.NewDirectoryHandler(filepath.Join(config.G[config.KraftKit](ctx).RuntimeDir, "oci"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return handle
+}
+
+// storeJSON serializes the value and saves it in the local store of the
+// context under the provided reference.
+func storeJSON(t *testing.T, ctx context.Context, ref, mediaType string, v any) ocispec.Descriptor {
+	t.Helper()
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return storeBytes(t, ctx, ref, mediaType, raw)
+}
+
+// storeBytes saves the blob in the local store of the context under the
+// provided reference.
+func storeBytes(t *testing.T, ctx context.Context, ref, mediaType string, raw []byte) ocispec.Descriptor {
+	t.Helper()
+
+	desc := ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(raw),
+		Size:      int64(len(raw)),
+	}
+
+	if err := localStore(t, ctx).SaveDescriptor(ctx, ref, desc, bytes.NewReader(raw), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	return desc
+}
+
+// layoutPackage stores a package with a kernel, an SBOM which refers to it and
+// a manifest which has not been pulled.
+func layoutPackage(t *testing.T, ctx context.Context) (kernel, manifest, sbom ocispec.Descriptor) {
+	t.Helper()
+
+	kernel = storeBytes(t, ctx, layoutTestRef, MediaTypeLayer, []byte("kernel"))
+	config := storeJSON(t, ctx, layoutTestRef, ocispec.MediaTypeImageConfig, ocispec.Image{
+		Platform: ocispec.Platform{Architecture: "x86_64", OS: "qemu"},
+	})
+	manifest = storeJSON(t, ctx, layoutTestRef, ocispec.MediaTypeImageManifest, ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    config,
+		Layers:    []ocispec.Descriptor{kernel},
+	})
+	manifest.Platform = &ocispec.Platform{Architecture: "x86_64", OS: "qemu"}
+
+	storeJSON(t, ctx, layoutTestRef, ocispec.MediaTypeImageIndex, ocispec.Index{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{manifest, {
+			MediaType: ocispec.MediaTypeImageManifest,
+			Digest:    digest.FromString("arm64"),
+			Size:      5,
+			Platform:  &ocispec.Platform{Architecture: "arm64", OS: "qemu"},
+		}},
+	})
+
+	document := storeBytes(t, ctx, "", "application/spdx+json", []byte(`{"spdxVersion":"SPDX-2.3"}`))
+	empty := storeBytes(t, ctx, "", ocispec.MediaTypeEmptyJSON, []byte("{}"))
+	sbom = storeJSON(t, ctx, "", ocispec.MediaTypeImageManifest, ocispec.Manifest{
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: "application/spdx+json",
+		Config:       empty,
+		Layers:       []ocispec.Descriptor{document},
+		Subject:      &manifest,
+	})
+
+	return kernel, manifest, sbom
+}
+
+func TestSaveLoadLayout(t *testing.T) {
+	offlinetest.DenyDial(t)
+
+	srcCtx := offlinetest.Context(t)
+	kernel, manifest, sbom := layoutPackage(t, srcCtx)
+
+	var buf bytes.Buffer
+	if err := SaveLayout(srcCtx, &buf, layoutTestRef); err != nil {
+		t.Fatalf("SaveLayout: %v", err)
+	}
+
+	dstCtx := offlinetest.Context(t)
+
+	refs, err := LoadLayout(dstCtx, bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("LoadLayout: %v", err)
+	}
+
+	if len(refs) != 1 || refs[0] != layoutTestRef {
+		t.Fatalf("expected to load %s, got %v", layoutTestRef, refs)
+	}
+
+	src, dst := localStore(t, srcCtx), localStore(t, dstCtx)
+
+	srcIndex, err := src.ResolveIndex(srcCtx, layoutTestRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dstIndex, err := dst.ResolveIndex(dstCtx, layoutTestRef)
+	if err != nil {
+		t.Fatalf("expected loaded package to be tagged: %v", err)
+	}
+
+	srcRaw, _ := json.Marshal(srcIndex)
+	dstRaw, _ := json.Marshal(dstIndex)
+	if !bytes.Equal(srcRaw, dstRaw) {
+		t.Errorf("expected index %s, got %s", srcRaw, dstRaw)
+	}
+
+	for _, desc := range []ocispec.Descriptor{kernel, manifest, sbom} {
+		reader, err := dst.FetchDigest(dstCtx, desc.Digest)
+		if err != nil {
+			t.Errorf("expected blob %s to be loaded: %v", desc.Digest, err)
+			continue
+		}
+
+		raw, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if digest.FromBytes(raw) != desc.Digest {
+			t.Errorf("blob %s was loaded with digest %s", desc.Digest, digest.FromBytes(raw))
+		}
+	}
+
+	// The SBOM is still a referrer of the manifest.
+	manifests, err := dst.ListManifests(dstCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	referrer, ok := manifests[sbom.Digest.String()]
+	if !ok || referrer.Subject == nil || referrer.Subject.Digest != manifest.Digest {
+		t.Errorf("expected SBOM to refer to %s, got %+v", manifest.Digest, referrer)
+	}
+}
+
+func TestLoadLayoutRejectsTamperedBlob(t *testing.T) {
+	offlinetest.DenyDial(t)
+
+	srcCtx := offlinetest.Context(t)
+	kernel, _, _ := layoutPackage(t, srcCtx)
+
+	var buf bytes.Buffer
+	if err := SaveLayout(srcCtx, &buf, layoutTestRef); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replace the contents of the kernel with other contents of the same size.
+	name := path.Join(ocispec.ImageBlobsDir, kernel.Digest.Algorithm().String(), kernel.Digest.Encoded())
+
+	var tampered bytes.Buffer
+	tr := tar.NewReader(&buf)
+	tw := tar.NewWriter(&tampered)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if hdr.Name == name {
+			data = []byte(strings.ToUpper(string(data)))
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dstCtx := offlinetest.Context(t)
+
+	if _, err := LoadLayout(dstCtx, &tampered); err == nil || !strings.Contains(err.Error(), "does not match its digest") {
+		t.Errorf("expected tampered blob to be rejected, got %v", err)
+	}
+
+	if _, err := localStore(t, dstCtx).ResolveIndex(dstCtx, layoutTestRef); err == nil {
+		t.Errorf("expected nothing to be loaded")
+	}
+}
@@ -24,6 +24,7 @@ import (
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 
 	"kraftkit.sh/config"
+	"kraftkit.sh/internal/offline"
 	"kraftkit.sh/internal/set"
 	"kraftkit.sh/internal/version"
 	"kraftkit.sh/log"
@@ -89,6 +90,10 @@ func (manager *ociManager) Update(ctx context.Context) error {
 }
 
 func (manager *ociManager) update(ctx context.Context, auths map[string]config.AuthConfig, query *packmanager.Query) (map[string]pack.Package, error) {
+	if offline.Enabled(ctx) {
+		return nil, fmt.Errorf("cannot query registries: %w", offline.ErrOffline)
+	}
+
 	ctx, handle, err := manager.handle(ctx)
 	if err != nil {
 		return nil, err
@@ -367,6 +372,15 @@ func (manager *ociManager) Catalog(ctx context.Context, qopts ...packmanager.Que
 		return nil, nil
 	}
 
+	// In offline mode, remote queries are only answered from the local store.
+	offlineRemote := offline.Enabled(ctx) && query.Remote()
+	if offlineRemote {
+		query = packmanager.NewQuery(append(qopts,
+			packmanager.WithRemote(false),
+			packmanager.WithLocal(true),
+		)...)
+	}
+
 	var qglob glob.Glob
 	var err error
 	packs := make(map[string]pack.Package)
@@ -673,6 +687,15 @@ returnPacks:
 
 	log.G(ctx).Debugf("found %d/%d matching packages in oci catalog", len(packs), total)
 
+	if offlineRemote && len(ret) == 0 && qglob == nil && len(qname) > 0 {
+		missing := qname
+		if len(qversion) > 0 {
+			missing += ":" + qversion
+		}
+
+		return nil, offline.NotCached(missing)
+	}
+
 	return ret, nil
 }
 
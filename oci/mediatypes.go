@@ -10,8 +10,31 @@ const (
 	MediaTypeInitrdCpio  = "application/vnd.unikraft.initrd.v1"
 	MediaTypeConfig      = "application/vnd.unikraft.config.v1"
 
+	MediaTypeInitrdErofs    = "application/vnd.unikraft.initrd.erofs.v1"
+	MediaTypeInitrdSquashfs = "application/vnd.unikraft.initrd.squashfs.v1"
+
+	MediaTypeDiskExt4  = "application/vnd.unikraft.disk.ext4.v1"
+	MediaTypeDiskErofs = "application/vnd.unikraft.disk.erofs.v1"
+
 	MediaTypeLayerGzip       = MediaTypeLayer + "+gzip"
 	MediaTypeImageKernelGzip = MediaTypeImageKernel + "+gzip"
 	MediaTypeInitrdCpioGzip  = MediaTypeInitrdCpio + "+gzip"
+	MediaTypeInitrdCpioZstd  = MediaTypeInitrdCpio + "+zstd"
+	MediaTypeInitrdCpioXz    = MediaTypeInitrdCpio + "+xz"
+	MediaTypeInitrdCpioLz4   = MediaTypeInitrdCpio + "+lz4"
 	MediaTypeConfigGzip      = MediaTypeConfig + "+gzip"
 )
+
+// diskFilesystems maps the disk media types to the names of the file system
+// formats which they represent.
+var diskFilesystems = map[string]string{
+	MediaTypeDiskExt4:  "ext4",
+	MediaTypeDiskErofs: "erofs",
+}
+
+// diskFilesystem returns the name of the file system format represented by the
+// disk media type.
+func diskFilesystem(mediaType string) (string, bool) {
+	filesystem, ok := diskFilesystems[mediaType]
+	return filesystem, ok
+}
@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package oci
+
+import (
+	"testing"
+
+	"kraftkit.sh/machine/volume/disk"
+)
+
+func TestDiskFilesystem(t *testing.T) {
+	// Every disk media type maps to a file system which the disk volume driver
+	// is able to attach.
+	supported := map[string]bool{}
+	for _, filesystem := range disk.Filesystems() {
+		supported[filesystem.String()] = true
+	}
+
+	for mediaType, expected := range map[string]disk.Filesystem{
+		MediaTypeDiskExt4:  disk.FilesystemExt4,
+		MediaTypeDiskErofs: disk.FilesystemErofs,
+	} {
+		filesystem, ok := diskFilesystem(mediaType)
+		if !ok || filesystem != expected.String() || !supported[filesystem] {
+			t.Errorf("%s: expected %s, got %q", mediaType, expected, filesystem)
+		}
+	}
+
+	for _, mediaType := range []string{MediaTypeInitrdCpio, MediaTypeLayer, ""} {
+		if filesystem, ok := diskFilesystem(mediaType); ok {
+			t.Errorf("%s: expected no file system, got %s", mediaType, filesystem)
+		}
+	}
+}
@@ -35,6 +35,7 @@ import (
 
 	"kraftkit.sh/config"
 	"kraftkit.sh/initrd"
+	"kraftkit.sh/internal/offline"
 	"kraftkit.sh/internal/set"
 	"kraftkit.sh/internal/tableprinter"
 	"kraftkit.sh/kconfig"
@@ -63,6 +64,7 @@ type ociPackage struct {
 	index    *Index
 	manifest *Manifest
 	auths    map[string]config.AuthConfig
+	append   bool
 
 	// Embedded attributes which represent target.Target
 	arch      arch.Architecture
@@ -72,13 +74,15 @@ type ociPackage struct {
 	kernelDbg string
 	initrd    initrd.Initrd
 	command   []string
+	disks     []pack.Disk
 
 	original *ociPackage
 }
 
 var (
-	_ pack.Package  = (*ociPackage)(nil)
-	_ target.Target = (*ociPackage)(nil)
+	_ pack.Package      = (*ociPackage)(nil)
+	_ pack.DiskProvider = (*ociPackage)(nil)
+	_ target.Target     = (*ociPackage)(nil)
 )
 
 // NewPackageFromTarget generates an OCI implementation of the pack.Package
@@ -100,6 +104,7 @@ func NewPackageFromTarget(ctx context.Context, targ target.Target, opts ...packm
 		kernel:    targ.Kernel(),
 		kernelDbg: targ.KernelDbg(),
 		command:   popts.Args(),
+		append:    popts.Append(),
 	}
 
 	// It is possible that `NewPackageFromTarget` is called with an existing
@@ -220,11 +225,49 @@ func NewPackageFromTarget(ctx context.Context, targ target.Target, opts ...packm
 			WithField("dest", WellKnownInitrdPath).
 			Debug("including initrd")
 
+		lopts := []LayerOption{
+			WithLayerAnnotation(AnnotationKernelInitrdPath, WellKnownInitrdPath),
+		}
+
+		if len(popts.InitrdMediaType()) > 0 {
+			lopts = append(lopts, WithLayerAnnotation(AnnotationMediaType, popts.InitrdMediaType()))
+		}
+
 		layer, err := NewLayerFromFile(ctx,
 			ocispec.MediaTypeImageLayer,
 			popts.Initrd(),
 			WellKnownInitrdPath,
-			WithLayerAnnotation(AnnotationKernelInitrdPath, WellKnownInitrdPath),
+			lopts...,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could build layer from file: %w", err)
+		}
+		defer os.Remove(layer.tmp)
+
+		if _, err := ocipack.manifest.AddLayer(ctx, layer); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, d := range popts.Disks() {
+		dest := fmt.Sprintf(WellKnownDiskPathPattern, i)
+
+		log.G(ctx).
+			WithField("src", d.Source).
+			WithField("dest", dest).
+			WithField("mount", d.Destination).
+			Debug("including disk")
+
+		if _, ok := diskFilesystem(d.MediaType); !ok {
+			return nil, fmt.Errorf("unsupported media type of disk %s: '%s'", d.Source, d.MediaType)
+		}
+
+		layer, err := NewLayerFromFile(ctx,
+			ocispec.MediaTypeImageLayer,
+			d.Source,
+			dest,
+			WithLayerAnnotation(fmt.Sprintf(AnnotationDiskIndexPathPattern, i), d.Destination),
+			WithLayerAnnotation(AnnotationMediaType, d.MediaType),
 		)
 		if err != nil {
 			return nil, fmt.Errorf("could build layer from file: %w", err)
@@ -653,6 +696,12 @@ func NewPackageFromOCIManifestDigest(ctx context.Context, handle handler.Handler
 		ocipack.kconfig.Override(kval)
 	}
 
+	for key, value := range ocipack.manifest.manifest.Annotations {
+		if option, ok := strings.CutPrefix(key, AnnotationKernelKConfig); ok {
+			ocipack.kconfig.Set(option, value)
+		}
+	}
+
 	return &ocipack, nil
 }
 
@@ -681,6 +730,19 @@ func (ocipack *ociPackage) Version() string {
 	return ocipack.ref.Identifier()
 }
 
+// Resolve implements pack.Resolver.
+func (ocipack *ociPackage) Resolve(context.Context) (*pack.Resolution, error) {
+	if ocipack.manifest == nil || ocipack.manifest.desc == nil {
+		return nil, fmt.Errorf("cannot resolve %s: manifest is not known", ocipack.imageRef())
+	}
+
+	return &pack.Resolution{
+		Version:  ocipack.Version(),
+		Resource: ocipack.Name(),
+		Digest:   ocipack.manifest.desc.Digest.String(),
+	}, nil
+}
+
 // imageRef returns the OCI-standard image name in the format `name:tag`
 func (ocipack *ociPackage) imageRef() string {
 	if strings.HasPrefix(ocipack.Version(), "sha256:") {
@@ -718,6 +780,10 @@ func (ocipack *ociPackage) Columns() []tableprinter.Column {
 
 // Push implements pack.Package
 func (ocipack *ociPackage) Push(ctx context.Context, opts ...pack.PushOption) error {
+	if offline.Enabled(ctx) {
+		return fmt.Errorf("cannot push %s: %w", ocipack.imageRef(), offline.ErrOffline)
+	}
+
 	// In the circumstance where the original package is available, we use
 	// google/go-containerregistry to re-tag (which is achieved via `pusher.Push`
 	// which ultimately checks if the manifest, its layers, config and ultimately
@@ -773,6 +839,10 @@ func (ocipack *ociPackage) Push(ctx context.Context, opts ...pack.PushOption) er
 		}
 	}
 
+	if ocipack.append {
+		return ocipack.pushAppend(ctx)
+	}
+
 	desc, err := ocipack.index.Descriptor()
 	if err != nil {
 		return err
@@ -785,6 +855,46 @@ func (ocipack *ociPackage) Push(ctx context.Context, opts ...pack.PushOption) er
 	return nil
 }
 
+// pushAppend pushes the manifest of the package and merges it into the index
+// which already exists at the remote reference, replacing the manifest for the
+// same architecture, platform and KConfig options.
+func (ocipack *ociPackage) pushAppend(ctx context.Context) error {
+	if ocipack.manifest.desc == nil {
+		return fmt.Errorf("cannot push: package has not been saved")
+	}
+
+	manifestRef := fmt.Sprintf("%s@%s", ocipack.ref.Context().Name(), ocipack.manifest.desc.Digest.String())
+
+	if err := ocipack.handle.PushDescriptor(ctx, manifestRef, ocipack.manifest.desc); err != nil {
+		return fmt.Errorf("could not push manifest: %w", err)
+	}
+
+	seed := ocipack.ref.String()
+
+	_, err := UpdateRemoteIndex(ctx, ocipack.imageRef(), func(index *ocispec.Index) error {
+		manifests, err := mergeManifests(seed, index.Manifests, []ocispec.Descriptor{*ocipack.manifest.desc})
+		if err != nil {
+			return err
+		}
+
+		index.Manifests = manifests
+
+		if index.Annotations == nil {
+			index.Annotations = map[string]string{}
+		}
+
+		for k, v := range ocipack.index.Annotations() {
+			if _, ok := index.Annotations[k]; !ok || k == ocispec.AnnotationCreated {
+				index.Annotations[k] = v
+			}
+		}
+
+		return nil
+	})
+
+	return err
+}
+
 // Unpack implements pack.Package
 func (ocipack *ociPackage) Unpack(ctx context.Context, dir string) error {
 	image, err := ocipack.handle.UnpackImage(ctx,
@@ -817,9 +927,45 @@ func (ocipack *ociPackage) Unpack(ctx context.Context, dir string) error {
 	// Set the environment variables
 	ocipack.manifest.config.Config.Env = image.Config.Env
 
+	// Set the disks which have been unpacked
+	ocipack.disks = nil
+	for _, layer := range ocipack.manifest.layers {
+		if layer.blob == nil {
+			continue
+		}
+
+		filesystem, ok := diskFilesystem(layer.blob.desc.Annotations[AnnotationMediaType])
+		if !ok {
+			continue
+		}
+
+		for key, dest := range layer.blob.desc.Annotations {
+			var i int
+			if _, err := fmt.Sscanf(key, AnnotationDiskIndexPathPattern, &i); err != nil {
+				continue
+			}
+
+			path := filepath.Join(dir, fmt.Sprintf(WellKnownDiskPathPattern, i))
+			if _, err := os.Stat(path); err != nil {
+				return fmt.Errorf("could not access disk %d: %w", i, err)
+			}
+
+			ocipack.disks = append(ocipack.disks, pack.Disk{
+				Path:        path,
+				Destination: dest,
+				Filesystem:  filesystem,
+			})
+		}
+	}
+
 	return nil
 }
 
+// Disks implements pack.DiskProvider
+func (ocipack *ociPackage) Disks() []pack.Disk {
+	return ocipack.disks
+}
+
 // Pull implements pack.Package
 func (ocipack *ociPackage) Pull(ctx context.Context, opts ...pack.PullOption) error {
 	popts, err := pack.NewPullOptions(opts...)
@@ -827,12 +973,40 @@ func (ocipack *ociPackage) Pull(ctx context.Context, opts ...pack.PullOption) er
 		return err
 	}
 
+	// Packages which are already in the local store are used as-is in offline
+	// mode.
+	if offline.Enabled(ctx) {
+		if exists, _, err := ocipack.PulledAt(ctx); err != nil || !exists {
+			return offline.NotCached(ocipack.imageRef())
+		}
+
+		return nil
+	}
+
+	verified, err := ocipack.verify(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Pull the verified index by its digest such that the index which is stored
+	// is the one which was verified, even if the tag is moved in the meantime.
+	fullref := ocipack.imageRef()
+	if verified != (v1.Hash{}) {
+		if err := ocipack.checkVerified(ctx, verified); err != nil {
+			return err
+		}
+
+		if !strings.ContainsRune(fullref, '@') {
+			fullref = fmt.Sprintf("%s@%s", fullref, verified.String())
+		}
+	}
+
 	// Pull the index but set the platform such that the relevant manifests can
 	// be retrieved as well.
 	if err := ocipack.handle.PullDigest(
 		ctx,
 		ocispec.MediaTypeImageIndex,
-		ocipack.imageRef(),
+		fullref,
 		ocipack.manifest.desc.Digest,
 		ocipack.manifest.desc.Platform,
 		popts.OnProgress,
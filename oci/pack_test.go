@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package oci
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/offline"
+	"kraftkit.sh/internal/offline/offlinetest"
+)
+
+func TestPullOffline(t *testing.T) {
+	ctx, host := testRegistry(t)
+	ref := host + "/unikraft/nginx:latest"
+
+	index := pushIndex(t, ref, "x86_64")
+
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := index.Image(manifest.Manifests[0].Digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := img.RawManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var spec ocispec.Manifest
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := name.ParseReference(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The registry is reachable, but must not be contacted.
+	offlinetest.DenyDial(t)
+	config.G[config.KraftKit](ctx).Offline = true
+
+	handle := localStore(t, ctx)
+	desc := FromGoogleV1DescriptorToOCISpec(manifest.Manifests[0])[0]
+
+	ocipack := &ociPackage{
+		handle:   handle,
+		ref:      r,
+		manifest: &Manifest{handle: handle, desc: &desc, manifest: &spec},
+	}
+
+	if err := ocipack.Pull(ctx); !errors.Is(err, offline.ErrOffline) {
+		t.Errorf("expected package which is not cached to be refused, got %v", err)
+	}
+
+	if _, err := handle.ResolveIndex(ctx, ref); err == nil {
+		t.Errorf("expected no index to be stored")
+	}
+}
@@ -0,0 +1,333 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package oci
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"kraftkit.sh/log"
+	"kraftkit.sh/pack"
+)
+
+// PushReferrer pushes an artifact which consists of a single blob of the
+// provided media type and which refers to the manifest or index at the
+// provided reference via its subject.  The artifact type is set to the media
+// type of the blob.  The digest of the pushed artifact is returned.
+func PushReferrer(ctx context.Context, ref name.Reference, mediaType string, blob []byte, annotations map[string]string, opts ...remote.Option) (v1.Hash, error) {
+	opts = append(opts, remote.WithContext(ctx))
+
+	subject, err := remote.Head(ref, opts...)
+	if err != nil {
+		return v1.Hash{}, fmt.Errorf("could not resolve %s: %w", ref.String(), err)
+	}
+
+	img, err := newReferrer(mediaType, blob, annotations, *subject)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	dgst, err := img.Digest()
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	if err := remote.Write(ref.Context().Digest(dgst.String()), img, opts...); err != nil {
+		return v1.Hash{}, fmt.Errorf("could not push artifact: %w", err)
+	}
+
+	log.G(ctx).
+		WithField("subject", subject.Digest.String()).
+		WithField("artifact", dgst.String()).
+		WithField("type", mediaType).
+		Debug("pushed referrer")
+
+	return dgst, nil
+}
+
+// newReferrer returns an artifact which consists of a single blob of the
+// provided media type and which refers to the subject.
+func newReferrer(mediaType string, blob []byte, annotations map[string]string, subject v1.Descriptor) (v1.Image, error) {
+	img, err := mutate.Append(
+		mutate.MediaType(empty.Image, types.OCIManifestSchema1),
+		mutate.Addendum{
+			Layer:       static.NewLayer(blob, types.MediaType(mediaType)),
+			Annotations: annotations,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	img = mutate.ConfigMediaType(img, types.MediaType(mediaType))
+	img, ok := mutate.Subject(img, v1.Descriptor{
+		MediaType: subject.MediaType,
+		Size:      subject.Size,
+		Digest:    subject.Digest,
+	}).(v1.Image)
+	if !ok {
+		return nil, fmt.Errorf("could not set subject of artifact")
+	}
+
+	return img, nil
+}
+
+// packageManifest returns the OCI package and the descriptor of its manifest.
+func packageManifest(ctx context.Context, p pack.Package) (*ociPackage, v1.Descriptor, error) {
+	ocipack, ok := p.(*ociPackage)
+	if !ok {
+		return nil, v1.Descriptor{}, fmt.Errorf("%s is not an OCI package", p.String())
+	}
+
+	if ocipack.manifest == nil || ocipack.manifest.desc == nil {
+		return nil, v1.Descriptor{}, fmt.Errorf("%s has not been saved", p.String())
+	}
+
+	dgst := ocipack.manifest.desc.Digest
+
+	info, err := ocipack.handle.DigestInfo(ctx, dgst)
+	if err != nil {
+		return nil, v1.Descriptor{}, fmt.Errorf("could not resolve manifest of %s: %w", p.String(), err)
+	}
+
+	hash, err := v1.NewHash(dgst.String())
+	if err != nil {
+		return nil, v1.Descriptor{}, err
+	}
+
+	return ocipack, v1.Descriptor{
+		MediaType: types.OCIManifestSchema1,
+		Size:      info.Size,
+		Digest:    hash,
+	}, nil
+}
+
+// PackageDigest returns the digest of the manifest of the package.
+func PackageDigest(ctx context.Context, p pack.Package) (v1.Hash, error) {
+	_, desc, err := packageManifest(ctx, p)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	return desc.Digest, nil
+}
+
+// SaveReferrer stores an artifact which consists of a single blob of the
+// provided media type and which refers to the manifest of the package in the
+// local store.  The digest of the saved artifact is returned.
+func SaveReferrer(ctx context.Context, p pack.Package, mediaType string, blob []byte, annotations map[string]string) (v1.Hash, error) {
+	ocipack, subject, err := packageManifest(ctx, p)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	img, err := newReferrer(mediaType, blob, annotations, subject)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	layers, err := img.Layers()
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	for _, layer := range layers {
+		dgst, err := layer.Digest()
+		if err != nil {
+			return v1.Hash{}, err
+		}
+
+		size, err := layer.Size()
+		if err != nil {
+			return v1.Hash{}, err
+		}
+
+		rc, err := layer.Compressed()
+		if err != nil {
+			return v1.Hash{}, err
+		}
+
+		err = ocipack.handle.SaveDescriptor(ctx, "", ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.Digest(dgst.String()),
+			Size:      size,
+		}, rc, nil)
+		rc.Close()
+		if err != nil {
+			return v1.Hash{}, fmt.Errorf("could not save artifact blob: %w", err)
+		}
+	}
+
+	rawConfig, err := img.RawConfigFile()
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	rawManifest, err := img.RawManifest()
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	// The manifest is saved last such that it only refers to existing blobs.
+	for _, desc := range []struct {
+		mediaType string
+		raw       []byte
+	}{
+		{mediaType, rawConfig},
+		{ocispec.MediaTypeImageManifest, rawManifest},
+	} {
+		if err := ocipack.handle.SaveDescriptor(ctx, "",
+			ocispec.Descriptor{
+				MediaType: desc.mediaType,
+				Digest:    digest.FromBytes(desc.raw),
+				Size:      int64(len(desc.raw)),
+			},
+			bytes.NewReader(desc.raw),
+			nil,
+		); err != nil {
+			return v1.Hash{}, fmt.Errorf("could not save artifact: %w", err)
+		}
+	}
+
+	dgst, err := img.Digest()
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	log.G(ctx).
+		WithField("subject", subject.Digest.String()).
+		WithField("artifact", dgst.String()).
+		WithField("type", mediaType).
+		Debug("saved referrer")
+
+	return dgst, nil
+}
+
+// PushPackageReferrer pushes an artifact which consists of a single blob of
+// the provided media type and which refers to the manifest of the package to
+// the registry of the package.
+func PushPackageReferrer(ctx context.Context, p pack.Package, mediaType string, blob []byte, annotations map[string]string) (v1.Hash, error) {
+	ocipack, subject, err := packageManifest(ctx, p)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	ref := ocipack.ref.Context().Digest(subject.Digest.String())
+
+	ropts, err := RemoteOptions(ctx, ref)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	return PushReferrer(ctx, ref, mediaType, blob, annotations, ropts...)
+}
+
+// PackageReferrers returns the blobs of the artifacts of the provided media
+// type which refer to the manifest of the package.  Artifacts are looked up in
+// the local store and otherwise in the registry of the package.
+func PackageReferrers(ctx context.Context, p pack.Package, mediaType string) ([][]byte, error) {
+	ocipack, subject, err := packageManifest(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	manifests, err := ocipack.handle.ListManifests(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var blobs [][]byte
+
+	for _, manifest := range manifests {
+		if manifest.Subject == nil || manifest.Subject.Digest.String() != subject.Digest.String() {
+			continue
+		}
+
+		if manifest.ArtifactType != mediaType && manifest.Config.MediaType != mediaType {
+			continue
+		}
+
+		for _, layer := range manifest.Layers {
+			rc, err := ocipack.handle.FetchDigest(ctx, layer.Digest)
+			if err != nil {
+				return nil, fmt.Errorf("could not read artifact: %w", err)
+			}
+
+			b, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return nil, err
+			}
+
+			blobs = append(blobs, b)
+		}
+	}
+
+	if len(blobs) > 0 {
+		return blobs, nil
+	}
+
+	ref := ocipack.ref.Context().Digest(subject.Digest.String())
+
+	ropts, err := RemoteOptions(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	referrers, err := remote.Referrers(ref, append(ropts, remote.WithFilter("artifactType", mediaType))...)
+	if err != nil {
+		return nil, fmt.Errorf("could not list referrers of %s: %w", ref.Name(), err)
+	}
+
+	index, err := referrers.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, desc := range index.Manifests {
+		if desc.ArtifactType != mediaType {
+			continue
+		}
+
+		img, err := remote.Image(ref.Context().Digest(desc.Digest.String()), ropts...)
+		if err != nil {
+			return nil, err
+		}
+
+		layers, err := img.Layers()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, layer := range layers {
+			rc, err := layer.Compressed()
+			if err != nil {
+				return nil, err
+			}
+
+			b, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return nil, err
+			}
+
+			blobs = append(blobs, b)
+		}
+	}
+
+	return blobs, nil
+}
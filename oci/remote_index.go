@@ -0,0 +1,268 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"kraftkit.sh/log"
+	ociutils "kraftkit.sh/oci/utils"
+)
+
+// remoteIndexAttempts is the number of times an update to a remote index is
+// attempted when it is concurrently modified by another client.
+var remoteIndexAttempts = 3
+
+// rawIndex is an index which is pushed to a registry as-is.
+type rawIndex []byte
+
+// RawManifest implements remote.Taggable
+func (raw rawIndex) RawManifest() ([]byte, error) {
+	return raw, nil
+}
+
+// MediaType implements remote.Taggable
+func (raw rawIndex) MediaType() (types.MediaType, error) {
+	return types.OCIImageIndex, nil
+}
+
+// fetchRemoteIndex retrieves the index at the remote reference along with its
+// digest.  A nil index is returned if the reference does not exist.
+func fetchRemoteIndex(ref name.Reference, ropts []remote.Option) (*ocispec.Index, *v1.Hash, error) {
+	desc, err := remote.Get(ref, ropts...)
+	if err != nil {
+		var terr *transport.Error
+		if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+			return nil, nil, nil
+		}
+
+		return nil, nil, fmt.Errorf("could not retrieve %s: %w", ref.Name(), err)
+	}
+
+	if !desc.MediaType.IsIndex() {
+		return nil, nil, fmt.Errorf("%s is not an index: %s", ref.Name(), desc.MediaType)
+	}
+
+	index := ocispec.Index{}
+	if err := json.Unmarshal(desc.Manifest, &index); err != nil {
+		return nil, nil, fmt.Errorf("could not parse index: %w", err)
+	}
+
+	return &index, &desc.Digest, nil
+}
+
+// FetchRemoteIndex retrieves the index at the remote reference.
+func FetchRemoteIndex(ctx context.Context, fullref string) (*ocispec.Index, error) {
+	ref, err := parseRef(fullref)
+	if err != nil {
+		return nil, err
+	}
+
+	ropts, err := RemoteOptions(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	index, _, err := fetchRemoteIndex(ref, ropts)
+	if err != nil {
+		return nil, err
+	}
+
+	if index == nil {
+		return nil, fmt.Errorf("%s does not exist", ref.Name())
+	}
+
+	return index, nil
+}
+
+// UpdateRemoteIndex applies the update to the index at the remote tag, or to
+// an empty index if it does not exist, and pushes the result.  Registries do
+// not support conditional writes, so the tag is read back after the push and
+// the update is applied again to the new index if another client modified it
+// in the meantime.  The digest of the pushed index is returned.
+func UpdateRemoteIndex(ctx context.Context, fullref string, update func(*ocispec.Index) error) (v1.Hash, error) {
+	ref, err := parseRef(fullref)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	if _, ok := ref.(name.Tag); !ok {
+		return v1.Hash{}, fmt.Errorf("cannot update index: reference must be a tag: %s", fullref)
+	}
+
+	ropts, err := RemoteOptions(ctx, ref)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		index, previous, err := fetchRemoteIndex(ref, ropts)
+		if err != nil {
+			return v1.Hash{}, err
+		}
+
+		if index == nil {
+			index = &ocispec.Index{
+				MediaType: ocispec.MediaTypeImageIndex,
+				Versioned: specs.Versioned{
+					SchemaVersion: 2,
+				},
+			}
+		}
+
+		if err := update(index); err != nil {
+			return v1.Hash{}, err
+		}
+
+		raw, err := json.Marshal(index)
+		if err != nil {
+			return v1.Hash{}, fmt.Errorf("could not marshal index: %w", err)
+		}
+
+		pushed, _, err := v1.SHA256(bytes.NewReader(raw))
+		if err != nil {
+			return v1.Hash{}, err
+		}
+
+		// Reduce the window in which a concurrent update can be lost by checking
+		// the tag has not moved since it was read.
+		if current, err := remote.Head(ref, ropts...); err == nil && (previous == nil || current.Digest != *previous) {
+			if attempt >= remoteIndexAttempts {
+				return v1.Hash{}, fmt.Errorf("could not update %s: index was concurrently modified", ref.Name())
+			}
+
+			continue
+		}
+
+		log.G(ctx).
+			WithField("ref", ref.Name()).
+			WithField("digest", pushed.String()).
+			Debug("pushing index")
+
+		if err := remote.Put(ref, rawIndex(raw), ropts...); err != nil {
+			return v1.Hash{}, fmt.Errorf("could not push index: %w", err)
+		}
+
+		current, err := remote.Head(ref, ropts...)
+		if err != nil {
+			return v1.Hash{}, fmt.Errorf("could not verify index: %w", err)
+		}
+
+		if current.Digest == pushed {
+			return pushed, nil
+		}
+
+		if attempt >= remoteIndexAttempts {
+			return v1.Hash{}, fmt.Errorf("could not update %s: index was concurrently modified", ref.Name())
+		}
+
+		log.G(ctx).
+			WithField("ref", ref.Name()).
+			WithField("attempt", attempt).
+			Debug("index was concurrently modified, retrying")
+	}
+}
+
+// platformChecksum returns the checksum of the architecture, platform and
+// KConfig options of the manifest descriptor, mirroring the checksum which is
+// used to detect conflicting manifests when packaging.
+func platformChecksum(fullref string, desc ocispec.Descriptor) (string, error) {
+	if desc.Platform == nil {
+		return "", nil
+	}
+
+	return ociutils.PlatformChecksum(fullref, &ocispec.Platform{
+		Architecture: desc.Platform.Architecture,
+		OS:           desc.Platform.OS,
+		OSVersion:    desc.Platform.OSVersion,
+		OSFeatures:   desc.Platform.OSFeatures,
+	})
+}
+
+// mergeManifests returns the existing manifest descriptors with the added
+// descriptors appended.  Existing descriptors for the same architecture,
+// platform and KConfig options as an added descriptor are replaced.
+func mergeManifests(fullref string, existing, added []ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+	replaced := map[string]struct{}{}
+
+	for _, desc := range added {
+		checksum, err := platformChecksum(fullref, desc)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate manifest platform checksum for '%s': %w", desc.Digest.String(), err)
+		}
+
+		if checksum != "" {
+			replaced[checksum] = struct{}{}
+		}
+
+		replaced[desc.Digest.String()] = struct{}{}
+	}
+
+	var merged []ocispec.Descriptor
+
+	for _, desc := range existing {
+		checksum, err := platformChecksum(fullref, desc)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate manifest platform checksum for '%s': %w", desc.Digest.String(), err)
+		}
+
+		if _, ok := replaced[checksum]; ok && checksum != "" {
+			continue
+		}
+
+		if _, ok := replaced[desc.Digest.String()]; ok {
+			continue
+		}
+
+		merged = append(merged, desc)
+	}
+
+	return append(merged, added...), nil
+}
+
+// ManifestMatches returns whether the manifest descriptor is for the provided
+// architecture and platform and has the provided digest, which may be
+// abbreviated.  Empty values match any manifest.
+func ManifestMatches(desc ocispec.Descriptor, architecture, platform, dgst string) bool {
+	arch := desc.Annotations[AnnotationKernelArch]
+	plat := desc.Annotations[AnnotationKernelPlat]
+
+	if desc.Platform != nil {
+		if arch == "" {
+			arch = desc.Platform.Architecture
+		}
+		if plat == "" {
+			plat = desc.Platform.OS
+		}
+	}
+
+	if architecture != "" && architecture != arch {
+		return false
+	}
+
+	if platform != "" && platform != plat {
+		return false
+	}
+
+	if dgst != "" && desc.Digest.String() != dgst && !strings.HasPrefix(desc.Digest.Encoded(), dgst) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,240 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package oci
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// platformManifest returns the descriptor of a manifest with the provided
+// digest seed, architecture and KConfig options.
+func platformManifest(seed, arch string, features ...string) ocispec.Descriptor {
+	return ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromString(seed),
+		Size:      int64(len(seed)),
+		Platform: &ocispec.Platform{
+			Architecture: arch,
+			OS:           "qemu",
+			OSFeatures:   features,
+		},
+	}
+}
+
+// seedsOf returns the comma-separated seeds of the manifests, in order.
+func seedsOf(descs []ocispec.Descriptor, seeds ...string) string {
+	names := map[digest.Digest]string{}
+	for _, seed := range seeds {
+		names[digest.FromString(seed)] = seed
+	}
+
+	var ret []string
+	for _, desc := range descs {
+		ret = append(ret, names[desc.Digest])
+	}
+
+	return strings.Join(ret, ",")
+}
+
+func TestMergeManifests(t *testing.T) {
+	const fullref = "unikraft.org/nginx:latest"
+
+	untagged := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromString("untagged"),
+	}
+
+	tests := []struct {
+		name     string
+		existing []ocispec.Descriptor
+		added    []ocispec.Descriptor
+		expected []string
+	}{
+		{
+			name:     "new platform is appended",
+			existing: []ocispec.Descriptor{platformManifest("x86", "x86_64")},
+			added:    []ocispec.Descriptor{platformManifest("arm", "arm64")},
+			expected: []string{"x86", "arm"},
+		},
+		{
+			name:     "same platform is replaced",
+			existing: []ocispec.Descriptor{platformManifest("x86-old", "x86_64"), platformManifest("arm", "arm64")},
+			added:    []ocispec.Descriptor{platformManifest("x86-new", "x86_64")},
+			expected: []string{"arm", "x86-new"},
+		},
+		{
+			name:     "different KConfig options are kept",
+			existing: []ocispec.Descriptor{platformManifest("x86", "x86_64", "CONFIG_LIBVFSCORE=y")},
+			added:    []ocispec.Descriptor{platformManifest("x86-debug", "x86_64", "CONFIG_LIBVFSCORE=y", "CONFIG_LIBUKDEBUG=y")},
+			expected: []string{"x86", "x86-debug"},
+		},
+		{
+			name:     "same digest is not duplicated",
+			existing: []ocispec.Descriptor{platformManifest("x86", "x86_64")},
+			added:    []ocispec.Descriptor{platformManifest("x86", "x86_64")},
+			expected: []string{"x86"},
+		},
+		{
+			name:     "manifests without platform are kept",
+			existing: []ocispec.Descriptor{untagged},
+			added:    []ocispec.Descriptor{platformManifest("x86", "x86_64")},
+			expected: []string{"untagged", "x86"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			merged, err := mergeManifests(fullref, tc.existing, tc.added)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual := seedsOf(merged, "x86", "x86-old", "x86-new", "x86-debug", "arm", "untagged")
+			if actual != strings.Join(tc.expected, ",") {
+				t.Errorf("expected manifests %s, got %s", strings.Join(tc.expected, ","), actual)
+			}
+		})
+	}
+}
+
+// pushManifest pushes a random image to the repository and returns its
+// descriptor for the provided architecture.
+func pushManifest(t *testing.T, repo name.Repository, arch string) ocispec.Descriptor {
+	t.Helper()
+
+	img, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dgst, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	size, err := img.Size()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mediaType, err := img.MediaType()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := remote.Write(repo.Digest(dgst.String()), img); err != nil {
+		t.Fatal(err)
+	}
+
+	return ocispec.Descriptor{
+		MediaType: string(mediaType),
+		Digest:    digest.Digest(dgst.String()),
+		Size:      size,
+		Platform: &ocispec.Platform{
+			Architecture: arch,
+			OS:           "qemu",
+		},
+	}
+}
+
+func TestUpdateRemoteIndex(t *testing.T) {
+	ctx, host := testRegistry(t)
+
+	fullref := host + "/unikraft/nginx:latest"
+
+	repo, err := name.NewRepository(host + "/unikraft/nginx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	x86 := pushManifest(t, repo, "x86_64")
+	arm := pushManifest(t, repo, "arm64")
+	riscv := pushManifest(t, repo, "riscv64")
+
+	add := func(desc ocispec.Descriptor) func(*ocispec.Index) error {
+		return func(index *ocispec.Index) error {
+			manifests, err := mergeManifests(fullref, index.Manifests, []ocispec.Descriptor{desc})
+			if err != nil {
+				return err
+			}
+
+			index.Manifests = manifests
+			return nil
+		}
+	}
+
+	// The index is created if it does not exist.
+	if _, err := UpdateRemoteIndex(ctx, fullref, add(x86)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another client adds a manifest whilst the index is updated, in which
+	// case the update is applied again to its index.
+	calls := 0
+	pushed, err := UpdateRemoteIndex(ctx, fullref, func(index *ocispec.Index) error {
+		calls++
+
+		if calls == 1 {
+			if _, err := UpdateRemoteIndex(ctx, fullref, add(riscv)); err != nil {
+				return err
+			}
+		}
+
+		return add(arm)(index)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 2 {
+		t.Errorf("expected the update to be applied twice, got %d", calls)
+	}
+
+	index, err := FetchRemoteIndex(ctx, fullref)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actual []string
+	for _, desc := range index.Manifests {
+		actual = append(actual, desc.Platform.Architecture)
+	}
+
+	if strings.Join(actual, ",") != "x86_64,riscv64,arm64" {
+		t.Errorf("expected manifests of x86_64, riscv64 and arm64, got %v", actual)
+	}
+
+	ref, err := name.ParseReference(fullref)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	desc, err := remote.Head(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if desc.Digest != pushed {
+		t.Errorf("expected index to have digest %s, got %s", pushed, desc.Digest)
+	}
+
+	// Updates which keep conflicting with other clients eventually fail.
+	if _, err := UpdateRemoteIndex(ctx, fullref, func(index *ocispec.Index) error {
+		_, err := UpdateRemoteIndex(ctx, fullref, func(index *ocispec.Index) error {
+			index.Manifests = index.Manifests[1:]
+			return nil
+		})
+		return err
+	}); err == nil || !strings.Contains(err.Error(), "concurrently modified") {
+		t.Errorf("expected update to fail after %d attempts, got %v", remoteIndexAttempts, err)
+	}
+}
@@ -0,0 +1,285 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/containerd/containerd/images"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"kraftkit.sh/log"
+)
+
+// parseRef parses a package reference, defaulting to the default registry and
+// tag.
+func parseRef(ref string) (name.Reference, error) {
+	r, err := name.ParseReference(ref,
+		name.WithDefaultRegistry(DefaultRegistry),
+		name.WithDefaultTag(DefaultTag),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse reference '%s': %w", ref, err)
+	}
+
+	return r, nil
+}
+
+// Tag creates the local reference dst which refers to the same manifests as
+// the local reference src.
+func Tag(ctx context.Context, src, dst string) error {
+	srcRef, err := parseRef(src)
+	if err != nil {
+		return err
+	}
+
+	dstRef, err := parseRef(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := dstRef.(name.Tag); !ok {
+		return fmt.Errorf("destination must be a tag: %s", dst)
+	}
+
+	ctx, handle, err := newHandle(ctx)
+	if err != nil {
+		return err
+	}
+
+	index, err := handle.ResolveIndex(ctx, srcRef.Name())
+	if err != nil {
+		return fmt.Errorf("could not resolve %s: %w", srcRef.Name(), err)
+	}
+
+	if index.Annotations == nil {
+		index.Annotations = map[string]string{}
+	}
+
+	index.Annotations[ocispec.AnnotationRefName] = dstRef.Context().String()
+	index.Annotations[images.AnnotationImageName] = dstRef.String()
+
+	raw, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("could not marshal index: %w", err)
+	}
+
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Digest:    digest.FromBytes(raw),
+		Size:      int64(len(raw)),
+	}
+
+	log.G(ctx).
+		WithField("src", srcRef.Name()).
+		WithField("dst", dstRef.Name()).
+		Debug("tagging")
+
+	return handle.SaveDescriptor(ctx, dstRef.Name(), desc, bytes.NewReader(raw), nil)
+}
+
+// Copy copies the index or manifest at the remote reference src, including all
+// of its manifests, blobs and referrers, to the remote reference dst.  Blobs
+// are mounted from the source repository rather than uploaded again when both
+// are in the same registry and it supports cross-repository mounts.  The digest
+// of the copied index or manifest is returned.
+func Copy(ctx context.Context, src, dst string) (v1.Hash, error) {
+	srcRef, err := parseRef(src)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	dstRef, err := parseRef(dst)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	srcOpts, err := RemoteOptions(ctx, srcRef)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	dstOpts, err := RemoteOptions(ctx, dstRef)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	desc, err := remote.Get(srcRef, srcOpts...)
+	if err != nil {
+		return v1.Hash{}, fmt.Errorf("could not retrieve %s: %w", srcRef.Name(), err)
+	}
+
+	subjects := []v1.Hash{desc.Digest}
+
+	log.G(ctx).
+		WithField("src", srcRef.Name()).
+		WithField("dst", dstRef.Name()).
+		WithField("digest", desc.Digest.String()).
+		Info("copying")
+
+	if desc.MediaType.IsIndex() {
+		index, err := desc.ImageIndex()
+		if err != nil {
+			return v1.Hash{}, err
+		}
+
+		manifest, err := index.IndexManifest()
+		if err != nil {
+			return v1.Hash{}, err
+		}
+
+		for _, m := range manifest.Manifests {
+			subjects = append(subjects, m.Digest)
+		}
+
+		if err := remote.WriteIndex(dstRef, index, dstOpts...); err != nil {
+			return v1.Hash{}, fmt.Errorf("could not write %s: %w", dstRef.Name(), err)
+		}
+	} else {
+		img, err := desc.Image()
+		if err != nil {
+			return v1.Hash{}, err
+		}
+
+		if err := remote.Write(dstRef, img, dstOpts...); err != nil {
+			return v1.Hash{}, fmt.Errorf("could not write %s: %w", dstRef.Name(), err)
+		}
+	}
+
+	// Signatures, SBOMs and other artifacts remain valid since the digests do
+	// not change and are copied on a best-effort basis.
+	for _, subject := range subjects {
+		if err := copyReferrers(ctx, srcRef.Context(), dstRef.Context(), subject, srcOpts, dstOpts); err != nil {
+			log.G(ctx).
+				WithField("subject", subject.String()).
+				Warnf("could not copy referrers: %v", err)
+		}
+	}
+
+	return desc.Digest, nil
+}
+
+// copyReferrers copies the manifests which refer to the subject from the
+// source to the destination repository.
+func copyReferrers(ctx context.Context, src, dst name.Repository, subject v1.Hash, srcOpts, dstOpts []remote.Option) error {
+	referrers, err := remote.Referrers(src.Digest(subject.String()), srcOpts...)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := referrers.IndexManifest()
+	if err != nil {
+		return err
+	}
+
+	for _, desc := range manifest.Manifests {
+		img, err := remote.Image(src.Digest(desc.Digest.String()), srcOpts...)
+		if err != nil {
+			return err
+		}
+
+		log.G(ctx).
+			WithField("subject", subject.String()).
+			WithField("referrer", desc.Digest.String()).
+			Debug("copying referrer")
+
+		if err := remote.Write(dst.Digest(desc.Digest.String()), img, dstOpts...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RemoteTag is a tag of a remote repository along with the platforms and
+// architectures of its manifests.
+type RemoteTag struct {
+	Name      string
+	Platforms []ocispec.Platform
+}
+
+// ListTags returns the tags of the remote repository.  If the architecture or
+// the platform are set, only tags with at least one manifest for them are
+// returned, which requires their index to be retrieved.
+func ListTags(ctx context.Context, repo, architecture, platform string) ([]RemoteTag, error) {
+	repository, err := name.NewRepository(repo, name.WithDefaultRegistry(DefaultRegistry))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse repository '%s': %w", repo, err)
+	}
+
+	ropts, err := RemoteOptions(ctx, repository.Tag(DefaultTag))
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := remote.List(repository, ropts...)
+	if err != nil {
+		return nil, fmt.Errorf("could not list tags of %s: %w", repository.Name(), err)
+	}
+
+	sort.Strings(names)
+
+	var tags []RemoteTag
+
+	for _, tag := range names {
+		if architecture == "" && platform == "" {
+			tags = append(tags, RemoteTag{Name: tag})
+			continue
+		}
+
+		index, err := remote.Index(repository.Tag(tag), ropts...)
+		if err != nil {
+			log.G(ctx).
+				WithField("tag", tag).
+				Debugf("skipping: %v", err)
+			continue
+		}
+
+		manifest, err := index.IndexManifest()
+		if err != nil {
+			return nil, err
+		}
+
+		remoteTag := RemoteTag{Name: tag}
+		matches := false
+
+		for _, m := range manifest.Manifests {
+			arch := m.Annotations[AnnotationKernelArch]
+			plat := m.Annotations[AnnotationKernelPlat]
+
+			if m.Platform != nil {
+				if arch == "" {
+					arch = m.Platform.Architecture
+				}
+				if plat == "" {
+					plat = m.Platform.OS
+				}
+			}
+
+			remoteTag.Platforms = append(remoteTag.Platforms, ocispec.Platform{
+				Architecture: arch,
+				OS:           plat,
+			})
+
+			if (architecture == "" || architecture == arch) && (platform == "" || platform == plat) {
+				matches = true
+			}
+		}
+
+		if matches {
+			tags = append(tags, remoteTag)
+		}
+	}
+
+	return tags, nil
+}
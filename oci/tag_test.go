@@ -0,0 +1,233 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package oci
+
+import (
+	"context"
+	"io"
+	golog "log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/offline/offlinetest"
+)
+
+// testRegistry starts an in-memory registry and returns its host along with
+// a context in which it can be accessed.
+func testRegistry(t *testing.T) (context.Context, string) {
+	t.Helper()
+
+	server := httptest.NewServer(registry.New(
+		registry.WithReferrersSupport(true),
+		registry.Logger(golog.New(io.Discard, "", 0)),
+	))
+	t.Cleanup(server.Close)
+
+	ctx := offlinetest.Context(t)
+	config.G[config.KraftKit](ctx).Offline = false
+
+	return ctx, strings.TrimPrefix(server.URL, "http://")
+}
+
+// pushIndex pushes an index with a random image for each of the provided
+// architectures to the reference and returns it.
+func pushIndex(t *testing.T, ref string, architectures ...string) v1.ImageIndex {
+	t.Helper()
+
+	var index v1.ImageIndex = empty.Index
+	index = mutate.IndexMediaType(index, types.OCIImageIndex)
+
+	for _, arch := range architectures {
+		img, err := random.Image(64, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		index = mutate.AppendManifests(index, mutate.IndexAddendum{
+			Add: img,
+			Descriptor: v1.Descriptor{
+				Platform: &v1.Platform{Architecture: arch, OS: "qemu"},
+				Annotations: map[string]string{
+					AnnotationKernelArch: arch,
+					AnnotationKernelPlat: "qemu",
+				},
+			},
+		})
+	}
+
+	r, err := name.ParseReference(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := remote.WriteIndex(r, index); err != nil {
+		t.Fatal(err)
+	}
+
+	return index
+}
+
+func TestTag(t *testing.T) {
+	offlinetest.DenyDial(t)
+
+	ctx := offlinetest.Context(t)
+	layoutPackage(t, ctx)
+
+	if err := Tag(ctx, layoutTestRef, "unikraft.org/nginx:stable"); err != nil {
+		t.Fatal(err)
+	}
+
+	handle := localStore(t, ctx)
+
+	src, err := handle.ResolveIndex(ctx, layoutTestRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst, err := handle.ResolveIndex(ctx, "unikraft.org/nginx:stable")
+	if err != nil {
+		t.Fatalf("expected tag to exist: %v", err)
+	}
+
+	if len(dst.Manifests) != len(src.Manifests) || dst.Manifests[0].Digest != src.Manifests[0].Digest {
+		t.Errorf("expected tag to refer to %+v, got %+v", src.Manifests, dst.Manifests)
+	}
+
+	if name := dst.Annotations[ocispec.AnnotationRefName]; name != "unikraft.org/nginx" {
+		t.Errorf("expected tag to be annotated with its name, got %q", name)
+	}
+
+	// The source is left untouched.
+	if _, ok := src.Annotations[ocispec.AnnotationRefName]; ok {
+		t.Errorf("expected source not to be annotated, got %v", src.Annotations)
+	}
+
+	if err := Tag(ctx, layoutTestRef, "unikraft.org/nginx@"+src.Manifests[0].Digest.String()); err == nil {
+		t.Errorf("expected a digest destination to be rejected")
+	}
+}
+
+func TestCopy(t *testing.T) {
+	ctx, host := testRegistry(t)
+
+	src := host + "/unikraft/nginx:latest"
+	dst := host + "/staging/nginx:1.25"
+
+	index := pushIndex(t, src, "x86_64", "arm64")
+
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A signature of the first manifest.
+	signature, err := random.Image(32, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature = mutate.Subject(signature, manifest.Manifests[0]).(v1.Image)
+	signatureDigest, err := signature.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcRepo, err := name.NewRepository(host + "/unikraft/nginx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := remote.Write(srcRepo.Digest(signatureDigest.String()), signature); err != nil {
+		t.Fatal(err)
+	}
+
+	copied, err := Copy(ctx, src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := index.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if copied != expected {
+		t.Errorf("expected copy to have digest %s, got %s", expected, copied)
+	}
+
+	dstRef, err := name.ParseReference(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	desc, err := remote.Head(dstRef)
+	if err != nil {
+		t.Fatalf("expected %s to exist: %v", dst, err)
+	}
+
+	if desc.Digest != expected {
+		t.Errorf("expected %s to have digest %s, got %s", dst, expected, desc.Digest)
+	}
+
+	referrers, err := remote.Referrers(dstRef.Context().Digest(manifest.Manifests[0].Digest.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	referrersManifest, err := referrers.IndexManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(referrersManifest.Manifests) != 1 || referrersManifest.Manifests[0].Digest != signatureDigest {
+		t.Errorf("expected signature %s to be copied, got %+v", signatureDigest, referrersManifest.Manifests)
+	}
+}
+
+func TestListTags(t *testing.T) {
+	ctx, host := testRegistry(t)
+
+	pushIndex(t, host+"/unikraft/nginx:1.24", "x86_64")
+	pushIndex(t, host+"/unikraft/nginx:1.25", "x86_64", "arm64")
+	pushIndex(t, host+"/unikraft/nginx:latest", "arm64")
+
+	tests := []struct {
+		architecture string
+		expected     []string
+	}{
+		{"", []string{"1.24", "1.25", "latest"}},
+		{"x86_64", []string{"1.24", "1.25"}},
+		{"arm64", []string{"1.25", "latest"}},
+		{"riscv64", nil},
+	}
+
+	for _, tc := range tests {
+		tags, err := ListTags(ctx, host+"/unikraft/nginx", tc.architecture, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var names []string
+		for _, tag := range tags {
+			names = append(names, tag.Name)
+		}
+
+		if strings.Join(names, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("%q: expected tags %v, got %v", tc.architecture, tc.expected, names)
+		}
+	}
+}
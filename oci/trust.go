@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package oci
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/offline"
+	"kraftkit.sh/oci/cache"
+	"kraftkit.sh/oci/simpleauth"
+	"kraftkit.sh/oci/trust"
+)
+
+// RemoteOptions returns the options to access the registry of the provided
+// reference with the authentication details configured for KraftKit.
+func RemoteOptions(ctx context.Context, ref name.Reference) ([]remote.Option, error) {
+	if offline.Enabled(ctx) {
+		return nil, fmt.Errorf("cannot access %s: %w", ref.Name(), offline.ErrOffline)
+	}
+
+	auths, err := defaultAuths(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not gather authentication details: %w", err)
+	}
+
+	return remoteOptions(ctx, ref, auths), nil
+}
+
+// remoteOptions returns the options to access the registry of the provided
+// reference with the provided authentication details.
+func remoteOptions(ctx context.Context, ref name.Reference, auths map[string]config.AuthConfig) []remote.Option {
+	authConfig := &authn.AuthConfig{}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	// Annoyingly convert between regtypes and authn.
+	if auth, ok := auths[ref.Context().RegistryStr()]; ok {
+		authConfig.Username = auth.User
+		authConfig.Password = auth.Token
+
+		if !auth.VerifySSL {
+			transport.TLSClientConfig = &tls.Config{
+				InsecureSkipVerify: true,
+			}
+		}
+	}
+
+	return []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuth(&simpleauth.SimpleAuthenticator{
+			Auth: authConfig,
+		}),
+		remote.WithTransport(transport),
+	}
+}
+
+// Verify checks the package against the configured trust policy.
+func (ocipack *ociPackage) Verify(ctx context.Context) error {
+	_, err := ocipack.verify(ctx)
+	return err
+}
+
+// verify checks the package against the configured trust policy and returns
+// the digest of the index which was accepted.  The digest is zero if the
+// policy accepts any package.
+func (ocipack *ociPackage) verify(ctx context.Context) (v1.Hash, error) {
+	// Offline, only requirements which do not need the registry can be met.
+	if offline.Enabled(ctx) {
+		return trust.Enforce(ctx, ocipack.ref)
+	}
+
+	opts, err := ocipack.remoteOptions(ctx)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	return trust.Enforce(ctx, ocipack.ref, opts...)
+}
+
+// checkVerified returns an error if the manifest of the package is neither the
+// verified digest itself nor part of the index at the verified digest, e.g.
+// because the tag of the package was moved after the package was resolved.
+func (ocipack *ociPackage) checkVerified(ctx context.Context, verified v1.Hash) error {
+	if ocipack.manifest.desc.Digest.String() == verified.String() {
+		return nil
+	}
+
+	opts, err := ocipack.remoteOptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	index, err := cache.RemoteIndex(ocipack.ref.Context().Digest(verified.String()), opts...)
+	if err != nil {
+		return fmt.Errorf("could not retrieve verified index: %w", err)
+	}
+
+	indexManifest, err := index.IndexManifest()
+	if err != nil {
+		return fmt.Errorf("could not access verified index: %w", err)
+	}
+
+	for _, desc := range indexManifest.Manifests {
+		if desc.Digest.String() == ocipack.manifest.desc.Digest.String() {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("manifest %s of %s is not part of the verified index %s",
+		ocipack.manifest.desc.Digest,
+		ocipack.ref.String(),
+		verified,
+	)
+}
+
+// remoteOptions returns the options to access the registry of the package.
+func (ocipack *ociPackage) remoteOptions(ctx context.Context) ([]remote.Option, error) {
+	if offline.Enabled(ctx) {
+		return nil, fmt.Errorf("cannot access %s: %w", ocipack.ref.Name(), offline.ErrOffline)
+	}
+
+	auths := ocipack.auths
+	if auths == nil {
+		var err error
+		if auths, err = defaultAuths(ctx); err != nil {
+			return nil, fmt.Errorf("could not gather authentication details: %w", err)
+		}
+	}
+
+	return remoteOptions(ctx, ocipack.ref, auths), nil
+}
@@ -0,0 +1,282 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package trust implements the verification of OCI image signatures against a
+// per-registry trust policy.
+package trust
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"gopkg.in/yaml.v3"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/offline"
+	"kraftkit.sh/log"
+)
+
+// RequirementType is the kind of check which is performed on images before
+// they are used.
+type RequirementType string
+
+const (
+	// RequirementReject rejects all images.
+	RequirementReject = RequirementType("reject")
+
+	// RequirementInsecureAcceptAnything accepts all images without verifying
+	// any signatures.
+	RequirementInsecureAcceptAnything = RequirementType("insecureAcceptAnything")
+
+	// RequirementSigstoreSigned only accepts images which have a signature
+	// attached as an OCI referrer that is verified by a public key.
+	RequirementSigstoreSigned = RequirementType("sigstoreSigned")
+)
+
+// Requirement is the check which is performed for images of a scope.
+type Requirement struct {
+	// Type is the kind of check.
+	Type RequirementType `yaml:"type" json:"type"`
+
+	// KeyPath is the path to the PEM-encoded public key which signatures are
+	// verified with when the type is sigstoreSigned.
+	KeyPath string `yaml:"keyPath,omitempty" json:"keyPath,omitempty"`
+}
+
+// Policy is the trust policy of KraftKit, e.g.:
+//
+//	default:
+//	  type: insecureAcceptAnything
+//	registries:
+//	  unikraft.org:
+//	    type: sigstoreSigned
+//	    keyPath: ~/.config/kraftkit/unikraft.pub
+//	  docker.io/library/nginx:
+//	    type: reject
+//
+// Scopes are either a registry or a repository, where the most specific scope
+// which matches an image applies.
+type Policy struct {
+	// Default is the requirement for images which match none of the scopes.
+	Default Requirement `yaml:"default" json:"default"`
+
+	// Registries are the requirements of registry or repository scopes.
+	Registries map[string]Requirement `yaml:"registries,omitempty" json:"registries,omitempty"`
+}
+
+// DefaultPolicy returns the policy which applies when no policy has been
+// configured, which accepts all images.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		Default: Requirement{
+			Type: RequirementInsecureAcceptAnything,
+		},
+	}
+}
+
+// LoadPolicy reads the policy at the provided path.  The default policy is
+// returned if no policy exists at the path.  Relative key paths are resolved
+// against the directory of the policy.
+func LoadPolicy(path string) (*Policy, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return DefaultPolicy(), nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read trust policy: %w", err)
+	}
+
+	policy := DefaultPolicy()
+	if err := yaml.Unmarshal(raw, policy); err != nil {
+		return nil, fmt.Errorf("could not parse trust policy %s: %w", path, err)
+	}
+
+	resolve := func(req *Requirement) {
+		if req.KeyPath == "" {
+			return
+		}
+
+		if req.KeyPath == "~" || strings.HasPrefix(req.KeyPath, "~/") {
+			home, _ := os.UserHomeDir()
+			req.KeyPath = filepath.Join(home, req.KeyPath[1:])
+		} else if !filepath.IsAbs(req.KeyPath) {
+			req.KeyPath = filepath.Join(filepath.Dir(path), req.KeyPath)
+		}
+	}
+
+	resolve(&policy.Default)
+	for scope, req := range policy.Registries {
+		resolve(&req)
+		policy.Registries[scope] = req
+	}
+
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid trust policy %s: %w", path, err)
+	}
+
+	return policy, nil
+}
+
+// Validate checks whether all requirements of the policy are well-formed and
+// that no two scopes refer to the same registry or repository, in which case
+// it would be ambiguous which of their requirements applies.
+func (policy *Policy) Validate() error {
+	if err := policy.Default.Validate(); err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+
+	scopes := make([]string, 0, len(policy.Registries))
+	for scope := range policy.Registries {
+		scopes = append(scopes, scope)
+	}
+
+	sort.Strings(scopes)
+
+	canonicals := make(map[string]string, len(scopes))
+
+	for _, scope := range scopes {
+		canonical, err := parseScope(scope)
+		if err != nil {
+			return fmt.Errorf("%s: %w", scope, err)
+		}
+
+		if other, ok := canonicals[canonical]; ok {
+			return fmt.Errorf("%s: same scope as %s", scope, other)
+		}
+
+		canonicals[canonical] = scope
+
+		if err := policy.Registries[scope].Validate(); err != nil {
+			return fmt.Errorf("%s: %w", scope, err)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks whether the requirement is well-formed.
+func (req Requirement) Validate() error {
+	switch req.Type {
+	case RequirementReject, RequirementInsecureAcceptAnything:
+		return nil
+	case RequirementSigstoreSigned:
+		if req.KeyPath == "" {
+			return fmt.Errorf("%s requires a keyPath", req.Type)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("unknown requirement type '%s': expected one of %s, %s or %s",
+		req.Type,
+		RequirementReject,
+		RequirementInsecureAcceptAnything,
+		RequirementSigstoreSigned,
+	)
+}
+
+// parseScope returns the canonical name of a registry or repository scope,
+// such that e.g. `docker.io` matches references to `index.docker.io`.
+func parseScope(scope string) (string, error) {
+	if !strings.Contains(scope, "/") {
+		registry, err := name.NewRegistry(scope)
+		if err != nil {
+			return "", err
+		}
+
+		return registry.RegistryStr(), nil
+	}
+
+	repo, err := name.NewRepository(scope)
+	if err != nil {
+		return "", err
+	}
+
+	return repo.Name(), nil
+}
+
+// RequirementFor returns the requirement of the most specific scope which
+// matches the provided reference.
+func (policy *Policy) RequirementFor(ref name.Reference) Requirement {
+	repo := ref.Context().Name()
+
+	match := ""
+	req := policy.Default
+
+	for scope, candidate := range policy.Registries {
+		canonical, err := parseScope(scope)
+		if err != nil {
+			continue
+		}
+
+		if repo != canonical && !strings.HasPrefix(repo, canonical+"/") {
+			continue
+		}
+
+		if len(canonical) > len(match) {
+			match = canonical
+			req = candidate
+		}
+	}
+
+	return req
+}
+
+// PolicyFromContext loads the trust policy configured for KraftKit.
+func PolicyFromContext(ctx context.Context) (*Policy, error) {
+	return LoadPolicy(config.G[config.KraftKit](ctx).TrustPolicy)
+}
+
+// Enforce checks the image at the provided reference against the configured
+// trust policy and returns an error if the image must not be used.  The digest
+// of the accepted image is returned such that it can be retrieved by digest,
+// rather than by a tag which may have been moved since.  The digest is zero if
+// the policy accepts any image and the reference is not a digest, in which
+// case the registry is not contacted.  In offline mode, images which require
+// a signature are refused since their signatures are not cached.
+func Enforce(ctx context.Context, ref name.Reference, opts ...remote.Option) (v1.Hash, error) {
+	policy, err := PolicyFromContext(ctx)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	req := policy.RequirementFor(ref)
+
+	log.G(ctx).
+		WithField("ref", ref.String()).
+		WithField("requirement", req.Type).
+		Debug("enforcing trust policy")
+
+	switch req.Type {
+	case RequirementReject:
+		return v1.Hash{}, fmt.Errorf("trust policy rejects images from %s", ref.Context().Name())
+
+	case RequirementSigstoreSigned:
+		// Signatures are only kept by the registry.
+		if offline.Enabled(ctx) {
+			return v1.Hash{}, offline.NotCached(ref.String() + " signature")
+		}
+
+		dgst, err := Verify(ctx, ref, req.KeyPath, opts...)
+		if err != nil {
+			return v1.Hash{}, fmt.Errorf("trust policy requires a valid signature for %s: %w", ref.String(), err)
+		}
+
+		return dgst, nil
+	}
+
+	if _, ok := ref.(name.Digest); ok {
+		return v1.NewHash(ref.Identifier())
+	}
+
+	return v1.Hash{}, nil
+}
@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package trust
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+func TestRequirementFor(t *testing.T) {
+	policy := &Policy{
+		Default: Requirement{Type: RequirementInsecureAcceptAnything},
+		Registries: map[string]Requirement{
+			"unikraft.org":            {Type: RequirementSigstoreSigned, KeyPath: "unikraft.pub"},
+			"unikraft.org/official":   {Type: RequirementReject},
+			"docker.io":               {Type: RequirementSigstoreSigned, KeyPath: "docker.pub"},
+			"docker.io/library/nginx": {Type: RequirementReject},
+			"ghcr.io/org":             {Type: RequirementSigstoreSigned, KeyPath: "org.pub"},
+			"localhost:5000":          {Type: RequirementReject},
+		},
+	}
+
+	if err := policy.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ref      string
+		expected Requirement
+	}{
+		// Registry scopes match all of their repositories.
+		{"unikraft.org/nginx:latest", policy.Registries["unikraft.org"]},
+		{"unikraft.org/nginx/base@sha256:" + sha256Zero, policy.Registries["unikraft.org"]},
+
+		// The most specific scope applies.
+		{"unikraft.org/official/nginx:latest", policy.Registries["unikraft.org/official"]},
+
+		// Repository scopes only match at path boundaries.
+		{"unikraft.org/officially/nginx:latest", policy.Registries["unikraft.org"]},
+		{"ghcr.io/org/app:latest", policy.Registries["ghcr.io/org"]},
+		{"ghcr.io/organization/app:latest", policy.Default},
+		{"ghcr.io/org:latest", policy.Registries["ghcr.io/org"]},
+
+		// Docker Hub references are canonicalised.
+		{"nginx:latest", policy.Registries["docker.io/library/nginx"]},
+		{"library/nginx:latest", policy.Registries["docker.io/library/nginx"]},
+		{"index.docker.io/library/nginx:latest", policy.Registries["docker.io/library/nginx"]},
+		{"redis:latest", policy.Registries["docker.io"]},
+		{"index.docker.io/unikraft/base:latest", policy.Registries["docker.io"]},
+
+		// Registries are distinguished by their port.
+		{"localhost:5000/app:latest", policy.Registries["localhost:5000"]},
+		{"localhost:5001/app:latest", policy.Default},
+
+		// Unmatched references fall back to the default.
+		{"quay.io/app:latest", policy.Default},
+	}
+
+	for _, tc := range tests {
+		ref, err := name.ParseReference(tc.ref)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.ref, err)
+		}
+
+		if req := policy.RequirementFor(ref); req != tc.expected {
+			t.Errorf("%s: expected %+v, got %+v", tc.ref, tc.expected, req)
+		}
+	}
+}
+
+// sha256Zero is a well-formed digest used in references.
+const sha256Zero = "0000000000000000000000000000000000000000000000000000000000000000"
+
+func TestPolicyValidate(t *testing.T) {
+	accept := Requirement{Type: RequirementInsecureAcceptAnything}
+	reject := Requirement{Type: RequirementReject}
+
+	tests := []struct {
+		name       string
+		def        Requirement
+		registries map[string]Requirement
+		valid      bool
+	}{
+		{"default policy", accept, nil, true},
+		{"distinct scopes", accept, map[string]Requirement{"docker.io": reject, "docker.io/library/nginx": accept}, true},
+		{"same registry", accept, map[string]Requirement{"docker.io": reject, "index.docker.io": accept}, false},
+		{"same repository", accept, map[string]Requirement{"docker.io/library/nginx": reject, "index.docker.io/library/nginx": accept}, false},
+		{"official image", accept, map[string]Requirement{"docker.io/library/nginx": reject, "index.docker.io/library/nginx": reject}, false},
+		{"invalid scope", accept, map[string]Requirement{"UPPER/case": reject}, false},
+		{"unknown type", Requirement{Type: "acceptSome"}, nil, false},
+		{"missing type", Requirement{}, nil, false},
+		{"signed without key", accept, map[string]Requirement{"unikraft.org": {Type: RequirementSigstoreSigned}}, false},
+		{"signed with key", Requirement{Type: RequirementSigstoreSigned, KeyPath: "cosign.pub"}, nil, true},
+	}
+
+	for _, tc := range tests {
+		policy := Policy{Default: tc.def, Registries: tc.registries}
+
+		err := policy.Validate()
+		if tc.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		} else if !tc.valid && err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		}
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policy.yaml")
+
+	if err := os.WriteFile(path, []byte(`
+default:
+  type: sigstoreSigned
+  keyPath: keys/default.pub
+registries:
+  unikraft.org:
+    type: sigstoreSigned
+    keyPath: ~/unikraft.pub
+  ghcr.io:
+    type: sigstoreSigned
+    keyPath: /etc/kraftkit/ghcr.pub
+  quay.io:
+    type: sigstoreSigned
+    keyPath: ~quay.pub
+  docker.io:
+    type: reject
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := LoadPolicy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		scope    string
+		expected string
+	}{
+		{"", filepath.Join(dir, "keys", "default.pub")},
+		{"unikraft.org", filepath.Join(home, "unikraft.pub")},
+		{"ghcr.io", "/etc/kraftkit/ghcr.pub"},
+		{"quay.io", filepath.Join(dir, "~quay.pub")}, // Not a home directory.
+		{"docker.io", ""},
+	}
+
+	for _, tc := range tests {
+		req := policy.Default
+		if tc.scope != "" {
+			req = policy.Registries[tc.scope]
+		}
+
+		if req.KeyPath != tc.expected {
+			t.Errorf("%q: expected key path %s, got %s", tc.scope, tc.expected, req.KeyPath)
+		}
+	}
+
+	// Without a policy, any image is accepted.
+	policy, err = LoadPolicy(filepath.Join(dir, "missing.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	} else if policy.Default.Type != RequirementInsecureAcceptAnything || len(policy.Registries) > 0 {
+		t.Errorf("expected the default policy, got %+v", policy)
+	}
+
+	invalid := map[string]string{
+		"malformed.yaml": "default: [",
+		"duplicate.yaml": "registries:\n  docker.io:\n    type: reject\n  index.docker.io:\n    type: insecureAcceptAnything\n",
+		"unknown.yaml":   "default:\n  type: acceptSome\n",
+	}
+
+	for name, content := range invalid {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := LoadPolicy(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
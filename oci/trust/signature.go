@@ -0,0 +1,260 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package trust
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"kraftkit.sh/log"
+)
+
+const (
+	// ArtifactType is the artifact type of the OCI manifests which carry
+	// signatures and refer to the signed image via their subject.
+	ArtifactType = "application/vnd.dev.cosign.artifact.sig.v1+json"
+
+	// PayloadMediaType is the media type of the signed payload, which follows
+	// the simple signing format.
+	PayloadMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+
+	// AnnotationSignature is the annotation of the payload layer which holds
+	// the base64-encoded signature of the payload.
+	AnnotationSignature = "dev.cosignproject.cosign/signature"
+
+	// payloadType identifies simple signing payloads of container images.
+	payloadType = "cosign container image signature"
+)
+
+// payload is the simple signing payload which binds the signature to the
+// digest of the signed image.
+type payload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	Optional map[string]string `json:"optional"`
+}
+
+// Sign signs the image at the provided reference with the PEM-encoded private
+// key at the provided path and pushes the signature as an OCI referrer of the
+// image.  The digest of the signed image is returned.
+func Sign(ctx context.Context, ref name.Reference, keyPath string, opts ...remote.Option) (v1.Hash, error) {
+	signer, err := loadPrivateKey(keyPath)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	opts = append(opts, remote.WithContext(ctx))
+
+	subject, err := remote.Head(ref, opts...)
+	if err != nil {
+		return v1.Hash{}, fmt.Errorf("could not resolve %s: %w", ref.String(), err)
+	}
+
+	var p payload
+	p.Critical.Identity.DockerReference = ref.Context().Name()
+	p.Critical.Image.DockerManifestDigest = subject.Digest.String()
+	p.Critical.Type = payloadType
+
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	sig, err := signPayload(signer, raw)
+	if err != nil {
+		return v1.Hash{}, fmt.Errorf("could not sign payload: %w", err)
+	}
+
+	img, err := mutate.Append(
+		mutate.MediaType(empty.Image, types.OCIManifestSchema1),
+		mutate.Addendum{
+			Layer: static.NewLayer(raw, types.MediaType(PayloadMediaType)),
+			Annotations: map[string]string{
+				AnnotationSignature: base64.StdEncoding.EncodeToString(sig),
+			},
+		},
+	)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	img = mutate.ConfigMediaType(img, types.MediaType(ArtifactType))
+	img, ok := mutate.Subject(img, v1.Descriptor{
+		MediaType: subject.MediaType,
+		Size:      subject.Size,
+		Digest:    subject.Digest,
+	}).(v1.Image)
+	if !ok {
+		return v1.Hash{}, fmt.Errorf("could not set subject of signature")
+	}
+
+	dgst, err := img.Digest()
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	if err := remote.Write(ref.Context().Digest(dgst.String()), img, opts...); err != nil {
+		return v1.Hash{}, fmt.Errorf("could not push signature: %w", err)
+	}
+
+	log.G(ctx).
+		WithField("subject", subject.Digest.String()).
+		WithField("signature", dgst.String()).
+		Debug("pushed signature")
+
+	return subject.Digest, nil
+}
+
+// Verify checks whether the image at the provided reference has a signature
+// attached as an OCI referrer which is verified by the PEM-encoded public key
+// at the provided path.  The digest of the verified image is returned.
+func Verify(ctx context.Context, ref name.Reference, keyPath string, opts ...remote.Option) (v1.Hash, error) {
+	pub, err := loadPublicKey(keyPath)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	opts = append(opts, remote.WithContext(ctx))
+
+	subject := ref
+	if _, ok := ref.(name.Digest); !ok {
+		desc, err := remote.Head(ref, opts...)
+		if err != nil {
+			return v1.Hash{}, fmt.Errorf("could not resolve %s: %w", ref.String(), err)
+		}
+
+		subject = ref.Context().Digest(desc.Digest.String())
+	}
+
+	dgst, err := v1.NewHash(subject.Identifier())
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	referrers, err := remote.Referrers(subject.(name.Digest),
+		append(opts, remote.WithFilter("artifactType", ArtifactType))...,
+	)
+	if err != nil {
+		return v1.Hash{}, fmt.Errorf("could not list signatures: %w", err)
+	}
+
+	manifest, err := referrers.IndexManifest()
+	if err != nil {
+		return v1.Hash{}, fmt.Errorf("could not list signatures: %w", err)
+	}
+
+	var lastErr error
+
+	for _, desc := range manifest.Manifests {
+		if desc.ArtifactType != ArtifactType {
+			continue
+		}
+
+		if err := verifySignature(ref.Context().Digest(desc.Digest.String()), dgst, pub, opts...); err != nil {
+			log.G(ctx).
+				WithField("signature", desc.Digest.String()).
+				Debugf("could not verify signature: %s", err)
+			lastErr = err
+			continue
+		}
+
+		log.G(ctx).
+			WithField("subject", dgst.String()).
+			WithField("signature", desc.Digest.String()).
+			Debug("verified signature")
+
+		return dgst, nil
+	}
+
+	if lastErr != nil {
+		return v1.Hash{}, fmt.Errorf("no valid signature for %s: %w", dgst.String(), lastErr)
+	}
+
+	return v1.Hash{}, fmt.Errorf("no signature for %s", dgst.String())
+}
+
+// verifySignature checks the payload layers of the signature manifest at the
+// provided reference against the public key and the digest of the subject.
+func verifySignature(ref name.Digest, subject v1.Hash, pub any, opts ...remote.Option) error {
+	img, err := remote.Image(ref, opts...)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := img.Manifest()
+	if err != nil {
+		return err
+	}
+
+	for _, desc := range manifest.Layers {
+		if desc.MediaType != types.MediaType(PayloadMediaType) {
+			continue
+		}
+
+		sig, err := base64.StdEncoding.DecodeString(desc.Annotations[AnnotationSignature])
+		if err != nil {
+			return fmt.Errorf("malformed signature: %w", err)
+		}
+
+		layer, err := img.LayerByDigest(desc.Digest)
+		if err != nil {
+			return err
+		}
+
+		reader, err := layer.Compressed()
+		if err != nil {
+			return err
+		}
+
+		raw, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return err
+		}
+
+		if err := verifyPayload(pub, raw, sig); err != nil {
+			return err
+		}
+
+		var p payload
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return fmt.Errorf("malformed payload: %w", err)
+		}
+
+		if p.Critical.Type != payloadType {
+			return fmt.Errorf("unexpected payload type '%s'", p.Critical.Type)
+		}
+
+		if p.Critical.Image.DockerManifestDigest != subject.String() {
+			return fmt.Errorf("signature is for %s", p.Critical.Image.DockerManifestDigest)
+		}
+
+		if p.Critical.Identity.DockerReference != ref.Context().Name() {
+			return fmt.Errorf("signature is for %s", p.Critical.Identity.DockerReference)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("signature has no payload")
+}
@@ -0,0 +1,200 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package oci
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"kraftkit.sh/config"
+	"kraftkit.sh/internal/offline"
+	"kraftkit.sh/internal/offline/offlinetest"
+	"kraftkit.sh/oci/trust"
+)
+
+// writeSigningKey writes an ECDSA key pair and returns the paths of the
+// private and public keys.
+func writeSigningKey(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	priv, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	privPath := filepath.Join(dir, "cosign.key")
+	pubPath := filepath.Join(dir, "cosign.pub")
+
+	if err := os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: priv}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return privPath, pubPath
+}
+
+func TestPullVerifiedDigest(t *testing.T) {
+	ctx, host := testRegistry(t)
+	ref := host + "/unikraft/nginx:latest"
+
+	priv, pub := writeSigningKey(t)
+
+	policy := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(policy, []byte("default:\n  type: sigstoreSigned\n  keyPath: "+pub+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config.G[config.KraftKit](ctx).TrustPolicy = policy
+
+	r, err := name.ParseReference(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// push pushes and signs an index and returns the descriptor of its manifest.
+	push := func() ocispec.Descriptor {
+		index := pushIndex(t, ref, "x86_64")
+
+		if _, err := trust.Sign(ctx, r, priv); err != nil {
+			t.Fatal(err)
+		}
+
+		manifest, err := index.IndexManifest()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return FromGoogleV1DescriptorToOCISpec(manifest.Manifests[0])[0]
+	}
+
+	// The package is resolved before the tag is moved to another signed index.
+	stale := push()
+	current := push()
+
+	handle := localStore(t, ctx)
+
+	pull := func(desc ocispec.Descriptor) error {
+		ocipack := &ociPackage{
+			handle:   handle,
+			ref:      r,
+			manifest: &Manifest{handle: handle, desc: &desc},
+		}
+
+		return ocipack.Pull(ctx)
+	}
+
+	if err := pull(stale); err == nil || !strings.Contains(err.Error(), "not part of the verified index") {
+		t.Fatalf("expected a manifest outside of the verified index to be rejected, got %v", err)
+	}
+
+	if err := pull(current); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := handle.ResolveIndex(ctx, ref)
+	if err != nil {
+		t.Fatalf("expected the index to be recorded under its tag: %v", err)
+	}
+
+	var digests []string
+	for _, manifest := range index.Manifests {
+		digests = append(digests, manifest.Digest.String())
+	}
+
+	if len(digests) != 1 || digests[0] != current.Digest.String() {
+		t.Errorf("expected the verified manifest %s to be pulled, got %v", current.Digest, digests)
+	}
+}
+
+func TestVerifyOffline(t *testing.T) {
+	ctx, host := testRegistry(t)
+	ref := host + "/unikraft/nginx:latest"
+
+	priv, pub := writeSigningKey(t)
+
+	dir := t.TempDir()
+	signed := filepath.Join(dir, "signed.yaml")
+	if err := os.WriteFile(signed, []byte("default:\n  type: sigstoreSigned\n  keyPath: "+pub+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	accept := filepath.Join(dir, "accept.yaml")
+	if err := os.WriteFile(accept, []byte("default:\n  type: insecureAcceptAnything\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config.G[config.KraftKit](ctx).TrustPolicy = signed
+
+	r, err := name.ParseReference(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index := pushIndex(t, ref, "x86_64")
+
+	if _, err := trust.Sign(ctx, r, priv); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handle := localStore(t, ctx)
+	desc := FromGoogleV1DescriptorToOCISpec(manifest.Manifests[0])[0]
+
+	ocipack := &ociPackage{
+		handle:   handle,
+		ref:      r,
+		manifest: &Manifest{handle: handle, desc: &desc},
+	}
+
+	if err := ocipack.Pull(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The registry is reachable, but must not be contacted.
+	offlinetest.DenyDial(t)
+	config.G[config.KraftKit](ctx).Offline = true
+
+	var notCached *offline.NotCachedError
+	if err := ocipack.Verify(ctx); !errors.As(err, &notCached) {
+		t.Fatalf("expected the signature to be reported as not cached, got %v", err)
+	} else if expected := ref + " signature"; notCached.Artifact != expected {
+		t.Errorf("expected missing artifact %s, got %s", expected, notCached.Artifact)
+	}
+
+	config.G[config.KraftKit](ctx).TrustPolicy = accept
+
+	if err := ocipack.Verify(ctx); err != nil {
+		t.Errorf("expected a package without signature requirement to be accepted offline, got %v", err)
+	}
+}
@@ -9,6 +9,7 @@ const (
 	WellKnownKernelDbgPath   = "/unikraft/bin/kernel.dbg"
 	WellKnownInitrdPath      = "/unikraft/bin/initrd"
 	WellKnownConfigPath      = "/unikraft/bin/config"
+	WellKnownDiskPathPattern = "/unikraft/disk/%d"
 	WellKnownKernelSourceDir = "/unikraft/src"
 	WellKnownAppSourceDir    = "/unikraft/app"
 )
@@ -56,3 +56,58 @@ type Package interface {
 	// Format returns the name of the implementation.
 	Format() PackageFormat
 }
+
+// Verifier is implemented by packages which can be checked against a trust
+// policy before they are used.
+type Verifier interface {
+	// Verify returns an error if the package must not be used.
+	Verify(context.Context) error
+}
+
+// Disk is a file system image which is shipped with a package and is attached
+// to the unikernel when it is run.
+type Disk struct {
+	// Path is the location of the image once the package has been unpacked.
+	Path string
+
+	// Destination is the path at which the image is mounted in the unikernel.
+	Destination string
+
+	// Filesystem is the format of the image, e.g. ext4 or erofs.
+	Filesystem string
+}
+
+// DiskProvider is implemented by packages which ship file system images.
+type DiskProvider interface {
+	// Disks returns the file system images of the package, which are only
+	// available once the package has been unpacked.
+	Disks() []Disk
+}
+
+// Resolution identifies the exact artifact which a package refers to,
+// regardless of how its version was requested.
+type Resolution struct {
+	// Version is the exact version of the package, e.g. a release or a Git SHA
+	// rather than a channel or a branch.
+	Version string
+
+	// Resource is the location from which the package is retrieved.
+	Resource string
+
+	// Commit is the Git SHA of the package, if it is retrieved from Git.
+	Commit string
+
+	// Sha256 is the checksum of the archive of the package, if it is known.
+	Sha256 string
+
+	// Digest is the digest of the manifest of the package, if it is an OCI
+	// image.
+	Digest string
+}
+
+// Resolver is implemented by packages which can report the exact artifact
+// which they refer to.
+type Resolver interface {
+	// Resolve returns the exact artifact which the package refers to.
+	Resolve(context.Context) (*Resolution, error)
+}
@@ -7,10 +7,13 @@ package packmanager
 // PackOptions contains the list of options which can be set when packaging a
 // component.
 type PackOptions struct {
+	append                           bool
 	appSourceFiles                   bool
 	args                             []string
+	disks                            []PackDisk
 	env                              []string
 	initrd                           string
+	initrdMediaType                  string
 	kconfig                          bool
 	kernelDbg                        bool
 	kernelLibraryIntermediateObjects bool
@@ -30,6 +33,12 @@ func NewPackOptions() *PackOptions {
 	}
 }
 
+// Append returns whether the package should be merged into the existing
+// remote index when it is pushed.
+func (popts *PackOptions) Append() bool {
+	return popts.append
+}
+
 // PackAppSourceFiles returns whether the application source files should be
 // packaged.
 func (popts *PackOptions) PackAppSourceFiles() bool {
@@ -41,6 +50,11 @@ func (popts *PackOptions) Args() []string {
 	return popts.args
 }
 
+// Disks returns the file system images which should be packaged.
+func (popts *PackOptions) Disks() []PackDisk {
+	return popts.disks
+}
+
 // Env returns the environment variables to be passed to the kernel.
 func (popts *PackOptions) Env() []string {
 	return popts.env
@@ -51,6 +65,12 @@ func (popts *PackOptions) Initrd() string {
 	return popts.initrd
 }
 
+// InitrdMediaType returns the media type which represents the format of the
+// initrd file that should be packaged.
+func (popts *PackOptions) InitrdMediaType() string {
+	return popts.initrdMediaType
+}
+
 // PackKConfig returns whether the .config file should be packaged.
 func (popts *PackOptions) PackKConfig() bool {
 	return popts.kconfig
@@ -100,6 +120,15 @@ func (popts *PackOptions) MergeStrategy() MergeStrategy {
 // PackOption is an option function which is used to modify PackOptions.
 type PackOption func(*PackOptions)
 
+// PackAppend marks that the package should replace the manifest for the same
+// architecture, platform and KConfig options in, or otherwise be appended to,
+// the existing remote index when it is pushed rather than replacing the index.
+func PackAppend(appendIndex bool) PackOption {
+	return func(popts *PackOptions) {
+		popts.append = appendIndex
+	}
+}
+
 // PackAppSourceFiles marks to include application source files
 func PackAppSourceFiles(pack bool) PackOption {
 	return func(popts *PackOptions) {
@@ -114,6 +143,23 @@ func PackArgs(args ...string) PackOption {
 	}
 }
 
+// PackDisk is a file system image which is shipped with the package and
+// mounted at the destination when the package is run.
+type PackDisk struct {
+	Source      string
+	Destination string
+
+	// MediaType represents the file system format of the image.
+	MediaType string
+}
+
+// PackDisks includes the provided file system images in the package.
+func PackDisks(disks ...PackDisk) PackOption {
+	return func(popts *PackOptions) {
+		popts.disks = disks
+	}
+}
+
 // PackKConfig marks to include the kconfig `.config` file into the package.
 func PackKConfig(kconfig bool) PackOption {
 	return func(popts *PackOptions) {
@@ -128,6 +174,14 @@ func PackInitrd(initrd string) PackOption {
 	}
 }
 
+// PackInitrdMediaType sets the media type which represents the format of the
+// packaged initrd file.
+func PackInitrdMediaType(mediaType string) PackOption {
+	return func(popts *PackOptions) {
+		popts.initrdMediaType = mediaType
+	}
+}
+
 // PackKernelDbg includes the debug kernel in the package.
 func PackKernelDbg(dbg bool) PackOption {
 	return func(popts *PackOptions) {
@@ -11,6 +11,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"kraftkit.sh/internal/offline"
 	"kraftkit.sh/log"
 
 	"kraftkit.sh/pack"
@@ -165,18 +166,30 @@ func (u UmbrellaManager) Unpack(ctx context.Context, source pack.Package, opts .
 
 func (u UmbrellaManager) Catalog(ctx context.Context, qopts ...QueryOption) ([]pack.Package, error) {
 	var packages []pack.Package
+	var errOffline error
 	for _, manager := range u.packageManagers {
 		pack, err := manager.Catalog(ctx, qopts...)
 		if err != nil {
 			log.G(ctx).
 				WithField("format", manager.Format()).
 				Debugf("could not query catalog: %v", err)
+
+			if errors.Is(err, offline.ErrOffline) {
+				errOffline = err
+			}
+
 			continue
 		}
 
 		packages = append(packages, pack...)
 	}
 
+	// Only report a missing artifact when no package manager could satisfy the
+	// query from its local cache.
+	if len(packages) == 0 && errOffline != nil {
+		return nil, errOffline
+	}
+
 	return packages, nil
 }
 
@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package provenance
+
+import (
+	"bytes"
+	"debug/elf"
+	"strings"
+
+	"kraftkit.sh/unikraft/app"
+)
+
+// componentRecord is the build information of a component as recorded in the
+// kernel image.
+type componentRecord struct {
+	version string
+	gitDesc string
+}
+
+// inspectKernel returns the components and the toolchain which are recorded in
+// the kernel image.  The toolchain is read from the uk_libinfo section and
+// otherwise from the .comment section which is populated by the compiler.
+func inspectKernel(path string) (map[string]componentRecord, string) {
+	if path == "" {
+		return nil, ""
+	}
+
+	components := map[string]componentRecord{}
+	var toolchain string
+
+	if records, err := app.ComponentInfoRecordsFromKernel(path); err == nil {
+		for _, record := range records {
+			name := record.LibName
+			if name == "" {
+				name = "unikraft"
+			}
+
+			component := componentRecord{
+				version: record.Version,
+				gitDesc: record.GitDesc,
+			}
+
+			if record.UkFullVersion != "" {
+				component.version = record.UkFullVersion
+			}
+
+			if record.LibName == "" && record.Compiler != "" {
+				toolchain = record.Compiler
+			}
+
+			components[name] = component
+		}
+	}
+
+	if toolchain == "" {
+		toolchain = compilerComment(path)
+	}
+
+	return components, toolchain
+}
+
+// compilerComment returns the compiler identification strings of the
+// .comment section of the ELF binary.
+func compilerComment(path string) string {
+	fe, err := elf.Open(path)
+	if err != nil {
+		return ""
+	}
+
+	defer fe.Close()
+
+	section := fe.Section(".comment")
+	if section == nil {
+		return ""
+	}
+
+	data, err := section.Data()
+	if err != nil {
+		return ""
+	}
+
+	var comments []string
+	for _, comment := range bytes.Split(data, []byte{0}) {
+		if len(comment) > 0 {
+			comments = append(comments, string(comment))
+		}
+	}
+
+	return strings.Join(comments, "; ")
+}
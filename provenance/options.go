@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package provenance
+
+import (
+	"time"
+
+	"kraftkit.sh/unikraft/app"
+	"kraftkit.sh/unikraft/target"
+)
+
+// ProvenanceOptions are the inputs of a provenance statement.
+type ProvenanceOptions struct {
+	project    app.Application
+	target     target.Target
+	rootfs     *Rootfs
+	startedOn  time.Time
+	finishedOn time.Time
+}
+
+// ProvenanceOption is a function which modifies the inputs of a provenance
+// statement.
+type ProvenanceOption func(*ProvenanceOptions) error
+
+// WithProject sets the project whose Kraftfile and components are recorded in
+// the provenance statement.
+func WithProject(project app.Application) ProvenanceOption {
+	return func(opts *ProvenanceOptions) error {
+		opts.project = project
+		return nil
+	}
+}
+
+// WithTarget sets the target whose KConfig and kernel image are recorded in the
+// provenance statement.
+func WithTarget(targ target.Target) ProvenanceOption {
+	return func(opts *ProvenanceOptions) error {
+		opts.target = targ
+		return nil
+	}
+}
+
+// WithRootfs sets the inputs of the root file system of the package.
+func WithRootfs(rootfs *Rootfs) ProvenanceOption {
+	return func(opts *ProvenanceOptions) error {
+		opts.rootfs = rootfs
+		return nil
+	}
+}
+
+// WithBuildTime sets the time at which the build started and finished.
+func WithBuildTime(startedOn, finishedOn time.Time) ProvenanceOption {
+	return func(opts *ProvenanceOptions) error {
+		opts.startedOn = startedOn
+		opts.finishedOn = finishedOn
+		return nil
+	}
+}
@@ -0,0 +1,372 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package provenance generates in-toto statements which carry the SLSA build
+// provenance of unikernel packages.
+package provenance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+
+	"kraftkit.sh/internal/version"
+	"kraftkit.sh/kconfig"
+	"kraftkit.sh/unikraft/app"
+	"kraftkit.sh/unikraft/target"
+)
+
+const (
+	// MediaType is the media type of in-toto statements serialized as JSON.
+	MediaType = "application/vnd.in-toto+json"
+
+	// StatementType is the type of in-toto statements.
+	StatementType = "https://in-toto.io/Statement/v1"
+
+	// PredicateType is the type of SLSA provenance predicates.
+	PredicateType = "https://slsa.dev/provenance/v1"
+
+	// BuildType describes how the external parameters of packages built by
+	// `kraft pkg` are interpreted.
+	BuildType = "https://kraftkit.sh/provenance/pkg/v1"
+
+	// BuilderID identifies KraftKit as the builder of packages.
+	BuilderID = "https://kraftkit.sh"
+)
+
+// ResourceDescriptor describes an artifact which is consumed or produced by
+// the build.
+type ResourceDescriptor struct {
+	Name        string            `json:"name,omitempty"`
+	URI         string            `json:"uri,omitempty"`
+	Digest      map[string]string `json:"digest,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Rootfs are the inputs of the root file system of the package.
+type Rootfs struct {
+	// Source is the rootfs as it was specified, e.g. a directory, a Dockerfile
+	// or an OCI image.
+	Source string `json:"source"`
+
+	// Format is the format of the root file system.
+	Format string `json:"format,omitempty"`
+
+	// Compression is the compression algorithm of the root file system.
+	Compression string `json:"compression,omitempty"`
+
+	// Files are the additional files which were layered on top of the rootfs.
+	Files []ResourceDescriptor `json:"files,omitempty"`
+
+	// Includes are the additional host files of rootfs generated from ELF
+	// executables.
+	Includes []string `json:"includes,omitempty"`
+
+	// Digest is the digest of the resulting root file system.
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+// ExternalParameters are the parameters of the build which are under the
+// control of the user.
+type ExternalParameters struct {
+	Kraftfile *ResourceDescriptor `json:"kraftfile,omitempty"`
+	Target    string              `json:"target,omitempty"`
+	Rootfs    *Rootfs             `json:"rootfs,omitempty"`
+}
+
+// InternalParameters are the parameters of the build which are set by the
+// builder.
+type InternalParameters struct {
+	KConfig   map[string]string `json:"kconfig,omitempty"`
+	Toolchain string            `json:"toolchain,omitempty"`
+}
+
+// BuildDefinition describes the inputs of the build.
+type BuildDefinition struct {
+	BuildType            string               `json:"buildType"`
+	ExternalParameters   ExternalParameters   `json:"externalParameters"`
+	InternalParameters   InternalParameters   `json:"internalParameters"`
+	ResolvedDependencies []ResourceDescriptor `json:"resolvedDependencies,omitempty"`
+}
+
+// Builder identifies the entity which performed the build.
+type Builder struct {
+	ID      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+// Metadata are details of the invocation of the build.
+type Metadata struct {
+	StartedOn  *time.Time `json:"startedOn,omitempty"`
+	FinishedOn *time.Time `json:"finishedOn,omitempty"`
+}
+
+// RunDetails describes the invocation of the build.
+type RunDetails struct {
+	Builder  Builder   `json:"builder"`
+	Metadata *Metadata `json:"metadata,omitempty"`
+}
+
+// Predicate is the SLSA provenance of a package.
+type Predicate struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+// Statement is an in-toto statement which attests the provenance of its
+// subjects.
+type Statement struct {
+	Type          string               `json:"_type"`
+	Subject       []ResourceDescriptor `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     Predicate            `json:"predicate"`
+}
+
+// New generates the provenance statement of the package with the provided
+// name and manifest digest.
+func New(ctx context.Context, name string, dgst digest.Digest, opts ...ProvenanceOption) (*Statement, error) {
+	popts := ProvenanceOptions{}
+	for _, opt := range opts {
+		if err := opt(&popts); err != nil {
+			return nil, err
+		}
+	}
+
+	statement := Statement{
+		Type: StatementType,
+		Subject: []ResourceDescriptor{{
+			Name:   name,
+			Digest: digestSet(dgst),
+		}},
+		PredicateType: PredicateType,
+		Predicate: Predicate{
+			BuildDefinition: BuildDefinition{
+				BuildType: BuildType,
+				ExternalParameters: ExternalParameters{
+					Rootfs: popts.rootfs,
+				},
+			},
+			RunDetails: RunDetails{
+				Builder: Builder{
+					ID: BuilderID,
+					Version: map[string]string{
+						"kraftkit": version.Version(),
+						"commit":   version.Commit(),
+					},
+				},
+			},
+		},
+	}
+
+	if !popts.startedOn.IsZero() {
+		startedOn := popts.startedOn.UTC()
+		finishedOn := popts.finishedOn.UTC()
+
+		statement.Predicate.RunDetails.Metadata = &Metadata{
+			StartedOn:  &startedOn,
+			FinishedOn: &finishedOn,
+		}
+	}
+
+	var records map[string]componentRecord
+
+	if popts.target != nil {
+		statement.Predicate.BuildDefinition.ExternalParameters.Target = popts.target.Platform().Name() + "/" + popts.target.Architecture().Name()
+		statement.Predicate.BuildDefinition.InternalParameters.KConfig = kconfigOf(popts.project, popts.target)
+
+		// The kernel is only inspected on a best-effort basis since not every
+		// kernel embeds its build information.
+		records, statement.Predicate.BuildDefinition.InternalParameters.Toolchain = inspectKernel(popts.target.Kernel())
+	}
+
+	if popts.project != nil {
+		if kraftfile := popts.project.Kraftfile(); kraftfile != nil && len(kraftfile.Content()) > 0 {
+			statement.Predicate.BuildDefinition.ExternalParameters.Kraftfile = &ResourceDescriptor{
+				URI:    kraftfile.Path(),
+				Digest: digestSet(digest.FromBytes(kraftfile.Content())),
+			}
+		}
+
+		dependencies, err := dependenciesOf(ctx, popts.project, records)
+		if err != nil {
+			return nil, fmt.Errorf("could not resolve components: %w", err)
+		}
+
+		statement.Predicate.BuildDefinition.ResolvedDependencies = dependencies
+	}
+
+	return &statement, nil
+}
+
+// Parse deserializes a provenance statement.
+func Parse(b []byte) (*Statement, error) {
+	var statement Statement
+	if err := json.Unmarshal(b, &statement); err != nil {
+		return nil, fmt.Errorf("could not parse provenance statement: %w", err)
+	}
+
+	if statement.Type != StatementType {
+		return nil, fmt.Errorf("unsupported statement type '%s'", statement.Type)
+	}
+
+	if statement.PredicateType != PredicateType {
+		return nil, fmt.Errorf("unsupported predicate type '%s'", statement.PredicateType)
+	}
+
+	return &statement, nil
+}
+
+// FinishedOn returns the time at which the build finished, or the zero time if
+// it is not recorded.
+func (statement *Statement) FinishedOn() time.Time {
+	if metadata := statement.Predicate.RunDetails.Metadata; metadata != nil && metadata.FinishedOn != nil {
+		return *metadata.FinishedOn
+	}
+
+	return time.Time{}
+}
+
+// Write serializes the provenance statement as JSON.
+func (statement *Statement) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(statement)
+}
+
+// FileDescriptor returns the descriptor of the file at the provided path along
+// with the digest of its contents.
+func FileDescriptor(name, path string) (ResourceDescriptor, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ResourceDescriptor{}, err
+	}
+
+	defer f.Close()
+
+	dgst, err := digest.FromReader(f)
+	if err != nil {
+		return ResourceDescriptor{}, err
+	}
+
+	return ResourceDescriptor{
+		Name:   name,
+		URI:    path,
+		Digest: digestSet(dgst),
+	}, nil
+}
+
+// digestSet returns the in-toto representation of the digest.
+func digestSet(dgst digest.Digest) map[string]string {
+	if dgst == "" {
+		return nil
+	}
+
+	return map[string]string{
+		dgst.Algorithm().String(): dgst.Encoded(),
+	}
+}
+
+// kconfigOf returns the KConfig of the target, preferring the configuration
+// file of the project which contains the full set of resolved options.
+func kconfigOf(project app.Application, targ target.Target) map[string]string {
+	values := targ.KConfig()
+
+	if project != nil {
+		if fromFile, err := kconfig.NewKeyValueMapFromFile(filepath.Join(project.WorkingDir(), targ.ConfigFilename())); err == nil {
+			values = fromFile
+		}
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	ret := make(map[string]string, len(values))
+	for _, kv := range values {
+		ret[kv.Key] = kv.Value
+	}
+
+	return ret
+}
+
+// dependenciesOf returns the components of the project, annotated with the
+// versions recorded in the kernel image.
+func dependenciesOf(ctx context.Context, project app.Application, records map[string]componentRecord) ([]ResourceDescriptor, error) {
+	var dependencies []ResourceDescriptor
+
+	add := func(component interface {
+		Name() string
+		Version() string
+		Source() string
+	}, kind string,
+	) {
+		dependency := ResourceDescriptor{
+			Name: component.Name(),
+			URI:  component.Source(),
+			Annotations: map[string]string{
+				"type": kind,
+			},
+		}
+
+		if component.Version() != "" {
+			dependency.Annotations["version"] = component.Version()
+
+			if dgst, err := digest.Parse(component.Version()); err == nil {
+				dependency.Digest = digestSet(dgst)
+			}
+		}
+
+		if record, ok := records[component.Name()]; ok {
+			for key, value := range map[string]string{
+				"resolved_version": record.version,
+				"git_description":  record.gitDesc,
+			} {
+				if value != "" {
+					dependency.Annotations[key] = value
+				}
+			}
+		}
+
+		dependencies = append(dependencies, dependency)
+	}
+
+	if core := project.Unikraft(ctx); core != nil {
+		add(core, "core")
+	}
+
+	libraries, err := project.Libraries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(libraries))
+	for name := range libraries {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		add(libraries[name], "library")
+	}
+
+	if template := project.Template(); template != nil {
+		add(template, "template")
+	}
+
+	if runtime := project.Runtime(); runtime != nil {
+		add(runtime, "runtime")
+	}
+
+	return dependencies, nil
+}
@@ -0,0 +1,292 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package provenance
+
+import (
+	"bytes"
+	"context"
+	"debug/elf"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+
+	"kraftkit.sh/kconfig"
+	"kraftkit.sh/unikraft/arch"
+	"kraftkit.sh/unikraft/plat"
+	"kraftkit.sh/unikraft/target"
+)
+
+// writeKernel writes an ELF file whose .comment section holds the provided
+// compiler identification strings.
+func writeKernel(t *testing.T, comments ...string) string {
+	t.Helper()
+
+	shstrtab := []byte("\x00.comment\x00.shstrtab\x00")
+	comment := []byte(strings.Join(comments, "\x00") + "\x00")
+
+	headerSize := uint64(binary.Size(elf.Header64{}))
+
+	header := elf.Header64{
+		Type:      uint16(elf.ET_EXEC),
+		Machine:   uint16(elf.EM_X86_64),
+		Version:   uint32(elf.EV_CURRENT),
+		Shoff:     headerSize + uint64(len(shstrtab)+len(comment)),
+		Ehsize:    uint16(headerSize),
+		Shentsize: uint16(binary.Size(elf.Section64{})),
+		Shnum:     3,
+		Shstrndx:  2,
+	}
+	copy(header.Ident[:], elf.ELFMAG)
+	header.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	header.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	header.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+
+	headers := []elf.Section64{
+		{},
+		{
+			Name: 1,
+			Type: uint32(elf.SHT_PROGBITS),
+			Off:  headerSize + uint64(len(shstrtab)),
+			Size: uint64(len(comment)),
+		},
+		{
+			Name: 10,
+			Type: uint32(elf.SHT_STRTAB),
+			Off:  headerSize,
+			Size: uint64(len(shstrtab)),
+		},
+	}
+
+	var buf bytes.Buffer
+	for _, v := range []any{header, shstrtab, comment, headers} {
+		if err := binary.Write(&buf, binary.LittleEndian, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "kernel")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// newTarget returns a qemu/x86_64 target with the provided kernel and KConfig
+// options.
+func newTarget(t *testing.T, kernel string, options ...interface{}) target.Target {
+	t.Helper()
+
+	architecture, err := arch.NewArchitectureFromOptions(arch.WithName("x86_64"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	platform, err := plat.NewPlatformFromOptions(plat.WithName("qemu"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := kconfig.NewKeyValueMapFromSlice(options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	targ, err := target.NewTargetFromOptions(
+		target.WithName("nginx_qemu-x86_64"),
+		target.WithArchitecture(architecture),
+		target.WithPlatform(platform),
+		target.WithKConfig(values),
+		target.WithKernel(kernel),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return targ
+}
+
+func TestNew(t *testing.T) {
+	dgst := digest.FromString("manifest")
+	rootfsDigest := digest.FromString("rootfs")
+
+	rootfs := &Rootfs{
+		Source:      "./rootfs",
+		Format:      "cpio",
+		Compression: "gzip",
+		Digest:      digestSet(rootfsDigest),
+	}
+
+	zone := time.FixedZone("CET", 60*60)
+	startedOn := time.Date(2024, 3, 1, 12, 0, 0, 0, zone)
+	finishedOn := startedOn.Add(90 * time.Second)
+
+	statement, err := New(context.Background(), "unikraft.org/nginx:latest", dgst,
+		WithTarget(newTarget(t,
+			writeKernel(t, "GCC: (Debian 12.2.0-14) 12.2.0", "Linker: LLD 16.0.6"),
+			"CONFIG_LIBVFSCORE=y",
+			"CONFIG_STACK_SIZE=16",
+		)),
+		WithRootfs(rootfs),
+		WithBuildTime(startedOn, finishedOn),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if statement.Type != StatementType || statement.PredicateType != PredicateType {
+		t.Errorf("expected an in-toto statement of SLSA provenance, got %s of %s", statement.Type, statement.PredicateType)
+	}
+
+	expectedSubject := []ResourceDescriptor{{
+		Name:   "unikraft.org/nginx:latest",
+		Digest: map[string]string{"sha256": dgst.Encoded()},
+	}}
+	if !reflect.DeepEqual(statement.Subject, expectedSubject) {
+		t.Errorf("expected subject %+v, got %+v", expectedSubject, statement.Subject)
+	}
+
+	definition := statement.Predicate.BuildDefinition
+	if definition.ExternalParameters.Target != "qemu/x86_64" {
+		t.Errorf("expected target qemu/x86_64, got %q", definition.ExternalParameters.Target)
+	}
+
+	if definition.ExternalParameters.Rootfs != rootfs {
+		t.Errorf("expected rootfs %+v, got %+v", rootfs, definition.ExternalParameters.Rootfs)
+	}
+
+	// The options of the platform and architecture are recorded as well.
+	expectedKConfig := map[string]string{
+		"CONFIG_ARCH_X86_64":  "y",
+		"CONFIG_KVM_VMM_QEMU": "y",
+		"CONFIG_PLAT_KVM":     "y",
+		"CONFIG_LIBVFSCORE":   "y",
+		"CONFIG_STACK_SIZE":   "16",
+	}
+	if !reflect.DeepEqual(definition.InternalParameters.KConfig, expectedKConfig) {
+		t.Errorf("expected KConfig %v, got %v", expectedKConfig, definition.InternalParameters.KConfig)
+	}
+
+	// Kernels without a uk_libinfo section fall back to the .comment section.
+	if toolchain := definition.InternalParameters.Toolchain; toolchain != "GCC: (Debian 12.2.0-14) 12.2.0; Linker: LLD 16.0.6" {
+		t.Errorf("expected toolchain from the .comment section, got %q", toolchain)
+	}
+
+	if !statement.FinishedOn().Equal(finishedOn) || statement.FinishedOn().Location() != time.UTC {
+		t.Errorf("expected build to have finished on %s in UTC, got %s", finishedOn.UTC(), statement.FinishedOn())
+	}
+
+	// The statement survives a round trip through its serialized form.
+	var written bytes.Buffer
+	if err := statement.Write(&written); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := Parse(written.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rewritten bytes.Buffer
+	if err := parsed.Write(&rewritten); err != nil {
+		t.Fatal(err)
+	}
+
+	if written.String() != rewritten.String() {
+		t.Errorf("expected statement to be unchanged after a round trip, got:\n%s\ninstead of:\n%s", rewritten.String(), written.String())
+	}
+}
+
+func TestNewWithoutInputs(t *testing.T) {
+	statement, err := New(context.Background(), "unikraft.org/nginx:latest", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if statement.Subject[0].Digest != nil {
+		t.Errorf("expected subject without digest, got %v", statement.Subject[0].Digest)
+	}
+
+	if !reflect.DeepEqual(statement.Predicate.BuildDefinition.ExternalParameters, ExternalParameters{}) {
+		t.Errorf("expected no external parameters, got %+v", statement.Predicate.BuildDefinition.ExternalParameters)
+	}
+
+	if statement.Predicate.RunDetails.Metadata != nil || !statement.FinishedOn().IsZero() {
+		t.Errorf("expected no build time, got %+v", statement.Predicate.RunDetails.Metadata)
+	}
+
+	var written bytes.Buffer
+	if err := statement.Write(&written); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(written.String(), "metadata") {
+		t.Errorf("expected metadata to be omitted, got:\n%s", written.String())
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{
+			name:  "invalid JSON",
+			input: `{"_type":`,
+			err:   "could not parse provenance statement",
+		},
+		{
+			name:  "statement type",
+			input: `{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"` + PredicateType + `"}`,
+			err:   "unsupported statement type",
+		},
+		{
+			name:  "predicate type",
+			input: `{"_type":"` + StatementType + `","predicateType":"https://slsa.dev/provenance/v0.2"}`,
+			err:   "unsupported predicate type",
+		},
+	}
+
+	for _, tc := range tests {
+		if _, err := Parse([]byte(tc.input)); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: expected error %q, got %v", tc.name, tc.err, err)
+		}
+	}
+}
+
+func TestFileDescriptor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nginx.conf")
+	if err := os.WriteFile(path, []byte("worker_processes 1;\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	desc, err := FileDescriptor("/etc/nginx/nginx.conf", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := ResourceDescriptor{
+		Name: "/etc/nginx/nginx.conf",
+		URI:  path,
+		Digest: map[string]string{
+			"sha256": digest.FromString("worker_processes 1;\n").Encoded(),
+		},
+	}
+
+	if !reflect.DeepEqual(desc, expected) {
+		t.Errorf("expected %+v, got %+v", expected, desc)
+	}
+
+	if _, err := FileDescriptor("missing", filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"kraftkit.sh/internal/version"
+)
+
+type cdxDocument struct {
+	BOMFormat    string         `json:"bomFormat"`
+	SpecVersion  string         `json:"specVersion"`
+	SerialNumber string         `json:"serialNumber"`
+	Version      int            `json:"version"`
+	Metadata     cdxMetadata    `json:"metadata"`
+	Components   []cdxComponent `json:"components"`
+}
+
+type cdxMetadata struct {
+	Timestamp string       `json:"timestamp"`
+	Tools     cdxTools     `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components"`
+}
+
+type cdxComponent struct {
+	Type       string        `json:"type"`
+	BOMRef     string        `json:"bom-ref,omitempty"`
+	Name       string        `json:"name"`
+	Version    string        `json:"version,omitempty"`
+	Licenses   []cdxLicense  `json:"licenses,omitempty"`
+	PURL       string        `json:"purl,omitempty"`
+	Hashes     []cdxHash     `json:"hashes,omitempty"`
+	Properties []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxLicense struct {
+	License    *cdxLicenseID `json:"license,omitempty"`
+	Expression string        `json:"expression,omitempty"`
+}
+
+type cdxLicenseID struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// cdxLicenseOf returns the license of the component, either as an SPDX
+// license identifier or expression, or by its free-form name.
+func cdxLicenseOf(component Component) []cdxLicense {
+	switch {
+	case component.License == "":
+		return nil
+	case !spdxLicense.MatchString(component.License):
+		return []cdxLicense{{License: &cdxLicenseID{Name: component.License}}}
+	case strings.ContainsAny(component.License, " ()"):
+		return []cdxLicense{{Expression: component.License}}
+	}
+
+	return []cdxLicense{{License: &cdxLicenseID{ID: component.License}}}
+}
+
+// writeCycloneDX serializes the SBOM as a CycloneDX 1.5 JSON document.
+func (sbom *SBOM) writeCycloneDX(w io.Writer) error {
+	doc := cdxDocument{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + sbom.uuid(),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: sbom.Created.Format(time.RFC3339),
+			Tools: cdxTools{
+				Components: []cdxComponent{{
+					Type:    "application",
+					Name:    "kraftkit",
+					Version: version.Version(),
+				}},
+			},
+			Component: cdxComponent{
+				Type:   "application",
+				BOMRef: "unikernel",
+				Name:   sbom.Name,
+			},
+		},
+		Components: []cdxComponent{},
+	}
+
+	for i, component := range sbom.Components {
+		cdx := cdxComponent{
+			Type:     "library",
+			BOMRef:   fmt.Sprintf("component-%d", i),
+			Name:     component.Name,
+			Version:  component.Version,
+			Licenses: cdxLicenseOf(component),
+			PURL:     component.PURL,
+		}
+
+		if component.Type == ComponentTypeCore {
+			cdx.Type = "framework"
+		}
+
+		keys := make([]string, 0, len(component.Properties))
+		for key := range component.Properties {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			cdx.Properties = append(cdx.Properties, cdxProperty{
+				Name:  key,
+				Value: component.Properties[key],
+			})
+		}
+
+		doc.Components = append(doc.Components, cdx)
+	}
+
+	for i, file := range sbom.Files {
+		doc.Components = append(doc.Components, cdxComponent{
+			Type:   "file",
+			BOMRef: fmt.Sprintf("file-%d", i),
+			Name:   file.Name,
+			Hashes: []cdxHash{
+				{Alg: "SHA-1", Content: file.SHA1},
+				{Alg: "SHA-256", Content: file.SHA256},
+			},
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(doc)
+}
@@ -0,0 +1,225 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package sbom
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cavaliergopher/cpio"
+
+	"kraftkit.sh/initrd"
+	"kraftkit.sh/log"
+)
+
+const (
+	dpkgStatus    = "/var/lib/dpkg/status"
+	dpkgStatusDir = "/var/lib/dpkg/status.d/"
+	apkInstalled  = "/lib/apk/db/installed"
+)
+
+// contentsFromInitrd returns the packages and the regular files of the CPIO
+// archive at the provided path.  Packages are read from the databases of the
+// package managers of the distribution which the root file system was built
+// from, if any.
+func contentsFromInitrd(ctx context.Context, path string) ([]Component, []File, error) {
+	format, err := initrd.DetectFormat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if format.BlockDevice() {
+		log.G(ctx).
+			WithField("format", format).
+			Warn("listing the contents of the root file system is only supported for CPIO archives")
+		return nil, nil, nil
+	}
+
+	f, err := initrd.Decompress(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer f.Close()
+
+	var files []File
+	var dpkg [][]byte
+	var apk []byte
+	osRelease := map[string][]byte{}
+
+	reader := cpio.NewReader(f)
+
+	for {
+		hdr, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not read CPIO archive: %w", err)
+		}
+
+		if !hdr.Mode.IsRegular() {
+			continue
+		}
+
+		name := filepath.ToSlash(filepath.Clean("/" + hdr.Name))
+
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not read %s: %w", name, err)
+		}
+
+		sha1sum := sha1.Sum(data)
+		sha256sum := sha256.Sum256(data)
+
+		files = append(files, File{
+			Name:   name,
+			Size:   int64(len(data)),
+			SHA1:   hex.EncodeToString(sha1sum[:]),
+			SHA256: hex.EncodeToString(sha256sum[:]),
+		})
+
+		switch {
+		case name == dpkgStatus, strings.HasPrefix(name, dpkgStatusDir):
+			dpkg = append(dpkg, data)
+		case name == apkInstalled:
+			apk = data
+		case name == "/etc/os-release", name == "/usr/lib/os-release":
+			osRelease[name] = data
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+
+	distro := "unknown"
+	for _, name := range []string{"/etc/os-release", "/usr/lib/os-release"} {
+		if id := osReleaseID(osRelease[name]); id != "" {
+			distro = id
+			break
+		}
+	}
+
+	var components []Component
+	for _, data := range dpkg {
+		components = append(components, dpkgPackages(data, distro)...)
+	}
+
+	components = append(components, apkPackages(apk, distro)...)
+
+	return components, files, nil
+}
+
+// osReleaseID returns the ID of the distribution from the contents of an
+// os-release file.
+func osReleaseID(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if id, ok := strings.CutPrefix(scanner.Text(), "ID="); ok {
+			return strings.Trim(id, `"'`)
+		}
+	}
+
+	return ""
+}
+
+// dpkgPackages returns the installed packages of a dpkg status database.
+func dpkgPackages(data []byte, distro string) []Component {
+	var components []Component
+
+	for _, paragraph := range strings.Split(string(data), "\n\n") {
+		fields := map[string]string{}
+
+		for _, line := range strings.Split(paragraph, "\n") {
+			key, value, ok := strings.Cut(line, ":")
+			if !ok || strings.HasPrefix(line, " ") {
+				continue
+			}
+
+			fields[key] = strings.TrimSpace(value)
+		}
+
+		if fields["Package"] == "" {
+			continue
+		}
+
+		// Packages which have been removed but whose configuration files remain
+		// are still listed.
+		if status, ok := fields["Status"]; ok && !strings.HasSuffix(status, " installed") {
+			continue
+		}
+
+		components = append(components, Component{
+			Type:    ComponentTypePackage,
+			Name:    fields["Package"],
+			Version: fields["Version"],
+			PURL:    purl("deb", distro, fields["Package"], fields["Version"], fields["Architecture"]),
+			Properties: map[string]string{
+				"package:manager": "dpkg",
+			},
+		})
+	}
+
+	return components
+}
+
+// apkPackages returns the installed packages of an apk database.
+func apkPackages(data []byte, distro string) []Component {
+	var components []Component
+
+	for _, paragraph := range strings.Split(string(data), "\n\n") {
+		fields := map[string]string{}
+
+		for _, line := range strings.Split(paragraph, "\n") {
+			if len(line) < 2 || line[1] != ':' {
+				continue
+			}
+
+			fields[line[:1]] = line[2:]
+		}
+
+		if fields["P"] == "" {
+			continue
+		}
+
+		components = append(components, Component{
+			Type:    ComponentTypePackage,
+			Name:    fields["P"],
+			Version: fields["V"],
+			License: fields["L"],
+			PURL:    purl("apk", distro, fields["P"], fields["V"], fields["A"]),
+			Properties: map[string]string{
+				"package:manager": "apk",
+			},
+		})
+	}
+
+	return components
+}
+
+// purl returns the package URL of a package of a distribution.
+func purl(typ, distro, name, version, arch string) string {
+	p := "pkg:" + typ + "/" + url.PathEscape(distro) + "/" + url.PathEscape(name)
+	if version != "" {
+		p += "@" + url.PathEscape(version)
+	}
+
+	if arch != "" {
+		p += "?arch=" + url.QueryEscape(arch)
+	}
+
+	return p
+}
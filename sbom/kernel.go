@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package sbom
+
+import (
+	"net/url"
+	"strings"
+
+	"kraftkit.sh/unikraft/app"
+)
+
+// componentsFromKernel returns the core and the libraries which are recorded
+// in the uk_libinfo section of the kernel image.
+func componentsFromKernel(path string) ([]Component, error) {
+	records, err := app.ComponentInfoRecordsFromKernel(path)
+	if err != nil {
+		return nil, err
+	}
+
+	components := make([]Component, 0, len(records))
+
+	for _, record := range records {
+		component := Component{
+			Type:       ComponentTypeLibrary,
+			Name:       record.LibName,
+			Version:    record.Version,
+			License:    record.License,
+			Properties: map[string]string{},
+		}
+
+		if record.UkVersion != "" {
+			component.Version = record.UkVersion
+		}
+
+		if record.UkFullVersion != "" {
+			component.Version = record.UkFullVersion
+		}
+
+		// The record of the core is the only one without a name.
+		if record.LibName == "" {
+			component.Type = ComponentTypeCore
+			component.Name = "unikraft"
+		}
+
+		component.PURL = "pkg:generic/unikraft/" + url.PathEscape(component.Name)
+		if component.Version != "" {
+			component.PURL += "@" + url.PathEscape(component.Version)
+		}
+
+		for key, value := range map[string]string{
+			"unikraft:comment":                 record.Comment,
+			"unikraft:git_description":         record.GitDesc,
+			"unikraft:code_name":               record.UkCodeName,
+			"unikraft:compiler":                record.Compiler,
+			"unikraft:compile_date":            record.CompileDate,
+			"unikraft:compiled_by":             record.CompiledBy,
+			"unikraft:compiled_by_association": record.CompiledByAssoc,
+			"unikraft:compile_flags":           strings.Join(record.CompileFlags, ","),
+		} {
+			if value != "" {
+				component.Properties[key] = value
+			}
+		}
+
+		components = append(components, component)
+	}
+
+	return components, nil
+}
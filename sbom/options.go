@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package sbom
+
+// SBOMOptions are the inputs of an SBOM.
+type SBOMOptions struct {
+	kernel string
+	initrd string
+}
+
+// SBOMOption is a function which modifies the inputs of an SBOM.
+type SBOMOption func(*SBOMOptions) error
+
+// WithKernel sets the path to the kernel image whose build information is
+// included in the SBOM.
+func WithKernel(path string) SBOMOption {
+	return func(opts *SBOMOptions) error {
+		opts.kernel = path
+		return nil
+	}
+}
+
+// WithInitrd sets the path to the CPIO archive whose contents are included in
+// the SBOM.
+func WithInitrd(path string) SBOMOption {
+	return func(opts *SBOMOptions) error {
+		opts.initrd = path
+		return nil
+	}
+}
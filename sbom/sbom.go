@@ -0,0 +1,216 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+
+// Package sbom generates software bills of materials of unikernels from the
+// build information embedded in the kernel image and from the contents of the
+// root file system.
+package sbom
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Format is the serialization format of an SBOM.
+type Format string
+
+const (
+	FormatSPDX      = Format("spdx")
+	FormatCycloneDX = Format("cyclonedx")
+)
+
+const (
+	// MediaTypeSPDX is the media type of SPDX SBOMs serialized as JSON.
+	MediaTypeSPDX = "application/spdx+json"
+
+	// MediaTypeCycloneDX is the media type of CycloneDX SBOMs serialized as
+	// JSON.
+	MediaTypeCycloneDX = "application/vnd.cyclonedx+json"
+)
+
+// String implements fmt.Stringer.
+func (format Format) String() string {
+	return string(format)
+}
+
+// MediaType returns the media type of SBOMs serialized in the format.
+func (format Format) MediaType() string {
+	switch format {
+	case FormatSPDX:
+		return MediaTypeSPDX
+	case FormatCycloneDX:
+		return MediaTypeCycloneDX
+	}
+
+	return ""
+}
+
+// Formats returns the list of supported SBOM formats.
+func Formats() []Format {
+	return []Format{
+		FormatSPDX,
+		FormatCycloneDX,
+	}
+}
+
+// ComponentType is the kind of a component of the unikernel.
+type ComponentType string
+
+const (
+	// ComponentTypeCore is the Unikraft core.
+	ComponentTypeCore = ComponentType("core")
+
+	// ComponentTypeLibrary is a library which is linked into the kernel.
+	ComponentTypeLibrary = ComponentType("library")
+
+	// ComponentTypePackage is a package of a distribution which is installed
+	// in the root file system.
+	ComponentTypePackage = ComponentType("package")
+)
+
+// Component is a single piece of software of the unikernel.
+type Component struct {
+	// Type is the kind of the component.
+	Type ComponentType
+
+	// Name of the component.
+	Name string
+
+	// Version of the component, if known.
+	Version string
+
+	// License of the component, ideally as an SPDX license expression.
+	License string
+
+	// PURL is the package URL which identifies the component.
+	PURL string
+
+	// Properties are additional build details of the component, e.g. the
+	// compiler and the compilation flags.
+	Properties map[string]string
+}
+
+// File is a regular file of the root file system.
+type File struct {
+	// Name is the absolute path of the file within the root file system.
+	Name string
+
+	// Size of the file in bytes.
+	Size int64
+
+	// SHA1 is the hex-encoded SHA-1 digest of the file's contents.
+	SHA1 string
+
+	// SHA256 is the hex-encoded SHA-256 digest of the file's contents.
+	SHA256 string
+}
+
+// SBOM is the software bill of materials of a unikernel.
+type SBOM struct {
+	// Name of the unikernel.
+	Name string
+
+	// Created is the time at which the SBOM was generated.
+	Created time.Time
+
+	// Components are the components of the kernel image followed by the
+	// packages of the root file system.
+	Components []Component
+
+	// Files are the regular files of the root file system.
+	Files []File
+}
+
+// New generates the SBOM of the unikernel with the provided name.
+func New(ctx context.Context, name string, opts ...SBOMOption) (*SBOM, error) {
+	sopts := SBOMOptions{}
+	for _, opt := range opts {
+		if err := opt(&sopts); err != nil {
+			return nil, err
+		}
+	}
+
+	sbom := SBOM{
+		Name:    name,
+		Created: created(),
+	}
+
+	if sopts.kernel != "" {
+		components, err := componentsFromKernel(sopts.kernel)
+		if err != nil {
+			return nil, fmt.Errorf("could not read components of kernel: %w", err)
+		}
+
+		sbom.Components = append(sbom.Components, components...)
+	}
+
+	if sopts.initrd != "" {
+		components, files, err := contentsFromInitrd(ctx, sopts.initrd)
+		if err != nil {
+			return nil, fmt.Errorf("could not read contents of initrd: %w", err)
+		}
+
+		sbom.Components = append(sbom.Components, components...)
+		sbom.Files = append(sbom.Files, files...)
+	}
+
+	return &sbom, nil
+}
+
+// Write serializes the SBOM in the provided format.
+func (sbom *SBOM) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatSPDX:
+		return sbom.writeSPDX(w)
+	case FormatCycloneDX:
+		return sbom.writeCycloneDX(w)
+	}
+
+	return fmt.Errorf("unsupported SBOM format '%s'", format)
+}
+
+// id returns a stable identifier of the SBOM which only depends on its
+// contents, such that regenerating the SBOM of the same unikernel results in
+// the same document.
+func (sbom *SBOM) id() []byte {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n", sbom.Name)
+
+	for _, component := range sbom.Components {
+		fmt.Fprintf(h, "%s %s %s %s\n", component.Type, component.Name, component.Version, component.PURL)
+	}
+
+	for _, file := range sbom.Files {
+		fmt.Fprintf(h, "%s %s\n", file.Name, file.SHA256)
+	}
+
+	return h.Sum(nil)
+}
+
+// uuid formats the identifier of the SBOM as a (version 5-like) UUID.
+func (sbom *SBOM) uuid() string {
+	id := sbom.id()
+	id[6] = (id[6] & 0x0f) | 0x50
+	id[8] = (id[8] & 0x3f) | 0x80
+
+	s := hex.EncodeToString(id[:16])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:32]
+}
+
+// created returns the creation time of SBOMs, which honours
+// SOURCE_DATE_EPOCH for reproducible builds.
+func created() time.Time {
+	if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		return time.Unix(epoch, 0).UTC()
+	}
+
+	return time.Now().UTC().Truncate(time.Second)
+}
@@ -0,0 +1,255 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package sbom
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kraftkit.sh/initrd"
+)
+
+const dpkgStatusFixture = `Package: libc6
+Status: install ok installed
+Architecture: amd64
+Version: 2.36-9+deb12u4
+
+Package: nginx
+Status: install ok installed
+Architecture: amd64
+Version: 1.22.1-9
+Description: small, powerful, scalable web/proxy server
+ Multi-line descriptions are not fields.
+
+Package: vim-tiny
+Status: deinstall ok config-files
+Architecture: amd64
+Version: 2:9.0.1378-2
+`
+
+// buildInitrd builds a CPIO archive of a root file system with the provided
+// files and returns its path.
+func buildInitrd(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output := filepath.Join(t.TempDir(), "initramfs.cpio")
+
+	ird, err := initrd.NewFromDirectory(context.Background(), root, initrd.WithOutput(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ird.Build(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return output
+}
+
+func newDebianSBOM(t *testing.T) *SBOM {
+	t.Helper()
+
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+	path := buildInitrd(t, map[string]string{
+		"etc/os-release":      "PRETTY_NAME=\"Debian GNU/Linux 12 (bookworm)\"\nID=debian\n",
+		"var/lib/dpkg/status": dpkgStatusFixture,
+		"usr/sbin/nginx":      "nginx",
+	})
+
+	sbom, err := New(context.Background(), "nginx", WithInitrd(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return sbom
+}
+
+func TestNewFromInitrd(t *testing.T) {
+	sbom := newDebianSBOM(t)
+
+	expected := []Component{
+		{Name: "libc6", Version: "2.36-9+deb12u4", PURL: "pkg:deb/debian/libc6@2.36-9+deb12u4?arch=amd64"},
+		{Name: "nginx", Version: "1.22.1-9", PURL: "pkg:deb/debian/nginx@1.22.1-9?arch=amd64"},
+	}
+
+	if len(sbom.Components) != len(expected) {
+		t.Fatalf("expected %d components, got %d: %+v", len(expected), len(sbom.Components), sbom.Components)
+	}
+
+	for i, component := range sbom.Components {
+		if component.Type != ComponentTypePackage || component.Name != expected[i].Name || component.Version != expected[i].Version || component.PURL != expected[i].PURL {
+			t.Errorf("expected component %+v, got %+v", expected[i], component)
+		}
+	}
+
+	sum := sha256.Sum256([]byte("nginx"))
+
+	var found bool
+	for _, file := range sbom.Files {
+		if file.Name == "/usr/sbin/nginx" {
+			found = true
+
+			if file.Size != 5 || file.SHA256 != hex.EncodeToString(sum[:]) {
+				t.Errorf("unexpected file %+v", file)
+			}
+		}
+	}
+
+	if !found {
+		t.Errorf("expected /usr/sbin/nginx to be listed, got %+v", sbom.Files)
+	}
+}
+
+func TestWriteSPDX(t *testing.T) {
+	sbom := newDebianSBOM(t)
+
+	var buf bytes.Buffer
+	if err := sbom.Write(&buf, FormatSPDX); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := spdxDocument{}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("could not parse SPDX document: %v", err)
+	}
+
+	if doc.SPDXVersion != "SPDX-2.3" || doc.Name != "nginx" || doc.CreationInfo.Created != "2023-11-14T22:13:20Z" {
+		t.Errorf("unexpected document header: %+v", doc)
+	}
+
+	// The unikernel itself is followed by its components.
+	if len(doc.Packages) != 1+len(sbom.Components) {
+		t.Fatalf("expected %d packages, got %d", 1+len(sbom.Components), len(doc.Packages))
+	}
+
+	if doc.Packages[2].Name != "nginx" || len(doc.Packages[2].ExternalRefs) != 1 || doc.Packages[2].ExternalRefs[0].ReferenceLocator != sbom.Components[1].PURL {
+		t.Errorf("unexpected package: %+v", doc.Packages[2])
+	}
+
+	if len(doc.Files) != len(sbom.Files) {
+		t.Fatalf("expected %d files, got %d", len(sbom.Files), len(doc.Files))
+	}
+
+	// Every package and file is related to the document or the unikernel.
+	ids := map[string]bool{}
+	for _, rel := range doc.Relationships {
+		ids[rel.RelatedSPDXElement] = true
+	}
+
+	for _, pkg := range doc.Packages {
+		if !ids[pkg.SPDXID] {
+			t.Errorf("expected a relationship to %s", pkg.SPDXID)
+		}
+	}
+
+	for _, file := range doc.Files {
+		if !ids[file.SPDXID] {
+			t.Errorf("expected a relationship to %s", file.SPDXID)
+		}
+	}
+
+	// Regenerating the SBOM of the same unikernel results in the same document.
+	var again bytes.Buffer
+	if err := newDebianSBOM(t).Write(&again, FormatSPDX); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(buf.Bytes(), again.Bytes()) {
+		t.Errorf("expected SPDX document to be reproducible")
+	}
+}
+
+func TestWriteCycloneDX(t *testing.T) {
+	sbom := newDebianSBOM(t)
+
+	var buf bytes.Buffer
+	if err := sbom.Write(&buf, FormatCycloneDX); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := cdxDocument{}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("could not parse CycloneDX document: %v", err)
+	}
+
+	if doc.BOMFormat != "CycloneDX" || doc.SerialNumber != "urn:uuid:"+sbom.uuid() || doc.Metadata.Component.Name != "nginx" {
+		t.Errorf("unexpected document header: %+v", doc)
+	}
+
+	if len(doc.Components) != len(sbom.Components)+len(sbom.Files) {
+		t.Fatalf("expected %d components, got %d", len(sbom.Components)+len(sbom.Files), len(doc.Components))
+	}
+
+	for i, component := range sbom.Components {
+		if doc.Components[i].Type != "library" || doc.Components[i].PURL != component.PURL {
+			t.Errorf("expected component %+v, got %+v", component, doc.Components[i])
+		}
+	}
+
+	for i, file := range sbom.Files {
+		cdx := doc.Components[len(sbom.Components)+i]
+		if cdx.Type != "file" || cdx.Name != file.Name || len(cdx.Hashes) != 2 || cdx.Hashes[1].Content != file.SHA256 {
+			t.Errorf("expected file %+v, got %+v", file, cdx)
+		}
+	}
+}
+
+func TestApkPackages(t *testing.T) {
+	components := apkPackages([]byte("P:musl\nV:1.2.4-r2\nA:x86_64\nL:MIT\n\nP:busybox\nV:1.36.1-r5\nA:x86_64\nL:GPL-2.0-only\n"), "alpine")
+
+	if len(components) != 2 {
+		t.Fatalf("expected 2 components, got %d", len(components))
+	}
+
+	if components[0].Name != "musl" || components[0].License != "MIT" || components[0].PURL != "pkg:apk/alpine/musl@1.2.4-r2?arch=x86_64" {
+		t.Errorf("unexpected component: %+v", components[0])
+	}
+}
+
+func TestLicenses(t *testing.T) {
+	tests := []struct {
+		license string
+		spdx    string
+		cdx     cdxLicense
+	}{
+		{"MIT", "MIT", cdxLicense{License: &cdxLicenseID{ID: "MIT"}}},
+		{"MIT OR Apache-2.0", "MIT OR Apache-2.0", cdxLicense{Expression: "MIT OR Apache-2.0"}},
+		{"GPL, see COPYING", spdxNoAssertion, cdxLicense{License: &cdxLicenseID{Name: "GPL, see COPYING"}}},
+	}
+
+	for _, tc := range tests {
+		component := Component{License: tc.license}
+
+		if actual := spdxLicenseOf(component); actual != tc.spdx {
+			t.Errorf("%q: expected SPDX license %q, got %q", tc.license, tc.spdx, actual)
+		}
+
+		actual := cdxLicenseOf(component)
+		if len(actual) != 1 || actual[0].Expression != tc.cdx.Expression ||
+			(actual[0].License == nil) != (tc.cdx.License == nil) ||
+			(actual[0].License != nil && *actual[0].License != *tc.cdx.License) {
+			t.Errorf("%q: expected CycloneDX license %+v, got %+v", tc.license, tc.cdx, actual)
+		}
+	}
+}
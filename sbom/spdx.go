@@ -0,0 +1,196 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"time"
+
+	"kraftkit.sh/internal/version"
+)
+
+const spdxNoAssertion = "NOASSERTION"
+
+// spdxLicense matches license expressions which consist of SPDX license
+// identifiers and operators.
+var spdxLicense = regexp.MustCompile(`^[A-Za-z0-9.+\-() ]+$`)
+
+// spdxInvalid matches characters which are not allowed in SPDX identifiers.
+var spdxInvalid = regexp.MustCompile(`[^A-Za-z0-9.\-]+`)
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Files             []spdxFile         `json:"files,omitempty"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+	PrimaryPurpose   string            `json:"primaryPackagePurpose,omitempty"`
+	Comment          string            `json:"comment,omitempty"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxFile struct {
+	FileName         string         `json:"fileName"`
+	SPDXID           string         `json:"SPDXID"`
+	Checksums        []spdxChecksum `json:"checksums"`
+	LicenseConcluded string         `json:"licenseConcluded"`
+	CopyrightText    string         `json:"copyrightText"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// spdxLicenseOf returns the license of the component if it is a valid SPDX
+// license expression.
+func spdxLicenseOf(component Component) string {
+	if component.License == "" || !spdxLicense.MatchString(component.License) {
+		return spdxNoAssertion
+	}
+
+	return component.License
+}
+
+// writeSPDX serializes the SBOM as an SPDX 2.3 JSON document.
+func (sbom *SBOM) writeSPDX(w io.Writer) error {
+	root := "SPDXRef-Unikernel"
+
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              sbom.Name,
+		DocumentNamespace: fmt.Sprintf("https://kraftkit.sh/spdx/%s-%s", spdxInvalid.ReplaceAllString(sbom.Name, "-"), sbom.uuid()),
+		CreationInfo: spdxCreationInfo{
+			Created: sbom.Created.Format(time.RFC3339),
+			Creators: []string{
+				"Tool: kraftkit-" + version.Version(),
+			},
+		},
+		Packages: []spdxPackage{{
+			Name:             sbom.Name,
+			SPDXID:           root,
+			DownloadLocation: spdxNoAssertion,
+			LicenseConcluded: spdxNoAssertion,
+			LicenseDeclared:  spdxNoAssertion,
+			CopyrightText:    spdxNoAssertion,
+			PrimaryPurpose:   "APPLICATION",
+		}},
+		Relationships: []spdxRelationship{{
+			SPDXElementID:      "SPDXRef-DOCUMENT",
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: root,
+		}},
+	}
+
+	for i, component := range sbom.Components {
+		id := fmt.Sprintf("SPDXRef-Package-%s-%d", spdxInvalid.ReplaceAllString(component.Name, "-"), i)
+
+		pkg := spdxPackage{
+			Name:             component.Name,
+			SPDXID:           id,
+			VersionInfo:      component.Version,
+			DownloadLocation: spdxNoAssertion,
+			LicenseConcluded: spdxNoAssertion,
+			LicenseDeclared:  spdxLicenseOf(component),
+			CopyrightText:    spdxNoAssertion,
+			PrimaryPurpose:   "LIBRARY",
+		}
+
+		if component.PURL != "" {
+			pkg.ExternalRefs = append(pkg.ExternalRefs, spdxExternalRef{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  component.PURL,
+			})
+		}
+
+		// SPDX has no notion of arbitrary properties, which are retained as a
+		// comment instead.
+		keys := make([]string, 0, len(component.Properties))
+		for key := range component.Properties {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if pkg.Comment != "" {
+				pkg.Comment += "\n"
+			}
+
+			pkg.Comment += key + ": " + component.Properties[key]
+		}
+
+		doc.Packages = append(doc.Packages, pkg)
+		doc.Relationships = append(doc.Relationships, spdxRelationship{
+			SPDXElementID:      root,
+			RelationshipType:   "CONTAINS",
+			RelatedSPDXElement: id,
+		})
+	}
+
+	for i, file := range sbom.Files {
+		id := fmt.Sprintf("SPDXRef-File-%d", i)
+
+		doc.Files = append(doc.Files, spdxFile{
+			FileName: "." + file.Name,
+			SPDXID:   id,
+			Checksums: []spdxChecksum{
+				{Algorithm: "SHA1", ChecksumValue: file.SHA1},
+				{Algorithm: "SHA256", ChecksumValue: file.SHA256},
+			},
+			LicenseConcluded: spdxNoAssertion,
+			CopyrightText:    spdxNoAssertion,
+		})
+		doc.Relationships = append(doc.Relationships, spdxRelationship{
+			SPDXElementID:      root,
+			RelationshipType:   "CONTAINS",
+			RelatedSPDXElement: id,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(doc)
+}
@@ -64,6 +64,10 @@ type Application interface {
 	// as the root filesystem.  This can either be an initramdisk or a volume.
 	Rootfs() string
 
+	// RootfsFiles are additional files, in the form SRC:DST[:mode[:uid:gid]],
+	// which are added to the root filesystem.
+	RootfsFiles() []string
+
 	// Command is the list of arguments passed to the application's runtime.
 	Command() []string
 
@@ -168,6 +172,7 @@ type application struct {
 	env           target.Env
 	command       []string
 	rootfs        string
+	rootfsFiles   []string
 	kraftfile     *Kraftfile
 	configuration kconfig.KeyValueMap
 	extensions    component.Extensions
@@ -240,6 +245,10 @@ func (app application) Rootfs() string {
 	return app.rootfs
 }
 
+func (app application) RootfsFiles() []string {
+	return app.rootfsFiles
+}
+
 func (app application) Command() []string {
 	return app.command
 }
@@ -959,7 +968,12 @@ func (app application) MarshalYAML() (interface{}, error) {
 		ret["template"] = app.template
 	}
 
-	if len(app.rootfs) > 0 {
+	if len(app.rootfsFiles) > 0 {
+		ret["rootfs"] = map[string]interface{}{
+			"source": app.rootfs,
+			"files":  app.rootfsFiles,
+		}
+	} else if len(app.rootfs) > 0 {
 		ret["rootfs"] = app.rootfs
 	}
 
@@ -127,6 +127,14 @@ func WithRootfs(rootfs string) ApplicationOption {
 	}
 }
 
+// WithRootfsFiles sets the files which are added to the application's rootfs
+func WithRootfsFiles(files ...string) ApplicationOption {
+	return func(ac *application) error {
+		ac.rootfsFiles = files
+		return nil
+	}
+}
+
 // WithTemplate sets the application's template
 func WithTemplate(template *template.TemplateConfig) ApplicationOption {
 	return func(ac *application) error {
@@ -95,14 +95,16 @@ func gunzipConfig(configgz []byte) ([]byte, error) {
 	return configData, nil
 }
 
-func unikraftFromRecord(ctx context.Context, unikraftRecord ComponentInfoRecord) (*core.UnikraftConfig, error) {
+// KConfig returns the enabled options of the configuration which is embedded
+// in the record of the core.
+func (record ComponentInfoRecord) KConfig() (kconfig.KeyValueMap, error) {
 	var configData []byte
 	var err error
 
-	if unikraftRecord.UkConfig != nil {
-		configData = unikraftRecord.UkConfig
-	} else if unikraftRecord.UkConfiggz != nil {
-		configData, err = gunzipConfig(unikraftRecord.UkConfiggz)
+	if record.UkConfig != nil {
+		configData = record.UkConfig
+	} else if record.UkConfiggz != nil {
+		configData, err = gunzipConfig(record.UkConfiggz)
 		if err != nil {
 			return nil, err
 		}
@@ -125,6 +127,15 @@ func unikraftFromRecord(ctx context.Context, unikraftRecord ComponentInfoRecord)
 		}
 	}
 
+	return configMap, nil
+}
+
+func unikraftFromRecord(ctx context.Context, unikraftRecord ComponentInfoRecord) (*core.UnikraftConfig, error) {
+	configMap, err := unikraftRecord.KConfig()
+	if err != nil {
+		return nil, err
+	}
+
 	version := "Unknown"
 	if unikraftRecord.UkVersion != "" {
 		version = unikraftRecord.UkVersion
@@ -212,21 +223,20 @@ func addFieldToRecord(record *ComponentInfoRecord, tnum int, data []byte, byteor
 	return nil
 }
 
-func parseUKLibInfo(ctx context.Context, elfName, configFile, kraftFile string, elfData []byte, ushortSize, uintSize int, byteorder binary.ByteOrder) (application, error) {
+// parseComponentInfoRecords parses the records of the uk_libinfo section.
+func parseComponentInfoRecords(configFile string, elfData []byte, ushortSize, uintSize int, byteorder binary.ByteOrder) ([]ComponentInfoRecord, error) {
 	hdrHdrLen := uintSize + ushortSize
 	recHdrLen := ushortSize + uintSize
 	seek := 0
 	left := len(elfData)
 
-	var err error
-	var unikraft *core.UnikraftConfig
-	var libraries []*lib.LibraryConfig
+	var records []ComponentInfoRecord
 
 	for left >= hdrHdrLen {
 		hdrLen := int(byteorder.Uint32(elfData[seek : seek+uintSize]))
 		seek += uintSize
 		if hdrLen > left {
-			return application{}, fmt.Errorf("invalid header size at byte position %d", seek)
+			return nil, fmt.Errorf("invalid header size at byte position %d", seek)
 		}
 		hdrVersion := int(byteorder.Uint16(elfData[seek : seek+ushortSize]))
 		seek += ushortSize
@@ -247,7 +257,7 @@ func parseUKLibInfo(ctx context.Context, elfName, configFile, kraftFile string,
 			recLen := int(byteorder.Uint32(elfData[recSeek : recSeek+uintSize]))
 			recSeek += uintSize
 			if recLen > recLeft {
-				return application{}, fmt.Errorf("invalid record size at byte position %d", recSeek)
+				return nil, fmt.Errorf("invalid record size at byte position %d", recSeek)
 			}
 			recData := elfData[recSeek : recSeek+(recLen-recHdrLen)]
 			recSeek += len(recData)
@@ -255,10 +265,26 @@ func parseUKLibInfo(ctx context.Context, elfName, configFile, kraftFile string,
 
 			err := addFieldToRecord(&record, recType, recData, byteorder, configFile)
 			if err != nil {
-				return application{}, err
+				return nil, err
 			}
 		}
 
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func parseUKLibInfo(ctx context.Context, elfName, configFile, kraftFile string, elfData []byte, ushortSize, uintSize int, byteorder binary.ByteOrder) (application, error) {
+	records, err := parseComponentInfoRecords(configFile, elfData, ushortSize, uintSize, byteorder)
+	if err != nil {
+		return application{}, err
+	}
+
+	var unikraft *core.UnikraftConfig
+	var libraries []*lib.LibraryConfig
+
+	for _, record := range records {
 		if record.LibName == "" {
 			unikraft, err = unikraftFromRecord(ctx, record)
 			if err != nil {
@@ -315,14 +341,16 @@ func parseUKLibInfo(ctx context.Context, elfName, configFile, kraftFile string,
 	}, nil
 }
 
-// This function attempts to read the uk_libinfo section of an ELF binary and fill in the fields of an application on a
-// best-effort basis.
-func NewApplicationFromKernel(ctx context.Context, elfPath, configFile, kraftFile string) (Application, error) {
+// readUKLibInfo returns the contents of the uk_libinfo section of an ELF
+// binary along with its byte order.
+func readUKLibInfo(elfPath string) ([]byte, binary.ByteOrder, error) {
 	fe, err := elf.Open(elfPath)
 	if err != nil {
-		return application{}, err
+		return nil, nil, err
 	}
 
+	defer fe.Close()
+
 	var libinfo_section *elf.Section
 
 	for _, section := range fe.Sections {
@@ -332,17 +360,41 @@ func NewApplicationFromKernel(ctx context.Context, elfPath, configFile, kraftFil
 	}
 
 	if libinfo_section == nil {
-		return application{}, fmt.Errorf("no %s section found", uk_ibinfo_section_name)
+		return nil, nil, fmt.Errorf("no %s section found", uk_ibinfo_section_name)
 	}
 
 	elfData, err := libinfo_section.Data()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return elfData, fe.ByteOrder, nil
+}
+
+// ComponentInfoRecordsFromKernel reads the uk_libinfo section of an ELF binary
+// and returns the records of the core and of each library, in the order in
+// which they appear in the section.
+func ComponentInfoRecordsFromKernel(elfPath string) ([]ComponentInfoRecord, error) {
+	elfData, byteorder, err := readUKLibInfo(elfPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// TODO: potentially look at the architecture to figure out those sizes
+	return parseComponentInfoRecords("", elfData, 2, 4, byteorder)
+}
+
+// This function attempts to read the uk_libinfo section of an ELF binary and fill in the fields of an application on a
+// best-effort basis.
+func NewApplicationFromKernel(ctx context.Context, elfPath, configFile, kraftFile string) (Application, error) {
+	elfData, byteorder, err := readUKLibInfo(elfPath)
 	if err != nil {
 		return application{}, err
 	}
 
 	elfName := strings.Split(elfPath, "/")[len(strings.Split(elfPath, "/"))-1]
 	// TODO: potentially look at the architecture to figure out those sizes
-	app, err := parseUKLibInfo(ctx, elfName, configFile, kraftFile, elfData, 2, 4, fe.ByteOrder)
+	app, err := parseUKLibInfo(ctx, elfName, configFile, kraftFile, elfData, 2, 4, byteorder)
 
 	return app, err
 }
@@ -55,9 +55,40 @@ func NewApplicationFromInterface(ctx context.Context, iface map[string]interface
 	}
 
 	if n, ok := iface["rootfs"]; ok {
-		app.rootfs, ok = n.(string)
-		if !ok {
-			return nil, errors.New("rootfs must be a string")
+		switch v := n.(type) {
+		case string:
+			app.rootfs = v
+		case map[string]interface{}:
+			if source, ok := v["source"]; ok {
+				app.rootfs, ok = source.(string)
+				if !ok {
+					return nil, errors.New("rootfs source must be a string")
+				}
+			}
+
+			if files, ok := v["files"]; ok {
+				list, ok := files.([]interface{})
+				if !ok {
+					return nil, errors.New("rootfs files must be a list")
+				}
+
+				for _, file := range list {
+					file, ok := file.(string)
+					if !ok {
+						return nil, errors.New("rootfs file must be a string in the form SRC:DST[:mode[:uid:gid]]")
+					}
+
+					// Sources are relative to the project rather than to where kraft
+					// is invoked from.
+					if src, rest, found := strings.Cut(file, ":"); found && popts.resolvePaths && src != "" {
+						file = popts.RelativePath(src) + ":" + rest
+					}
+
+					app.rootfsFiles = append(app.rootfsFiles, file)
+				}
+			}
+		default:
+			return nil, errors.New("rootfs must be a string or a map")
 		}
 	}
 
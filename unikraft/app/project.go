@@ -169,6 +169,7 @@ func NewProjectFromOptions(ctx context.Context, opts ...ProjectOption) (Applicat
 		WithUnikraft(app.unikraft),
 		WithRuntime(app.runtime),
 		WithRootfs(app.rootfs),
+		WithRootfsFiles(app.rootfsFiles...),
 		WithTemplate(app.template),
 		WithCommand(app.command...),
 		WithLibraries(app.libraries),
@@ -42,6 +42,17 @@ type Kraftfile struct {
 	config map[string]interface{}
 }
 
+// Path returns the path of the Kraftfile, which is empty if the Kraftfile was
+// not read from a file.
+func (kf *Kraftfile) Path() string {
+	return kf.path
+}
+
+// Content returns the raw yaml content of the Kraftfile.
+func (kf *Kraftfile) Content() []byte {
+	return kf.content
+}
+
 // ProjectOptions group configuration options used to instantiate a new
 // ApplicationConfig from a working directory and a kraftfile
 type ProjectOptions struct {
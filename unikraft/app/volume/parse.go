@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package volume
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Syntax is the short-hand syntax of a volume which is accepted both by the
+// `--volume` flag and by the string form of the Kraftfile's volumes.
+const Syntax = "<source>:<destination>[:<driver>][:<options>]"
+
+// Parse returns the volume described by the short-hand syntax
+// <source>:<destination>[:<driver>][:<options>], where options is a
+// comma-separated list of mount options, e.g. ro,mode=0755.  The driver and
+// the options may be provided in either order and are told apart by whether
+// every element of the field is a known mount option.
+func Parse(entry string) (*VolumeConfig, error) {
+	split := strings.Split(entry, ":")
+	if len(split) < 2 || len(split) > 4 {
+		return nil, fmt.Errorf("expected volume to be in the format %s", Syntax)
+	}
+
+	volume := VolumeConfig{
+		source:      split[0],
+		destination: split[1],
+	}
+
+	for _, field := range split[2:] {
+		if !IsOptions(field) {
+			if len(volume.driver) > 0 {
+				return nil, fmt.Errorf("driver specified multiple times")
+			}
+
+			volume.driver = field
+			continue
+		}
+
+		for _, opt := range strings.Split(field, ",") {
+			switch {
+			case opt == "ro":
+				volume.readOnly = true
+			case opt == "rw":
+				volume.readOnly = false
+			case strings.HasPrefix(opt, "mode="):
+				volume.mode = strings.TrimPrefix(opt, "mode=")
+			}
+		}
+	}
+
+	return &volume, nil
+}
+
+// IsOptions returns whether the provided field of the short-hand syntax of a
+// volume is a comma-separated list of mount options rather than the name of a
+// driver.
+func IsOptions(field string) bool {
+	for _, opt := range strings.Split(field, ",") {
+		if opt != "ro" && opt != "rw" && !strings.HasPrefix(opt, "mode=") {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2024, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package volume
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		entry    string
+		expected *VolumeConfig
+	}{
+		{"./data:/data", &VolumeConfig{source: "./data", destination: "/data"}},
+		{"./data:/data:virtiofs", &VolumeConfig{source: "./data", destination: "/data", driver: "virtiofs"}},
+		{"./data:/data:ro", &VolumeConfig{source: "./data", destination: "/data", readOnly: true}},
+		{"./data:/data:rw", &VolumeConfig{source: "./data", destination: "/data"}},
+		{"./data:/data:mode=0755", &VolumeConfig{source: "./data", destination: "/data", mode: "0755"}},
+		{"./data:/data:ro,mode=0755", &VolumeConfig{source: "./data", destination: "/data", mode: "0755", readOnly: true}},
+		{"./data:/data:9pfs:ro,mode=0755", &VolumeConfig{source: "./data", destination: "/data", driver: "9pfs", mode: "0755", readOnly: true}},
+		{"./data:/data:ro:9pfs", &VolumeConfig{source: "./data", destination: "/data", driver: "9pfs", readOnly: true}},
+		{"./data:/data:ro,rw", &VolumeConfig{source: "./data", destination: "/data"}},
+		{"my-volume:/data:ext4", &VolumeConfig{source: "my-volume", destination: "/data", driver: "ext4"}},
+
+		// A field which is not solely made of options is the driver.
+		{"./data:/data:ro,foo", &VolumeConfig{source: "./data", destination: "/data", driver: "ro,foo"}},
+
+		// Invalid syntax.
+		{"./data", nil},
+		{"./data:/data:9pfs:ro:rw", nil},
+		{"./data:/data:9pfs:virtiofs", nil},
+	}
+
+	for _, tc := range tests {
+		volume, err := Parse(tc.entry)
+		if tc.expected == nil {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %+v", tc.entry, volume)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %v", tc.entry, err)
+		} else if *volume != *tc.expected {
+			t.Errorf("%s: expected %+v, got %+v", tc.entry, *tc.expected, *volume)
+		}
+	}
+}
+
+func TestTransformFromSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     interface{}
+		expected *VolumeConfig
+	}{
+		{"root file system", "./rootfs", &VolumeConfig{source: "./rootfs", destination: "/"}},
+		{"driver", "./data:/data:9pfs", &VolumeConfig{source: "./data", destination: "/data", driver: "9pfs"}},
+		{"options", "./data:/data:ro", &VolumeConfig{source: "./data", destination: "/data", readOnly: true}},
+		{"driver and options", "./data:/data:9pfs:ro,mode=0755", &VolumeConfig{source: "./data", destination: "/data", driver: "9pfs", mode: "0755", readOnly: true}},
+		{"too many fields", "./data:/data:9pfs:ro:rw", nil},
+		{"map", map[string]interface{}{
+			"source":      "./data",
+			"destination": "/data",
+			"driver":      "9pfs",
+			"mode":        0o755,
+			"readOnly":    true,
+		}, &VolumeConfig{source: "./data", destination: "/data", driver: "9pfs", mode: "0755", readOnly: true}},
+		{"map with string mode", map[string]interface{}{
+			"source":      "./data",
+			"destination": "/data",
+			"mode":        "0700",
+		}, &VolumeConfig{source: "./data", destination: "/data", mode: "0700"}},
+	}
+
+	for _, tc := range tests {
+		volume, err := TransformFromSchema(context.Background(), tc.data)
+		if tc.expected == nil {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %+v", tc.name, volume)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		} else if volume.(VolumeConfig) != *tc.expected {
+			t.Errorf("%s: expected %+v, got %+v", tc.name, *tc.expected, volume)
+		}
+	}
+}
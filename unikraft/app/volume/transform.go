@@ -17,17 +17,13 @@ func TransformFromSchema(ctx context.Context, data interface{}) (interface{}, er
 
 	switch entry := data.(type) {
 	case string:
-		var split []string
 		if strings.Contains(entry, ":") {
-			split = strings.Split(entry, ":")
-			if len(split) > 2 {
-				return nil, fmt.Errorf("expected format template value to be <url>@<version>")
+			parsed, err := Parse(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid volume %s: %w", entry, err)
 			}
 
-			volume.source = split[0]
-			if len(split) == 2 {
-				volume.destination = split[1]
-			}
+			volume = *parsed
 		} else {
 			// When no colon is specified, assume the root file system
 			volume.source = entry
@@ -46,9 +42,17 @@ func TransformFromSchema(ctx context.Context, data interface{}) (interface{}, er
 			case "destination":
 				volume.destination = prop.(string)
 
-			case "readonly":
-				volume.readOnly = prop.(bool)
+			case "mode":
+				switch mode := prop.(type) {
+				case int:
+					// YAML decodes unquoted octal permissions, e.g. 0755, as integers.
+					volume.mode = fmt.Sprintf("%04o", mode)
+				default:
+					volume.mode = fmt.Sprintf("%v", mode)
+				}
 
+			case "readonly", "readOnly":
+				volume.readOnly = prop.(bool)
 			}
 		}
 	}
@@ -78,6 +78,9 @@ func (volume *VolumeConfig) MarshalYAML() (interface{}, error) {
 	if len(volume.Driver()) > 0 {
 		ret["driver"] = volume.Driver()
 	}
+	if len(volume.Mode()) > 0 {
+		ret["mode"] = volume.Mode()
+	}
 	if len(ret) == 0 {
 		return nil, nil
 	}
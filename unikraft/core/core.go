@@ -71,6 +71,10 @@ func NewUnikraftFromOptions(ctx context.Context, opts ...UnikraftOption) (*Unikr
 			unikraft.ComponentTypeCore,
 			"unikraft",
 		)
+
+		if vendored, ok := unikraft.VendoredComponent(uk.UK_BASE, unikraft.ComponentTypeCore, "unikraft"); ok {
+			uc.path = vendored
+		}
 	}
 
 	for _, opt := range opts {
@@ -50,6 +50,8 @@ func TransformFromSchema(ctx context.Context, props interface{}) (interface{}, e
 		}
 	}
 
+	core.path = unikraft.VendoredPath(ctx, unikraft.ComponentTypeCore, "unikraft", core.path, core.source)
+
 	if version, ok := c["version"]; ok {
 		core.version = version.(string)
 	}
@@ -19,6 +19,10 @@ func ExportedParams() []ukargparse.Param {
 	}
 }
 
+// FstabFlagReadOnly is the value of the flags field of an FstabEntry which
+// mounts the volume read-only (MS_RDONLY).
+const FstabFlagReadOnly = "0x1"
+
 // FstabEntry is a vfscore mount entry.
 type FstabEntry struct {
 	sourceDevice string
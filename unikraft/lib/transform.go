@@ -55,6 +55,8 @@ func TransformFromSchema(ctx context.Context, name string, props interface{}) (L
 		}
 	}
 
+	lib.path = unikraft.VendoredPath(ctx, unikraft.ComponentTypeLib, lib.name, lib.path, lib.source)
+
 	if version, ok := c["version"]; ok {
 		lib.version = version.(string)
 	}
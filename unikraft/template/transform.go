@@ -54,6 +54,8 @@ func TransformFromSchema(ctx context.Context, props interface{}) (interface{}, e
 		template.name = name.(string)
 	}
 
+	template.path = unikraft.VendoredPath(ctx, unikraft.ComponentTypeApp, template.name, template.path, template.source)
+
 	if version, ok := c["version"]; ok {
 		template.version = version.(string)
 	}
@@ -6,7 +6,9 @@
 package unikraft
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -109,6 +111,45 @@ func PlaceComponent(workdir string, t ComponentType, name string) (string, error
 	return "", fmt.Errorf("cannot place component of unknown type")
 }
 
+// VendoredComponent returns the path to the sources of a component which are
+// vendored into the project with `kraft vendor`, and whether these sources
+// exist.  Vendored sources take precedence over the sources of the component.
+func VendoredComponent(workdir string, t ComponentType, name string) (string, bool) {
+	var path string
+	switch t {
+	case ComponentTypeCore:
+		path = filepath.Join(workdir, HermeticDir, "unikraft")
+	case ComponentTypeApp,
+		ComponentTypeLib:
+		path = filepath.Join(workdir, HermeticDir, t.Plural(), name)
+	default:
+		return "", false
+	}
+
+	if f, err := os.Stat(path); err != nil || !f.IsDir() {
+		return path, false
+	}
+
+	return path, true
+}
+
+// VendoredPath returns the path to the vendored sources of the component in
+// the project of the context, if any, which take precedence over the provided
+// path unless the source of the component is a directory on the host, i.e. the
+// path is the source.
+func VendoredPath(ctx context.Context, t ComponentType, name, path, source string) string {
+	uk := FromContext(ctx)
+	if uk == nil || uk.UK_BASE == "" || path == source {
+		return path
+	}
+
+	if vendored, ok := VendoredComponent(uk.UK_BASE, t, name); ok {
+		return vendored
+	}
+
+	return path
+}
+
 // TypeNameVersion returns the canonical name of the component using the format
 // <TYPE>/<NAME>:<VERSION>
 func TypeNameVersion(entity Nameable) string {
@@ -35,4 +35,8 @@ const (
 	VendorDir = ".unikraft"
 	BuildDir  = ".unikraft/build"
 	LibsDir   = ".unikraft/libs"
+
+	// HermeticDir is the directory of a project which contains the sources of
+	// its components and their upstream archives after `kraft vendor`.
+	HermeticDir = "vendor"
 )